@@ -35,6 +35,8 @@ type App struct {
 	locale     *locale
 	auth       map[string]AuthProvider
 	session    *session // nil when no auth configured
+
+	fileAccessKinds []bbl.FileAccessKind
 }
 
 func New(cfg Config) (*App, error) {
@@ -68,6 +70,11 @@ func New(cfg Config) (*App, error) {
 		locale:     loc,
 		auth:       cfg.Auth,
 	}
+	var profiles *bbl.Profiles
+	if cfg.Services != nil && cfg.Services.Repo != nil {
+		profiles = cfg.Services.Repo.Profiles
+	}
+	app.fileAccessKinds = profiles.FileAccessKinds()
 	if len(cfg.Auth) > 0 {
 		app.session = newSession(cfg.HashSecret, cfg.Secret, cfg.Secure)
 	}
@@ -89,10 +96,17 @@ func (app *App) Handler() http.Handler {
 
 	// SRU endpoints — per-entity, public.
 	mux.Handle("GET /sru/works", app.sruWorksHandler())
+	mux.HandleFunc("GET /sru/collections/{name}", app.sruCollectionHandler)
 
 	// OAI-PMH endpoint.
 	mux.Handle("GET /oai", app.oaiHandler())
 
+	// Disk file store — serves its own presigned URLs.
+	if h, ok := app.services.Files.(http.Handler); ok {
+		mux.Handle("GET /files/", http.StripPrefix("/files", h))
+		mux.Handle("PUT /files/", http.StripPrefix("/files", h))
+	}
+
 	base := chain{
 		sloghttp.Recovery,
 		sloghttp.NewWithConfig(app.log.WithGroup("http"), sloghttp.Config{
@@ -105,12 +119,18 @@ func (app *App) Handler() http.Handler {
 	mux.Handle("GET /", discovery.handle(app.home))
 	mux.Handle("GET /works", discovery.handle(app.searchWorks))
 	mux.Handle("GET /works/{id}", discovery.handle(app.showWork))
+	mux.Handle("GET /works/{id}/files/{file_id}", discovery.handle(app.downloadWorkFile))
 	mux.Handle("GET /people", discovery.handle(app.searchPeople))
 	mux.Handle("GET /people/{id}", discovery.handle(app.showPerson))
 	mux.Handle("GET /projects", discovery.handle(app.searchProjects))
 	mux.Handle("GET /projects/{id}", discovery.handle(app.showProject))
 	mux.Handle("GET /organizations", discovery.handle(app.searchOrganizations))
 	mux.Handle("GET /organizations/{id}", discovery.handle(app.showOrganization))
+	mux.Handle("GET /collections", discovery.handle(app.collections))
+	mux.Handle("GET /collections/{name}", discovery.handle(app.showCollection))
+	mux.Handle("GET /collections/{name}/export", discovery.handle(app.exportCollection))
+	mux.Handle("GET /lists/{id}", discovery.handle(app.showList))
+	mux.Handle("GET /lists/{id}/export", discovery.handle(app.exportList))
 
 	// Auth routes — anonymous (login/callback don't require a session).
 	mux.Handle("GET /backoffice/login", discovery.handle(app.login))
@@ -121,17 +141,80 @@ func (app *App) Handler() http.Handler {
 	backoffice := newGroup(base, app.newAuthCtx, app.htmlError)
 	mux.Handle("GET /backoffice", backoffice.handle(app.backofficeHome))
 	mux.Handle("GET /backoffice/works", backoffice.handle(app.backofficeSearchWorks))
+	mux.Handle("GET /backoffice/works/new", backoffice.handle(app.backofficeNewWork))
+	mux.Handle("POST /backoffice/works", backoffice.handle(app.backofficeCreateWork))
 	mux.Handle("GET /backoffice/works/{id}", backoffice.handle(app.backofficeShowWork))
 	mux.Handle("GET /backoffice/works/{id}/edit", backoffice.handle(app.backofficeEditWork))
 	mux.Handle("POST /backoffice/works/{id}/edit", backoffice.handle(app.backofficeUpdateWork))
 	mux.Handle("GET /backoffice/works/{id}/history", backoffice.handle(app.backofficeWorkHistory))
+	mux.Handle("GET /backoffice/works/{id}/kind", backoffice.handle(app.backofficeChangeWorkKind))
+	mux.Handle("POST /backoffice/works/{id}/kind", backoffice.handle(app.backofficeUpdateWorkKind))
+	mux.Handle("POST /backoffice/works/{id}/merge", backoffice.handle(app.backofficeMergeWork))
+	mux.Handle("POST /backoffice/works/{id}/files/upload", backoffice.handle(app.backofficeStartFileUpload))
+	mux.Handle("POST /backoffice/works/{id}/files", backoffice.handle(app.backofficeAttachWorkFile))
+	mux.Handle("GET /backoffice/works/{id}/files/{file_id}", backoffice.handle(app.backofficeDownloadWorkFile))
+	mux.Handle("POST /backoffice/works/{id}/files/{file_id}/detach", backoffice.handle(app.backofficeDetachWorkFile))
+	mux.Handle("POST /backoffice/works/{id}/submit", backoffice.handle(app.backofficeSubmitWork))
+	mux.Handle("POST /backoffice/works/{id}/pick-up", backoffice.handle(app.backofficePickUpWork))
+	mux.Handle("POST /backoffice/works/{id}/return", backoffice.handle(app.backofficeReturnWork))
+	mux.Handle("POST /backoffice/works/{id}/approve", backoffice.handle(app.backofficeApproveWork))
+	mux.Handle("POST /backoffice/works/{id}/lock", backoffice.handle(app.backofficeLockWork))
+	mux.Handle("POST /backoffice/works/{id}/unlock", backoffice.handle(app.backofficeUnlockWork))
+	mux.Handle("POST /backoffice/works/{id}/conflicts/{conflict_id}/accept", backoffice.handle(app.backofficeAcceptLockConflict))
+	mux.Handle("POST /backoffice/works/{id}/conflicts/{conflict_id}/dismiss", backoffice.handle(app.backofficeDismissLockConflict))
+	mux.Handle("GET /backoffice/reviews", backoffice.handle(app.backofficeReviewQueue))
+	mux.Handle("GET /backoffice/conflicts", backoffice.handle(app.backofficeLockConflicts))
+	mux.Handle("GET /backoffice/duplicates", backoffice.handle(app.backofficeDuplicates))
+	mux.Handle("GET /backoffice/batch-edit", backoffice.handle(app.backofficeBatchEdit))
+	mux.Handle("POST /backoffice/batch-edit", backoffice.handle(app.backofficeUploadBatchEdit))
+	mux.Handle("GET /backoffice/batch-edit/export", backoffice.handle(app.backofficeExportBatchEdit))
+	mux.Handle("GET /backoffice/batch-edit/{id}", backoffice.handle(app.backofficeShowBatchEdit))
+	mux.Handle("POST /backoffice/batch-edit/{id}/apply", backoffice.handle(app.backofficeApplyBatchEdit))
+	mux.Handle("GET /backoffice/collections", backoffice.handle(app.backofficeCollections))
+	mux.Handle("POST /backoffice/collections", backoffice.handle(app.backofficeCreateCollection))
+	mux.Handle("GET /backoffice/collections/{id}", backoffice.handle(app.backofficeShowCollection))
+	mux.Handle("POST /backoffice/collections/{id}", backoffice.handle(app.backofficeUpdateCollection))
+	mux.Handle("POST /backoffice/collections/{id}/delete", backoffice.handle(app.backofficeDeleteCollection))
+	mux.Handle("GET /backoffice/collections/{id}/export", backoffice.handle(app.backofficeExportCollection))
+	mux.Handle("POST /backoffice/collections/{id}/works", backoffice.handle(app.backofficeAddCollectionWorks))
+	mux.Handle("POST /backoffice/collections/{id}/works/{work_id}", backoffice.handle(app.backofficeMoveCollectionWork))
+	mux.Handle("POST /backoffice/collections/{id}/works/{work_id}/remove", backoffice.handle(app.backofficeRemoveCollectionWork))
+	mux.Handle("GET /backoffice/lists", backoffice.handle(app.backofficeLists))
+	mux.Handle("POST /backoffice/lists", backoffice.handle(app.backofficeCreateList))
+	mux.Handle("POST /backoffice/lists/items", backoffice.handle(app.backofficeAddListItems))
+	mux.Handle("GET /backoffice/lists/{id}", backoffice.handle(app.backofficeShowList))
+	mux.Handle("POST /backoffice/lists/{id}", backoffice.handle(app.backofficeUpdateList))
+	mux.Handle("POST /backoffice/lists/{id}/delete", backoffice.handle(app.backofficeDeleteList))
+	mux.Handle("GET /backoffice/lists/{id}/export", backoffice.handle(app.backofficeExportList))
+	mux.Handle("POST /backoffice/lists/{id}/items", backoffice.handle(app.backofficeAddListItems))
+	mux.Handle("POST /backoffice/lists/{id}/items/{work_id}", backoffice.handle(app.backofficeUpdateListItem))
+	mux.Handle("POST /backoffice/lists/{id}/items/{work_id}/remove", backoffice.handle(app.backofficeRemoveListItem))
 	mux.Handle("GET /backoffice/people/suggest", backoffice.handle(app.suggestPeople))
 	mux.Handle("GET /backoffice/people", backoffice.handle(app.backofficeSearchPeople))
+	mux.Handle("GET /backoffice/people/new", backoffice.handle(app.backofficeNewPerson))
+	mux.Handle("POST /backoffice/people", backoffice.handle(app.backofficeCreatePerson))
 	mux.Handle("GET /backoffice/people/{id}", backoffice.handle(app.backofficeShowPerson))
+	mux.Handle("GET /backoffice/people/{id}/edit", backoffice.handle(app.backofficeEditPerson))
+	mux.Handle("POST /backoffice/people/{id}/edit", backoffice.handle(app.backofficeUpdatePerson))
+	mux.Handle("POST /backoffice/people/{id}/delete", backoffice.handle(app.backofficeDeletePerson))
+	mux.Handle("POST /backoffice/people/{id}/merge", backoffice.handle(app.backofficeMergePerson))
 	mux.Handle("GET /backoffice/projects", backoffice.handle(app.backofficeSearchProjects))
+	mux.Handle("GET /backoffice/projects/new", backoffice.handle(app.backofficeNewProject))
+	mux.Handle("POST /backoffice/projects", backoffice.handle(app.backofficeCreateProject))
 	mux.Handle("GET /backoffice/projects/{id}", backoffice.handle(app.backofficeShowProject))
+	mux.Handle("GET /backoffice/projects/{id}/edit", backoffice.handle(app.backofficeEditProject))
+	mux.Handle("POST /backoffice/projects/{id}/edit", backoffice.handle(app.backofficeUpdateProject))
+	mux.Handle("POST /backoffice/projects/{id}/delete", backoffice.handle(app.backofficeDeleteProject))
+	mux.Handle("GET /backoffice/organizations/suggest", backoffice.handle(app.suggestOrganizations))
 	mux.Handle("GET /backoffice/organizations", backoffice.handle(app.backofficeSearchOrganizations))
+	mux.Handle("GET /backoffice/organizations/new", backoffice.handle(app.backofficeNewOrganization))
+	mux.Handle("POST /backoffice/organizations", backoffice.handle(app.backofficeCreateOrganization))
 	mux.Handle("GET /backoffice/organizations/{id}", backoffice.handle(app.backofficeShowOrganization))
+	mux.Handle("GET /backoffice/organizations/{id}/edit", backoffice.handle(app.backofficeEditOrganization))
+	mux.Handle("POST /backoffice/organizations/{id}/edit", backoffice.handle(app.backofficeUpdateOrganization))
+	mux.Handle("POST /backoffice/organizations/{id}/delete", backoffice.handle(app.backofficeDeleteOrganization))
+	mux.Handle("POST /backoffice/impersonate", backoffice.handle(app.backofficeStartImpersonation))
+	mux.Handle("POST /backoffice/impersonate/stop", backoffice.handle(app.backofficeStopImpersonation))
 	mux.Handle("POST /backoffice/logout", backoffice.handle(app.logout))
 
 	return mux
@@ -184,9 +184,128 @@ function initPersonSuggest(rootEl) {
 	});
 }
 
+// --- Record suggest ---
+
+// Autocomplete for pickers of related records (organizations, people). The
+// hidden [data-suggest-id] input holds the ID of the picked record; typing
+// clears it until a suggestion is picked. Listeners are delegated from the
+// document so pickers added by repeatable fields work too.
+function initRecordSuggest() {
+	var debounceTimer;
+
+	document.addEventListener("input", function (e) {
+		var input = e.target.closest("[data-record-suggest] [data-suggest-input]");
+		if (!input) return;
+
+		var el = input.closest("[data-record-suggest]");
+		var idInput = el.querySelector("[data-suggest-id]");
+		var results = el.querySelector("[data-suggest-results]");
+		idInput.value = "";
+		clearTimeout(debounceTimer);
+
+		var query = input.value.trim();
+		if (query.length < 2) {
+			results.innerHTML = "";
+			return;
+		}
+
+		debounceTimer = setTimeout(function () {
+			var url = input.getAttribute("data-suggest-url") + "?q=" + encodeURIComponent(query);
+			fetch(url)
+				.then(function (r) {
+					if (!r.ok) throw new Error("suggest: " + r.status);
+					return r.json();
+				})
+				.then(function (records) {
+					results.innerHTML = "";
+					records.forEach(function (rec) {
+						var li = document.createElement("li");
+						li.textContent = rec.name;
+						if (rec.kind) {
+							var small = document.createElement("small");
+							small.textContent = " (" + rec.kind + ")";
+							li.appendChild(small);
+						}
+						li.style.cursor = "pointer";
+						li.addEventListener("click", function () {
+							idInput.value = rec.id;
+							input.value = rec.name;
+							results.innerHTML = "";
+						});
+						results.appendChild(li);
+					});
+				})
+				.catch(function (err) {
+					console.error(err);
+				});
+		}, 300);
+	});
+
+	document.addEventListener("focusout", function (e) {
+		var input = e.target.closest("[data-record-suggest] [data-suggest-input]");
+		if (!input) return;
+
+		var results = input.closest("[data-record-suggest]").querySelector("[data-suggest-results]");
+		setTimeout(function () { results.innerHTML = ""; }, 200);
+	});
+}
+
+// --- File upload ---
+
+// Uploads the selected file straight to the file store before submitting
+// the attach form: ask the server for a presigned URL, PUT the file, then
+// submit the form with the new file ID.
+function initFileUpload(rootEl) {
+	rootEl.querySelectorAll("[data-file-upload]").forEach(function (form) {
+		form.addEventListener("submit", function (e) {
+			var fileIdInput = form.querySelector('[name="file_id"]');
+			if (fileIdInput.value) return;
+			e.preventDefault();
+
+			var file = form.querySelector("[data-file-input]").files[0];
+			if (!file) return;
+			var status = form.querySelector("[data-upload-status]");
+			var button = form.querySelector('[type="submit"]');
+			var contentType = file.type || "application/octet-stream";
+			button.disabled = true;
+			if (status) status.textContent = "…";
+
+			var body = new URLSearchParams();
+			body.set("name", file.name);
+			body.set("content_type", contentType);
+
+			fetch(form.getAttribute("data-upload-url"), { method: "POST", body: body })
+				.then(function (r) {
+					if (!r.ok) throw new Error("upload: " + r.status);
+					return r.json();
+				})
+				.then(function (upload) {
+					return fetch(upload.url, {
+						method: "PUT",
+						headers: { "Content-Type": contentType },
+						body: file,
+					}).then(function (r) {
+						if (!r.ok) throw new Error("upload: " + r.status);
+						fileIdInput.value = upload.file_id;
+						form.querySelector('[name="name"]').value = file.name;
+						form.submit();
+					});
+				})
+				.catch(function (err) {
+					console.error(err);
+					button.disabled = false;
+					if (status) status.textContent = err.message;
+				});
+		});
+	});
+}
+
 // --- Boot ---
 
+initRecordSuggest();
+
 htmx.onLoad(function (rootEl) {
 	initRepeatable(rootEl);
 	initPersonSuggest(rootEl);
+	initFileUpload(rootEl);
 });
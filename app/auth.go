@@ -2,6 +2,7 @@ package app
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/ugent-library/bbl"
 )
@@ -70,6 +71,10 @@ func (app *App) authCallback(w http.ResponseWriter, r *http.Request, c *Ctx) err
 		}
 		return err
 	}
+	if user.Deactivated(time.Now()) {
+		http.Error(w, "Account deactivated", http.StatusForbidden)
+		return nil
+	}
 
 	if err := app.session.save(w, &sessionData{UserID: user.ID.String()}); err != nil {
 		return err
@@ -80,6 +85,11 @@ func (app *App) authCallback(w http.ResponseWriter, r *http.Request, c *Ctx) err
 }
 
 func (app *App) logout(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	if c.Impersonator != nil {
+		if err := app.services.Repo.StopImpersonation(r.Context(), c.Impersonator, c.User); err != nil {
+			return err
+		}
+	}
 	app.session.clear(w)
 	http.Redirect(w, r, "/", http.StatusFound)
 	return nil
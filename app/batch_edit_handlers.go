@@ -0,0 +1,142 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+
+	"github.com/ugent-library/bbl"
+	"github.com/ugent-library/bbl/app/views"
+)
+
+// Batch edit. Curators download a batch-edit CSV for the works matching a
+// search, upload the edited file, review the changes and conflicts, and
+// confirm to apply them. Large uploads are processed by the background
+// worker; the batch edit page refreshes until they are done.
+
+// maxBatchEditSize limits the size of uploaded batch-edit CSVs.
+const maxBatchEditSize = 32 << 20
+
+const recentBatchEdits = 20
+
+func (app *App) backofficeBatchEdit(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	if !bbl.CanBatchEdit(c.User) {
+		return bbl.ErrForbidden
+	}
+	edits, err := app.services.Repo.GetUserBatchEdits(r.Context(), c.User.ID, recentBatchEdits)
+	if err != nil {
+		return err
+	}
+	return views.BackofficeBatchEdit(c.ViewCtx, edits, bbl.BatchCollectionFields()).Render(r.Context(), w)
+}
+
+// backofficeExportBatchEdit downloads the batch-edit CSV for the works
+// matching the q and filter query parameters. The field parameter selects a
+// collection field; without it scalar fields are exported.
+func (app *App) backofficeExportBatchEdit(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	if !bbl.CanBatchEdit(c.User) {
+		return bbl.ErrForbidden
+	}
+	q := r.URL.Query()
+	field := q.Get("field")
+	if field != "" && !slices.Contains(bbl.BatchCollectionFields(), field) {
+		http.Error(w, fmt.Sprintf("field %q can't be batch edited", field), http.StatusBadRequest)
+		return nil
+	}
+	opts := &bbl.SearchOpts{Query: q.Get("q")}
+	if v := q.Get("filter"); v != "" {
+		f, err := bbl.ParseQueryFilter(v)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid filter: %s", err), http.StatusBadRequest)
+			return nil
+		}
+		opts.Filter = f
+	}
+	var ids []bbl.ID
+	for hit, err := range bbl.SearchAllWorks(r.Context(), app.services.Index.Works(), opts) {
+		if err != nil {
+			return err
+		}
+		ids = append(ids, hit.ID)
+	}
+
+	filename := "batch-edit"
+	if field != "" {
+		filename += "-" + field
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.csv"`, filename))
+	var err error
+	if field != "" {
+		err = bbl.WriteWorkCollectionBatch(r.Context(), app.services.Repo, w, field, ids)
+	} else {
+		err = bbl.WriteWorkBatch(r.Context(), app.services.Repo, w, ids)
+	}
+	// Headers are sent with the first write; errors after that can only be
+	// logged.
+	if err != nil {
+		app.log.Error("export batch edit", "err", err)
+	}
+	return nil
+}
+
+func (app *App) backofficeUploadBatchEdit(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	if !bbl.CanBatchEdit(c.User) {
+		return bbl.ErrForbidden
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxBatchEditSize)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil
+	}
+	b, err := app.services.CreateBatchEdit(r.Context(), c.User, header.Filename, data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil
+	}
+	http.Redirect(w, r, fmt.Sprintf("/backoffice/batch-edit/%s", b.ID), http.StatusSeeOther)
+	return nil
+}
+
+func (app *App) getBatchEdit(r *http.Request, c *Ctx) (*bbl.BatchEdit, error) {
+	id, err := bbl.ParseID(r.PathValue("id"))
+	if err != nil {
+		return nil, bbl.ErrNotFound
+	}
+	b, err := app.services.Repo.GetBatchEdit(r.Context(), id)
+	if err != nil {
+		return nil, err
+	}
+	if !b.CanView(c.User) {
+		return nil, bbl.ErrNotFound
+	}
+	return b, nil
+}
+
+func (app *App) backofficeShowBatchEdit(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	b, err := app.getBatchEdit(r, c)
+	if err != nil {
+		return err
+	}
+	return views.BackofficeShowBatchEdit(c.ViewCtx, b).Render(r.Context(), w)
+}
+
+func (app *App) backofficeApplyBatchEdit(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	b, err := app.getBatchEdit(r, c)
+	if err != nil {
+		return err
+	}
+	if _, err := app.services.ApplyBatchEdit(r.Context(), c.User, b.ID); err != nil {
+		return fmt.Errorf("backofficeApplyBatchEdit: %w", err)
+	}
+	http.Redirect(w, r, fmt.Sprintf("/backoffice/batch-edit/%s", b.ID), http.StatusSeeOther)
+	return nil
+}
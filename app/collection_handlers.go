@@ -0,0 +1,230 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ugent-library/bbl"
+	"github.com/ugent-library/bbl/app/views"
+)
+
+// Collections. Curators and admins manage collections in the backoffice.
+// Public collections are browsable on the discovery side, as OAI-PMH sets
+// and as SRU databases (see oai_handlers.go and sru_handlers.go).
+
+// getPublicCollection loads the public collection named in the path.
+func (app *App) getPublicCollection(r *http.Request) (*bbl.Collection, error) {
+	c, err := app.services.Repo.GetCollectionByName(r.Context(), r.PathValue("name"))
+	if err != nil {
+		return nil, err
+	}
+	if !c.Public {
+		return nil, bbl.ErrNotFound
+	}
+	return c, nil
+}
+
+// getCollection loads the collection in the path for management.
+func (app *App) getCollection(r *http.Request, c *Ctx) (*bbl.Collection, error) {
+	if !bbl.CanManageCollections(c.User) {
+		return nil, bbl.ErrForbidden
+	}
+	id, err := bbl.ParseID(r.PathValue("id"))
+	if err != nil {
+		return nil, bbl.ErrNotFound
+	}
+	return app.services.Repo.GetCollection(r.Context(), id)
+}
+
+func collectionAttrsFromForm(r *http.Request) bbl.CollectionAttrs {
+	return bbl.CollectionAttrs{
+		Name:        r.FormValue("name"),
+		Title:       r.FormValue("title"),
+		Description: r.FormValue("description"),
+		Kind:        r.FormValue("kind"),
+		Query:       r.FormValue("query"),
+		Filter:      r.FormValue("filter"),
+		Public:      r.FormValue("public") == "true",
+	}
+}
+
+func (app *App) collections(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	collections, err := app.services.Repo.GetCollections(r.Context(), true)
+	if err != nil {
+		return err
+	}
+	return views.Collections(c.ViewCtx, collections).Render(r.Context(), w)
+}
+
+func (app *App) showCollection(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	coll, err := app.getPublicCollection(r)
+	if err != nil {
+		return err
+	}
+	opts := parseSearchOpts(r)
+	hits, err := app.services.SearchCollectionWorks(r.Context(), coll, opts)
+	if err != nil {
+		return err
+	}
+	return views.ShowCollection(c.ViewCtx, coll, hits, opts, bbl.WorkWriterFormats()).Render(r.Context(), w)
+}
+
+func (app *App) exportCollection(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	coll, err := app.getPublicCollection(r)
+	if err != nil {
+		return err
+	}
+	return app.writeWorks(w, r, coll.Name, app.services.EachCollectionWork(r.Context(), coll))
+}
+
+func (app *App) backofficeCollections(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	if !bbl.CanManageCollections(c.User) {
+		return bbl.ErrForbidden
+	}
+	collections, err := app.services.Repo.GetCollections(r.Context(), false)
+	if err != nil {
+		return err
+	}
+	return views.BackofficeCollections(c.ViewCtx, collections).Render(r.Context(), w)
+}
+
+func (app *App) backofficeCreateCollection(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	if !bbl.CanManageCollections(c.User) {
+		return bbl.ErrForbidden
+	}
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	coll, err := app.services.Repo.CreateCollection(r.Context(), collectionAttrsFromForm(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil
+	}
+	http.Redirect(w, r, fmt.Sprintf("/backoffice/collections/%s", coll.ID), http.StatusSeeOther)
+	return nil
+}
+
+func (app *App) backofficeShowCollection(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	coll, err := app.getCollection(r, c)
+	if err != nil {
+		return err
+	}
+	var works []*bbl.Work
+	if coll.Kind == bbl.CollectionKindManual {
+		ids, err := app.services.Repo.GetCollectionWorkIDs(r.Context(), coll.ID)
+		if err != nil {
+			return err
+		}
+		if works, err = app.services.Repo.GetWorks(r.Context(), ids); err != nil {
+			return err
+		}
+	}
+	return views.BackofficeShowCollection(c.ViewCtx, coll, works, bbl.WorkWriterFormats()).Render(r.Context(), w)
+}
+
+func (app *App) backofficeUpdateCollection(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	coll, err := app.getCollection(r, c)
+	if err != nil {
+		return err
+	}
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	attrs := collectionAttrsFromForm(r)
+	attrs.Kind = coll.Kind
+	if err := app.services.Repo.UpdateCollection(r.Context(), coll.ID, attrs); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil
+	}
+	http.Redirect(w, r, fmt.Sprintf("/backoffice/collections/%s", coll.ID), http.StatusSeeOther)
+	return nil
+}
+
+func (app *App) backofficeDeleteCollection(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	coll, err := app.getCollection(r, c)
+	if err != nil {
+		return err
+	}
+	if err := app.services.Repo.DeleteCollection(r.Context(), coll.ID); err != nil {
+		return fmt.Errorf("backofficeDeleteCollection: %w", err)
+	}
+	http.Redirect(w, r, "/backoffice/collections", http.StatusSeeOther)
+	return nil
+}
+
+// backofficeAddCollectionWorks adds the work IDs in the work_ids form value
+// (separated by whitespace or commas) to a manual collection.
+func (app *App) backofficeAddCollectionWorks(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	coll, err := app.getCollection(r, c)
+	if err != nil {
+		return err
+	}
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	var ids []bbl.ID
+	for _, v := range strings.FieldsFunc(r.FormValue("work_ids"), func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\n' || r == '\r' || r == '\t'
+	}) {
+		id, err := bbl.ParseID(v)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid work id %q", v), http.StatusBadRequest)
+			return nil
+		}
+		ids = append(ids, id)
+	}
+	if _, err := app.services.Repo.AddCollectionWorks(r.Context(), coll.ID, ids); err != nil {
+		return fmt.Errorf("backofficeAddCollectionWorks: %w", err)
+	}
+	http.Redirect(w, r, fmt.Sprintf("/backoffice/collections/%s", coll.ID), http.StatusSeeOther)
+	return nil
+}
+
+// backofficeMoveCollectionWork moves a work of a manual collection to the
+// 1-based position in the position form value.
+func (app *App) backofficeMoveCollectionWork(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	coll, err := app.getCollection(r, c)
+	if err != nil {
+		return err
+	}
+	workID, err := bbl.ParseID(r.PathValue("work_id"))
+	if err != nil {
+		return bbl.ErrNotFound
+	}
+	pos, err := strconv.Atoi(r.FormValue("position"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid position %q", r.FormValue("position")), http.StatusBadRequest)
+		return nil
+	}
+	if err := app.services.Repo.MoveCollectionWork(r.Context(), coll.ID, workID, pos-1); err != nil {
+		return fmt.Errorf("backofficeMoveCollectionWork: %w", err)
+	}
+	http.Redirect(w, r, fmt.Sprintf("/backoffice/collections/%s", coll.ID), http.StatusSeeOther)
+	return nil
+}
+
+func (app *App) backofficeRemoveCollectionWork(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	coll, err := app.getCollection(r, c)
+	if err != nil {
+		return err
+	}
+	workID, err := bbl.ParseID(r.PathValue("work_id"))
+	if err != nil {
+		return bbl.ErrNotFound
+	}
+	if err := app.services.Repo.RemoveCollectionWork(r.Context(), coll.ID, workID); err != nil {
+		return fmt.Errorf("backofficeRemoveCollectionWork: %w", err)
+	}
+	http.Redirect(w, r, fmt.Sprintf("/backoffice/collections/%s", coll.ID), http.StatusSeeOther)
+	return nil
+}
+
+func (app *App) backofficeExportCollection(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	coll, err := app.getCollection(r, c)
+	if err != nil {
+		return err
+	}
+	return app.writeWorks(w, r, coll.Name, app.services.EachCollectionWork(r.Context(), coll))
+}
@@ -2,6 +2,7 @@ package app
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/ugent-library/bbl"
 	"github.com/ugent-library/bbl/app/views"
@@ -10,7 +11,9 @@ import (
 // Ctx holds per-request state. Just data — no methods, no infrastructure.
 type Ctx struct {
 	User *bbl.User
-	ViewCtx views.Ctx
+	// Impersonator is the admin acting as User, if any.
+	Impersonator *bbl.User
+	ViewCtx      views.Ctx
 }
 
 // newCtx builds a Ctx, loading the user from session if present.
@@ -19,13 +22,14 @@ func (app *App) newCtx(r *http.Request) (*Ctx, error) {
 	lang := app.locale.match(r.Header.Get("Accept-Language"), localeCookieValue(r), r.URL.Query().Get("lang"))
 	c := &Ctx{
 		ViewCtx: views.Ctx{
-			AssetPath: app.assets.Path,
-			Loc:       app.locale.translateFunc(lang),
-			Lang:      lang,
-			Langs:     app.locale.langs,
-			LangNames: app.locale.langNames[lang],
-			MainLangs: app.locale.mainLangs,
-			Path:      r.URL.Path,
+			AssetPath:       app.assets.Path,
+			Loc:             app.locale.translateFunc(lang),
+			Lang:            lang,
+			Langs:           app.locale.langs,
+			LangNames:       app.locale.langNames[lang],
+			MainLangs:       app.locale.mainLangs,
+			Path:            r.URL.Path,
+			FileAccessKinds: app.fileAccessKinds,
 		},
 	}
 	if app.session == nil {
@@ -49,11 +53,51 @@ func (app *App) newCtx(r *http.Request) (*Ctx, error) {
 			}
 			return nil, err
 		}
+		if sess.ImpersonatorID != "" {
+			admin, err := app.loadImpersonator(r, sess.ImpersonatorID, user)
+			if err != nil {
+				return nil, err
+			}
+			if admin == nil {
+				// Impersonation no longer allowed — treat as no session.
+				return c, nil
+			}
+			if !sess.validFor(admin, time.Now()) {
+				return c, nil
+			}
+			user = bbl.Impersonate(admin, user)
+			c.Impersonator = admin
+			c.ViewCtx.Impersonator = admin
+		} else if !sess.validFor(user, time.Now()) {
+			// Deactivated or session revoked — treat as no session.
+			return c, nil
+		}
 		c.User = user
+		c.ViewCtx.User = user
 	}
 	return c, nil
 }
 
+// loadImpersonator returns the admin impersonating user, or nil if the admin
+// no longer exists or may no longer impersonate user.
+func (app *App) loadImpersonator(r *http.Request, adminID string, user *bbl.User) (*bbl.User, error) {
+	id, err := bbl.ParseID(adminID)
+	if err != nil {
+		return nil, nil
+	}
+	admin, err := app.services.Repo.GetUser(r.Context(), id)
+	if err == bbl.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !bbl.CanImpersonate(admin, user) {
+		return nil, nil
+	}
+	return admin, nil
+}
+
 // newAuthCtx builds a Ctx and requires a logged-in user.
 // Returns errNotAuthenticated if no user — the error handler maps this
 // to a login redirect.
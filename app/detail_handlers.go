@@ -1,6 +1,7 @@
 package app
 
 import (
+	"fmt"
 	"net/http"
 	"slices"
 
@@ -12,6 +13,9 @@ import (
 
 func (app *App) showWork(w http.ResponseWriter, r *http.Request, c *Ctx) error {
 	work, err := app.getWork(r, "public")
+	if err == bbl.ErrNotFound && app.redirectMergedWork(w, r, "/works/%s") {
+		return nil
+	}
 	if err != nil {
 		return err
 	}
@@ -46,10 +50,25 @@ func (app *App) showOrganization(w http.ResponseWriter, r *http.Request, c *Ctx)
 
 func (app *App) backofficeShowWork(w http.ResponseWriter, r *http.Request, c *Ctx) error {
 	work, err := app.getWork(r, "public", "private")
+	if err == bbl.ErrNotFound && app.redirectMergedWork(w, r, "/backoffice/works/%s") {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	reviews, err := app.services.Repo.GetWorkReviews(r.Context(), work.ID)
+	if err != nil {
+		return err
+	}
+	locks, err := app.services.Repo.GetWorkLocks(r.Context(), work.ID)
+	if err != nil {
+		return err
+	}
+	conflicts, err := app.services.Repo.GetWorkLockConflicts(r.Context(), work.ID)
 	if err != nil {
 		return err
 	}
-	return views.BackofficeShowWork(c.ViewCtx, work).Render(r.Context(), w)
+	return views.BackofficeShowWork(c.ViewCtx, work, reviews, locks, conflicts).Render(r.Context(), w)
 }
 
 func (app *App) backofficeWorkHistory(w http.ResponseWriter, r *http.Request, c *Ctx) error {
@@ -105,6 +124,21 @@ func (app *App) getWork(r *http.Request, allowedStatuses ...string) (*bbl.Work,
 	return work, nil
 }
 
+// redirectMergedWork permanently redirects to the surviving work if the
+// requested work was merged. Returns false if there is nothing to redirect to.
+func (app *App) redirectMergedWork(w http.ResponseWriter, r *http.Request, path string) bool {
+	id, err := bbl.ParseID(r.PathValue("id"))
+	if err != nil {
+		return false
+	}
+	workID, err := app.services.Repo.GetWorkRedirect(r.Context(), id)
+	if err != nil {
+		return false
+	}
+	http.Redirect(w, r, fmt.Sprintf(path, workID), http.StatusMovedPermanently)
+	return true
+}
+
 func (app *App) getPerson(r *http.Request) (*bbl.Person, error) {
 	id, err := bbl.ParseID(r.PathValue("id"))
 	if err != nil {
@@ -0,0 +1,89 @@
+package app
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/ugent-library/bbl"
+	"github.com/ugent-library/bbl/app/views"
+)
+
+const defaultDuplicateConfidence = 0.5
+
+func (app *App) backofficeDuplicates(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	opts := parseSearchOpts(r)
+	recordType := bbl.RecordTypeWork
+	if r.URL.Query().Get("type") == bbl.RecordTypePerson {
+		recordType = bbl.RecordTypePerson
+	}
+	minConfidence := defaultDuplicateConfidence
+	if v, err := strconv.ParseFloat(r.URL.Query().Get("min"), 64); err == nil && v > 0 && v <= 1 {
+		minConfidence = v
+	}
+
+	clusters, total, err := app.services.Repo.GetDuplicateClusters(r.Context(), bbl.DuplicateClusterOpts{
+		RecordType:    recordType,
+		MinConfidence: minConfidence,
+		Limit:         opts.Size,
+		Offset:        opts.Offset,
+	})
+	if err != nil {
+		return err
+	}
+	var ids []bbl.ID
+	for _, cluster := range clusters {
+		ids = append(ids, cluster.IDs...)
+	}
+
+	works := make(map[bbl.ID]*bbl.Work)
+	people := make(map[bbl.ID]*bbl.Person)
+	if recordType == bbl.RecordTypeWork {
+		recs, err := app.services.Repo.GetWorks(r.Context(), ids)
+		if err != nil {
+			return err
+		}
+		for _, work := range recs {
+			works[work.ID] = work
+		}
+		// The first work of a cluster is the suggested survivor of a merge.
+		for _, cluster := range clusters {
+			slices.SortStableFunc(cluster.IDs, func(a, b bbl.ID) int {
+				return compareMergeSurvivors(works[a], works[b])
+			})
+		}
+	} else {
+		recs, err := app.services.Repo.GetPeople(r.Context(), ids)
+		if err != nil {
+			return err
+		}
+		for _, person := range recs {
+			people[person.ID] = person
+		}
+		// The oldest person of a cluster is the suggested survivor.
+		for _, cluster := range clusters {
+			slices.SortStableFunc(cluster.IDs, func(a, b bbl.ID) int {
+				pa, pb := people[a], people[b]
+				if pa == nil || pb == nil {
+					return 0
+				}
+				return pa.CreatedAt.Compare(pb.CreatedAt)
+			})
+		}
+	}
+
+	return views.BackofficeDuplicates(c.ViewCtx, recordType, minConfidence, clusters, works, people, total, opts).Render(r.Context(), w)
+}
+
+// compareMergeSurvivors prefers public works, then the oldest work.
+func compareMergeSurvivors(a, b *bbl.Work) int {
+	switch {
+	case a == nil || b == nil:
+		return 0
+	case a.Status != b.Status && a.Status == bbl.WorkStatusPublic:
+		return -1
+	case a.Status != b.Status && b.Status == bbl.WorkStatusPublic:
+		return 1
+	}
+	return a.CreatedAt.Compare(b.CreatedAt)
+}
@@ -61,31 +61,11 @@ func buildWorkUpdates(r *http.Request, defs []bbl.FieldDef, work *bbl.Work) []an
 				updates = append(updates, &bbl.Unset{RecordType: "work", RecordID: work.ID, Field: f.Name})
 			}
 		case "title":
-			langs := r.Form[f.Name+".lang"]
-			vals := r.Form[f.Name+".val"]
-			var titles []bbl.Title
-			for i := range min(len(langs), len(vals)) {
-				lang := strings.TrimSpace(langs[i])
-				val := strings.TrimSpace(vals[i])
-				if lang != "" || val != "" {
-					titles = append(titles, bbl.Title{Lang: lang, Val: val})
-				}
-			}
-			if len(titles) > 0 {
+			if titles := formTitles(r.Form, f.Name); len(titles) > 0 {
 				updates = append(updates, &bbl.Set{RecordType: "work", RecordID: work.ID, Field: f.Name, Val: titles})
 			}
 		case "text":
-			langs := r.Form[f.Name+".lang"]
-			vals := r.Form[f.Name+".val"]
-			var texts []bbl.Text
-			for i := range min(len(langs), len(vals)) {
-				lang := strings.TrimSpace(langs[i])
-				val := strings.TrimSpace(vals[i])
-				if lang != "" || val != "" {
-					texts = append(texts, bbl.Text{Lang: lang, Val: val})
-				}
-			}
-			if len(texts) > 0 {
+			if texts := formTexts(r.Form, f.Name); len(texts) > 0 {
 				updates = append(updates, &bbl.Set{RecordType: "work", RecordID: work.ID, Field: f.Name, Val: texts})
 			} else {
 				updates = append(updates, &bbl.Unset{RecordType: "work", RecordID: work.ID, Field: f.Name})
@@ -149,11 +129,12 @@ func buildWorkUpdates(r *http.Request, defs []bbl.FieldDef, work *bbl.Work) []an
 					continue
 				}
 				co := bbl.WorkContributor{
-					Kind:       g.Get("kind"),
-					Name:       name,
-					GivenName:  gn,
-					FamilyName: fn,
-					Roles:      g["roles"],
+					Kind:         g.Get("kind"),
+					Name:         name,
+					GivenName:    gn,
+					FamilyName:   fn,
+					Roles:        g["roles"],
+					Affiliations: g["affiliations"],
 				}
 				if pid := g.Get("person_id"); pid != "" {
 					if id, err := bbl.ParseID(pid); err == nil {
@@ -14,6 +14,10 @@ func (app *App) htmlError(w http.ResponseWriter, r *http.Request, err error) {
 		http.Redirect(w, r, "/backoffice/login", http.StatusFound)
 		return
 	}
+	if errors.Is(err, bbl.ErrForbidden) || errors.Is(err, bbl.ErrCuratorLock) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
 	if errors.Is(err, bbl.ErrNotFound) {
 		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
 		return
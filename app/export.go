@@ -0,0 +1,40 @@
+package app
+
+import (
+	"fmt"
+	"iter"
+	"net/http"
+
+	"github.com/ugent-library/bbl"
+)
+
+// writeWorks streams works as a download in the format given in the format
+// query parameter, using any registered work writer. Defaults to CSV.
+func (app *App) writeWorks(w http.ResponseWriter, r *http.Request, filename string, works iter.Seq2[*bbl.Work, error]) error {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	ww, err := bbl.NewWorkWriter(format)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil
+	}
+	contentType, ext := "text/plain; charset=utf-8", "txt"
+	switch format {
+	case "json":
+		contentType, ext = "application/json", "json"
+	case "jsonl":
+		contentType, ext = "application/x-ndjson", "jsonl"
+	case "csv":
+		contentType, ext = "text/csv; charset=utf-8", "csv"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, filename, ext))
+	// Headers are sent with the first write; errors after that can only be
+	// logged.
+	if _, err := bbl.WriteWorks(w, ww, works); err != nil {
+		app.log.Error("export works", "path", r.URL.Path, "err", err)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ugent-library/bbl"
+)
+
+// Work files. Browsers upload straight to the file store: the upload
+// handler hands out a presigned URL, the attach form then finishes the
+// upload (checksum, MIME sniffing) and attaches the file in a revision.
+
+type fileUpload struct {
+	FileID string `json:"file_id"`
+	URL    string `json:"url"`
+}
+
+// backofficeStartFileUpload registers a pending file and returns its ID and
+// presigned upload URL as JSON.
+func (app *App) backofficeStartFileUpload(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	if _, err := app.getWork(r, "public", "private"); err != nil {
+		return err
+	}
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	name := strings.TrimSpace(r.FormValue("name"))
+	if name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return nil
+	}
+	f, u, err := app.services.StartFileUpload(r.Context(), c.User, name, r.FormValue("content_type"))
+	if err != nil {
+		return fmt.Errorf("backofficeStartFileUpload: %w", err)
+	}
+	return writeJSON(w, fileUpload{FileID: f.ID.String(), URL: u})
+}
+
+// backofficeAttachWorkFile attaches an uploaded file, or updates the access
+// settings of an attached one.
+func (app *App) backofficeAttachWorkFile(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	work, err := app.getWork(r, "public", "private")
+	if err != nil {
+		return err
+	}
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	fileID, err := bbl.ParseID(r.FormValue("file_id"))
+	if err != nil {
+		return bbl.ErrNotFound
+	}
+	// Files that are already attached only get their settings updated;
+	// new ones are finished first.
+	var f *bbl.File
+	if slices.ContainsFunc(work.Files, func(wf bbl.WorkFile) bool { return wf.FileID == fileID }) {
+		f, err = app.services.Repo.GetFile(r.Context(), fileID)
+	} else {
+		f, err = app.services.FinishFileUpload(r.Context(), c.User, fileID)
+	}
+	if err != nil {
+		return fmt.Errorf("backofficeAttachWorkFile: %w", err)
+	}
+	wf, err := workFileFromForm(r, f)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil
+	}
+	if _, err := app.services.UpdateAndIndex(r.Context(), c.User, &bbl.AttachWorkFile{WorkID: work.ID, File: wf}); err != nil {
+		return fmt.Errorf("backofficeAttachWorkFile: %w", err)
+	}
+	http.Redirect(w, r, fmt.Sprintf("/backoffice/works/%s", work.ID), http.StatusSeeOther)
+	return nil
+}
+
+func (app *App) backofficeDetachWorkFile(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	work, err := app.getWork(r, "public", "private")
+	if err != nil {
+		return err
+	}
+	fileID, err := bbl.ParseID(r.PathValue("file_id"))
+	if err != nil {
+		return bbl.ErrNotFound
+	}
+	if _, err := app.services.UpdateAndIndex(r.Context(), c.User, &bbl.DetachWorkFile{WorkID: work.ID, FileID: fileID}); err != nil {
+		return fmt.Errorf("backofficeDetachWorkFile: %w", err)
+	}
+	http.Redirect(w, r, fmt.Sprintf("/backoffice/works/%s", work.ID), http.StatusSeeOther)
+	return nil
+}
+
+// downloadWorkFile redirects to a short-lived download URL if the current
+// user may access the file. Public works only.
+func (app *App) downloadWorkFile(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	work, err := app.getWork(r, "public")
+	if err != nil {
+		return err
+	}
+	return app.redirectWorkFile(w, r, c, work)
+}
+
+// backofficeDownloadWorkFile is downloadWorkFile for private works.
+func (app *App) backofficeDownloadWorkFile(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	work, err := app.getWork(r, "public", "private")
+	if err != nil {
+		return err
+	}
+	return app.redirectWorkFile(w, r, c, work)
+}
+
+func (app *App) redirectWorkFile(w http.ResponseWriter, r *http.Request, c *Ctx, work *bbl.Work) error {
+	fileID, err := bbl.ParseID(r.PathValue("file_id"))
+	if err != nil {
+		return bbl.ErrNotFound
+	}
+	for _, f := range work.Files {
+		if f.FileID != fileID {
+			continue
+		}
+		u, err := app.services.FileDownloadURL(r.Context(), c.User, f)
+		if err != nil {
+			return err
+		}
+		http.Redirect(w, r, u, http.StatusFound)
+		return nil
+	}
+	return bbl.ErrNotFound
+}
+
+// workFileFromForm reads the asserted file settings from a form. The name
+// defaults to the uploaded file name.
+func workFileFromForm(r *http.Request, f *bbl.File) (bbl.WorkFile, error) {
+	wf := bbl.WorkFile{
+		FileID:     f.ID,
+		Name:       strings.TrimSpace(r.FormValue("name")),
+		AccessKind: r.FormValue("access_kind"),
+		License:    strings.TrimSpace(r.FormValue("license")),
+	}
+	if wf.Name == "" {
+		wf.Name = f.Name
+	}
+	if v := strings.TrimSpace(r.FormValue("embargo_until")); v != "" {
+		t, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			return wf, fmt.Errorf("invalid embargo date %q", v)
+		}
+		wf.EmbargoUntil = &t
+		wf.EmbargoAccessKind = r.FormValue("embargo_access_kind")
+	}
+	return wf, nil
+}
@@ -4,6 +4,8 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+
+	"github.com/ugent-library/bbl"
 )
 
 // formGroups parses indexed form fields like prefix[0].field, prefix[1].field
@@ -43,3 +45,51 @@ func formGroups(form url.Values, prefix string) []url.Values {
 	}
 	return result
 }
+
+// formTitles parses the parallel name.lang and name.val fields of a title
+// list, skipping empty items.
+func formTitles(form url.Values, name string) []bbl.Title {
+	langs := form[name+".lang"]
+	vals := form[name+".val"]
+	var titles []bbl.Title
+	for i := range min(len(langs), len(vals)) {
+		lang := strings.TrimSpace(langs[i])
+		val := strings.TrimSpace(vals[i])
+		if lang != "" || val != "" {
+			titles = append(titles, bbl.Title{Lang: lang, Val: val})
+		}
+	}
+	return titles
+}
+
+// formTexts parses the parallel name.lang and name.val fields of a text
+// list, skipping empty items.
+func formTexts(form url.Values, name string) []bbl.Text {
+	langs := form[name+".lang"]
+	vals := form[name+".val"]
+	var texts []bbl.Text
+	for i := range min(len(langs), len(vals)) {
+		lang := strings.TrimSpace(langs[i])
+		val := strings.TrimSpace(vals[i])
+		if lang != "" || val != "" {
+			texts = append(texts, bbl.Text{Lang: lang, Val: val})
+		}
+	}
+	return texts
+}
+
+// formIdentifiers parses the parallel name.scheme and name.val fields of an
+// identifier list, skipping empty items.
+func formIdentifiers(form url.Values, name string) []bbl.Identifier {
+	schemes := form[name+".scheme"]
+	vals := form[name+".val"]
+	var ids []bbl.Identifier
+	for i := range min(len(schemes), len(vals)) {
+		scheme := strings.TrimSpace(schemes[i])
+		val := strings.TrimSpace(vals[i])
+		if scheme != "" || val != "" {
+			ids = append(ids, bbl.Identifier{Scheme: scheme, Val: val})
+		}
+	}
+	return ids
+}
@@ -0,0 +1,50 @@
+package app
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/ugent-library/bbl"
+)
+
+// Impersonation. An admin can act as another user to see the backoffice
+// exactly as they do. The session keeps both users; revisions record the
+// admin as impersonator and start and stop are logged as user events.
+
+func (app *App) backofficeStartImpersonation(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	if c.Impersonator != nil || c.User.Role != bbl.RoleAdmin {
+		return bbl.ErrForbidden
+	}
+	username := strings.TrimSpace(r.FormValue("username"))
+	if username == "" {
+		http.Error(w, "username is required", http.StatusBadRequest)
+		return nil
+	}
+	user, err := app.services.Repo.GetUserByUsername(r.Context(), username)
+	if err != nil {
+		return err
+	}
+	if err := app.services.Repo.StartImpersonation(r.Context(), c.User, user); err != nil {
+		return err
+	}
+	if err := app.session.save(w, &sessionData{UserID: user.ID.String(), ImpersonatorID: c.User.ID.String()}); err != nil {
+		return err
+	}
+	http.Redirect(w, r, "/backoffice", http.StatusSeeOther)
+	return nil
+}
+
+func (app *App) backofficeStopImpersonation(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	if c.Impersonator == nil {
+		http.Redirect(w, r, "/backoffice", http.StatusSeeOther)
+		return nil
+	}
+	if err := app.services.Repo.StopImpersonation(r.Context(), c.Impersonator, c.User); err != nil {
+		return err
+	}
+	if err := app.session.save(w, &sessionData{UserID: c.Impersonator.ID.String()}); err != nil {
+		return err
+	}
+	http.Redirect(w, r, "/backoffice", http.StatusSeeOther)
+	return nil
+}
@@ -0,0 +1,47 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/ugent-library/bbl"
+	"github.com/ugent-library/bbl/app/views"
+)
+
+// backofficeChangeWorkKind shows the kind picker and, once a kind is picked,
+// a preview of the fields it drops.
+func (app *App) backofficeChangeWorkKind(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	work, err := app.getWork(r, "public", "private")
+	if err != nil {
+		return err
+	}
+	var preview *bbl.WorkKindChangePreview
+	if kind := r.URL.Query().Get("kind"); kind != "" && kind != work.Kind {
+		preview, err = app.services.Repo.PreviewChangeWorkKind(r.Context(), c.User, &bbl.ChangeWorkKind{WorkID: work.ID, Kind: kind})
+		if err != nil {
+			return err
+		}
+	}
+	kinds := app.services.Repo.Profiles.WorkKinds()
+	return views.BackofficeChangeWorkKind(c.ViewCtx, work, kinds, preview).Render(r.Context(), w)
+}
+
+func (app *App) backofficeUpdateWorkKind(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	work, err := app.getWork(r, "public", "private")
+	if err != nil {
+		return err
+	}
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	change := &bbl.ChangeWorkKind{
+		WorkID:  work.ID,
+		Kind:    r.FormValue("kind"),
+		Archive: r.FormValue("dropped") == "archive",
+	}
+	if _, err := app.services.UpdateAndIndex(r.Context(), c.User, change); err != nil {
+		return fmt.Errorf("backofficeUpdateWorkKind: %w", err)
+	}
+	http.Redirect(w, r, fmt.Sprintf("/backoffice/works/%s", work.ID), http.StatusSeeOther)
+	return nil
+}
@@ -0,0 +1,260 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ugent-library/bbl"
+	"github.com/ugent-library/bbl/app/views"
+)
+
+// Lists. Users keep named, ordered lists of works in the backoffice. Public
+// lists are shared by link on the discovery side, where only public works
+// are shown.
+
+// getList loads the list in the path. Lists the user may not view are
+// reported as not found.
+func (app *App) getList(r *http.Request, c *Ctx) (*bbl.List, error) {
+	id, err := bbl.ParseID(r.PathValue("id"))
+	if err != nil {
+		return nil, bbl.ErrNotFound
+	}
+	list, err := app.services.Repo.GetList(r.Context(), id)
+	if err != nil {
+		return nil, err
+	}
+	if !list.CanView(c.User) {
+		return nil, bbl.ErrNotFound
+	}
+	return list, nil
+}
+
+// getEditableList loads the list in the path and checks the user may change
+// it.
+func (app *App) getEditableList(r *http.Request, c *Ctx) (*bbl.List, error) {
+	list, err := app.getList(r, c)
+	if err != nil {
+		return nil, err
+	}
+	if !list.CanEdit(c.User) {
+		return nil, bbl.ErrForbidden
+	}
+	return list, nil
+}
+
+// listWorks returns the items of a list with their works. Items whose work
+// is gone or hidden from the user are dropped.
+func (app *App) listWorks(r *http.Request, list *bbl.List, all bool) ([]bbl.ListItem, map[bbl.ID]*bbl.Work, error) {
+	items, err := app.services.Repo.GetListItems(r.Context(), list.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	ids := make([]bbl.ID, len(items))
+	for i, item := range items {
+		ids[i] = item.WorkID
+	}
+	recs, err := app.services.Repo.GetWorks(r.Context(), ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	works := make(map[bbl.ID]*bbl.Work, len(recs))
+	for _, w := range recs {
+		if all || w.Status == bbl.WorkStatusPublic {
+			works[w.ID] = w
+		}
+	}
+	items = slices.DeleteFunc(items, func(item bbl.ListItem) bool {
+		return works[item.WorkID] == nil
+	})
+	return items, works, nil
+}
+
+func listAttrsFromForm(r *http.Request) bbl.ListAttrs {
+	return bbl.ListAttrs{
+		Name:        r.FormValue("name"),
+		Description: r.FormValue("description"),
+		Public:      r.FormValue("public") == "true",
+	}
+}
+
+func (app *App) showList(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	list, err := app.getList(r, c)
+	if err != nil {
+		return err
+	}
+	if !list.Public {
+		return bbl.ErrNotFound
+	}
+	items, works, err := app.listWorks(r, list, false)
+	if err != nil {
+		return err
+	}
+	return views.ShowList(c.ViewCtx, list, items, works, bbl.WorkWriterFormats()).Render(r.Context(), w)
+}
+
+func (app *App) exportList(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	list, err := app.getList(r, c)
+	if err != nil {
+		return err
+	}
+	if !list.Public {
+		return bbl.ErrNotFound
+	}
+	return app.writeList(w, r, list, false)
+}
+
+func (app *App) backofficeLists(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	lists, err := app.services.Repo.GetUserLists(r.Context(), c.User.ID)
+	if err != nil {
+		return err
+	}
+	return views.BackofficeLists(c.ViewCtx, lists).Render(r.Context(), w)
+}
+
+func (app *App) backofficeCreateList(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	list, err := app.services.Repo.CreateList(r.Context(), c.User, listAttrsFromForm(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil
+	}
+	http.Redirect(w, r, fmt.Sprintf("/backoffice/lists/%s", list.ID), http.StatusSeeOther)
+	return nil
+}
+
+func (app *App) backofficeShowList(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	list, err := app.getList(r, c)
+	if err != nil {
+		return err
+	}
+	items, works, err := app.listWorks(r, list, true)
+	if err != nil {
+		return err
+	}
+	return views.BackofficeShowList(c.ViewCtx, list, list.CanEdit(c.User), items, works, bbl.WorkWriterFormats()).Render(r.Context(), w)
+}
+
+func (app *App) backofficeUpdateList(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	list, err := app.getEditableList(r, c)
+	if err != nil {
+		return err
+	}
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	if err := app.services.Repo.UpdateList(r.Context(), list.ID, listAttrsFromForm(r)); err != nil {
+		return fmt.Errorf("backofficeUpdateList: %w", err)
+	}
+	http.Redirect(w, r, fmt.Sprintf("/backoffice/lists/%s", list.ID), http.StatusSeeOther)
+	return nil
+}
+
+func (app *App) backofficeDeleteList(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	list, err := app.getEditableList(r, c)
+	if err != nil {
+		return err
+	}
+	if err := app.services.Repo.DeleteList(r.Context(), list.ID); err != nil {
+		return fmt.Errorf("backofficeDeleteList: %w", err)
+	}
+	http.Redirect(w, r, "/backoffice/lists", http.StatusSeeOther)
+	return nil
+}
+
+// backofficeAddListItems adds the works given in the work_id form values to
+// the list in the path, or to the list in the list_id form value when posted
+// from search results.
+func (app *App) backofficeAddListItems(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	if r.PathValue("id") == "" {
+		r.SetPathValue("id", r.FormValue("list_id"))
+	}
+	list, err := app.getEditableList(r, c)
+	if err != nil {
+		return err
+	}
+	var ids []bbl.ID
+	for _, v := range r.Form["work_id"] {
+		id, err := bbl.ParseID(strings.TrimSpace(v))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid work id %q", v), http.StatusBadRequest)
+			return nil
+		}
+		ids = append(ids, id)
+	}
+	if _, err := app.services.Repo.AddListItems(r.Context(), list.ID, ids); err != nil {
+		return fmt.Errorf("backofficeAddListItems: %w", err)
+	}
+	http.Redirect(w, r, fmt.Sprintf("/backoffice/lists/%s", list.ID), http.StatusSeeOther)
+	return nil
+}
+
+func (app *App) backofficeRemoveListItem(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	list, err := app.getEditableList(r, c)
+	if err != nil {
+		return err
+	}
+	workID, err := bbl.ParseID(r.PathValue("work_id"))
+	if err != nil {
+		return bbl.ErrNotFound
+	}
+	if err := app.services.Repo.RemoveListItem(r.Context(), list.ID, workID); err != nil {
+		return fmt.Errorf("backofficeRemoveListItem: %w", err)
+	}
+	http.Redirect(w, r, fmt.Sprintf("/backoffice/lists/%s", list.ID), http.StatusSeeOther)
+	return nil
+}
+
+// backofficeUpdateListItem sets the note of a list item and, if a position
+// is given, moves it there. Positions are 1-based.
+func (app *App) backofficeUpdateListItem(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	list, err := app.getEditableList(r, c)
+	if err != nil {
+		return err
+	}
+	workID, err := bbl.ParseID(r.PathValue("work_id"))
+	if err != nil {
+		return bbl.ErrNotFound
+	}
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	if _, ok := r.Form["note"]; ok {
+		if err := app.services.Repo.SetListItemNote(r.Context(), list.ID, workID, r.FormValue("note")); err != nil {
+			return fmt.Errorf("backofficeUpdateListItem: %w", err)
+		}
+	}
+	if v := r.FormValue("position"); v != "" {
+		pos, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid position %q", v), http.StatusBadRequest)
+			return nil
+		}
+		if err := app.services.Repo.MoveListItem(r.Context(), list.ID, workID, pos-1); err != nil {
+			return fmt.Errorf("backofficeUpdateListItem: %w", err)
+		}
+	}
+	http.Redirect(w, r, fmt.Sprintf("/backoffice/lists/%s", list.ID), http.StatusSeeOther)
+	return nil
+}
+
+func (app *App) backofficeExportList(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	list, err := app.getList(r, c)
+	if err != nil {
+		return err
+	}
+	return app.writeList(w, r, list, true)
+}
+
+// writeList streams the works of a list in the format given in the format
+// query parameter.
+func (app *App) writeList(w http.ResponseWriter, r *http.Request, list *bbl.List, all bool) error {
+	return app.writeWorks(w, r, "list-"+list.ID.String(), app.services.Repo.EachListWork(r.Context(), list.ID, all))
+}
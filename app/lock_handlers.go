@@ -0,0 +1,128 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ugent-library/bbl"
+	"github.com/ugent-library/bbl/app/views"
+)
+
+// Curator locks and the lock conflict queue. Role checks happen in the
+// updaters; these handlers only translate forms into updates.
+
+func (app *App) backofficeLockWork(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	work, err := app.getWork(r, "public", "private")
+	if err != nil {
+		return err
+	}
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	var fields []string
+	for _, f := range r.Form["field"] {
+		if f = strings.TrimSpace(f); f != "" {
+			fields = append(fields, f)
+		}
+	}
+	lock := &bbl.LockWork{WorkID: work.ID, Fields: fields, Note: strings.TrimSpace(r.FormValue("note"))}
+	if _, err := app.services.UpdateAndIndex(r.Context(), c.User, lock); err != nil {
+		return fmt.Errorf("backofficeLockWork: %w", err)
+	}
+	http.Redirect(w, r, fmt.Sprintf("/backoffice/works/%s", work.ID), http.StatusSeeOther)
+	return nil
+}
+
+func (app *App) backofficeUnlockWork(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	work, err := app.getWork(r, "public", "private")
+	if err != nil {
+		return err
+	}
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	unlock := &bbl.UnlockWork{WorkID: work.ID}
+	if f := r.FormValue("field"); f != "" {
+		unlock.Fields = []string{f}
+	}
+	if _, err := app.services.UpdateAndIndex(r.Context(), c.User, unlock); err != nil {
+		return fmt.Errorf("backofficeUnlockWork: %w", err)
+	}
+	http.Redirect(w, r, fmt.Sprintf("/backoffice/works/%s", work.ID), http.StatusSeeOther)
+	return nil
+}
+
+func (app *App) backofficeAcceptLockConflict(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	conflict, err := app.getLockConflict(r)
+	if err != nil {
+		return err
+	}
+	updates, err := conflict.AcceptUpdates()
+	if err != nil {
+		return err
+	}
+	if _, err := app.services.UpdateAndIndex(r.Context(), c.User, updates...); err != nil {
+		return fmt.Errorf("backofficeAcceptLockConflict: %w", err)
+	}
+	http.Redirect(w, r, lockConflictReturnURL(r, conflict), http.StatusSeeOther)
+	return nil
+}
+
+func (app *App) backofficeDismissLockConflict(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	conflict, err := app.getLockConflict(r)
+	if err != nil {
+		return err
+	}
+	if _, err := app.services.UpdateAndIndex(r.Context(), c.User, conflict.DismissUpdates()...); err != nil {
+		return fmt.Errorf("backofficeDismissLockConflict: %w", err)
+	}
+	http.Redirect(w, r, lockConflictReturnURL(r, conflict), http.StatusSeeOther)
+	return nil
+}
+
+func (app *App) backofficeLockConflicts(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	opts := parseSearchOpts(r)
+	conflicts, total, err := app.services.Repo.ListWorkLockConflicts(r.Context(), opts.Size, opts.Offset)
+	if err != nil {
+		return err
+	}
+	workIDs := make([]bbl.ID, len(conflicts))
+	for i, conflict := range conflicts {
+		workIDs[i] = conflict.WorkID
+	}
+	recs, err := app.services.Repo.GetWorks(r.Context(), workIDs)
+	if err != nil {
+		return err
+	}
+	works := make(map[bbl.ID]*bbl.Work, len(recs))
+	for _, work := range recs {
+		works[work.ID] = work
+	}
+	return views.BackofficeLockConflicts(c.ViewCtx, conflicts, works, total, opts).Render(r.Context(), w)
+}
+
+func (app *App) getLockConflict(r *http.Request) (*bbl.WorkLockConflict, error) {
+	id, err := strconv.ParseInt(r.PathValue("conflict_id"), 10, 64)
+	if err != nil {
+		return nil, bbl.ErrNotFound
+	}
+	conflict, err := app.services.Repo.GetWorkLockConflict(r.Context(), id)
+	if err != nil {
+		return nil, err
+	}
+	if conflict.WorkID.String() != r.PathValue("id") {
+		return nil, bbl.ErrNotFound
+	}
+	return conflict, nil
+}
+
+// lockConflictReturnURL sends the curator back to the queue when the action
+// was taken from there, and to the work otherwise.
+func lockConflictReturnURL(r *http.Request, conflict *bbl.WorkLockConflict) string {
+	if r.FormValue("return") == "queue" {
+		return "/backoffice/conflicts"
+	}
+	return fmt.Sprintf("/backoffice/works/%s", conflict.WorkID)
+}
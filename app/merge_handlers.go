@@ -0,0 +1,61 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ugent-library/bbl"
+)
+
+// backofficeMergeWork merges the work given in the merged_id form value into
+// the work in the path.
+func (app *App) backofficeMergeWork(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	work, err := app.getWork(r, "public", "private")
+	if err != nil {
+		return err
+	}
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	mergedID, err := bbl.ParseID(strings.TrimSpace(r.FormValue("merged_id")))
+	if err != nil {
+		return bbl.ErrNotFound
+	}
+	merge := &bbl.MergeWorks{WorkID: work.ID, MergedID: mergedID}
+	if _, err := app.services.UpdateAndIndex(r.Context(), c.User, merge); err != nil {
+		return fmt.Errorf("backofficeMergeWork: %w", err)
+	}
+	if r.FormValue("return") == "duplicates" {
+		http.Redirect(w, r, "/backoffice/duplicates", http.StatusSeeOther)
+		return nil
+	}
+	http.Redirect(w, r, fmt.Sprintf("/backoffice/works/%s", work.ID), http.StatusSeeOther)
+	return nil
+}
+
+// backofficeMergePerson merges the person given in the merged_id form value
+// into the person in the path.
+func (app *App) backofficeMergePerson(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	person, err := app.getPerson(r)
+	if err != nil {
+		return err
+	}
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	mergedID, err := bbl.ParseID(strings.TrimSpace(r.FormValue("merged_id")))
+	if err != nil {
+		return bbl.ErrNotFound
+	}
+	merge := &bbl.MergePeople{PersonID: person.ID, MergedID: mergedID}
+	if _, err := app.services.UpdateAndIndex(r.Context(), c.User, merge); err != nil {
+		return fmt.Errorf("backofficeMergePerson: %w", err)
+	}
+	if r.FormValue("return") == "duplicates" {
+		http.Redirect(w, r, "/backoffice/duplicates?type="+bbl.RecordTypePerson, http.StatusSeeOther)
+		return nil
+	}
+	http.Redirect(w, r, fmt.Sprintf("/backoffice/people/%s", person.ID), http.StatusSeeOther)
+	return nil
+}
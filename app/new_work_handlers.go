@@ -0,0 +1,142 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ugent-library/bbl"
+	"github.com/ugent-library/bbl/app/views"
+	"github.com/ugent-library/vo"
+)
+
+// New work. The user either picks a kind and fills in an empty form, or
+// pastes an identifier that is looked up in the matching get source and
+// shown as a prefilled draft. Nothing is stored until the form is saved.
+
+func (app *App) backofficeNewWork(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	q := r.URL.Query()
+	kinds := app.services.Repo.Profiles.WorkKinds()
+
+	if identifier := q.Get("identifier"); identifier != "" {
+		l, err := app.services.LookupWork(r.Context(), identifier)
+		if err != nil {
+			app.log.Warn("lookup work", "identifier", identifier, "err", err)
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return views.BackofficeNewWork(c.ViewCtx, kinds, identifier, err.Error()).Render(r.Context(), w)
+		}
+		defs := app.services.Repo.Profiles.FieldDefs(bbl.RecordTypeWork, l.Work.Kind)
+		if defs == nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return views.BackofficeNewWork(c.ViewCtx, kinds, identifier, fmt.Sprintf("no profile for kind %q", l.Work.Kind)).Render(r.Context(), w)
+		}
+		return views.BackofficeNewWorkForm(c.ViewCtx, importDraft(l.Work), defs, l, nil).Render(r.Context(), w)
+	}
+
+	kind := q.Get("kind")
+	defs := app.services.Repo.Profiles.FieldDefs(bbl.RecordTypeWork, kind)
+	if defs == nil {
+		return views.BackofficeNewWork(c.ViewCtx, kinds, "", "").Render(r.Context(), w)
+	}
+	return views.BackofficeNewWorkForm(c.ViewCtx, &bbl.Work{Kind: kind}, defs, nil, nil).Render(r.Context(), w)
+}
+
+func (app *App) backofficeCreateWork(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	kind := r.FormValue("kind")
+	defs := app.services.Repo.Profiles.FieldDefs(bbl.RecordTypeWork, kind)
+	if defs == nil {
+		http.Error(w, fmt.Sprintf("unknown work kind %q", kind), http.StatusBadRequest)
+		return nil
+	}
+
+	work := &bbl.Work{ID: bbl.NewID(), Kind: kind}
+	updates := append([]any{&bbl.CreateWork{ID: work.ID, Kind: kind}}, buildWorkUpdates(r, defs, work)...)
+	// The edit form shows identifiers read-only; a new work takes them from
+	// the form.
+	for _, f := range defs {
+		if f.Type != "identifier" {
+			continue
+		}
+		if ids := formIdentifiers(r.Form, f.Name); len(ids) > 0 {
+			updates = append(updates, &bbl.Set{RecordType: bbl.RecordTypeWork, RecordID: work.ID, Field: f.Name, Val: ids})
+		}
+	}
+
+	if _, err := app.services.UpdateAndIndex(r.Context(), c.User, updates...); err != nil {
+		var errs vo.Errors
+		if errors.As(err, &errs) {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return views.BackofficeNewWorkForm(c.ViewCtx, formDraft(work, updates), defs, nil, errs).Render(r.Context(), w)
+		}
+		return fmt.Errorf("backofficeCreateWork: %w", err)
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/backoffice/works/%s", work.ID), http.StatusSeeOther)
+	return nil
+}
+
+// importDraft turns a record fetched from a source into an unsaved work for
+// the new work form. References to other records are dropped; contributors
+// keep their names.
+func importDraft(in *bbl.ImportWorkInput) *bbl.Work {
+	work := &bbl.Work{
+		Kind:                in.Kind,
+		ArticleNumber:       in.ArticleNumber,
+		BookTitle:           in.BookTitle,
+		Conference:          in.Conference,
+		Edition:             in.Edition,
+		Issue:               in.Issue,
+		IssueTitle:          in.IssueTitle,
+		JournalAbbreviation: in.JournalAbbreviation,
+		JournalTitle:        in.JournalTitle,
+		Pages:               in.Pages,
+		PlaceOfPublication:  in.PlaceOfPublication,
+		PublicationStatus:   in.PublicationStatus,
+		PublicationYear:     in.PublicationYear,
+		Publisher:           in.Publisher,
+		ReportNumber:        in.ReportNumber,
+		SeriesTitle:         in.SeriesTitle,
+		TotalPages:          in.TotalPages,
+		Volume:              in.Volume,
+		Identifiers:         in.Identifiers,
+		Classifications:     in.Classifications,
+		Titles:              in.Titles,
+		Abstracts:           in.Abstracts,
+		LaySummaries:        in.LaySummaries,
+		Notes:               in.Notes,
+		Keywords:            in.Keywords,
+	}
+	for _, co := range in.Contributors {
+		work.Contributors = append(work.Contributors, bbl.WorkContributor{
+			Kind:         co.Kind,
+			Name:         co.Name,
+			GivenName:    co.GivenName,
+			FamilyName:   co.FamilyName,
+			Roles:        co.Roles,
+			Affiliations: co.Affiliations,
+		})
+	}
+	return work
+}
+
+// formDraft rebuilds an unsaved work from the Set updates of a rejected new
+// work form, so the form can be shown again with the submitted values. Set
+// values marshal to the same JSON as the matching Work fields.
+func formDraft(work *bbl.Work, updates []any) *bbl.Work {
+	fields := make(map[string]any)
+	for _, u := range updates {
+		if s, ok := u.(*bbl.Set); ok {
+			fields[s.Field] = s.Val
+		}
+	}
+	draft := &bbl.Work{ID: work.ID, Kind: work.Kind}
+	if b, err := json.Marshal(fields); err == nil {
+		_ = json.Unmarshal(b, draft)
+	}
+	draft.ID, draft.Kind = work.ID, work.Kind
+	return draft
+}
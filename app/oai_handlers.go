@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"slices"
 	"time"
 
 	"github.com/ugent-library/bbl"
@@ -19,7 +20,7 @@ func (app *App) oaiHandler() http.Handler {
 		BaseURL:         app.rootURL + "/oai",
 		AdminEmails:     []string{},
 		MetadataFormats: []oaipmh.MetadataFormat{oaipmh.OAIDC},
-		DeletedRecord:   "no",
+		DeletedRecord:   "persistent",
 		RecordProvider:  &oaiBackend{services: app.services, encoder: &dcformat.OAIWorkEncoder{}},
 	})
 	return p
@@ -30,11 +31,15 @@ type oaiBackend struct {
 	encoder  bbl.WorkEncoder
 }
 
-// oaiCursor wraps the repo cursor with from/until so resumption tokens are self-contained.
+// oaiCursor wraps the repo cursor with from/until/set so resumption tokens
+// are self-contained. Harvests of a query collection also carry the snapshot
+// the collection was evaluated into on the first page.
 type oaiCursor struct {
-	Cursor string    `json:"c,omitempty"`
-	From   time.Time `json:"f,omitempty"`
-	Until  time.Time `json:"t,omitempty"`
+	Cursor   string    `json:"c,omitempty"`
+	From     time.Time `json:"f,omitempty"`
+	Until    time.Time `json:"t,omitempty"`
+	Set      string    `json:"s,omitempty"`
+	Snapshot *bbl.ID   `json:"n,omitempty"`
 }
 
 func encodeOAICursor(c oaiCursor) string {
@@ -59,76 +64,170 @@ func (b *oaiBackend) GetEarliestDatestamp(ctx context.Context) (time.Time, error
 }
 
 func (b *oaiBackend) ListRecords(ctx context.Context, q oaipmh.Query) (*oaipmh.Page, error) {
-	res, from, until, err := b.listWorks(ctx, q)
+	res, cur, err := b.listWorks(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	specs, err := b.setSpecs(ctx, res.Works, cur.Set)
 	if err != nil {
 		return nil, err
 	}
 
 	records := make([]*oaipmh.Record, len(res.Works))
 	for i, w := range res.Works {
-		data, err := b.encoder.Encode(w)
+		records[i], err = b.workRecord(w, specs[w.ID])
 		if err != nil {
-			return nil, fmt.Errorf("oaiBackend encode: %w", err)
-		}
-		records[i] = &oaipmh.Record{
-			Header:   workHeader(w),
-			Metadata: &oaipmh.Payload{XML: string(data)},
+			return nil, err
 		}
 	}
 
 	return &oaipmh.Page{
 		Records: records,
-		Cursor:  b.wrapCursor(res.Cursor, from, until),
+		Cursor:  wrapCursor(res.Cursor, cur),
 	}, nil
 }
 
 func (b *oaiBackend) ListIdentifiers(ctx context.Context, q oaipmh.Query) (*oaipmh.IdentifierPage, error) {
-	res, from, until, err := b.listWorks(ctx, q)
+	res, cur, err := b.listWorks(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	specs, err := b.setSpecs(ctx, res.Works, cur.Set)
 	if err != nil {
 		return nil, err
 	}
 
 	headers := make([]*oaipmh.Header, len(res.Works))
 	for i, w := range res.Works {
-		headers[i] = workHeader(w)
+		headers[i] = workHeader(w, specs[w.ID])
 	}
 
 	return &oaipmh.IdentifierPage{
 		Headers: headers,
-		Cursor:  b.wrapCursor(res.Cursor, from, until),
+		Cursor:  wrapCursor(res.Cursor, cur),
 	}, nil
 }
 
-func (b *oaiBackend) listWorks(ctx context.Context, q oaipmh.Query) (*bbl.ListPublicWorksResult, time.Time, time.Time, error) {
-	from, until := q.From, q.Until
-	var repoCursor string
-
+// listWorks returns a page of works. The returned cursor has the from, until
+// and set of the original request, restored from the resumption token.
+//
+// Manual sets are filtered in the database. Query sets are evaluated through
+// the index once, on the first page, into a snapshot that later pages filter
+// on.
+func (b *oaiBackend) listWorks(ctx context.Context, q oaipmh.Query) (*bbl.ListPublicWorksResult, oaiCursor, error) {
+	cur := oaiCursor{From: q.From, Until: q.Until, Set: q.Set}
 	if q.Cursor != "" {
-		cur, err := decodeOAICursor(q.Cursor)
+		c, err := decodeOAICursor(q.Cursor)
 		if err != nil {
-			return nil, time.Time{}, time.Time{}, oaipmh.ErrBadResumptionToken
+			return nil, cur, oaipmh.ErrBadResumptionToken
 		}
-		repoCursor = cur.Cursor
-		from, until = cur.From, cur.Until
+		cur = c
 	}
 
-	res, err := b.services.Repo.ListPublicWorks(ctx, bbl.ListPublicWorksOpts{
-		From:   from,
-		Until:  until,
-		Cursor: repoCursor,
-		Limit:  q.Limit,
-	})
+	opts := bbl.ListPublicWorksOpts{
+		From:           cur.From,
+		Until:          cur.Until,
+		Cursor:         cur.Cursor,
+		Limit:          q.Limit,
+		IncludeDeleted: true,
+	}
+	if cur.Set != "" {
+		c, err := b.getSet(ctx, cur.Set)
+		if err != nil {
+			return nil, cur, err
+		}
+		switch {
+		case c.Kind == bbl.CollectionKindManual:
+			opts.CollectionID = &c.ID
+		case cur.Snapshot != nil:
+			ok, err := b.services.Repo.HasCollectionSnapshot(ctx, c.ID, *cur.Snapshot)
+			if err != nil {
+				return nil, cur, err
+			}
+			if !ok {
+				return nil, cur, oaipmh.ErrBadResumptionToken
+			}
+		default:
+			ids, err := b.services.CollectionWorkIDs(ctx, c)
+			if err != nil {
+				return nil, cur, err
+			}
+			id, err := b.services.Repo.CreateCollectionSnapshot(ctx, c.ID, ids)
+			if err != nil {
+				return nil, cur, err
+			}
+			cur.Snapshot = &id
+		}
+		opts.SnapshotID = cur.Snapshot
+	}
+
+	res, err := b.services.Repo.ListPublicWorks(ctx, opts)
 	if err != nil {
-		return nil, time.Time{}, time.Time{}, err
+		return nil, cur, err
 	}
-	return res, from, until, nil
+	return res, cur, nil
 }
 
-func (b *oaiBackend) wrapCursor(repoCursor string, from, until time.Time) string {
+func wrapCursor(repoCursor string, cur oaiCursor) string {
 	if repoCursor == "" {
 		return ""
 	}
-	return encodeOAICursor(oaiCursor{Cursor: repoCursor, From: from, Until: until})
+	cur.Cursor = repoCursor
+	return encodeOAICursor(cur)
+}
+
+// setSpecs returns the sets of each work. Works listed for a set are always
+// reported in it, even after they dropped out of a query set by leaving the
+// public status.
+func (b *oaiBackend) setSpecs(ctx context.Context, works []*bbl.Work, set string) (map[bbl.ID][]string, error) {
+	ids := make([]bbl.ID, len(works))
+	for i, w := range works {
+		ids[i] = w.ID
+	}
+	specs, err := b.services.PublicCollectionNames(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	if set != "" {
+		for _, id := range ids {
+			if !slices.Contains(specs[id], set) {
+				specs[id] = append(specs[id], set)
+			}
+		}
+	}
+	return specs, nil
+}
+
+// Sets are the public collections.
+
+func (b *oaiBackend) ListSets(ctx context.Context) ([]oaipmh.Set, error) {
+	collections, err := b.services.Repo.GetCollections(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	sets := make([]oaipmh.Set, len(collections))
+	for i, c := range collections {
+		sets[i] = oaipmh.Set{Spec: c.Name, Name: c.DisplayTitle()}
+	}
+	return sets, nil
+}
+
+func (b *oaiBackend) HasSet(ctx context.Context, spec string) (bool, error) {
+	_, err := b.getSet(ctx, spec)
+	if err == oaipmh.ErrBadResumptionToken {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// getSet returns the public collection with the given spec. Unknown sets can
+// only come from stale resumption tokens, HasSet guards new requests.
+func (b *oaiBackend) getSet(ctx context.Context, spec string) (*bbl.Collection, error) {
+	c, err := b.services.Repo.GetCollectionByName(ctx, spec)
+	if err == bbl.ErrNotFound || (err == nil && !c.Public) {
+		return nil, oaipmh.ErrBadResumptionToken
+	}
+	return c, err
 }
 
 func (b *oaiBackend) GetRecord(ctx context.Context, id, metadataPrefix string) (*oaipmh.Record, error) {
@@ -136,29 +235,49 @@ func (b *oaiBackend) GetRecord(ctx context.Context, id, metadataPrefix string) (
 	if err != nil {
 		return nil, oaipmh.ErrIDDoesNotExist
 	}
-	w, err := b.services.Repo.GetWork(ctx, workID)
-	if err == bbl.ErrNotFound {
+	// Deleted and merged works are only reported if they were public.
+	res, err := b.services.Repo.ListPublicWorks(ctx, bbl.ListPublicWorksOpts{
+		IDs:            []bbl.ID{workID},
+		Limit:          1,
+		IncludeDeleted: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Works) == 0 {
 		return nil, oaipmh.ErrIDDoesNotExist
 	}
+	specs, err := b.setSpecs(ctx, res.Works, "")
 	if err != nil {
 		return nil, err
 	}
-	if w.Status != "public" {
-		return nil, oaipmh.ErrIDDoesNotExist
+	return b.workRecord(res.Works[0], specs[workID])
+}
+
+// workRecord encodes a work as an OAI-PMH record. Works that are no longer
+// public are returned as a deleted header without metadata.
+func (b *oaiBackend) workRecord(w *bbl.Work, specs []string) (*oaipmh.Record, error) {
+	if w.Status != bbl.WorkStatusPublic {
+		return &oaipmh.Record{Header: workHeader(w, specs)}, nil
 	}
 	data, err := b.encoder.Encode(w)
 	if err != nil {
 		return nil, fmt.Errorf("oaiBackend encode: %w", err)
 	}
 	return &oaipmh.Record{
-		Header:   workHeader(w),
+		Header:   workHeader(w, specs),
 		Metadata: &oaipmh.Payload{XML: string(data)},
 	}, nil
 }
 
-func workHeader(w *bbl.Work) *oaipmh.Header {
-	return &oaipmh.Header{
+func workHeader(w *bbl.Work, specs []string) *oaipmh.Header {
+	h := &oaipmh.Header{
 		Identifier: w.ID.String(),
 		Datestamp:  w.UpdatedAt.UTC().Format(time.RFC3339),
+		SetSpecs:   specs,
+	}
+	if w.Status != bbl.WorkStatusPublic {
+		h.Status = "deleted"
 	}
+	return h
 }
@@ -0,0 +1,456 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ugent-library/bbl"
+	"github.com/ugent-library/bbl/app/views"
+	"github.com/ugent-library/vo"
+)
+
+// Backoffice editing of people, projects and organizations. Forms are
+// driven by the profile of the record type; relations to other records
+// (affiliations, participants, organization rels) are picked through the
+// suggest endpoints. Only curators and admins can manage these records.
+
+// --- people ---
+
+func (app *App) backofficeNewPerson(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	if !bbl.CanManageRecords(c.User) {
+		return bbl.ErrForbidden
+	}
+	defs := app.services.Repo.Profiles.FieldDefs(bbl.RecordTypePerson, "")
+	return views.BackofficeEditPerson(c.ViewCtx, &bbl.Person{}, defs, nil, nil).Render(r.Context(), w)
+}
+
+func (app *App) backofficeCreatePerson(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	if !bbl.CanManageRecords(c.User) {
+		return bbl.ErrForbidden
+	}
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	defs := app.services.Repo.Profiles.FieldDefs(bbl.RecordTypePerson, "")
+	id := bbl.NewID()
+	updates := append([]any{&bbl.CreatePerson{ID: id}},
+		buildRecordUpdates(r, bbl.RecordTypePerson, id, defs)...)
+	if _, err := app.services.UpdateAndIndex(r.Context(), c.User, updates...); err != nil {
+		var errs vo.Errors
+		if errors.As(err, &errs) {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return views.BackofficeEditPerson(c.ViewCtx, &bbl.Person{}, defs, nil, errs).Render(r.Context(), w)
+		}
+		return fmt.Errorf("backofficeCreatePerson: %w", err)
+	}
+	http.Redirect(w, r, fmt.Sprintf("/backoffice/people/%s", id), http.StatusSeeOther)
+	return nil
+}
+
+func (app *App) backofficeEditPerson(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	person, err := app.getEditablePerson(r, c)
+	if err != nil {
+		return err
+	}
+	names, err := app.personRelationNames(r, person)
+	if err != nil {
+		return err
+	}
+	defs := app.services.Repo.Profiles.FieldDefs(bbl.RecordTypePerson, "")
+	return views.BackofficeEditPerson(c.ViewCtx, person, defs, names, nil).Render(r.Context(), w)
+}
+
+func (app *App) backofficeUpdatePerson(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	person, err := app.getEditablePerson(r, c)
+	if err != nil {
+		return err
+	}
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	defs := app.services.Repo.Profiles.FieldDefs(bbl.RecordTypePerson, "")
+	updates := buildRecordUpdates(r, bbl.RecordTypePerson, person.ID, defs)
+	if _, err := app.services.UpdateAndIndex(r.Context(), c.User, updates...); err != nil {
+		var errs vo.Errors
+		if errors.As(err, &errs) {
+			names, err := app.personRelationNames(r, person)
+			if err != nil {
+				return err
+			}
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return views.BackofficeEditPerson(c.ViewCtx, person, defs, names, errs).Render(r.Context(), w)
+		}
+		return fmt.Errorf("backofficeUpdatePerson: %w", err)
+	}
+	http.Redirect(w, r, fmt.Sprintf("/backoffice/people/%s", person.ID), http.StatusSeeOther)
+	return nil
+}
+
+func (app *App) backofficeDeletePerson(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	person, err := app.getEditablePerson(r, c)
+	if err != nil {
+		return err
+	}
+	if _, err := app.services.UpdateAndIndex(r.Context(), c.User, &bbl.DeletePerson{PersonID: person.ID}); err != nil {
+		return fmt.Errorf("backofficeDeletePerson: %w", err)
+	}
+	http.Redirect(w, r, "/backoffice/people", http.StatusSeeOther)
+	return nil
+}
+
+func (app *App) getEditablePerson(r *http.Request, c *Ctx) (*bbl.Person, error) {
+	if !bbl.CanManageRecords(c.User) {
+		return nil, bbl.ErrForbidden
+	}
+	person, err := app.getPerson(r)
+	if err != nil {
+		return nil, err
+	}
+	if person.Status == bbl.PersonStatusDeleted {
+		return nil, bbl.ErrNotFound
+	}
+	return person, nil
+}
+
+// personRelationNames returns the names of the organizations a person is
+// affiliated with, for the organization pickers.
+func (app *App) personRelationNames(r *http.Request, person *bbl.Person) (map[bbl.ID]string, error) {
+	ids := make([]bbl.ID, len(person.Affiliations))
+	for i, a := range person.Affiliations {
+		ids[i] = a.OrganizationID
+	}
+	return app.organizationNames(r, ids)
+}
+
+// --- projects ---
+
+func (app *App) backofficeNewProject(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	if !bbl.CanManageRecords(c.User) {
+		return bbl.ErrForbidden
+	}
+	defs := app.services.Repo.Profiles.FieldDefs(bbl.RecordTypeProject, "")
+	return views.BackofficeEditProject(c.ViewCtx, &bbl.Project{}, defs, nil, nil).Render(r.Context(), w)
+}
+
+func (app *App) backofficeCreateProject(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	if !bbl.CanManageRecords(c.User) {
+		return bbl.ErrForbidden
+	}
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	defs := app.services.Repo.Profiles.FieldDefs(bbl.RecordTypeProject, "")
+	id := bbl.NewID()
+	updates := append([]any{&bbl.CreateProject{ID: id}},
+		buildRecordUpdates(r, bbl.RecordTypeProject, id, defs)...)
+	if _, err := app.services.UpdateAndIndex(r.Context(), c.User, updates...); err != nil {
+		var errs vo.Errors
+		if errors.As(err, &errs) {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return views.BackofficeEditProject(c.ViewCtx, &bbl.Project{}, defs, nil, errs).Render(r.Context(), w)
+		}
+		return fmt.Errorf("backofficeCreateProject: %w", err)
+	}
+	http.Redirect(w, r, fmt.Sprintf("/backoffice/projects/%s", id), http.StatusSeeOther)
+	return nil
+}
+
+func (app *App) backofficeEditProject(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	project, err := app.getEditableProject(r, c)
+	if err != nil {
+		return err
+	}
+	names, err := app.projectRelationNames(r, project)
+	if err != nil {
+		return err
+	}
+	defs := app.services.Repo.Profiles.FieldDefs(bbl.RecordTypeProject, "")
+	return views.BackofficeEditProject(c.ViewCtx, project, defs, names, nil).Render(r.Context(), w)
+}
+
+func (app *App) backofficeUpdateProject(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	project, err := app.getEditableProject(r, c)
+	if err != nil {
+		return err
+	}
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	defs := app.services.Repo.Profiles.FieldDefs(bbl.RecordTypeProject, "")
+	updates := buildRecordUpdates(r, bbl.RecordTypeProject, project.ID, defs)
+	if _, err := app.services.UpdateAndIndex(r.Context(), c.User, updates...); err != nil {
+		var errs vo.Errors
+		if errors.As(err, &errs) {
+			names, err := app.projectRelationNames(r, project)
+			if err != nil {
+				return err
+			}
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return views.BackofficeEditProject(c.ViewCtx, project, defs, names, errs).Render(r.Context(), w)
+		}
+		return fmt.Errorf("backofficeUpdateProject: %w", err)
+	}
+	http.Redirect(w, r, fmt.Sprintf("/backoffice/projects/%s", project.ID), http.StatusSeeOther)
+	return nil
+}
+
+func (app *App) backofficeDeleteProject(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	project, err := app.getEditableProject(r, c)
+	if err != nil {
+		return err
+	}
+	if _, err := app.services.UpdateAndIndex(r.Context(), c.User, &bbl.DeleteProject{ProjectID: project.ID}); err != nil {
+		return fmt.Errorf("backofficeDeleteProject: %w", err)
+	}
+	http.Redirect(w, r, "/backoffice/projects", http.StatusSeeOther)
+	return nil
+}
+
+func (app *App) getEditableProject(r *http.Request, c *Ctx) (*bbl.Project, error) {
+	if !bbl.CanManageRecords(c.User) {
+		return nil, bbl.ErrForbidden
+	}
+	return app.getProject(r, bbl.ProjectStatusPublic)
+}
+
+// projectRelationNames returns the names of the participants of a project,
+// for the person pickers.
+func (app *App) projectRelationNames(r *http.Request, project *bbl.Project) (map[bbl.ID]string, error) {
+	ids := make([]bbl.ID, len(project.Participants))
+	for i, p := range project.Participants {
+		ids[i] = p.PersonID
+	}
+	people, err := app.services.Repo.GetPeople(r.Context(), ids)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[bbl.ID]string, len(people))
+	for _, p := range people {
+		names[p.ID] = p.Name
+	}
+	return names, nil
+}
+
+// --- organizations ---
+
+func (app *App) backofficeNewOrganization(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	if !bbl.CanManageRecords(c.User) {
+		return bbl.ErrForbidden
+	}
+	kinds := app.services.Repo.Profiles.OrganizationKinds()
+	kind := r.URL.Query().Get("kind")
+	defs := app.services.Repo.Profiles.FieldDefs(bbl.RecordTypeOrganization, kind)
+	if defs == nil {
+		// Choose a kind first.
+		return views.BackofficeNewOrganization(c.ViewCtx, kinds).Render(r.Context(), w)
+	}
+	return views.BackofficeEditOrganization(c.ViewCtx, &bbl.Organization{Kind: kind}, defs, nil, nil).Render(r.Context(), w)
+}
+
+func (app *App) backofficeCreateOrganization(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	if !bbl.CanManageRecords(c.User) {
+		return bbl.ErrForbidden
+	}
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	kind := r.FormValue("kind")
+	defs := app.services.Repo.Profiles.FieldDefs(bbl.RecordTypeOrganization, kind)
+	if defs == nil {
+		http.Error(w, fmt.Sprintf("unknown organization kind %q", kind), http.StatusBadRequest)
+		return nil
+	}
+	id := bbl.NewID()
+	updates := append([]any{&bbl.CreateOrganization{ID: id, Kind: kind}},
+		buildRecordUpdates(r, bbl.RecordTypeOrganization, id, defs)...)
+	if _, err := app.services.UpdateAndIndex(r.Context(), c.User, updates...); err != nil {
+		var errs vo.Errors
+		if errors.As(err, &errs) {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return views.BackofficeEditOrganization(c.ViewCtx, &bbl.Organization{Kind: kind}, defs, nil, errs).Render(r.Context(), w)
+		}
+		return fmt.Errorf("backofficeCreateOrganization: %w", err)
+	}
+	http.Redirect(w, r, fmt.Sprintf("/backoffice/organizations/%s", id), http.StatusSeeOther)
+	return nil
+}
+
+func (app *App) backofficeEditOrganization(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	org, defs, err := app.getEditableOrganization(r, c)
+	if err != nil {
+		return err
+	}
+	names, err := app.organizationRelationNames(r, org)
+	if err != nil {
+		return err
+	}
+	return views.BackofficeEditOrganization(c.ViewCtx, org, defs, names, nil).Render(r.Context(), w)
+}
+
+func (app *App) backofficeUpdateOrganization(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	org, defs, err := app.getEditableOrganization(r, c)
+	if err != nil {
+		return err
+	}
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	updates := buildRecordUpdates(r, bbl.RecordTypeOrganization, org.ID, defs)
+	if _, err := app.services.UpdateAndIndex(r.Context(), c.User, updates...); err != nil {
+		var errs vo.Errors
+		if errors.As(err, &errs) {
+			names, err := app.organizationRelationNames(r, org)
+			if err != nil {
+				return err
+			}
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return views.BackofficeEditOrganization(c.ViewCtx, org, defs, names, errs).Render(r.Context(), w)
+		}
+		return fmt.Errorf("backofficeUpdateOrganization: %w", err)
+	}
+	http.Redirect(w, r, fmt.Sprintf("/backoffice/organizations/%s", org.ID), http.StatusSeeOther)
+	return nil
+}
+
+func (app *App) backofficeDeleteOrganization(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	org, _, err := app.getEditableOrganization(r, c)
+	if err != nil {
+		return err
+	}
+	if _, err := app.services.UpdateAndIndex(r.Context(), c.User, &bbl.DeleteOrganization{OrganizationID: org.ID}); err != nil {
+		return fmt.Errorf("backofficeDeleteOrganization: %w", err)
+	}
+	http.Redirect(w, r, "/backoffice/organizations", http.StatusSeeOther)
+	return nil
+}
+
+func (app *App) getEditableOrganization(r *http.Request, c *Ctx) (*bbl.Organization, []bbl.FieldDef, error) {
+	if !bbl.CanManageRecords(c.User) {
+		return nil, nil, bbl.ErrForbidden
+	}
+	org, err := app.getOrganization(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	if org.Status == bbl.OrganizationStatusDeleted {
+		return nil, nil, bbl.ErrNotFound
+	}
+	defs := app.services.Repo.Profiles.FieldDefs(bbl.RecordTypeOrganization, org.Kind)
+	if defs == nil {
+		return nil, nil, fmt.Errorf("no profile for organization kind %q", org.Kind)
+	}
+	return org, defs, nil
+}
+
+// organizationRelationNames returns the names of the related organizations
+// of an organization, for the organization pickers.
+func (app *App) organizationRelationNames(r *http.Request, org *bbl.Organization) (map[bbl.ID]string, error) {
+	ids := make([]bbl.ID, len(org.Rels))
+	for i, rel := range org.Rels {
+		ids[i] = rel.RelOrganizationID
+	}
+	return app.organizationNames(r, ids)
+}
+
+func (app *App) organizationNames(r *http.Request, ids []bbl.ID) (map[bbl.ID]string, error) {
+	orgs, err := app.services.Repo.GetOrganizations(r.Context(), ids)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[bbl.ID]string, len(orgs))
+	for _, o := range orgs {
+		for _, n := range o.Names {
+			if n.Val != "" {
+				names[o.ID] = n.Val
+				break
+			}
+		}
+	}
+	return names, nil
+}
+
+// --- form ---
+
+// buildRecordUpdates builds Set/Unset updates from the form for the profile
+// fields of a person, project or organization. Relation items without a
+// picked record are skipped.
+func buildRecordUpdates(r *http.Request, recordType string, id bbl.ID, defs []bbl.FieldDef) []any {
+	var updates []any
+	setOrUnset := func(field string, val any, empty bool) {
+		if empty {
+			updates = append(updates, &bbl.Unset{RecordType: recordType, RecordID: id, Field: field})
+		} else {
+			updates = append(updates, &bbl.Set{RecordType: recordType, RecordID: id, Field: field, Val: val})
+		}
+	}
+	for _, f := range defs {
+		switch f.Type {
+		case "string":
+			val := strings.TrimSpace(r.FormValue(f.Name))
+			setOrUnset(f.Name, val, val == "")
+		case "title":
+			titles := formTitles(r.Form, f.Name)
+			setOrUnset(f.Name, titles, len(titles) == 0)
+		case "text":
+			texts := formTexts(r.Form, f.Name)
+			setOrUnset(f.Name, texts, len(texts) == 0)
+		case "identifier":
+			ids := formIdentifiers(r.Form, f.Name)
+			setOrUnset(f.Name, ids, len(ids) == 0)
+		case "personAffiliation":
+			var affs []bbl.PersonAffiliation
+			for _, v := range r.Form[f.Name+".organization_id"] {
+				if orgID, err := bbl.ParseID(v); err == nil {
+					affs = append(affs, bbl.PersonAffiliation{OrganizationID: orgID})
+				}
+			}
+			setOrUnset(f.Name, affs, len(affs) == 0)
+		case "projectParticipant":
+			personIDs := r.Form[f.Name+".person_id"]
+			roles := r.Form[f.Name+".role"]
+			var participants []bbl.ProjectParticipant
+			for i := range min(len(personIDs), len(roles)) {
+				if personID, err := bbl.ParseID(personIDs[i]); err == nil {
+					participants = append(participants, bbl.ProjectParticipant{
+						PersonID: personID,
+						Role:     strings.TrimSpace(roles[i]),
+					})
+				}
+			}
+			setOrUnset(f.Name, participants, len(participants) == 0)
+		case "organizationRel":
+			orgIDs := r.Form[f.Name+".rel_organization_id"]
+			kinds := r.Form[f.Name+".kind"]
+			starts := r.Form[f.Name+".start_date"]
+			ends := r.Form[f.Name+".end_date"]
+			var rels []bbl.OrganizationRel
+			for i := range min(len(orgIDs), len(kinds), len(starts), len(ends)) {
+				orgID, err := bbl.ParseID(orgIDs[i])
+				kind := strings.TrimSpace(kinds[i])
+				if err != nil || kind == "" {
+					continue
+				}
+				rels = append(rels, bbl.OrganizationRel{
+					RelOrganizationID: orgID,
+					Kind:              kind,
+					StartDate:         formDate(starts[i]),
+					EndDate:           formDate(ends[i]),
+				})
+			}
+			setOrUnset(f.Name, rels, len(rels) == 0)
+		}
+	}
+	return updates
+}
+
+// formDate parses a date input value. Returns nil if empty or invalid.
+func formDate(v string) *time.Time {
+	t, err := time.Parse(time.DateOnly, strings.TrimSpace(v))
+	if err != nil {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,91 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ugent-library/bbl"
+	"github.com/ugent-library/bbl/app/views"
+	"github.com/ugent-library/vo"
+)
+
+// Review workflow. Transitions and role checks are enforced by the
+// updaters; these handlers only translate forms into updates.
+
+func (app *App) backofficeSubmitWork(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	return app.reviewWork(w, r, c, func(work *bbl.Work) any {
+		return &bbl.SubmitWork{WorkID: work.ID, Message: strings.TrimSpace(r.FormValue("message"))}
+	})
+}
+
+func (app *App) backofficePickUpWork(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	return app.reviewWork(w, r, c, func(work *bbl.Work) any {
+		return &bbl.PickUpWork{WorkID: work.ID}
+	})
+}
+
+func (app *App) backofficeReturnWork(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	return app.reviewWork(w, r, c, func(work *bbl.Work) any {
+		return &bbl.ReturnWork{WorkID: work.ID, Message: strings.TrimSpace(r.FormValue("message"))}
+	})
+}
+
+func (app *App) backofficeApproveWork(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	return app.reviewWork(w, r, c, func(work *bbl.Work) any {
+		return &bbl.ApproveWork{WorkID: work.ID, Message: strings.TrimSpace(r.FormValue("message"))}
+	})
+}
+
+// reviewWork applies a single review transition. A work that fails public
+// validation on approval is shown in the edit form with the errors.
+func (app *App) reviewWork(w http.ResponseWriter, r *http.Request, c *Ctx, update func(*bbl.Work) any) error {
+	work, err := app.getWork(r, "public", "private")
+	if err != nil {
+		return err
+	}
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	_, err = app.services.UpdateAndIndex(r.Context(), c.User, update(work))
+	var errs vo.Errors
+	if errors.As(err, &errs) {
+		defs := app.services.Repo.Profiles.FieldDefs("work", work.Kind)
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return views.BackofficeEditWork(c.ViewCtx, work, defs, errs).Render(r.Context(), w)
+	}
+	if errors.Is(err, bbl.ErrInvalidTransition) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reviewWork: %w", err)
+	}
+	http.Redirect(w, r, fmt.Sprintf("/backoffice/works/%s", work.ID), http.StatusSeeOther)
+	return nil
+}
+
+func (app *App) backofficeReviewQueue(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	opts := parseSearchOpts(r)
+	queueOpts := bbl.ReviewQueueOpts{Limit: opts.Size, Offset: opts.Offset}
+	if s := r.URL.Query().Get("status"); s != "" {
+		queueOpts.ReviewStatuses = []string{s}
+	}
+	var org *bbl.Organization
+	if s := r.URL.Query().Get("organization"); s != "" {
+		id, err := bbl.ParseID(s)
+		if err != nil {
+			return bbl.ErrNotFound
+		}
+		if org, err = app.services.Repo.GetOrganization(r.Context(), id); err != nil {
+			return err
+		}
+		queueOpts.OrganizationID = &org.ID
+	}
+	works, total, err := app.services.Repo.GetReviewQueue(r.Context(), queueOpts)
+	if err != nil {
+		return err
+	}
+	return views.BackofficeReviewQueue(c.ViewCtx, works, org, total, opts).Render(r.Context(), w)
+}
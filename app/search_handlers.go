@@ -69,7 +69,11 @@ func (app *App) backofficeSearchWorks(w http.ResponseWriter, r *http.Request, c
 	if err != nil {
 		return err
 	}
-	return views.BackofficeSearchWorks(c.ViewCtx, hits, opts).Render(r.Context(), w)
+	lists, err := app.services.Repo.GetUserLists(r.Context(), c.User.ID)
+	if err != nil {
+		return err
+	}
+	return views.BackofficeSearchWorks(c.ViewCtx, hits, opts, lists).Render(r.Context(), w)
 }
 
 func (app *App) backofficeSearchPeople(w http.ResponseWriter, r *http.Request, c *Ctx) error {
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/gorilla/securecookie"
+	"github.com/ugent-library/bbl"
 )
 
 const (
@@ -15,6 +16,20 @@ const (
 
 type sessionData struct {
 	UserID string `json:"u,omitempty"`
+	// ImpersonatorID is the admin acting as UserID, if any.
+	ImpersonatorID string `json:"i,omitempty"`
+	// IssuedAt is the unix time the session was saved. Sessions issued
+	// before a deactivation stay invalid after reactivation.
+	IssuedAt int64 `json:"t,omitempty"`
+}
+
+// validFor reports whether the session may be used by user, the user that
+// logged in. Deactivated users have no session.
+func (d *sessionData) validFor(user *bbl.User, now time.Time) bool {
+	if user.Deactivated(now) {
+		return false
+	}
+	return user.DeactivatedAt == nil || d.IssuedAt >= user.DeactivatedAt.Unix()
 }
 
 type session struct {
@@ -46,6 +61,7 @@ func (s *session) load(r *http.Request) (*sessionData, error) {
 }
 
 func (s *session) save(w http.ResponseWriter, data *sessionData) error {
+	data.IssuedAt = time.Now().Unix()
 	encoded, err := s.cookies.Encode(sessionCookieName, data)
 	if err != nil {
 		return err
@@ -9,11 +9,31 @@ import (
 )
 
 func (app *App) sruWorksHandler() http.Handler {
+	return app.sruHandler("works", "Work records", app.services.SearchPublicWorkRecords)
+}
+
+// sruCollectionHandler serves each public collection as an SRU database.
+func (app *App) sruCollectionHandler(w http.ResponseWriter, r *http.Request) {
+	c, err := app.services.Repo.GetCollectionByName(r.Context(), r.PathValue("name"))
+	if err == nil && !c.Public {
+		err = bbl.ErrNotFound
+	}
+	if err != nil {
+		app.htmlError(w, r, err)
+		return
+	}
+	search := func(ctx context.Context, opts *bbl.SearchOpts) (*bbl.WorkRecordHits, error) {
+		return app.services.SearchCollectionWorks(ctx, c, opts)
+	}
+	app.sruHandler("collections/"+c.Name, c.DisplayTitle(), search).ServeHTTP(w, r)
+}
+
+func (app *App) sruHandler(database, title string, search func(context.Context, *bbl.SearchOpts) (*bbl.WorkRecordHits, error)) http.Handler {
 	enc, _ := bbl.NewWorkEncoder("dc")
 
 	return sru.Handler(sru.ServerConfig{
-		Database: "works",
-		Title:    "Work records",
+		Database: database,
+		Title:    title,
 		Indexes: []sru.Index{
 			{CQLName: "cql.serverChoice", Title: "Free text"},
 		},
@@ -26,7 +46,7 @@ func (app *App) sruWorksHandler() http.Handler {
 				Size:   size,
 				Offset: offset,
 			}
-			hits, err := app.services.SearchPublicWorkRecords(ctx, opts)
+			hits, err := search(ctx, opts)
 			if err != nil {
 				return nil, err
 			}
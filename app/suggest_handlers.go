@@ -45,6 +45,38 @@ func (app *App) suggestPeople(w http.ResponseWriter, r *http.Request, c *Ctx) er
 	return writeJSON(w, suggestions)
 }
 
+type organizationSuggestion struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Kind string `json:"kind,omitempty"`
+}
+
+func (app *App) suggestOrganizations(w http.ResponseWriter, r *http.Request, c *Ctx) error {
+	q := r.URL.Query().Get("q")
+	if q == "" {
+		return writeJSON(w, []organizationSuggestion{})
+	}
+
+	hits, err := app.services.Index.Organizations().Search(r.Context(), &bbl.SearchOpts{
+		Query: q,
+		Size:  10,
+	})
+	if err != nil {
+		return err
+	}
+
+	suggestions := make([]organizationSuggestion, 0, len(hits.Hits))
+	for _, h := range hits.Hits {
+		suggestions = append(suggestions, organizationSuggestion{
+			ID:   h.ID.String(),
+			Name: h.Name,
+			Kind: h.Kind,
+		})
+	}
+
+	return writeJSON(w, suggestions)
+}
+
 func writeJSON(w http.ResponseWriter, v any) error {
 	w.Header().Set("Content-Type", "application/json")
 	return json.NewEncoder(w).Encode(v)
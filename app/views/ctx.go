@@ -1,5 +1,7 @@
 package views
 
+import "github.com/ugent-library/bbl"
+
 type Ctx struct {
 	AssetPath func(string) string
 	Loc       func(string, ...any) string
@@ -8,4 +10,15 @@ type Ctx struct {
 	LangNames map[string]string // ISO 639-2 code → localized name (code as fallback)
 	MainLangs []string          // preferred languages shown first in selects
 	Path      string            // current request path (for lang switcher redirect)
+	User      *bbl.User         // nil for anonymous requests
+	// FileAccessKinds are the configured work file access levels.
+	FileAccessKinds []bbl.FileAccessKind
+	// Impersonator is the admin acting as User, if any.
+	Impersonator *bbl.User
+}
+
+// IsCurator reports whether the current user may lock records and resolve
+// lock conflicts.
+func (c Ctx) IsCurator() bool {
+	return c.User != nil && c.User.Role == bbl.RoleCurator
 }
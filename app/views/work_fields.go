@@ -1,6 +1,11 @@
 package views
 
-import "github.com/ugent-library/bbl"
+import (
+	"fmt"
+	"time"
+
+	"github.com/ugent-library/bbl"
+)
 
 // Field getters for template rendering — map field name to Work values.
 
@@ -90,3 +95,87 @@ func workAttrNoteList(work *bbl.Work, name string) []bbl.Note {
 func fieldLabel(c Ctx, name string) string {
 	return c.Loc("field." + name)
 }
+
+// fileSize formats a byte count with a binary unit.
+func fileSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// fileAccess describes the access level of a work file, including a running
+// embargo.
+func fileAccess(c Ctx, f bbl.WorkFile) string {
+	now := time.Now()
+	if f.Embargoed(now) {
+		return c.Loc("%s until %s, then %s", c.Loc("access."+f.AccessKind), f.EmbargoUntil.Format(time.DateOnly), c.Loc("access."+f.EmbargoAccessKind))
+	}
+	return c.Loc("access." + f.AccessKindAt(now))
+}
+
+// embargoDate formats the embargo date of a work file for a date input.
+func embargoDate(f bbl.WorkFile) string {
+	if f.EmbargoUntil == nil {
+		return ""
+	}
+	return f.EmbargoUntil.Format(time.DateOnly)
+}
+
+// Field getters for the person, project and organization forms.
+
+func personAttrString(person *bbl.Person, name string) string {
+	switch name {
+	case "name":
+		return person.Name
+	case "given_name":
+		return person.GivenName
+	case "middle_name":
+		return person.MiddleName
+	case "family_name":
+		return person.FamilyName
+	default:
+		return ""
+	}
+}
+
+func projectAttrTextList(project *bbl.Project, name string) []bbl.Text {
+	switch name {
+	case "descriptions":
+		return project.Descriptions
+	default:
+		return nil
+	}
+}
+
+func organizationAttrTextList(org *bbl.Organization, name string) []bbl.Text {
+	switch name {
+	case "names":
+		return org.Names
+	default:
+		return nil
+	}
+}
+
+// relOrganizationID returns the ID of the related organization, or an empty
+// string for a new rel.
+func relOrganizationID(rel bbl.OrganizationRel) string {
+	if rel.RelOrganizationID == (bbl.ID{}) {
+		return ""
+	}
+	return rel.RelOrganizationID.String()
+}
+
+// dateInputValue formats a date for a date input.
+func dateInputValue(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.DateOnly)
+}
@@ -13,7 +13,10 @@ import (
 	"github.com/ugent-library/bbl"
 )
 
-var reNormalizeID = regexp.MustCompile(`(?i)^arxiv:`)
+var (
+	reNormalizeID = regexp.MustCompile(`(?i)^arxiv:`)
+	reEntryID     = regexp.MustCompile(`^https?://arxiv\.org/abs/(.+?)(v\d+)?$`)
+)
 
 type feed struct {
 	XMLName      xml.Name `xml:"feed"`
@@ -22,6 +25,7 @@ type feed struct {
 }
 
 type entry struct {
+	ID        string `xml:"id"`
 	Title     string `xml:"title"`
 	Summary   string `xml:"summary"`
 	Published string `xml:"published"`
@@ -46,6 +50,12 @@ func NewWorkSource() *WorkSource {
 	}
 }
 
+// IdentifierScheme implements bbl.WorkIdentifierSource; records are looked
+// up by arXiv ID.
+func (ws *WorkSource) IdentifierScheme() string {
+	return "arxiv"
+}
+
 func (ws *WorkSource) Get(ctx context.Context, id string) (*bbl.ImportWorkInput, error) {
 	id = reNormalizeID.ReplaceAllString(id, "")
 
@@ -83,6 +93,24 @@ func (ws *WorkSource) Get(ctx context.Context, id string) (*bbl.ImportWorkInput,
 		return nil, fmt.Errorf("arxiv.Get: expected 1 result, got %d", f.TotalResults)
 	}
 
+	return mapEntry(f.Entry, id, body), nil
+}
+
+// Map implements bbl.WorkSourceMapper; record is a stored arXiv API
+// response.
+func (ws *WorkSource) Map(record []byte) (*bbl.ImportWorkInput, error) {
+	var f feed
+	if err := xml.Unmarshal(record, &f); err != nil {
+		return nil, fmt.Errorf("arxiv.Map: %w", err)
+	}
+	m := reEntryID.FindStringSubmatch(f.Entry.ID)
+	if m == nil {
+		return nil, fmt.Errorf("arxiv.Map: unexpected entry id %q", f.Entry.ID)
+	}
+	return mapEntry(f.Entry, m[1], record), nil
+}
+
+func mapEntry(e entry, id string, body []byte) *bbl.ImportWorkInput {
 	rec := &bbl.ImportWorkInput{
 		SourceID:     id,
 		Kind:         "journal_article",
@@ -90,32 +118,32 @@ func (ws *WorkSource) Get(ctx context.Context, id string) (*bbl.ImportWorkInput,
 		Identifiers: []bbl.Identifier{
 			{Scheme: "arxiv", Val: id},
 		},
-		Titles:            []bbl.Title{{Lang: "und", Val: f.Entry.Title}},
+		Titles:            []bbl.Title{{Lang: "und", Val: e.Title}},
 		PublicationStatus: "unpublished",
 	}
 
-	if f.Entry.DOI != "" {
-		rec.Identifiers = append(rec.Identifiers, bbl.Identifier{Scheme: "doi", Val: f.Entry.DOI})
+	if e.DOI != "" {
+		rec.Identifiers = append(rec.Identifiers, bbl.Identifier{Scheme: "doi", Val: e.DOI})
 	}
 
-	if f.Entry.Summary != "" {
-		rec.Abstracts = append(rec.Abstracts, bbl.Text{Lang: "und", Val: f.Entry.Summary})
+	if e.Summary != "" {
+		rec.Abstracts = append(rec.Abstracts, bbl.Text{Lang: "und", Val: e.Summary})
 	}
 
-	if f.Entry.Comment != "" {
-		rec.Notes = append(rec.Notes, bbl.Note{Val: f.Entry.Comment})
+	if e.Comment != "" {
+		rec.Notes = append(rec.Notes, bbl.Note{Val: e.Comment})
 	}
 
-	if len(f.Entry.Published) >= 4 {
-		rec.PublicationYear = f.Entry.Published[:4]
+	if len(e.Published) >= 4 {
+		rec.PublicationYear = e.Published[:4]
 	}
 
-	for _, a := range f.Entry.Author {
+	for _, a := range e.Author {
 		rec.Contributors = append(rec.Contributors, bbl.ImportWorkContributor{
 			Roles: []string{"author"},
 			Name:  a.Name,
 		})
 	}
 
-	return rec, nil
+	return rec
 }
@@ -16,6 +16,7 @@ type assertion struct {
 	source         string
 	pinned         bool
 	hidden         bool
+	kindHidden     bool // works only; hidden by a kind change
 }
 
 // firstPinned returns the first pinned assertion, or nil.
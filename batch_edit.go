@@ -4,33 +4,65 @@ import (
 	"context"
 	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"slices"
 	"sort"
 	"strconv"
 )
 
 // BatchConflict is a field that changed since export.
 type BatchConflict struct {
-	WorkID     ID
-	Field      string
-	CurrentVal string
-	CSVVal     string
+	WorkID     ID     `json:"work_id"`
+	Field      string `json:"field"`
+	CurrentVal string `json:"current_val"`
+	CSVVal     string `json:"csv_val"`
+}
+
+// BatchChange is a field value a batch edit would change.
+type BatchChange struct {
+	WorkID     ID     `json:"work_id"`
+	Field      string `json:"field"`
+	CurrentVal string `json:"current_val"`
+	CSVVal     string `json:"csv_val"`
+}
+
+// BatchError is a CSV row whose value can't be applied, e.g. because it
+// fails profile validation or names an unknown work. Line is the CSV line
+// number.
+type BatchError struct {
+	Line    int    `json:"line,omitempty"`
+	WorkID  ID     `json:"work_id"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
 }
 
 // BatchResult holds the outcome of reading and diffing a batch edit.
 type BatchResult struct {
 	updates   []any
-	Conflicts []BatchConflict
-	Skipped   int
+	Changes   []BatchChange   `json:"changes,omitempty"`
+	Conflicts []BatchConflict `json:"conflicts,omitempty"`
+	Errors    []BatchError    `json:"errors,omitempty"`
+	Skipped   int             `json:"skipped"`
 }
 
-// Updates returns the updaters to apply. Conflicted changes are included
-// (additive assertions are always safe to apply).
+// Updates returns the updaters to apply. Conflicted and invalid changes are
+// not included.
 func (r *BatchResult) Updates() []any {
 	return r.updates
 }
 
+// addChange records a valid change and its updater.
+func (r *BatchResult) addChange(workID ID, field, currentVal, csvVal string, val any) {
+	r.Changes = append(r.Changes, BatchChange{WorkID: workID, Field: field, CurrentVal: currentVal, CSVVal: csvVal})
+	if val != nil {
+		r.updates = append(r.updates, &Set{RecordType: "work", RecordID: workID, Field: field, Val: val})
+	} else {
+		r.updates = append(r.updates, &Hide{RecordType: "work", RecordID: workID, Field: field})
+	}
+}
+
 // WriteWorkBatch exports scalar fields for the given works as a
 // batch-edit CSV.
 func WriteWorkBatch(ctx context.Context, repo *Repo, w io.Writer, workIDs []ID) error {
@@ -65,15 +97,71 @@ func WriteWorkBatch(ctx context.Context, repo *Repo, w io.Writer, workIDs []ID)
 }
 
 // ReadWorkBatch reads a batch-edit CSV, diffs against current pinned
-// values, and returns the result.
+// values, and returns the result. Both scalar CSVs and the collection field
+// CSVs written by WriteWorkCollectionBatch are accepted. Malformed CSV is
+// an error; values that fail validation are reported in BatchResult.Errors.
 func ReadWorkBatch(ctx context.Context, repo *Repo, r io.Reader) (*BatchResult, error) {
-	rows, err := parseBatchCSV(r)
+	return readWorkBatch(ctx, repo, r, nil)
+}
+
+// CountWorkBatch returns the number of works in a batch-edit CSV.
+func CountWorkBatch(r io.Reader) (int, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+	seen := make(map[string]bool)
+	for i := 0; ; i++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return len(seen), nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		if i > 0 && len(record) > 0 {
+			seen[record[0]] = true
+		}
+	}
+}
+
+// readWorkBatch is ReadWorkBatch, calling progress after each work.
+func readWorkBatch(ctx context.Context, repo *Repo, r io.Reader, progress func(int)) (*BatchResult, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	if field, bc, ok := batchCollectionField(header); ok {
+		return readWorkCollectionBatch(ctx, repo, cr, header, field, bc, progress)
+	}
+
+	rows, err := parseBatchCSV(cr, header)
 	if err != nil {
 		return nil, err
 	}
 
+	fieldTypes := csvFieldTypes("work")
+	fields := make([]string, 0, len(fieldTypes))
+	for field := range fieldTypes {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
 	result := &BatchResult{}
-	for _, row := range rows {
+	for i, row := range rows {
+		if progress != nil && i > 0 {
+			progress(i)
+		}
+		work, err := repo.GetWork(ctx, row.workID)
+		if errors.Is(err, ErrNotFound) {
+			result.Errors = append(result.Errors, BatchError{Line: row.line, WorkID: row.workID, Message: "work not found"})
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ReadWorkBatch: %w", err)
+		}
 		current, _, _, err := getWorkPinnedScalars(ctx, repo, row.workID)
 		if err != nil {
 			return nil, fmt.Errorf("ReadWorkBatch: %w", err)
@@ -83,7 +171,8 @@ func ReadWorkBatch(ctx context.Context, repo *Repo, r io.Reader) (*BatchResult,
 			return nil, fmt.Errorf("ReadWorkBatch: %w", err)
 		}
 
-		for field, ft := range csvFieldTypes("work") {
+		for _, field := range fields {
+			ft := fieldTypes[field]
 			csvCols := ft.csv.columns(field)
 
 			changed := false
@@ -109,32 +198,57 @@ func ReadWorkBatch(ctx context.Context, repo *Repo, r io.Reader) (*BatchResult,
 			}
 
 			val, hasData := ft.csv.unflatten(field, row.values)
-			if hasData {
-				result.updates = append(result.updates, &Set{RecordType: "work", RecordID: row.workID, Field: field, Val: val})
-			} else {
-				result.updates = append(result.updates, &Hide{RecordType: "work", RecordID: row.workID, Field: field})
+			if !hasData {
+				val = nil
+			}
+			if msg := validateBatchValue(repo.Profiles, work, field, val); msg != "" {
+				result.Errors = append(result.Errors, BatchError{Line: row.line, WorkID: row.workID, Field: field, Message: msg})
+				continue
 			}
+			result.addChange(row.workID, field, current[csvCols[0]], row.values[csvCols[0]], val)
 		}
 	}
+	if progress != nil {
+		progress(len(rows))
+	}
 	return result, nil
 }
 
+// validateBatchValue checks a new field value against the profile of the
+// work's kind. A nil val clears the field. Returns an empty string if the
+// value is valid or there are no profiles.
+func validateBatchValue(profiles *Profiles, work *Work, field string, val any) string {
+	defs := profiles.FieldDefs(RecordTypeWork, work.Kind)
+	if defs == nil {
+		return ""
+	}
+	i := slices.IndexFunc(defs, func(def FieldDef) bool { return def.Name == field })
+	if i < 0 {
+		if val == nil {
+			return ""
+		}
+		return fmt.Sprintf("field is not used by kind %s", work.Kind)
+	}
+	fields := map[string]any{}
+	if val != nil {
+		fields[field] = val
+	}
+	if errs := validateRecord(work.Status, fields, defs[i:i+1]); errs != nil {
+		return errs.ToError().Error()
+	}
+	return ""
+}
+
 // --- private ---
 
 type batchRow struct {
+	line   int
 	workID ID
 	revID  int64
 	values map[string]string
 }
 
-func parseBatchCSV(r io.Reader) ([]batchRow, error) {
-	cr := csv.NewReader(r)
-	cr.FieldsPerRecord = -1
-
-	header, err := cr.Read()
-	if err != nil {
-		return nil, fmt.Errorf("read header: %w", err)
-	}
+func parseBatchCSV(cr *csv.Reader, header []string) ([]batchRow, error) {
 	if len(header) < 3 || header[0] != "work_id" || header[1] != "rev_id" || header[2] != "kind" {
 		return nil, fmt.Errorf("invalid header: expected work_id, rev_id, kind, ...")
 	}
@@ -170,7 +284,7 @@ func parseBatchCSV(r io.Reader) ([]batchRow, error) {
 				values[col] = record[i+3]
 			}
 		}
-		rows = append(rows, batchRow{workID: workID, revID: revID, values: values})
+		rows = append(rows, batchRow{line: lineNum, workID: workID, revID: revID, values: values})
 	}
 	return rows, nil
 }
@@ -255,7 +369,7 @@ func getWorkFieldRevIDs(ctx context.Context, repo *Repo, workID ID) (map[string]
 		if err := rows.Scan(&field, &revID); err != nil {
 			return nil, err
 		}
-		revs[field] = revID
+		revs[field] = max(revs[field], revID)
 	}
 	return revs, rows.Err()
 }
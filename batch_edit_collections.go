@@ -0,0 +1,393 @@
+package bbl
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Collection fields are batch edited with one CSV per field and one row per
+// item. Columns are prefixed with the field name (e.g. work_id, rev_id,
+// titles.lang, titles.val) so ReadWorkBatch can tell the CSV types apart.
+// The rows of a work, in file order, make up the complete new value of the
+// field. Works without items are exported as a single row with empty item
+// columns; a work whose rows are all empty has its field cleared.
+
+// batchCollection describes the CSV columns of a work collection field.
+type batchCollection struct {
+	columns []string // without field prefix
+	// flatten returns one row of column values per item.
+	flatten func(w *Work) [][]string
+	// unflatten builds the field value from non-empty rows.
+	unflatten func(rows [][]string) (any, error)
+}
+
+// contributorRoleSep separates contributor roles in a single CSV cell.
+const contributorRoleSep = ";"
+
+// contributorAffiliationSep separates contributor affiliations in a single
+// CSV cell; affiliation strings often contain ";".
+const contributorAffiliationSep = "|"
+
+var batchCollections = map[string]*batchCollection{
+	"titles": {
+		columns: []string{"lang", "val"},
+		flatten: func(w *Work) [][]string {
+			rows := make([][]string, len(w.Titles))
+			for i, t := range w.Titles {
+				rows[i] = []string{t.Lang, t.Val}
+			}
+			return rows
+		},
+		unflatten: func(rows [][]string) (any, error) {
+			val := make([]Title, len(rows))
+			for i, row := range rows {
+				val[i] = Title{Lang: row[0], Val: row[1]}
+			}
+			return val, nil
+		},
+	},
+	"abstracts": {
+		columns: []string{"lang", "val"},
+		flatten: func(w *Work) [][]string {
+			rows := make([][]string, len(w.Abstracts))
+			for i, t := range w.Abstracts {
+				rows[i] = []string{t.Lang, t.Val}
+			}
+			return rows
+		},
+		unflatten: func(rows [][]string) (any, error) {
+			val := make([]Text, len(rows))
+			for i, row := range rows {
+				val[i] = Text{Lang: row[0], Val: row[1]}
+			}
+			return val, nil
+		},
+	},
+	"keywords": {
+		columns: []string{"val"},
+		flatten: func(w *Work) [][]string {
+			rows := make([][]string, len(w.Keywords))
+			for i, k := range w.Keywords {
+				rows[i] = []string{k.Val}
+			}
+			return rows
+		},
+		unflatten: func(rows [][]string) (any, error) {
+			val := make([]Keyword, len(rows))
+			for i, row := range rows {
+				val[i] = Keyword{Val: row[0]}
+			}
+			return val, nil
+		},
+	},
+	"identifiers": {
+		columns: []string{"scheme", "val"},
+		flatten: func(w *Work) [][]string {
+			return flattenBatchIdentifiers(w.Identifiers)
+		},
+		unflatten: unflattenBatchIdentifiers,
+	},
+	"classifications": {
+		columns: []string{"scheme", "val"},
+		flatten: func(w *Work) [][]string {
+			return flattenBatchIdentifiers(w.Classifications)
+		},
+		unflatten: unflattenBatchIdentifiers,
+	},
+	"contributors": {
+		columns: []string{"kind", "person_id", "name", "given_name", "family_name", "roles", "affiliations"},
+		flatten: func(w *Work) [][]string {
+			rows := make([][]string, len(w.Contributors))
+			for i, c := range w.Contributors {
+				var personID string
+				if c.PersonID != nil {
+					personID = c.PersonID.String()
+				}
+				rows[i] = []string{c.Kind, personID, c.Name, c.GivenName, c.FamilyName, strings.Join(c.Roles, contributorRoleSep), strings.Join(c.Affiliations, contributorAffiliationSep)}
+			}
+			return rows
+		},
+		unflatten: func(rows [][]string) (any, error) {
+			val := make([]WorkContributor, len(rows))
+			for i, row := range rows {
+				c := WorkContributor{Kind: row[0], Name: row[2], GivenName: row[3], FamilyName: row[4]}
+				if row[1] != "" {
+					id, err := ParseID(row[1])
+					if err != nil {
+						return nil, fmt.Errorf("invalid person_id %q: %w", row[1], err)
+					}
+					c.PersonID = &id
+				}
+				for _, role := range strings.Split(row[5], contributorRoleSep) {
+					if role = strings.TrimSpace(role); role != "" {
+						c.Roles = append(c.Roles, role)
+					}
+				}
+				for _, aff := range strings.Split(row[6], contributorAffiliationSep) {
+					if aff = strings.TrimSpace(aff); aff != "" {
+						c.Affiliations = append(c.Affiliations, aff)
+					}
+				}
+				val[i] = c
+			}
+			return val, nil
+		},
+	},
+}
+
+func flattenBatchIdentifiers(ids []Identifier) [][]string {
+	rows := make([][]string, len(ids))
+	for i, id := range ids {
+		rows[i] = []string{id.Scheme, id.Val}
+	}
+	return rows
+}
+
+func unflattenBatchIdentifiers(rows [][]string) (any, error) {
+	val := make([]Identifier, len(rows))
+	for i, row := range rows {
+		val[i] = Identifier{Scheme: row[0], Val: row[1]}
+	}
+	return val, nil
+}
+
+// BatchCollectionFields returns the work collection fields that can be
+// batch edited, sorted by name.
+func BatchCollectionFields() []string {
+	fields := make([]string, 0, len(batchCollections))
+	for field := range batchCollections {
+		fields = append(fields, field)
+	}
+	slices.Sort(fields)
+	return fields
+}
+
+// WriteWorkCollectionBatch exports a collection field of the given works as
+// a batch-edit CSV with one row per item.
+func WriteWorkCollectionBatch(ctx context.Context, repo *Repo, w io.Writer, field string, workIDs []ID) error {
+	bc, ok := batchCollections[field]
+	if !ok {
+		return fmt.Errorf("WriteWorkCollectionBatch: field %q can't be batch edited", field)
+	}
+
+	cw := csv.NewWriter(w)
+	defer cw.Flush()
+
+	if err := cw.Write(batchCollectionHeader(field, bc)); err != nil {
+		return err
+	}
+
+	for _, id := range workIDs {
+		work, err := repo.GetWork(ctx, id)
+		if err != nil {
+			return fmt.Errorf("WriteWorkCollectionBatch: %w", err)
+		}
+		revID, err := getWorkRevID(ctx, repo, id)
+		if err != nil {
+			return fmt.Errorf("WriteWorkCollectionBatch: %w", err)
+		}
+		items := bc.flatten(work)
+		if len(items) == 0 {
+			items = [][]string{make([]string, len(bc.columns))}
+		}
+		for _, item := range items {
+			row := append([]string{id.String(), strconv.FormatInt(revID, 10)}, item...)
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	return cw.Error()
+}
+
+// --- private ---
+
+func batchCollectionHeader(field string, bc *batchCollection) []string {
+	header := []string{"work_id", "rev_id"}
+	for _, col := range bc.columns {
+		header = append(header, field+"."+col)
+	}
+	return header
+}
+
+// batchCollectionField returns the collection field a batch-edit CSV header
+// belongs to.
+func batchCollectionField(header []string) (string, *batchCollection, bool) {
+	if len(header) < 3 {
+		return "", nil, false
+	}
+	field, _, ok := strings.Cut(header[2], ".")
+	if !ok {
+		return "", nil, false
+	}
+	bc, ok := batchCollections[field]
+	return field, bc, ok
+}
+
+// batchWork holds the rows of one work in a collection batch-edit CSV.
+type batchWork struct {
+	line   int // line of the first row
+	workID ID
+	revID  int64
+	rows   [][]string // non-empty item rows in file order
+}
+
+func parseBatchCollectionCSV(cr *csv.Reader, header []string, field string, bc *batchCollection) ([]*batchWork, error) {
+	// Map item columns by header position so columns can be reordered.
+	colIdx := make([]int, len(bc.columns))
+	for i, col := range bc.columns {
+		colIdx[i] = slices.Index(header, field+"."+col)
+		if colIdx[i] < 0 {
+			return nil, fmt.Errorf("invalid header: missing column %s.%s", field, col)
+		}
+	}
+	if header[0] != "work_id" || header[1] != "rev_id" {
+		return nil, fmt.Errorf("invalid header: expected work_id, rev_id, %s.*", field)
+	}
+
+	var works []*batchWork
+	byID := make(map[ID]*batchWork)
+	lineNum := 1
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum+1, err)
+		}
+		lineNum++
+
+		if len(record) < 2 {
+			return nil, fmt.Errorf("line %d: too few columns", lineNum)
+		}
+		workID, err := ParseID(record[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid work_id: %w", lineNum, err)
+		}
+		revID, err := strconv.ParseInt(record[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid rev_id: %w", lineNum, err)
+		}
+
+		bw := byID[workID]
+		if bw == nil {
+			bw = &batchWork{line: lineNum, workID: workID, revID: revID}
+			byID[workID] = bw
+			works = append(works, bw)
+		}
+		// Rows of one work may come from different exports; compare against
+		// the oldest.
+		bw.revID = min(bw.revID, revID)
+
+		row := make([]string, len(bc.columns))
+		empty := true
+		for i, idx := range colIdx {
+			if idx < len(record) {
+				row[i] = strings.TrimSpace(record[idx])
+			}
+			if row[i] != "" {
+				empty = false
+			}
+		}
+		if !empty {
+			bw.rows = append(bw.rows, row)
+		}
+	}
+	return works, nil
+}
+
+func readWorkCollectionBatch(ctx context.Context, repo *Repo, cr *csv.Reader, header []string, field string, bc *batchCollection, progress func(int)) (*BatchResult, error) {
+	works, err := parseBatchCollectionCSV(cr, header, field, bc)
+	if err != nil {
+		return nil, err
+	}
+	ft, err := resolveFieldType("work", field)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BatchResult{}
+	for i, bw := range works {
+		if progress != nil && i > 0 {
+			progress(i)
+		}
+		val, err := bc.unflatten(bw.rows)
+		if err != nil {
+			result.Errors = append(result.Errors, BatchError{Line: bw.line, WorkID: bw.workID, Field: field, Message: err.Error()})
+			continue
+		}
+		work, err := repo.GetWork(ctx, bw.workID)
+		if errors.Is(err, ErrNotFound) {
+			result.Errors = append(result.Errors, BatchError{Line: bw.line, WorkID: bw.workID, Message: "work not found"})
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ReadWorkBatch: %w", err)
+		}
+		current := bc.flatten(work)
+		currentVal, err := bc.unflatten(current)
+		if err != nil {
+			return nil, fmt.Errorf("ReadWorkBatch: %w", err)
+		}
+		if ft.equal(currentVal, val) {
+			result.Skipped++
+			continue
+		}
+
+		fieldRevs, err := getWorkFieldRevIDs(ctx, repo, bw.workID)
+		if err != nil {
+			return nil, fmt.Errorf("ReadWorkBatch: %w", err)
+		}
+		if fieldRev, ok := fieldRevs[field]; ok && fieldRev > bw.revID {
+			result.Conflicts = append(result.Conflicts, BatchConflict{
+				WorkID:     bw.workID,
+				Field:      field,
+				CurrentVal: summarizeBatchRows(current),
+				CSVVal:     summarizeBatchRows(bw.rows),
+			})
+			continue
+		}
+
+		if len(bw.rows) == 0 {
+			val = nil
+		}
+		if msg := validateBatchValue(repo.Profiles, work, field, val); msg != "" {
+			result.Errors = append(result.Errors, BatchError{Line: bw.line, WorkID: bw.workID, Field: field, Message: msg})
+			continue
+		}
+		result.addChange(bw.workID, field, summarizeBatchRows(current), summarizeBatchRows(bw.rows), val)
+	}
+	if progress != nil {
+		progress(len(works))
+	}
+	return result, nil
+}
+
+// summarizeBatchRows renders item rows on one line for conflict reports.
+func summarizeBatchRows(rows [][]string) string {
+	items := make([]string, len(rows))
+	for i, row := range rows {
+		items[i] = strings.Join(slices.DeleteFunc(slices.Clone(row), func(s string) bool { return s == "" }), " ")
+	}
+	return strings.Join(items, "; ")
+}
+
+// getWorkRevID returns the latest rev that changed a pinned field of a work.
+func getWorkRevID(ctx context.Context, repo *Repo, workID ID) (int64, error) {
+	fieldRevs, err := getWorkFieldRevIDs(ctx, repo, workID)
+	if err != nil {
+		return 0, err
+	}
+	var revID int64
+	for _, rev := range fieldRevs {
+		revID = max(revID, rev)
+	}
+	return revID, nil
+}
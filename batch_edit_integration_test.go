@@ -0,0 +1,136 @@
+package bbl
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestWorkCollectionBatch(t *testing.T) {
+	repo := testRepo(t)
+	ctx := context.Background()
+	user := createTestUser(t, repo, RoleUser)
+
+	workID := newID()
+	if _, _, err := repo.Update(ctx, user,
+		&CreateWork{ID: workID, Kind: "journal_article"},
+		&Set{RecordType: "work", RecordID: workID, Field: "keywords", Val: []Keyword{{Val: "a"}, {Val: "b"}}},
+	); err != nil {
+		t.Fatalf("create work: %v", err)
+	}
+	emptyID := newID()
+	if _, _, err := repo.Update(ctx, user, &CreateWork{ID: emptyID, Kind: "journal_article"}); err != nil {
+		t.Fatalf("create work: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteWorkCollectionBatch(ctx, repo, &buf, "keywords", []ID{workID, emptyID}); err != nil {
+		t.Fatalf("write batch: %v", err)
+	}
+	export := buf.String()
+	lines := strings.Split(strings.TrimSpace(export), "\n")
+	if len(lines) != 4 || lines[0] != "work_id,rev_id,keywords.val" || !strings.HasSuffix(lines[3], ",") {
+		t.Fatalf("export = %q", export)
+	}
+
+	// Unchanged CSV is a noop.
+	result, err := ReadWorkBatch(ctx, repo, strings.NewReader(export))
+	if err != nil {
+		t.Fatalf("read batch: %v", err)
+	}
+	if len(result.Updates()) != 0 || result.Skipped != 2 {
+		t.Errorf("updates = %d, skipped = %d", len(result.Updates()), result.Skipped)
+	}
+
+	// Drop a keyword and add one to the empty work.
+	edited := strings.Replace(export, lines[2]+"\n", "", 1)
+	edited = strings.Replace(edited, lines[3], lines[3]+"c", 1)
+	result, err = ReadWorkBatch(ctx, repo, strings.NewReader(edited))
+	if err != nil {
+		t.Fatalf("read batch: %v", err)
+	}
+	if len(result.Updates()) != 2 || len(result.Conflicts) != 0 {
+		t.Fatalf("updates = %d, conflicts = %v", len(result.Updates()), result.Conflicts)
+	}
+	if _, _, err := repo.Update(ctx, user, result.Updates()...); err != nil {
+		t.Fatalf("apply batch: %v", err)
+	}
+	work, err := repo.GetWork(ctx, workID)
+	if err != nil {
+		t.Fatalf("get work: %v", err)
+	}
+	if len(work.Keywords) != 1 || work.Keywords[0].Val != "a" {
+		t.Errorf("keywords = %v", work.Keywords)
+	}
+
+	// The original export of the first work is now stale.
+	stale := strings.Join([]string{lines[0], lines[1], strings.TrimSuffix(lines[2], "b") + "d"}, "\n")
+	result, err = ReadWorkBatch(ctx, repo, strings.NewReader(stale))
+	if err != nil {
+		t.Fatalf("read batch: %v", err)
+	}
+	if len(result.Conflicts) != 1 || result.Conflicts[0].WorkID != workID || result.Conflicts[0].Field != "keywords" {
+		t.Errorf("conflicts = %v", result.Conflicts)
+	}
+}
+
+func TestApplyBatchEditImpersonated(t *testing.T) {
+	repo := testRepo(t)
+	ctx := context.Background()
+	svc := &Services{Repo: repo}
+	curator := createTestUser(t, repo, RoleCurator)
+	admin := createTestUser(t, repo, RoleAdmin)
+	user := Impersonate(admin, curator)
+
+	workID := newID()
+	if _, _, err := repo.Update(ctx, curator,
+		&CreateWork{ID: workID, Kind: "journal_article"},
+		&Set{RecordType: "work", RecordID: workID, Field: "keywords", Val: []Keyword{{Val: "a"}}},
+	); err != nil {
+		t.Fatalf("create work: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := WriteWorkCollectionBatch(ctx, repo, &buf, "keywords", []ID{workID}); err != nil {
+		t.Fatalf("write batch: %v", err)
+	}
+	edited := strings.TrimSuffix(buf.String(), "a\n") + "b\n"
+
+	b, err := svc.CreateBatchEdit(ctx, user, "keywords.csv", []byte(edited))
+	if err != nil {
+		t.Fatalf("create batch edit: %v", err)
+	}
+	if b.Status != BatchEditChecked {
+		t.Fatalf("status = %s, want %s", b.Status, BatchEditChecked)
+	}
+
+	// Claim and apply the batch edit the way the background worker does,
+	// with nothing but its id.
+	if _, err := repo.db.Exec(ctx, `UPDATE bbl_batch_edits SET status = $2 WHERE id = $1`, b.ID, BatchEditApplying); err != nil {
+		t.Fatalf("claim batch edit: %v", err)
+	}
+	if err := svc.runBatchEdit(ctx, b.ID, true); err != nil {
+		t.Fatalf("run batch edit: %v", err)
+	}
+	if b, err = repo.GetBatchEdit(ctx, b.ID); err != nil {
+		t.Fatalf("get batch edit: %v", err)
+	}
+	if b.Status != BatchEditApplied || b.Applied == 0 {
+		t.Fatalf("status = %s, applied = %d, error = %q", b.Status, b.Applied, b.Error)
+	}
+
+	var userID ID
+	var impersonatorID *ID
+	if err := repo.db.QueryRow(ctx, `
+		SELECT r.user_id, r.impersonator_id
+		FROM bbl_revs r JOIN bbl_work_assertions a ON a.rev_id = r.id
+		WHERE a.work_id = $1 AND a.field = 'keywords' AND a.user_id IS NOT NULL`, workID).Scan(&userID, &impersonatorID); err != nil {
+		t.Fatalf("get rev: %v", err)
+	}
+	if userID != curator.ID {
+		t.Errorf("user_id = %s, want %s", userID, curator.ID)
+	}
+	if impersonatorID == nil || *impersonatorID != admin.ID {
+		t.Errorf("impersonator_id = %v, want %s", impersonatorID, admin.ID)
+	}
+}
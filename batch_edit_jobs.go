@@ -0,0 +1,305 @@
+package bbl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/ugent-library/catbird"
+)
+
+// Batch edit statuses.
+const (
+	BatchEditChecking = "checking" // diffing the upload against current values
+	BatchEditChecked  = "checked"  // preview ready, waiting for confirmation
+	BatchEditApplying = "applying"
+	BatchEditApplied  = "applied"
+	BatchEditFailed   = "failed"
+)
+
+// batchEditInlineWorks is the largest upload, in works, that is checked and
+// applied within the request. Larger uploads go to the background worker.
+const batchEditInlineWorks = 100
+
+// batchEditProgressEvery is how often, in works, progress is saved.
+const batchEditProgressEvery = 25
+
+// BatchEdit is a batch-edit CSV uploaded through the backoffice. It is
+// checked first; applying it is a separate, confirmed step that checks the
+// CSV again, so changes made in between show up as conflicts.
+type BatchEdit struct {
+	ID        ID           `json:"id"`
+	UserID    ID           `json:"user_id"`
+	Filename  string       `json:"filename"`
+	Status    string       `json:"status"`
+	Total     int          `json:"total"`     // works in the current step
+	Processed int          `json:"processed"` // works done in the current step
+	Applied   int          `json:"applied"`   // changes applied
+	Result    *BatchResult `json:"result,omitempty"`
+	Error     string       `json:"error,omitempty"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+// Done reports whether the batch edit is not being processed.
+func (b *BatchEdit) Done() bool {
+	return b.Status != BatchEditChecking && b.Status != BatchEditApplying
+}
+
+// CanBatchEdit reports whether user may batch edit works.
+func CanBatchEdit(user *User) bool {
+	return user != nil && (user.Role == RoleCurator || user.Role == RoleAdmin)
+}
+
+// CanView reports whether user may see the batch edit.
+func (b *BatchEdit) CanView(user *User) bool {
+	return user != nil && (user.ID == b.UserID || user.Role == RoleAdmin)
+}
+
+const batchEditCols = `id, user_id, filename, status, total, processed, applied, result, error, created_at, updated_at`
+
+func scanBatchEdit(row pgx.Row) (*BatchEdit, error) {
+	var b BatchEdit
+	var result []byte
+	err := row.Scan(&b.ID, &b.UserID, &b.Filename, &b.Status, &b.Total, &b.Processed, &b.Applied, &result, &b.Error, &b.CreatedAt, &b.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if result != nil {
+		if err := json.Unmarshal(result, &b.Result); err != nil {
+			return nil, err
+		}
+	}
+	return &b, nil
+}
+
+// GetBatchEdit fetches a batch edit. Returns ErrNotFound if it does not
+// exist.
+func (r *Repo) GetBatchEdit(ctx context.Context, id ID) (*BatchEdit, error) {
+	b, err := scanBatchEdit(r.db.QueryRow(ctx, `SELECT `+batchEditCols+` FROM bbl_batch_edits WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("GetBatchEdit: %w", err)
+	}
+	return b, nil
+}
+
+// GetUserBatchEdits returns the most recent batch edits of a user, newest
+// first.
+func (r *Repo) GetUserBatchEdits(ctx context.Context, userID ID, limit int) ([]*BatchEdit, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+batchEditCols+`
+		FROM bbl_batch_edits
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2`, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("GetUserBatchEdits: %w", err)
+	}
+	edits, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*BatchEdit, error) {
+		return scanBatchEdit(row)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetUserBatchEdits: %w", err)
+	}
+	return edits, nil
+}
+
+// CreateBatchEdit stores an uploaded batch-edit CSV and checks it, inline
+// for small files and in the background for large ones. An impersonating
+// admin is recorded with it.
+func (s *Services) CreateBatchEdit(ctx context.Context, user *User, filename string, data []byte) (*BatchEdit, error) {
+	if !CanBatchEdit(user) {
+		return nil, ErrForbidden
+	}
+	total, err := CountWorkBatch(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("CreateBatchEdit: invalid CSV: %w", err)
+	}
+	id := newID()
+	if _, err := s.Repo.db.Exec(ctx, `
+		INSERT INTO bbl_batch_edits (id, user_id, impersonator_id, filename, data, status, total)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		id, user.ID, user.ImpersonatorID, filename, data, BatchEditChecking, total); err != nil {
+		return nil, fmt.Errorf("CreateBatchEdit: %w", err)
+	}
+	if err := s.startBatchEdit(ctx, id, total, false); err != nil {
+		return nil, fmt.Errorf("CreateBatchEdit: %w", err)
+	}
+	return s.Repo.GetBatchEdit(ctx, id)
+}
+
+// ApplyBatchEdit applies a checked batch edit. Each work is updated in its
+// own revision; works that fail are reported in the result errors.
+func (s *Services) ApplyBatchEdit(ctx context.Context, user *User, id ID) (*BatchEdit, error) {
+	b, err := s.Repo.GetBatchEdit(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !b.CanView(user) || !CanBatchEdit(user) {
+		return nil, ErrForbidden
+	}
+	// Claim the batch edit so it is applied once. Whoever confirms it is
+	// who the revisions are attributed to.
+	tag, err := s.Repo.db.Exec(ctx, `
+		UPDATE bbl_batch_edits
+		SET status = $2, impersonator_id = $4, processed = 0, updated_at = transaction_timestamp()
+		WHERE id = $1 AND status = $3`,
+		id, BatchEditApplying, BatchEditChecked, user.ImpersonatorID)
+	if err != nil {
+		return nil, fmt.Errorf("ApplyBatchEdit: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, fmt.Errorf("ApplyBatchEdit: batch edit is %s", b.Status)
+	}
+	if err := s.startBatchEdit(ctx, id, b.Total, true); err != nil {
+		return nil, fmt.Errorf("ApplyBatchEdit: %w", err)
+	}
+	return s.Repo.GetBatchEdit(ctx, id)
+}
+
+type batchEditTaskInput struct {
+	ID    ID   `json:"id"`
+	Apply bool `json:"apply"`
+}
+
+// startBatchEdit checks or applies a batch edit inline if it is small and
+// hands it to the background worker otherwise. Inline runs are detached from
+// the request, so a client that goes away doesn't leave the batch edit
+// half applied without a recorded failure.
+func (s *Services) startBatchEdit(ctx context.Context, id ID, total int, apply bool) error {
+	if total <= batchEditInlineWorks {
+		return s.runBatchEdit(context.WithoutCancel(ctx), id, apply)
+	}
+	_, err := catbird.New(s.Repo.db).RunTask(ctx, taskBatchEdit, batchEditTaskInput{ID: id, Apply: apply})
+	return err
+}
+
+// runBatchEdit checks or applies a batch edit. Failures are recorded on the
+// batch edit; the returned error is for database errors only.
+func (s *Services) runBatchEdit(ctx context.Context, id ID, apply bool) error {
+	var userID ID
+	var impersonatorID *ID
+	var data []byte
+	if err := s.Repo.db.QueryRow(ctx, `
+		SELECT user_id, impersonator_id, data FROM bbl_batch_edits WHERE id = $1`, id).Scan(&userID, &impersonatorID, &data); err != nil {
+		return fmt.Errorf("runBatchEdit: %w", err)
+	}
+
+	fail := func(err error) error {
+		_, dbErr := s.Repo.db.Exec(context.WithoutCancel(ctx), `
+			UPDATE bbl_batch_edits SET status = $2, error = $3, updated_at = transaction_timestamp()
+			WHERE id = $1`, id, BatchEditFailed, err.Error())
+		return dbErr
+	}
+	progress := func(n int) {
+		if n%batchEditProgressEvery != 0 {
+			return
+		}
+		if _, err := s.Repo.db.Exec(ctx, `
+			UPDATE bbl_batch_edits SET processed = $2, updated_at = transaction_timestamp()
+			WHERE id = $1`, id, n); err != nil {
+			slog.Error("runBatchEdit: progress", "id", id, "err", err)
+		}
+	}
+
+	result, err := readWorkBatch(ctx, s.Repo, bytes.NewReader(data), progress)
+	if err != nil {
+		return fail(err)
+	}
+
+	status := BatchEditChecked
+	var applied int
+	if apply {
+		user, err := s.Repo.GetUser(ctx, userID)
+		if err != nil {
+			return fail(err)
+		}
+		user.ImpersonatorID = impersonatorID
+		if _, err := s.Repo.db.Exec(ctx, `
+			UPDATE bbl_batch_edits SET total = $2, processed = 0, updated_at = transaction_timestamp()
+			WHERE id = $1`, id, result.changedWorks()); err != nil {
+			return fmt.Errorf("runBatchEdit: %w", err)
+		}
+		if applied, err = s.applyBatchResult(ctx, user, result, progress); err != nil {
+			return fail(err)
+		}
+		status = BatchEditApplied
+	}
+
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return fail(err)
+	}
+	_, err = s.Repo.db.Exec(ctx, `
+		UPDATE bbl_batch_edits
+		SET status = $2, processed = total, applied = $3, result = $4, error = '', updated_at = transaction_timestamp()
+		WHERE id = $1`, id, status, applied, resultJSON)
+	if err != nil {
+		return fmt.Errorf("runBatchEdit: %w", err)
+	}
+	return nil
+}
+
+// applyBatchResult applies the updates of a checked batch, one revision per
+// work. Works that can't be updated are added to the result errors and
+// their changes dropped. Returns the number of changes applied.
+func (s *Services) applyBatchResult(ctx context.Context, user *User, result *BatchResult, progress func(int)) (int, error) {
+	failed := make(map[ID]bool)
+	var applied, done int
+	updates := result.updates
+	for len(updates) > 0 {
+		if done > 0 {
+			progress(done)
+		}
+		done++
+		workID := batchUpdateWorkID(updates[0])
+		n := 1
+		for n < len(updates) && batchUpdateWorkID(updates[n]) == workID {
+			n++
+		}
+		if _, err := s.UpdateAndIndex(ctx, user, updates[:n]...); err != nil {
+			if ctx.Err() != nil {
+				return applied, ctx.Err()
+			}
+			failed[workID] = true
+			result.Errors = append(result.Errors, BatchError{WorkID: workID, Message: err.Error()})
+		} else {
+			applied += n
+		}
+		updates = updates[n:]
+	}
+	result.Changes = slices.DeleteFunc(result.Changes, func(c BatchChange) bool { return failed[c.WorkID] })
+	return applied, nil
+}
+
+// changedWorks returns the number of works with changes.
+func (r *BatchResult) changedWorks() int {
+	var n int
+	var last ID
+	for _, u := range r.updates {
+		if id := batchUpdateWorkID(u); n == 0 || id != last {
+			n++
+			last = id
+		}
+	}
+	return n
+}
+
+func batchUpdateWorkID(u any) ID {
+	switch u := u.(type) {
+	case *Set:
+		return u.RecordID
+	case *Hide:
+		return u.RecordID
+	}
+	return ID{}
+}
@@ -0,0 +1,170 @@
+package bbl
+
+import (
+	"encoding/csv"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestBatchCollections_RoundTrip(t *testing.T) {
+	personID := newID()
+	work := &Work{
+		Titles:          []Title{{Lang: "eng", Val: "A title"}, {Lang: "dut", Val: "Een titel"}},
+		Abstracts:       []Text{{Lang: "eng", Val: "An abstract"}},
+		Keywords:        []Keyword{{Val: "a"}, {Val: "b"}},
+		Identifiers:     []Identifier{{Scheme: "doi", Val: "10.1000/1"}},
+		Classifications: []Identifier{{Scheme: "ddc", Val: "500"}},
+		Contributors: []WorkContributor{
+			{PersonID: &personID, Name: "Jane Doe", GivenName: "Jane", FamilyName: "Doe", Roles: []string{"author", "editor"}, Affiliations: []string{"Ghent University; Belgium", "VIB"}},
+			{Kind: "organization", Name: "Consortium"},
+		},
+	}
+	want := map[string]any{
+		"titles":          work.Titles,
+		"abstracts":       work.Abstracts,
+		"keywords":        work.Keywords,
+		"identifiers":     work.Identifiers,
+		"classifications": work.Classifications,
+		"contributors":    work.Contributors,
+	}
+	for _, field := range BatchCollectionFields() {
+		bc := batchCollections[field]
+		rows := bc.flatten(work)
+		for _, row := range rows {
+			if len(row) != len(bc.columns) {
+				t.Fatalf("%s: row has %d columns, want %d", field, len(row), len(bc.columns))
+			}
+		}
+		val, err := bc.unflatten(rows)
+		if err != nil {
+			t.Fatalf("%s: unflatten: %v", field, err)
+		}
+		ft, err := resolveFieldType("work", field)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ft.equal(val, want[field]) {
+			t.Errorf("%s = %+v, want %+v", field, val, want[field])
+		}
+	}
+
+	rows := batchCollections["contributors"].flatten(work)
+	if rows[0][5] != "author;editor" || rows[0][6] != "Ghent University; Belgium|VIB" || rows[0][1] != personID.String() {
+		t.Errorf("contributor row = %v", rows[0])
+	}
+	if _, err := batchCollections["contributors"].unflatten([][]string{{"", "nope", "X", "", "", "", ""}}); err == nil {
+		t.Error("expected an error for an invalid person_id")
+	}
+}
+
+func TestParseBatchCollectionCSV(t *testing.T) {
+	id1, id2 := newID(), newID()
+	in := "work_id,rev_id,keywords.val\n" +
+		id1.String() + ",5, a \n" +
+		id2.String() + ",3,\n" +
+		id1.String() + ",4,b\n"
+
+	cr := csv.NewReader(strings.NewReader(in))
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	field, bc, ok := batchCollectionField(header)
+	if !ok || field != "keywords" {
+		t.Fatalf("batchCollectionField = %q, %v", field, ok)
+	}
+	works, err := parseBatchCollectionCSV(cr, header, field, bc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(works) != 2 {
+		t.Fatalf("got %d works, want 2", len(works))
+	}
+	if works[0].workID != id1 || works[0].revID != 4 || len(works[0].rows) != 2 || works[0].rows[0][0] != "a" || works[0].rows[1][0] != "b" {
+		t.Errorf("work 1 = %+v", works[0])
+	}
+	if works[1].workID != id2 || len(works[1].rows) != 0 {
+		t.Errorf("work 2 = %+v", works[1])
+	}
+
+	if _, _, ok := batchCollectionField([]string{"work_id", "rev_id", "kind", "volume"}); ok {
+		t.Error("scalar header detected as collection header")
+	}
+
+	header = []string{"work_id", "rev_id", "titles.val"}
+	cr = csv.NewReader(strings.NewReader(""))
+	if _, err := parseBatchCollectionCSV(cr, header, "titles", batchCollections["titles"]); err == nil {
+		t.Error("expected an error for a missing column")
+	}
+}
+
+func TestBatchCollectionFields(t *testing.T) {
+	want := []string{"abstracts", "classifications", "contributors", "identifiers", "keywords", "titles"}
+	if got := BatchCollectionFields(); !slices.Equal(got, want) {
+		t.Errorf("BatchCollectionFields() = %v, want %v", got, want)
+	}
+	for _, field := range want {
+		if _, err := resolveFieldType("work", field); err != nil {
+			t.Errorf("%s: %v", field, err)
+		}
+	}
+}
+
+func TestCountWorkBatch(t *testing.T) {
+	id1, id2 := newID(), newID()
+	input := "work_id,rev_id,keywords.val\n" +
+		id1.String() + ",1,a\n" +
+		id1.String() + ",1,b\n" +
+		id2.String() + ",1,\n"
+	n, err := CountWorkBatch(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("got %d works, want 2", n)
+	}
+}
+
+func TestValidateBatchValue(t *testing.T) {
+	p := testProfiles(t)
+	work := &Work{Kind: "journal_article", Status: "private"}
+
+	if msg := validateBatchValue(p, work, "titles", []Title{{Lang: "eng", Val: "A title"}}); msg != "" {
+		t.Errorf("valid titles: got %q", msg)
+	}
+	if msg := validateBatchValue(p, work, "titles", nil); msg == "" {
+		t.Error("clearing required titles: expected error")
+	}
+	if msg := validateBatchValue(p, work, "keywords", []Keyword{{Val: "a"}}); msg == "" {
+		t.Error("field not in profile: expected error")
+	}
+	if msg := validateBatchValue(p, work, "keywords", nil); msg != "" {
+		t.Errorf("clearing field not in profile: got %q", msg)
+	}
+	if msg := validateBatchValue(nil, work, "keywords", []Keyword{{Val: "a"}}); msg != "" {
+		t.Errorf("no profiles: got %q", msg)
+	}
+}
+
+func TestBatchEditPermissions(t *testing.T) {
+	owner := &User{ID: newID(), Role: RoleCurator}
+	other := &User{ID: newID(), Role: RoleCurator}
+	admin := &User{ID: newID(), Role: RoleAdmin}
+	b := &BatchEdit{UserID: owner.ID, Status: BatchEditChecked}
+
+	if !b.CanView(owner) || b.CanView(other) || !b.CanView(admin) || b.CanView(nil) {
+		t.Error("CanView: only the owner and admins may view a batch edit")
+	}
+	if CanBatchEdit(&User{Role: RoleUser}) || !CanBatchEdit(owner) || !CanBatchEdit(admin) {
+		t.Error("CanBatchEdit: only curators and admins may batch edit")
+	}
+	if !b.Done() {
+		t.Error("checked batch edit should be done")
+	}
+	b.Status = BatchEditApplying
+	if b.Done() {
+		t.Error("applying batch edit should not be done")
+	}
+}
@@ -1,6 +1,10 @@
 package citeformat
 
-import "github.com/ugent-library/bbl"
+import (
+	"io"
+
+	"github.com/ugent-library/bbl"
+)
 
 // Config is the YAML configuration for a citeproc citation style.
 type Config struct {
@@ -29,4 +33,29 @@ func (e *WorkEncoder) Encode(work *bbl.Work) ([]byte, error) {
 	return []byte(s), nil
 }
 
-var _ bbl.WorkEncoder = (*WorkEncoder)(nil)
+// WorkWriter writes works as a plain list of citations, one per line.
+// It implements bbl.WorkWriter.
+type WorkWriter struct {
+	Encoder bbl.WorkEncoder
+}
+
+func (w *WorkWriter) Begin(io.Writer) error { return nil }
+
+func (w *WorkWriter) Encode(out io.Writer, work *bbl.Work) error {
+	b, err := w.Encoder.Encode(work)
+	if err != nil {
+		return err
+	}
+	if _, err := out.Write(b); err != nil {
+		return err
+	}
+	_, err = io.WriteString(out, "\n")
+	return err
+}
+
+func (w *WorkWriter) End(io.Writer) error { return nil }
+
+var (
+	_ bbl.WorkEncoder = (*WorkEncoder)(nil)
+	_ bbl.WorkWriter  = (*WorkWriter)(nil)
+)
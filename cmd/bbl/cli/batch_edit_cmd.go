@@ -10,16 +10,18 @@ import (
 )
 
 func newWorksBatchExportCmd(e *env) *cobra.Command {
-	var ids, filter string
+	var ids, filter, field string
 
 	cmd := &cobra.Command{
 		Use:   "batch-export",
 		Short: "Export works as batch-edit CSV",
 		Long: `Export scalar fields for a set of works as a CSV suitable for batch editing.
+With --field, export one collection field instead, one row per item.
 
 Examples:
   bbl works batch-export --ids 01JXYZ,01JABC > edit.csv
-  bbl works batch-export --filter "status=public kind=journal_article" > edit.csv`,
+  bbl works batch-export --filter "status=public kind=journal_article" > edit.csv
+  bbl works batch-export --filter "kind=journal_article" --field contributors > contributors.csv`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := cmd.Context()
 			svc, err := e.services(ctx)
@@ -59,12 +61,16 @@ Examples:
 				return fmt.Errorf("no works found")
 			}
 
+			if field != "" {
+				return bbl.WriteWorkCollectionBatch(ctx, svc.Repo, cmd.OutOrStdout(), field, workIDs)
+			}
 			return bbl.WriteWorkBatch(ctx, svc.Repo, cmd.OutOrStdout(), workIDs)
 		},
 	}
 
 	cmd.Flags().StringVar(&ids, "ids", "", "comma-separated work IDs")
 	cmd.Flags().StringVarP(&filter, "filter", "f", "", "filter expression")
+	cmd.Flags().StringVar(&field, "field", "", "collection field to export ("+strings.Join(bbl.BatchCollectionFields(), ", ")+")")
 
 	return cmd
 }
@@ -76,7 +82,8 @@ func newWorksBatchImportCmd(e *env) *cobra.Command {
 		Use:   "batch-import",
 		Short: "Apply batch-edit CSV from stdin",
 		Long: `Read a batch-edit CSV from stdin (as exported by batch-export),
-diff against current values, and apply changes.
+diff against current values, and apply changes. Scalar and collection
+field CSVs are told apart by their header.
 
 Example:
   cat edit.csv | bbl works batch-import --user 01J...`,
@@ -112,6 +119,13 @@ Example:
 				}
 			}
 
+			if len(result.Errors) > 0 {
+				fmt.Fprintf(cmd.ErrOrStderr(), "note: %d invalid %s skipped:\n", len(result.Errors), plural(len(result.Errors), "value", "values"))
+				for _, e := range result.Errors {
+					fmt.Fprintf(cmd.ErrOrStderr(), "  line %d: %s %s: %s\n", e.Line, e.WorkID, e.Field, e.Message)
+				}
+			}
+
 			updates := result.Updates()
 			if len(updates) == 0 {
 				fmt.Fprintln(cmd.OutOrStdout(), "no changes to apply")
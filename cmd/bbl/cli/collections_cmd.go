@@ -0,0 +1,225 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/ugent-library/bbl"
+)
+
+func newCollectionsCmd(e *env) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "collections",
+		Short: "Manage work collections",
+	}
+	cmd.AddCommand(newCollectionsListCmd(e))
+	cmd.AddCommand(newCollectionsCreateCmd(e))
+	cmd.AddCommand(newCollectionsGetCmd(e))
+	cmd.AddCommand(newCollectionsAddCmd(e))
+	cmd.AddCommand(newCollectionsRemoveCmd(e))
+	cmd.AddCommand(newCollectionsExportCmd(e))
+	cmd.AddCommand(newCollectionsDeleteCmd(e))
+	return cmd
+}
+
+// getCollectionArg looks up a collection by ID or, failing that, by name.
+func getCollectionArg(ctx context.Context, svc *bbl.Services, arg string) (*bbl.Collection, error) {
+	if id, err := bbl.ParseID(arg); err == nil {
+		return svc.Repo.GetCollection(ctx, id)
+	}
+	return svc.Repo.GetCollectionByName(ctx, arg)
+}
+
+func newCollectionsListCmd(e *env) *cobra.Command {
+	var public bool
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List collections as JSONL",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			svc, err := e.services(ctx)
+			if err != nil {
+				return err
+			}
+			collections, err := svc.Repo.GetCollections(ctx, public)
+			if err != nil {
+				return err
+			}
+			for _, c := range collections {
+				if err := writeJSON(cmd.OutOrStdout(), c); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&public, "public", false, "only list public collections")
+	return cmd
+}
+
+func newCollectionsCreateCmd(e *env) *cobra.Command {
+	var attrs bbl.CollectionAttrs
+	cmd := &cobra.Command{
+		Use:   "create <name>",
+		Short: "Create a collection",
+		Long: `Create a collection. Manual collections hold an explicit list of works.
+Query collections hold the public works matching --query and --filter, e.g.
+
+  bbl collections create biology --kind query --filter "organization=<id> kind=journal_article"`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			svc, err := e.services(ctx)
+			if err != nil {
+				return err
+			}
+			attrs.Name = args[0]
+			c, err := svc.Repo.CreateCollection(ctx, attrs)
+			if err != nil {
+				return err
+			}
+			return writeJSON(cmd.OutOrStdout(), c)
+		},
+	}
+	cmd.Flags().StringVar(&attrs.Kind, "kind", bbl.CollectionKindManual, "collection kind (manual, query)")
+	cmd.Flags().StringVar(&attrs.Title, "title", "", "collection title")
+	cmd.Flags().StringVar(&attrs.Description, "description", "", "collection description")
+	cmd.Flags().StringVar(&attrs.Query, "query", "", "search query (query collections)")
+	cmd.Flags().StringVar(&attrs.Filter, "filter", "", "filter expression (query collections)")
+	cmd.Flags().BoolVar(&attrs.Public, "public", false, "show the collection on public pages, OAI-PMH and SRU")
+	return cmd
+}
+
+func newCollectionsGetCmd(e *env) *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <id-or-name>",
+		Short: "Get a collection and its work IDs",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			svc, err := e.services(ctx)
+			if err != nil {
+				return err
+			}
+			c, err := getCollectionArg(ctx, svc, args[0])
+			if err != nil {
+				return err
+			}
+			ids, err := svc.CollectionWorkIDs(ctx, c)
+			if err != nil {
+				return err
+			}
+			return writeJSON(cmd.OutOrStdout(), struct {
+				*bbl.Collection
+				WorkIDs []bbl.ID `json:"work_ids"`
+			}{c, ids})
+		},
+	}
+}
+
+func newCollectionsAddCmd(e *env) *cobra.Command {
+	return &cobra.Command{
+		Use:   "add <id-or-name> <work-id>...",
+		Short: "Append works to a manual collection",
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			svc, err := e.services(ctx)
+			if err != nil {
+				return err
+			}
+			c, err := getCollectionArg(ctx, svc, args[0])
+			if err != nil {
+				return err
+			}
+			workIDs := make([]bbl.ID, len(args)-1)
+			for i, arg := range args[1:] {
+				if workIDs[i], err = bbl.ParseID(arg); err != nil {
+					return fmt.Errorf("invalid work ID %q: %w", arg, err)
+				}
+			}
+			n, err := svc.Repo.AddCollectionWorks(ctx, c.ID, workIDs)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "added %d %s\n", n, plural(n, "work", "works"))
+			return nil
+		},
+	}
+}
+
+func newCollectionsRemoveCmd(e *env) *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove <id-or-name> <work-id>",
+		Short: "Remove a work from a manual collection",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			svc, err := e.services(ctx)
+			if err != nil {
+				return err
+			}
+			c, err := getCollectionArg(ctx, svc, args[0])
+			if err != nil {
+				return err
+			}
+			workID, err := bbl.ParseID(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid work ID: %w", err)
+			}
+			return svc.Repo.RemoveCollectionWork(ctx, c.ID, workID)
+		},
+	}
+}
+
+func newCollectionsExportCmd(e *env) *cobra.Command {
+	var format string
+	cmd := &cobra.Command{
+		Use:   "export <id-or-name>",
+		Short: "Export the public works of a collection",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			svc, err := e.services(ctx)
+			if err != nil {
+				return err
+			}
+			c, err := getCollectionArg(ctx, svc, args[0])
+			if err != nil {
+				return err
+			}
+			if format == "" {
+				format = "jsonl"
+			}
+			ww, err := bbl.NewWorkWriter(format)
+			if err != nil {
+				return err
+			}
+			_, err = bbl.WriteWorks(cmd.OutOrStdout(), ww, svc.EachCollectionWork(ctx, c))
+			return err
+		},
+	}
+	cmd.Flags().StringVarP(&format, "format", "F", "", "output format ("+bbl.WorkWriterFormatsHelp()+", default: jsonl)")
+	return cmd
+}
+
+func newCollectionsDeleteCmd(e *env) *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete <id-or-name>",
+		Short: "Delete a collection",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			svc, err := e.services(ctx)
+			if err != nil {
+				return err
+			}
+			c, err := getCollectionArg(ctx, svc, args[0])
+			if err != nil {
+				return err
+			}
+			return svc.Repo.DeleteCollection(ctx, c.ID)
+		},
+	}
+}
@@ -13,10 +13,16 @@ import (
 	"github.com/ugent-library/bbl/app"
 	"github.com/ugent-library/bbl/arxivsource"
 	"github.com/ugent-library/bbl/citeformat"
+	"github.com/ugent-library/bbl/crossrefsource"
 	"github.com/ugent-library/bbl/csvformat"
 	"github.com/ugent-library/bbl/dcformat"
 	"github.com/ugent-library/bbl/ldapsource"
+	"github.com/ugent-library/bbl/localfilestore"
+	"github.com/ugent-library/bbl/oaisource"
 	"github.com/ugent-library/bbl/opensearchindex"
+	"github.com/ugent-library/bbl/pubmedsource"
+	"github.com/ugent-library/bbl/s3filestore"
+	"github.com/ugent-library/bbl/smtpnotifier"
 	"gopkg.in/yaml.v3"
 )
 
@@ -42,6 +48,10 @@ type config struct {
 	UserSources   map[string]userSourceConfig   `yaml:"user_sources"`
 	WorkSources   map[string]workSourceConfig   `yaml:"work_sources"`
 	WorkEncoders  map[string]workEncoderConfig  `yaml:"work_encoders"`
+	Files         fileStoreConfig               `yaml:"files"`
+	Notifier      notifierConfig                `yaml:"notifier"`
+	Embargoes     embargoConfig                 `yaml:"embargoes"`
+	Deactivations deactivationConfig            `yaml:"deactivations"`
 }
 
 type openSearchConfig struct {
@@ -56,12 +66,40 @@ type authProviderConfig struct {
 type userSourceConfig struct {
 	Type         string    `yaml:"type"`          // informational, e.g. "ldap"
 	AuthProvider string    `yaml:"auth_provider"` // optional auth provider name
+	Sweep        bool      `yaml:"sweep"`         // deactivate users that vanish from a harvest
+	GraceDays    int       `yaml:"grace_days"`    // days between vanishing and deactivation
+	MaxVanished  float64   `yaml:"max_vanished"`  // skip the sweep if more than this fraction vanished; default 0.1
 	Config       yaml.Node `yaml:"config"`        // decoded by RegisterUserSource
 }
 
 type workSourceConfig struct {
-	Type   string    `yaml:"type"`   // informational, e.g. "plato"
-	Config yaml.Node `yaml:"config"` // decoded by RegisterWorkSource
+	Type   string          `yaml:"type"`   // informational, e.g. "plato"
+	Match  workMatchConfig `yaml:"match"`  // match new records with existing works
+	Config yaml.Node       `yaml:"config"` // decoded by RegisterWorkSource
+}
+
+type workMatchConfig struct {
+	Mode    string   `yaml:"mode"`    // "none" (default), "attach" or "review"
+	Schemes []string `yaml:"schemes"` // identifier schemes to match by; default doi, pubmed, arxiv, isbn
+}
+
+type fileStoreConfig struct {
+	Type   string    `yaml:"type"`   // "s3" or "local"; empty disables file uploads
+	Config yaml.Node `yaml:"config"` // decoded by type
+}
+
+type notifierConfig struct {
+	Type   string    `yaml:"type"`   // "smtp"; empty disables notifications
+	Config yaml.Node `yaml:"config"` // decoded by type
+}
+
+type embargoConfig struct {
+	Schedule   string `yaml:"schedule"`    // cron spec of the embargo task, default "@hourly"
+	NoticeDays int    `yaml:"notice_days"` // notify depositors this many days before expiry; 0 = never
+}
+
+type deactivationConfig struct {
+	Schedule string `yaml:"schedule"` // cron spec of the user deactivation task, default "@hourly"
 }
 
 type workEncoderConfig struct {
@@ -277,15 +315,7 @@ func (e *env) newServices(ctx context.Context) (*bbl.Services, error) {
 		workIterSources[name] = src
 	}
 
-	for name, sc := range cfg.WorkSources {
-		if _, ok := workIterSources[name]; ok {
-			continue
-		}
-		repo.Close()
-		return nil, fmt.Errorf("work source %q: unknown type %q", name, sc.Type)
-	}
-
-	// --- Work get sources (built-in) ---
+	// --- Work get sources ---
 	workGetSources := make(map[string]bbl.WorkSourceGetter)
 
 	for name, factory := range reg.workGetterFactories {
@@ -297,7 +327,79 @@ func (e *env) newServices(ctx context.Context) (*bbl.Services, error) {
 		workGetSources[name] = src
 	}
 
+	// Built-in work source types serve both iteration and lookups.
+	builtin := make(map[string]bool)
+	for name, sc := range cfg.WorkSources {
+		if _, ok := workIterSources[name]; ok {
+			continue
+		}
+		if _, ok := workGetSources[name]; ok {
+			continue
+		}
+		switch sc.Type {
+		case "crossref":
+			var c crossrefsource.Config
+			if err := sc.Config.Decode(&c); err != nil {
+				repo.Close()
+				return nil, fmt.Errorf("work source %q: decode config: %w", name, err)
+			}
+			src, err := crossrefsource.New(c)
+			if err != nil {
+				repo.Close()
+				return nil, fmt.Errorf("work source %q: %w", name, err)
+			}
+			workIterSources[name] = src
+			workGetSources[name] = src
+		case "pubmed":
+			var c pubmedsource.Config
+			if err := sc.Config.Decode(&c); err != nil {
+				repo.Close()
+				return nil, fmt.Errorf("work source %q: decode config: %w", name, err)
+			}
+			src, err := pubmedsource.New(c)
+			if err != nil {
+				repo.Close()
+				return nil, fmt.Errorf("work source %q: %w", name, err)
+			}
+			workIterSources[name] = src
+			workGetSources[name] = src
+		case "oai":
+			var c oaisource.Config
+			if err := sc.Config.Decode(&c); err != nil {
+				repo.Close()
+				return nil, fmt.Errorf("work source %q: decode config: %w", name, err)
+			}
+			src, err := oaisource.New(c)
+			if err != nil {
+				repo.Close()
+				return nil, fmt.Errorf("work source %q: %w", name, err)
+			}
+			workIterSources[name] = src
+		default:
+			repo.Close()
+			return nil, fmt.Errorf("work source %q: unknown type %q", name, sc.Type)
+		}
+		builtin[sc.Type] = true
+	}
+
 	workGetSources["arxiv"] = arxivsource.NewWorkSource()
+	// DOI and PMID lookups work without configuration.
+	if !builtin["crossref"] {
+		src, err := crossrefsource.New(crossrefsource.Config{})
+		if err != nil {
+			repo.Close()
+			return nil, err
+		}
+		workGetSources["crossref"] = src
+	}
+	if !builtin["pubmed"] {
+		src, err := pubmedsource.New(pubmedsource.Config{})
+		if err != nil {
+			repo.Close()
+			return nil, err
+		}
+		workGetSources["pubmed"] = src
+	}
 
 	// Seed built-in sources (curator, self_deposit) with default priorities.
 	if err := repo.SeedBuiltinSources(ctx); err != nil {
@@ -324,6 +426,13 @@ func (e *env) newServices(ctx context.Context) (*bbl.Services, error) {
 			return nil, err
 		}
 	}
+	for name, sc := range cfg.WorkSources {
+		p := bbl.WorkMatchPolicy{Mode: sc.Match.Mode, Schemes: sc.Match.Schemes}
+		if err := repo.SetSourceMatchPolicy(ctx, name, p); err != nil {
+			repo.Close()
+			return nil, fmt.Errorf("work source %s: %w", name, err)
+		}
+	}
 
 	// --- OpenSearch index (optional) ---
 	var index bbl.Index
@@ -350,6 +459,60 @@ func (e *env) newServices(ctx context.Context) (*bbl.Services, error) {
 		index = idx
 	}
 
+	// --- File store (optional) ---
+	var files bbl.FileStore
+	switch cfg.Files.Type {
+	case "":
+	case "s3":
+		var c s3filestore.Config
+		if err := cfg.Files.Config.Decode(&c); err != nil {
+			repo.Close()
+			return nil, fmt.Errorf("files: decode config: %w", err)
+		}
+		store, err := s3filestore.New(ctx, c)
+		if err != nil {
+			repo.Close()
+			return nil, fmt.Errorf("files: %w", err)
+		}
+		files = store
+	case "local":
+		var c localfilestore.Config
+		if err := cfg.Files.Config.Decode(&c); err != nil {
+			repo.Close()
+			return nil, fmt.Errorf("files: decode config: %w", err)
+		}
+		store, err := localfilestore.New(c)
+		if err != nil {
+			repo.Close()
+			return nil, fmt.Errorf("files: %w", err)
+		}
+		files = store
+	default:
+		repo.Close()
+		return nil, fmt.Errorf("files: unknown type %q", cfg.Files.Type)
+	}
+
+	// --- Notifier (optional) ---
+	var notifier bbl.Notifier
+	switch cfg.Notifier.Type {
+	case "":
+	case "smtp":
+		var c smtpnotifier.Config
+		if err := cfg.Notifier.Config.Decode(&c); err != nil {
+			repo.Close()
+			return nil, fmt.Errorf("notifier: decode config: %w", err)
+		}
+		n, err := smtpnotifier.New(c)
+		if err != nil {
+			repo.Close()
+			return nil, fmt.Errorf("notifier: %w", err)
+		}
+		notifier = n
+	default:
+		repo.Close()
+		return nil, fmt.Errorf("notifier: unknown type %q", cfg.Notifier.Type)
+	}
+
 	// --- Built-in work encoders ---
 	bbl.RegisterWorkEncoder("csv", func() bbl.WorkEncoder { return &csvformat.WorkEncoder{} })
 	bbl.RegisterWorkWriter("csv", func() bbl.WorkWriter { return &csvformat.WorkWriter{} })
@@ -383,6 +546,7 @@ func (e *env) newServices(ctx context.Context) (*bbl.Services, error) {
 				return nil, fmt.Errorf("work encoder %q: %w", name, err)
 			}
 			bbl.RegisterWorkEncoder(name, func() bbl.WorkEncoder { return enc })
+			bbl.RegisterWorkWriter(name, func() bbl.WorkWriter { return &citeformat.WorkWriter{Encoder: enc} })
 		default:
 			repo.Close()
 			return nil, fmt.Errorf("work encoder %q: unknown type %q", name, wc.Type)
@@ -395,5 +559,7 @@ func (e *env) newServices(ctx context.Context) (*bbl.Services, error) {
 		UserSources:     userSources,
 		WorkIterSources: workIterSources,
 		WorkGetSources:  workGetSources,
+		Files:           files,
+		Notifier:        notifier,
 	}, nil
 }
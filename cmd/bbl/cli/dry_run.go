@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"iter"
+	"maps"
+	"slices"
+	"text/tabwriter"
+
+	"github.com/ugent-library/bbl"
+)
+
+const (
+	reportSummary = "summary"
+	reportJSONL   = "jsonl"
+)
+
+var importActions = []string{
+	bbl.ImportActionNew,
+	bbl.ImportActionMatched,
+	bbl.ImportActionUpdated,
+	bbl.ImportActionUnchanged,
+	bbl.ImportActionWithdrawn,
+	bbl.ImportActionRefFailed,
+	bbl.ImportActionFailed,
+}
+
+func checkReport(report string) error {
+	if report != reportSummary && report != reportJSONL {
+		return fmt.Errorf("invalid report format %q: use %s or %s", report, reportSummary, reportJSONL)
+	}
+	return nil
+}
+
+// writeDryRun writes the diffs of an import dry run as JSONL, or as a table
+// of the number of records per action and changes per field followed by
+// the records that failed. With a limit the dry run stops after that many
+// records.
+func writeDryRun(w io.Writer, seq iter.Seq2[*bbl.ImportDiff, error], report string, limit int) error {
+	actions := map[string]int{}
+	fields := map[string]int{}
+	var failed []*bbl.ImportDiff
+	var n int
+
+	for d, err := range seq {
+		if err != nil {
+			return err
+		}
+		n++
+		if report == reportJSONL {
+			if err := writeJSON(w, d); err != nil {
+				return err
+			}
+		} else {
+			actions[d.Action]++
+			for _, f := range d.Fields {
+				fields[f.Field]++
+			}
+			if d.Error != "" {
+				failed = append(failed, d)
+			}
+		}
+		if n == limit {
+			break
+		}
+	}
+	if report == reportJSONL {
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ACTION\tRECORDS")
+	for _, a := range importActions {
+		fmt.Fprintf(tw, "%s\t%d\n", a, actions[a])
+	}
+	if len(fields) > 0 {
+		fmt.Fprintln(tw, "\nFIELD\tCHANGES")
+		for _, f := range slices.Sorted(maps.Keys(fields)) {
+			fmt.Fprintf(tw, "%s\t%d\n", f, fields[f])
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		fmt.Fprintf(w, "\n%d %s failed:\n", len(failed), plural(len(failed), "record", "records"))
+		for _, d := range failed {
+			fmt.Fprintf(w, "  %s: %s\n", d.SourceID, d.Error)
+		}
+	}
+	if n == limit {
+		fmt.Fprintf(w, "\nstopped after %d %s\n", n, plural(n, "record", "records"))
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/ugent-library/bbl"
+)
+
+// newDuplicatesCmd reports duplicate clusters for works or people as JSONL.
+func newDuplicatesCmd(e *env, recordType, entities string) *cobra.Command {
+	var full bool
+	var minConfidence float64
+	cmd := &cobra.Command{
+		Use:   "duplicates",
+		Short: "Report duplicate " + entities,
+		Long: "Report clusters of likely duplicate " + entities + " as JSONL, strongest first.\n\n" +
+			"Candidates are kept up to date as records change. Use --full to rebuild\n" +
+			"them from scratch, e.g. after changing the matching rules or after a CLI import.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			svc, err := e.services(ctx)
+			if err != nil {
+				return err
+			}
+			if full {
+				if err := svc.Repo.DetectAllDuplicates(ctx, recordType); err != nil {
+					return err
+				}
+			}
+			clusters, total, err := svc.Repo.GetDuplicateClusters(ctx, bbl.DuplicateClusterOpts{
+				RecordType:    recordType,
+				MinConfidence: minConfidence,
+			})
+			if err != nil {
+				return err
+			}
+			for _, c := range clusters {
+				if err := writeJSON(cmd.OutOrStdout(), c); err != nil {
+					return err
+				}
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "%d %s\n", total, plural(total, "cluster", "clusters"))
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&full, "full", false, "rebuild all duplicate candidates before reporting")
+	cmd.Flags().Float64Var(&minConfidence, "min-confidence", 0.5, "only report clusters at or above this confidence")
+	return cmd
+}
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newNormalizeIdentifiersCmd(e *env) *cobra.Command {
+	var dryRun bool
+	cmd := &cobra.Command{
+		Use:   "normalize-identifiers",
+		Short: "Normalize stored identifiers",
+		Long: `Rewrite the stored identifiers of all works, people, projects and
+organizations to their normalized form, e.g. DOIs without resolver URL and
+ISBNs as ISBN-13.
+
+Each changed identifier is written to stdout as a JSON line with its old and
+new value. Identifiers that become equal to another identifier of the same
+record and source are removed; identifiers that are not valid are reported
+with an error and left as they are. The records that change are reindexed.
+With --dry-run nothing is written to the database.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			svc, err := e.services(ctx)
+			if err != nil {
+				return err
+			}
+
+			var changed, removed, invalid int
+			for c, err := range svc.NormalizeStoredIdentifiersAndIndex(ctx, dryRun) {
+				if err != nil {
+					return err
+				}
+				switch {
+				case c.Error != "":
+					invalid++
+				case c.Removed:
+					removed++
+				default:
+					changed++
+				}
+				if err := writeJSON(cmd.OutOrStdout(), c); err != nil {
+					return err
+				}
+			}
+			verb := "normalized"
+			if dryRun {
+				verb = "would normalize"
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "%s %d %s, %d duplicate, %d invalid\n",
+				verb, changed, plural(changed, "identifier", "identifiers"), removed, invalid)
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "report the changes without writing them")
+	return cmd
+}
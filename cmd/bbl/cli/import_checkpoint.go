@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/ugent-library/bbl"
+)
+
+// importOpts returns the import options for the --errors and --checkpoint
+// flags of an import command: failed records are written as JSON lines to
+// the errors file or stderr and progress to the checkpoint file. Call close
+// when the import is done.
+func importOpts(cmd *cobra.Command, errorsPath, checkpointPath string) (opts bbl.ImportOpts, close func(), err error) {
+	errorsW := cmd.ErrOrStderr()
+	close = func() {}
+	if errorsPath != "" {
+		f, err := os.OpenFile(errorsPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return opts, close, err
+		}
+		errorsW = f
+		close = func() { f.Close() }
+	}
+	opts.OnFailure = func(f *bbl.ImportFailure) error {
+		return writeJSON(errorsW, f)
+	}
+	if checkpointPath != "" {
+		if opts.Skip, err = readCheckpointFile(checkpointPath); err != nil {
+			close()
+			return opts, func() {}, err
+		}
+		opts.OnCheckpoint = func(processed int) error {
+			return writeCheckpointFile(checkpointPath, processed)
+		}
+	}
+	return opts, close, nil
+}
+
+// finishImport reports the result of an import and removes the checkpoint
+// file once the import completed.
+func finishImport(w io.Writer, source string, res *bbl.ImportResult, err error, checkpointPath, one, many string) error {
+	fmt.Fprintf(w, "%s: imported %d %s, %d failed", source, res.Imported, plural(res.Imported, one, many), res.Failed)
+	if res.Skipped > 0 {
+		fmt.Fprintf(w, ", skipped %d already processed", res.Skipped)
+	}
+	fmt.Fprintln(w)
+	if err != nil {
+		return err
+	}
+	if checkpointPath != "" {
+		if err := os.Remove(checkpointPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// readCheckpointFile returns the number of input records an interrupted
+// import processed, 0 if there is no checkpoint file.
+func readCheckpointFile(path string) (int, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(string(b)))
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid checkpoint file %s", path)
+	}
+	return n, nil
+}
+
+// writeCheckpointFile replaces the checkpoint file atomically, so that an
+// interrupted write doesn't lose the previous checkpoint.
+func writeCheckpointFile(path string, n int) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(tmp, n); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,246 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/ugent-library/bbl"
+)
+
+func newListsCmd(e *env) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lists",
+		Short: "Manage curated lists of works",
+	}
+	cmd.AddCommand(newListsListCmd(e))
+	cmd.AddCommand(newListsCreateCmd(e))
+	cmd.AddCommand(newListsGetCmd(e))
+	cmd.AddCommand(newListsAddCmd(e))
+	cmd.AddCommand(newListsRemoveCmd(e))
+	cmd.AddCommand(newListsExportCmd(e))
+	cmd.AddCommand(newListsDeleteCmd(e))
+	return cmd
+}
+
+func newListsListCmd(e *env) *cobra.Command {
+	var userIDFlag string
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List lists as JSONL",
+		Long:  "List all lists as JSONL, most recently updated first. Use --user to only list the lists of one user.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			svc, err := e.services(ctx)
+			if err != nil {
+				return err
+			}
+			var lists []*bbl.List
+			if userIDFlag != "" {
+				uid, err := bbl.ParseID(userIDFlag)
+				if err != nil {
+					return fmt.Errorf("invalid user ID: %w", err)
+				}
+				lists, err = svc.Repo.GetUserLists(ctx, uid)
+				if err != nil {
+					return err
+				}
+			} else {
+				lists, err = svc.Repo.GetAllLists(ctx)
+				if err != nil {
+					return err
+				}
+			}
+			for _, l := range lists {
+				if err := writeJSON(cmd.OutOrStdout(), l); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&userIDFlag, "user", "", "only lists owned by this user ID")
+	return cmd
+}
+
+func newListsCreateCmd(e *env) *cobra.Command {
+	var userIDFlag, description string
+	var public bool
+	cmd := &cobra.Command{
+		Use:   "create <name>",
+		Short: "Create a list",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			svc, err := e.services(ctx)
+			if err != nil {
+				return err
+			}
+			if userIDFlag == "" {
+				return fmt.Errorf("--user is required")
+			}
+			uid, err := bbl.ParseID(userIDFlag)
+			if err != nil {
+				return fmt.Errorf("invalid user ID: %w", err)
+			}
+			user, err := svc.Repo.GetUser(ctx, uid)
+			if err != nil {
+				return fmt.Errorf("get user: %w", err)
+			}
+			list, err := svc.Repo.CreateList(ctx, user, bbl.ListAttrs{
+				Name:        args[0],
+				Description: description,
+				Public:      public,
+			})
+			if err != nil {
+				return err
+			}
+			return writeJSON(cmd.OutOrStdout(), list)
+		},
+	}
+	cmd.Flags().StringVar(&userIDFlag, "user", "", "owner user ID (required)")
+	cmd.Flags().StringVar(&description, "description", "", "list description")
+	cmd.Flags().BoolVar(&public, "public", false, "anyone with the link can view the list")
+	return cmd
+}
+
+func newListsGetCmd(e *env) *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <id>",
+		Short: "Get a list and its items",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			svc, err := e.services(ctx)
+			if err != nil {
+				return err
+			}
+			id, err := bbl.ParseID(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid list ID: %w", err)
+			}
+			list, err := svc.Repo.GetList(ctx, id)
+			if err != nil {
+				return err
+			}
+			items, err := svc.Repo.GetListItems(ctx, id)
+			if err != nil {
+				return err
+			}
+			return writeJSON(cmd.OutOrStdout(), struct {
+				*bbl.List
+				Items []bbl.ListItem `json:"items"`
+			}{list, items})
+		},
+	}
+}
+
+func newListsAddCmd(e *env) *cobra.Command {
+	return &cobra.Command{
+		Use:   "add <list-id> <work-id>...",
+		Short: "Append works to a list",
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			svc, err := e.services(ctx)
+			if err != nil {
+				return err
+			}
+			listID, err := bbl.ParseID(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid list ID: %w", err)
+			}
+			workIDs := make([]bbl.ID, len(args)-1)
+			for i, arg := range args[1:] {
+				if workIDs[i], err = bbl.ParseID(arg); err != nil {
+					return fmt.Errorf("invalid work ID %q: %w", arg, err)
+				}
+			}
+			n, err := svc.Repo.AddListItems(ctx, listID, workIDs)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "added %d %s\n", n, plural(n, "work", "works"))
+			return nil
+		},
+	}
+}
+
+func newListsRemoveCmd(e *env) *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove <list-id> <work-id>",
+		Short: "Remove a work from a list",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			svc, err := e.services(ctx)
+			if err != nil {
+				return err
+			}
+			listID, err := bbl.ParseID(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid list ID: %w", err)
+			}
+			workID, err := bbl.ParseID(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid work ID: %w", err)
+			}
+			return svc.Repo.RemoveListItem(ctx, listID, workID)
+		},
+	}
+}
+
+func newListsExportCmd(e *env) *cobra.Command {
+	var format string
+	var all bool
+	cmd := &cobra.Command{
+		Use:   "export <id>",
+		Short: "Export the works of a list in list order",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			svc, err := e.services(ctx)
+			if err != nil {
+				return err
+			}
+			id, err := bbl.ParseID(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid list ID: %w", err)
+			}
+			if _, err := svc.Repo.GetList(ctx, id); err != nil {
+				return err
+			}
+			if format == "" {
+				format = "jsonl"
+			}
+			ww, err := bbl.NewWorkWriter(format)
+			if err != nil {
+				return err
+			}
+			_, err = bbl.WriteWorks(cmd.OutOrStdout(), ww, svc.Repo.EachListWork(ctx, id, all))
+			return err
+		},
+	}
+	cmd.Flags().StringVarP(&format, "format", "F", "", "output format ("+bbl.WorkWriterFormatsHelp()+", default: jsonl)")
+	cmd.Flags().BoolVar(&all, "all", false, "include works that are not public")
+	return cmd
+}
+
+func newListsDeleteCmd(e *env) *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete <id>",
+		Short: "Delete a list",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			svc, err := e.services(ctx)
+			if err != nil {
+				return err
+			}
+			id, err := bbl.ParseID(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid list ID: %w", err)
+			}
+			return svc.Repo.DeleteList(ctx, id)
+		},
+	}
+}
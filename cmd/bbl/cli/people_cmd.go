@@ -20,22 +20,48 @@ func newPeopleCmd(e *env) *cobra.Command {
 	cmd.AddCommand(newPeopleListCmd(e))
 	cmd.AddCommand(newPeopleSearchCmd(e))
 	cmd.AddCommand(newPeopleSearchAllCmd(e))
+	cmd.AddCommand(newDuplicatesCmd(e, bbl.RecordTypePerson, "people"))
 	return cmd
 }
 
 func newPeopleImportCmd(e *env) *cobra.Command {
-	return &cobra.Command{
+	var (
+		dryRun         bool
+		limit          int
+		report         string
+		errorsPath     string
+		checkpointPath string
+	)
+	cmd := &cobra.Command{
 		Use:   "import <source>",
 		Short: "Import people from stdin (JSONL)",
-		Args:  cobra.ExactArgs(1),
+		Long: `Import people from stdin (JSONL).
+
+Records that fail validation are left out and reported as JSON lines with
+their source_id and the reason, to the --errors file or stderr. The other
+records are imported.
+
+With --checkpoint the number of input records processed is written to a
+file after every batch. Run the same command on the same input to resume an
+interrupted import; the file is removed once the import completes.
+
+With --dry-run the import runs in a transaction that is rolled back and
+reports what it would do to each record: new, updated, unchanged or failed,
+with the pinned values that change. The transaction keeps every record it
+touches locked until the dry run ends, so updates of those records wait for
+it; use --limit to try a sample on a live database.`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := cmd.Context()
 			source := args[0]
-			svc, err := e.services(ctx)
-			if err != nil {
+			if err := checkReport(report); err != nil {
 				return err
 			}
-			if err := svc.Repo.UpsertSource(ctx, source); err != nil {
+			if limit > 0 && !dryRun {
+				return fmt.Errorf("--limit only applies to --dry-run")
+			}
+			svc, err := e.services(ctx)
+			if err != nil {
 				return err
 			}
 			seq := func(yield func(*bbl.ImportPersonInput, error) bool) {
@@ -59,11 +85,29 @@ func newPeopleImportCmd(e *env) *cobra.Command {
 					}
 				}
 			}
-			n, err := svc.Repo.ImportPeople(ctx, source, seq)
-			fmt.Fprintf(cmd.OutOrStdout(), "%s: imported %d %s\n", source, n, plural(n, "person", "people"))
-			return err
+			if dryRun {
+				return writeDryRun(cmd.OutOrStdout(), svc.Repo.DryRunImportPeople(ctx, source, seq), report, limit)
+			}
+			if err := svc.Repo.UpsertSource(ctx, source); err != nil {
+				return err
+			}
+			opts, closeErrors, err := importOpts(cmd, errorsPath, checkpointPath)
+			if err != nil {
+				return err
+			}
+			defer closeErrors()
+			res, err := svc.Repo.ImportPeopleWithOpts(ctx, source, seq, opts)
+			return finishImport(cmd.OutOrStdout(), source, res, err, checkpointPath, "person", "people")
 		},
 	}
+	cmd.Flags().StringVar(&errorsPath, "errors", "", "append failed records to this JSONL file instead of stderr")
+	cmd.Flags().StringVar(&checkpointPath, "checkpoint", "", "record progress in this file and resume from it")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "report what the import would change without writing it")
+	cmd.Flags().IntVar(&limit, "limit", 0, "stop a dry run after this many records (0 = all)")
+	cmd.Flags().StringVar(&report, "report", reportSummary, "dry run report format (summary or jsonl)")
+	cmd.MarkFlagsMutuallyExclusive("dry-run", "errors")
+	cmd.MarkFlagsMutuallyExclusive("dry-run", "checkpoint")
+	return cmd
 }
 
 func newPeopleGetCmd(e *env) *cobra.Command {
@@ -39,8 +39,11 @@ func NewRootCmd(reg *Registry) *cobra.Command {
 	root.AddCommand(newPeopleCmd(e))
 	root.AddCommand(newProjectsCmd(e))
 	root.AddCommand(newWorksCmd(e))
+	root.AddCommand(newListsCmd(e))
+	root.AddCommand(newCollectionsCmd(e))
 	root.AddCommand(newUpdateCmd(e))
 	root.AddCommand(newReindexCmd(e))
+	root.AddCommand(newNormalizeIdentifiersCmd(e))
 	root.AddCommand(newSeedCmd(e))
 	root.AddCommand(newStartCmd(e))
 
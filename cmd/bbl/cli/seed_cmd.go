@@ -198,7 +198,7 @@ func seedPeople() []*bbl.ImportPersonInput {
 			GivenName:  "Albert",
 			FamilyName: "Einstein",
 			Identifiers: []bbl.Identifier{
-				{Scheme: "orcid", Val: "0000-0001-0001-0001"},
+				{Scheme: "orcid", Val: "0000-0001-0001-0012"},
 			},
 			Affiliations: []bbl.ImportPersonAffiliation{
 				{Ref: bbl.Ref{SourceID: "dept-cs"}},
@@ -210,7 +210,7 @@ func seedPeople() []*bbl.ImportPersonInput {
 			GivenName:  "Marie",
 			FamilyName: "Curie",
 			Identifiers: []bbl.Identifier{
-				{Scheme: "orcid", Val: "0000-0001-0001-0002"},
+				{Scheme: "orcid", Val: "0000-0001-0001-0020"},
 			},
 			Affiliations: []bbl.ImportPersonAffiliation{
 				{Ref: bbl.Ref{SourceID: "dept-cs"}},
@@ -223,7 +223,7 @@ func seedPeople() []*bbl.ImportPersonInput {
 			MiddleName: "Mathison",
 			FamilyName: "Turing",
 			Identifiers: []bbl.Identifier{
-				{Scheme: "orcid", Val: "0000-0001-0001-0003"},
+				{Scheme: "orcid", Val: "0000-0001-0001-0039"},
 			},
 			Affiliations: []bbl.ImportPersonAffiliation{
 				{Ref: bbl.Ref{SourceID: "dept-cs"}},
@@ -235,7 +235,7 @@ func seedPeople() []*bbl.ImportPersonInput {
 			GivenName:  "Emmy",
 			FamilyName: "Noether",
 			Identifiers: []bbl.Identifier{
-				{Scheme: "orcid", Val: "0000-0001-0001-0004"},
+				{Scheme: "orcid", Val: "0000-0001-0001-0047"},
 			},
 		},
 		{
@@ -432,7 +432,7 @@ func seedWorks(_ []*bbl.ImportPersonInput, _ []*bbl.ImportProjectInput) []*bbl.I
 			w.Publisher = publishers[i%len(publishers)]
 			w.PlaceOfPublication = locations[i%len(locations)]
 			w.Identifiers = []bbl.Identifier{
-				{Scheme: "isbn", Val: fmt.Sprintf("978-0-00-%06d-%d", i, i%10)},
+				{Scheme: "isbn", Val: seedISBN(fmt.Sprintf("97800%06d", i))},
 			}
 		case "book_chapter":
 			w.Publisher = publishers[i%len(publishers)]
@@ -495,6 +495,19 @@ func seedWorks(_ []*bbl.ImportPersonInput, _ []*bbl.ImportProjectInput) []*bbl.I
 	return works
 }
 
+// seedISBN appends the check digit to the first 12 digits of an ISBN-13.
+func seedISBN(digits string) string {
+	var sum int
+	for i, c := range digits {
+		d := int(c - '0')
+		if i%2 == 1 {
+			d *= 3
+		}
+		sum += d
+	}
+	return fmt.Sprintf("%s%d", digits, (10-sum%10)%10)
+}
+
 func seedUsers() []bbl.UserAttrs {
 	return []bbl.UserAttrs{
 		{Username: "admin", Email: "admin@example.com", Name: "Admin User", Role: "admin"},
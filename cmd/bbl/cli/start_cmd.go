@@ -11,6 +11,7 @@ import (
 
 	"charm.land/log/v2"
 	"github.com/spf13/cobra"
+	"github.com/ugent-library/bbl"
 	"github.com/ugent-library/bbl/app"
 	"github.com/ugent-library/bbl/oidcauth"
 	"golang.org/x/sync/errgroup"
@@ -20,6 +21,7 @@ func newStartCmd(e *env) *cobra.Command {
 	host := envStrOr("BBL_HOST", "localhost")
 	port := envIntOr("BBL_PORT", 3000)
 	var devFlag bool // --dev flag overrides config
+	var noWorker bool
 
 	cmd := &cobra.Command{
 		Use:   "start",
@@ -119,7 +121,17 @@ func newStartCmd(e *env) *cobra.Command {
 				return server.Shutdown(shutdownCtx)
 			})
 
-			// Future: g.Go(func() error { return worker.Run(ctx) })
+			if !noWorker {
+				g.Go(func() error {
+					logger.Info("worker starting")
+					return svc.RunWorker(ctx, bbl.WorkerConfig{
+						Logger:               logger,
+						EmbargoSchedule:      e.cfg.Embargoes.Schedule,
+						EmbargoNoticeDays:    e.cfg.Embargoes.NoticeDays,
+						DeactivationSchedule: e.cfg.Deactivations.Schedule,
+					})
+				})
+			}
 
 			if err := g.Wait(); err != nil {
 				return err
@@ -132,6 +144,7 @@ func newStartCmd(e *env) *cobra.Command {
 	cmd.Flags().StringVar(&host, "host", host, "Listen host [$BBL_HOST]")
 	cmd.Flags().IntVar(&port, "port", port, "Listen port [$BBL_PORT]")
 	cmd.Flags().BoolVar(&devFlag, "dev", false, "Dev mode: serve assets from disk, no caching")
+	cmd.Flags().BoolVar(&noWorker, "no-worker", false, "Don't run scheduled background tasks")
 
 	return cmd
 }
@@ -2,8 +2,11 @@ package cli
 
 import (
 	"fmt"
+	"iter"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/ugent-library/bbl"
 )
 
 func newUsersCmd(e *env) *cobra.Command {
@@ -12,6 +15,7 @@ func newUsersCmd(e *env) *cobra.Command {
 		Short: "Manage users",
 	}
 	cmd.AddCommand(newUsersImportSourceCmd(e))
+	cmd.AddCommand(newUsersDeactivateCmd(e))
 	return cmd
 }
 
@@ -19,7 +23,12 @@ func newUsersImportSourceCmd(e *env) *cobra.Command {
 	return &cobra.Command{
 		Use:   "import-source <source>",
 		Short: "Import users from a configured source",
-		Args:  cobra.ExactArgs(1),
+		Long: `Import users from a configured source.
+
+If the source has sweep enabled, users it no longer has are scheduled for
+deactivation after grace_days, and deactivations that are due are carried
+out. A user that comes back before then keeps their account.`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := cmd.Context()
 			name := args[0]
@@ -31,13 +40,72 @@ func newUsersImportSourceCmd(e *env) *cobra.Command {
 			if !ok {
 				return fmt.Errorf("unknown user source %q", name)
 			}
+			sc := e.cfg.UserSources[name]
+
+			var startedAt time.Time
+			if sc.Sweep {
+				if startedAt, err = svc.Repo.StartSourceSweep(ctx); err != nil {
+					return err
+				}
+			}
+
 			seq, err := src.Iter(ctx)
 			if err != nil {
 				return err
 			}
-			n, err := svc.Repo.ImportUsers(ctx, name, e.cfg.UserSources[name].AuthProvider, seq)
+			if sc.Sweep {
+				expiresAt := time.Now().AddDate(0, 0, sc.GraceDays)
+				seq = withUserExpiry(seq, expiresAt)
+			}
+			n, err := svc.Repo.ImportUsers(ctx, name, sc.AuthProvider, seq)
 			fmt.Fprintf(cmd.OutOrStdout(), "%s: imported %d %s\n", name, n, plural(n, "user", "users"))
+			if err != nil || !sc.Sweep {
+				return err
+			}
+
+			res, err := svc.Repo.SweepUsers(ctx, name, startedAt, bbl.SweepOpts{MaxVanished: sc.MaxVanished})
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s: scheduled the deactivation of %d of %d %s\n",
+				name, res.Scheduled, res.Total, plural(res.Total, "user", "users"))
+			n, err = svc.Repo.DeactivateUsers(ctx, time.Now())
+			fmt.Fprintf(cmd.OutOrStdout(), "deactivated %d %s\n", n, plural(n, "user", "users"))
+			return err
+		},
+	}
+}
+
+func newUsersDeactivateCmd(e *env) *cobra.Command {
+	return &cobra.Command{
+		Use:   "deactivate",
+		Short: "Carry out the user deactivations that are due",
+		Long:  "Carry out the user deactivations that are due. The worker does this hourly.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			svc, err := e.services(ctx)
+			if err != nil {
+				return err
+			}
+			n, err := svc.Repo.DeactivateUsers(ctx, time.Now())
+			fmt.Fprintf(cmd.OutOrStdout(), "deactivated %d %s\n", n, plural(n, "user", "users"))
 			return err
 		},
 	}
 }
+
+// withUserExpiry sets the expiry of user records that have none, marking
+// them as swept by the source.
+func withUserExpiry(seq iter.Seq2[*bbl.ImportUserInput, error], expiresAt time.Time) iter.Seq2[*bbl.ImportUserInput, error] {
+	return func(yield func(*bbl.ImportUserInput, error) bool) {
+		for in, err := range seq {
+			if in != nil && in.ExpiresAt == nil {
+				in.ExpiresAt = &expiresAt
+			}
+			if !yield(in, err) {
+				return
+			}
+		}
+	}
+}
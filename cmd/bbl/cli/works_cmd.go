@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"iter"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/ugent-library/bbl"
@@ -16,41 +17,115 @@ func newWorksCmd(e *env) *cobra.Command {
 	}
 	cmd.AddCommand(newWorksImportCmd(e))
 	cmd.AddCommand(newWorksImportSourceCmd(e))
+	cmd.AddCommand(newWorksRemapCmd(e))
 	cmd.AddCommand(newWorksGetCmd(e))
 	cmd.AddCommand(newWorksListCmd(e))
 	cmd.AddCommand(newWorksSearchCmd(e))
 	cmd.AddCommand(newWorksSearchAllCmd(e))
 	cmd.AddCommand(newWorksBatchExportCmd(e))
 	cmd.AddCommand(newWorksBatchImportCmd(e))
+	cmd.AddCommand(newDuplicatesCmd(e, bbl.RecordTypeWork, "works"))
+	cmd.AddCommand(newWorksLiftEmbargoesCmd(e))
+	return cmd
+}
+
+func newWorksLiftEmbargoesCmd(e *env) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lift-embargoes",
+		Short: "Lift expired file embargoes",
+		Long: `Lift expired file embargoes and notify depositors of upcoming ones,
+like the scheduled embargo task of the worker does.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			svc, err := e.services(ctx)
+			if err != nil {
+				return err
+			}
+			now := time.Now()
+			n, err := svc.LiftEmbargoes(ctx, now)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "lifted embargoes on %d %s\n", n, plural(n, "work", "works"))
+			n, err = svc.NotifyExpiringEmbargoes(ctx, now, time.Duration(e.cfg.Embargoes.NoticeDays)*24*time.Hour)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "sent %d embargo %s\n", n, plural(n, "notice", "notices"))
+			return nil
+		},
+	}
 	return cmd
 }
 
 func newWorksImportCmd(e *env) *cobra.Command {
-	var format string
+	var (
+		format         string
+		dryRun         bool
+		limit          int
+		report         string
+		errorsPath     string
+		checkpointPath string
+	)
 	cmd := &cobra.Command{
 		Use:   "import <source>",
 		Short: "Import works from stdin",
-		Args:  cobra.ExactArgs(1),
+		Long: `Import works from stdin.
+
+Records that fail validation or have a ref that doesn't resolve are left
+out and reported as JSON lines with their source_id and the reason, to the
+--errors file or stderr. The other records are imported.
+
+With --checkpoint the number of input records processed is written to a
+file after every batch. Run the same command on the same input to resume an
+interrupted import; the file is removed once the import completes.
+
+With --dry-run the import runs in a transaction that is rolled back and
+reports what it would do to each record: new, updated, unchanged or failed,
+with the pinned values that change. The transaction keeps every record it
+touches locked until the dry run ends, so updates of those records wait for
+it; use --limit to try a sample on a live database.`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := cmd.Context()
 			source := args[0]
+			if err := checkReport(report); err != nil {
+				return err
+			}
+			if limit > 0 && !dryRun {
+				return fmt.Errorf("--limit only applies to --dry-run")
+			}
 			svc, err := e.services(ctx)
 			if err != nil {
 				return err
 			}
+			seq, err := bbl.ReadWorks(os.Stdin, format)
+			if err != nil {
+				return err
+			}
+			if dryRun {
+				return writeDryRun(cmd.OutOrStdout(), svc.Repo.DryRunImportWorks(ctx, source, seq), report, limit)
+			}
 			if err := svc.Repo.UpsertSource(ctx, source); err != nil {
 				return err
 			}
-			seq, err := bbl.ReadWorks(os.Stdin, format)
+			opts, closeErrors, err := importOpts(cmd, errorsPath, checkpointPath)
 			if err != nil {
 				return err
 			}
-			n, err := svc.Repo.ImportWorks(ctx, source, seq)
-			fmt.Fprintf(cmd.OutOrStdout(), "%s: imported %d %s\n", source, n, plural(n, "work", "works"))
-			return err
+			defer closeErrors()
+			res, err := svc.Repo.ImportWorksWithOpts(ctx, source, seq, opts)
+			return finishImport(cmd.OutOrStdout(), source, res, err, checkpointPath, "work", "works")
 		},
 	}
 	cmd.Flags().StringVarP(&format, "format", "F", "jsonl", "input format ("+bbl.WorkReaderFormatsHelp()+")")
+	cmd.Flags().StringVar(&errorsPath, "errors", "", "append failed records to this JSONL file instead of stderr")
+	cmd.Flags().StringVar(&checkpointPath, "checkpoint", "", "record progress in this file and resume from it")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "report what the import would change without writing it")
+	cmd.Flags().IntVar(&limit, "limit", 0, "stop a dry run after this many records (0 = all)")
+	cmd.Flags().StringVar(&report, "report", reportSummary, "dry run report format (summary or jsonl)")
+	cmd.MarkFlagsMutuallyExclusive("dry-run", "errors")
+	cmd.MarkFlagsMutuallyExclusive("dry-run", "checkpoint")
 	return cmd
 }
 
@@ -229,20 +304,64 @@ func newWorksSearchAllCmd(e *env) *cobra.Command {
 }
 
 func newWorksImportSourceCmd(e *env) *cobra.Command {
-	var id string
+	var (
+		id          string
+		full        bool
+		since       string
+		sweep       bool
+		maxVanished float64
+		dryRun      bool
+		limit       int
+		report      string
+		errorsPath  string
+	)
 	cmd := &cobra.Command{
 		Use:   "import-source <source>",
 		Short: "Import works from a configured source",
-		Args:  cobra.ExactArgs(1),
+		Long: `Import works from a configured source.
+
+Sources that support incremental harvesting continue from the checkpoint
+stored by the previous successful run. Use --full to ignore it or --since
+to start from another checkpoint; the checkpoint is updated either way.
+
+With --sweep the run is a full run, after which the records the source no
+longer has are withdrawn. Sources that only harvest part of their records,
+like an OAI-PMH source with a from date, can't be swept. Works left without assertions are flagged for
+review. The sweep is skipped with an error if more than --max-vanished of
+the records are gone, e.g. because the source returned a partial result.
+Records that fail are kept and not withdrawn.
+
+Records that fail validation or have a ref that doesn't resolve are left
+out and reported as JSON lines to the --errors file or stderr. The stored
+checkpoint is not updated when records fail, so the next run harvests them
+again.
+
+With --dry-run the import runs in a transaction that is rolled back and
+reports what it would do to each record; the checkpoint is not updated. The
+transaction keeps every record it touches locked until the dry run ends, so
+updates of those records wait for it; use --limit to try a sample.`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := cmd.Context()
 			source := args[0]
+			if err := checkReport(report); err != nil {
+				return err
+			}
+			if limit > 0 && !dryRun {
+				return fmt.Errorf("--limit only applies to --dry-run")
+			}
 			svc, err := e.services(ctx)
 			if err != nil {
 				return err
 			}
 
 			var seq iter.Seq2[*bbl.ImportWorkInput, error]
+			var checkpoint string
+			var sweepStartedAt time.Time
+
+			if sweep {
+				full = true
+			}
 
 			if id != "" {
 				src, ok := svc.WorkGetSources[source]
@@ -261,17 +380,152 @@ func newWorksImportSourceCmd(e *env) *cobra.Command {
 				if !ok {
 					return fmt.Errorf("unknown work source %q", source)
 				}
-				seq, err = src.Iter(ctx)
+				if sweep {
+					if sweepStartedAt, err = svc.Repo.StartWorkSourceSweep(ctx, src, since); err != nil {
+						return err
+					}
+				}
+				if s, ok := src.(bbl.WorkSourceIterSince); ok {
+					if !full && since == "" {
+						since, err = svc.Repo.GetSourceCheckpoint(ctx, source)
+						if err != nil {
+							return err
+						}
+					}
+					seq, checkpoint, err = s.IterSince(ctx, since)
+					if err != nil {
+						return err
+					}
+				} else if since != "" {
+					return fmt.Errorf("source %q does not support incremental harvesting", source)
+				} else {
+					seq, err = src.Iter(ctx)
+					if err != nil {
+						return err
+					}
+				}
+			}
+
+			if dryRun {
+				return writeDryRun(cmd.OutOrStdout(), svc.Repo.DryRunImportWorks(ctx, source, seq), report, limit)
+			}
+
+			// A harvest is not stable enough to resume by record count, so
+			// there is no checkpoint file.
+			opts, closeErrors, err := importOpts(cmd, errorsPath, "")
+			if err != nil {
+				return err
+			}
+			defer closeErrors()
+			res, err := svc.Repo.ImportWorksWithOpts(ctx, source, seq, opts)
+			if err := finishImport(cmd.OutOrStdout(), source, res, err, "", "work", "works"); err != nil {
+				return err
+			}
+			// Only a completed run without failures moves the checkpoint.
+			if checkpoint != "" {
+				if res.Failed > 0 {
+					fmt.Fprintf(cmd.OutOrStdout(), "%s: checkpoint not advanced because records failed\n", source)
+				} else if err := svc.Repo.SetSourceCheckpoint(ctx, source, checkpoint); err != nil {
+					return err
+				}
+			}
+			if sweep {
+				res, err := svc.SweepWorkSourceAndIndex(ctx, source, sweepStartedAt, bbl.SweepOpts{MaxVanished: maxVanished})
 				if err != nil {
 					return err
 				}
+				fmt.Fprintf(cmd.OutOrStdout(), "%s: withdrew %d of %d %s, flagged %d %s for review\n",
+					source, res.Withdrawn, res.Total, plural(res.Total, "record", "records"),
+					res.Flagged, plural(res.Flagged, "work", "works"))
 			}
-
-			n, err := svc.Repo.ImportWorks(ctx, source, seq)
-			fmt.Fprintf(cmd.OutOrStdout(), "%s: imported %d %s\n", source, n, plural(n, "work", "works"))
-			return err
+			return nil
 		},
 	}
 	cmd.Flags().StringVar(&id, "id", "", "import a single record by source ID")
+	cmd.Flags().BoolVar(&full, "full", false, "ignore the stored checkpoint and harvest everything")
+	cmd.Flags().StringVar(&since, "since", "", "harvest from this checkpoint instead of the stored one")
+	cmd.Flags().BoolVar(&sweep, "sweep", false, "harvest everything and withdraw the records the source no longer has")
+	cmd.Flags().Float64Var(&maxVanished, "max-vanished", bbl.DefaultSweepMaxVanished, "fraction of the records that may vanish in a sweep")
+	cmd.MarkFlagsMutuallyExclusive("id", "full", "since")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "report what the import would change without writing it")
+	cmd.Flags().IntVar(&limit, "limit", 0, "stop a dry run after this many records (0 = all)")
+	cmd.Flags().StringVar(&report, "report", reportSummary, "dry run report format (summary or jsonl)")
+	cmd.MarkFlagsMutuallyExclusive("id", "since", "sweep")
+	cmd.Flags().StringVar(&errorsPath, "errors", "", "append failed records to this JSONL file instead of stderr")
+	cmd.MarkFlagsMutuallyExclusive("dry-run", "sweep")
+	cmd.MarkFlagsMutuallyExclusive("dry-run", "errors")
+	return cmd
+}
+
+func newWorksRemapCmd(e *env) *cobra.Command {
+	var (
+		since  string
+		dryRun bool
+	)
+	cmd := &cobra.Command{
+		Use:   "remap <source>",
+		Short: "Map stored source records again",
+		Long: `Map the stored records of a configured source again, without fetching
+them, and replace the source's assertions where they differ. Use this after
+a fix to the source's mapping.
+
+Each changed record is written to stdout as a JSON line with the old and new
+value of every field that changes. With --dry-run nothing is written to the
+database. Run bbl reindex works afterwards.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			source := args[0]
+			svc, err := e.services(ctx)
+			if err != nil {
+				return err
+			}
+
+			var mapper bbl.WorkSourceMapper
+			if src, ok := svc.WorkIterSources[source]; ok {
+				mapper, _ = src.(bbl.WorkSourceMapper)
+			} else if src, ok := svc.WorkGetSources[source]; ok {
+				mapper, _ = src.(bbl.WorkSourceMapper)
+			} else {
+				return fmt.Errorf("unknown work source %q", source)
+			}
+			if mapper == nil {
+				return fmt.Errorf("source %q does not support remapping", source)
+			}
+
+			opts := bbl.RemapOpts{DryRun: dryRun}
+			if since != "" {
+				if opts.Since, err = time.Parse(time.DateOnly, since); err != nil {
+					if opts.Since, err = time.Parse(time.RFC3339, since); err != nil {
+						return fmt.Errorf("invalid --since %q: use YYYY-MM-DD or RFC 3339", since)
+					}
+				}
+			}
+
+			var changed, failed int
+			for d, err := range svc.Repo.RemapWorks(ctx, source, mapper, opts) {
+				if err != nil {
+					return err
+				}
+				if d.Error != "" {
+					failed++
+				} else {
+					changed++
+				}
+				if err := writeJSON(cmd.OutOrStdout(), d); err != nil {
+					return err
+				}
+			}
+			verb := "remapped"
+			if dryRun {
+				verb = "would remap"
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "%s: %s %d %s, %d failed\n",
+				source, verb, changed, plural(changed, "record", "records"), failed)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&since, "since", "", "only remap records ingested since this date")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "report the changes without writing them")
 	return cmd
 }
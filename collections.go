@@ -0,0 +1,588 @@
+package bbl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Collection kinds.
+const (
+	CollectionKindManual = "manual" // explicit, ordered membership
+	CollectionKindQuery  = "query"  // search query and filter evaluated through the index
+)
+
+// Collection is a named set of works. Public collections are shown on
+// public pages and exposed as OAI-PMH sets and SRU databases. Only public
+// works are ever exposed, whatever the collection holds.
+type Collection struct {
+	ID ID `json:"id"`
+	// Name identifies the collection in URLs, as OAI-PMH setSpec and as SRU
+	// database name.
+	Name        string    `json:"name"`
+	Title       string    `json:"title"`
+	Description string    `json:"description,omitempty"`
+	Kind        string    `json:"kind"`
+	Query       string    `json:"query,omitempty"`  // query collections only
+	Filter      string    `json:"filter,omitempty"` // query collections only, see ParseQueryFilter
+	Public      bool      `json:"public"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	WorkCount   int       `json:"work_count,omitempty"` // manual collections only
+}
+
+// CollectionAttrs are the editable attributes of a collection.
+type CollectionAttrs struct {
+	Name        string
+	Title       string
+	Description string
+	Kind        string
+	Query       string
+	Filter      string
+	Public      bool
+}
+
+// DisplayTitle returns the title, or the name if the collection has none.
+func (c *Collection) DisplayTitle() string {
+	if c.Title != "" {
+		return c.Title
+	}
+	return c.Name
+}
+
+// CanManageCollections reports whether user may create and change
+// collections.
+func CanManageCollections(user *User) bool {
+	return user != nil && (user.Role == RoleCurator || user.Role == RoleAdmin)
+}
+
+var collectionNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]*$`)
+
+func validateCollectionAttrs(attrs *CollectionAttrs) error {
+	attrs.Name = strings.TrimSpace(attrs.Name)
+	attrs.Title = strings.TrimSpace(attrs.Title)
+	attrs.Description = strings.TrimSpace(attrs.Description)
+	attrs.Query = strings.TrimSpace(attrs.Query)
+	attrs.Filter = strings.TrimSpace(attrs.Filter)
+	if !collectionNameRe.MatchString(attrs.Name) {
+		return fmt.Errorf("invalid collection name %q: use lowercase letters, digits, '_', '.' and '-'", attrs.Name)
+	}
+	switch attrs.Kind {
+	case CollectionKindManual:
+		if attrs.Query != "" || attrs.Filter != "" {
+			return fmt.Errorf("manual collections have no query or filter")
+		}
+	case CollectionKindQuery:
+		if attrs.Query == "" && attrs.Filter == "" {
+			return fmt.Errorf("query collections need a query or a filter")
+		}
+		if attrs.Filter != "" {
+			if _, err := ParseQueryFilter(attrs.Filter); err != nil {
+				return fmt.Errorf("invalid filter: %w", err)
+			}
+		}
+	default:
+		return fmt.Errorf("invalid collection kind %q", attrs.Kind)
+	}
+	return nil
+}
+
+const collectionCols = `c.id, c.name, c.title, c.description, c.kind, c.query, c.filter, c.public, c.created_at, c.updated_at,
+	(SELECT count(*) FROM bbl_work_collection_works cw WHERE cw.collection_id = c.id)`
+
+func scanCollection(row pgx.Row) (*Collection, error) {
+	var c Collection
+	err := row.Scan(&c.ID, &c.Name, &c.Title, &c.Description, &c.Kind, &c.Query, &c.Filter, &c.Public, &c.CreatedAt, &c.UpdatedAt, &c.WorkCount)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// CreateCollection creates an empty collection. Names are unique.
+func (r *Repo) CreateCollection(ctx context.Context, attrs CollectionAttrs) (*Collection, error) {
+	if err := validateCollectionAttrs(&attrs); err != nil {
+		return nil, fmt.Errorf("CreateCollection: %w", err)
+	}
+	id := newID()
+	if _, err := r.db.Exec(ctx, `
+		INSERT INTO bbl_work_collections (id, name, title, description, kind, query, filter, public)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		id, attrs.Name, attrs.Title, attrs.Description, attrs.Kind, attrs.Query, attrs.Filter, attrs.Public); err != nil {
+		return nil, fmt.Errorf("CreateCollection: %w", err)
+	}
+	return r.GetCollection(ctx, id)
+}
+
+// GetCollection fetches a collection by ID. Returns ErrNotFound if it does
+// not exist.
+func (r *Repo) GetCollection(ctx context.Context, id ID) (*Collection, error) {
+	return r.getCollection(ctx, `c.id = $1`, id)
+}
+
+// GetCollectionByName fetches a collection by name. Returns ErrNotFound if
+// it does not exist.
+func (r *Repo) GetCollectionByName(ctx context.Context, name string) (*Collection, error) {
+	return r.getCollection(ctx, `c.name = $1`, name)
+}
+
+func (r *Repo) getCollection(ctx context.Context, where string, arg any) (*Collection, error) {
+	c, err := scanCollection(r.db.QueryRow(ctx, `SELECT `+collectionCols+` FROM bbl_work_collections c WHERE `+where, arg))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getCollection: %w", err)
+	}
+	return c, nil
+}
+
+// GetCollections returns all collections ordered by name. If public is set,
+// only public collections are returned.
+func (r *Repo) GetCollections(ctx context.Context, public bool) ([]*Collection, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+collectionCols+`
+		FROM bbl_work_collections c
+		WHERE c.public OR NOT $1
+		ORDER BY c.name`, public)
+	if err != nil {
+		return nil, fmt.Errorf("GetCollections: %w", err)
+	}
+	collections, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*Collection, error) {
+		return scanCollection(row)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetCollections: %w", err)
+	}
+	return collections, nil
+}
+
+// UpdateCollection changes the attributes of a collection. The kind of a
+// collection cannot be changed.
+func (r *Repo) UpdateCollection(ctx context.Context, id ID, attrs CollectionAttrs) error {
+	c, err := r.GetCollection(ctx, id)
+	if err != nil {
+		return err
+	}
+	if attrs.Kind == "" {
+		attrs.Kind = c.Kind
+	}
+	if attrs.Kind != c.Kind {
+		return fmt.Errorf("UpdateCollection: cannot change kind of collection %s from %s to %s", c.Name, c.Kind, attrs.Kind)
+	}
+	if err := validateCollectionAttrs(&attrs); err != nil {
+		return fmt.Errorf("UpdateCollection: %w", err)
+	}
+	_, err = r.db.Exec(ctx, `
+		UPDATE bbl_work_collections
+		SET name = $2, title = $3, description = $4, query = $5, filter = $6, public = $7,
+		    updated_at = transaction_timestamp()
+		WHERE id = $1`,
+		id, attrs.Name, attrs.Title, attrs.Description, attrs.Query, attrs.Filter, attrs.Public)
+	if err != nil {
+		return fmt.Errorf("UpdateCollection: %w", err)
+	}
+	return nil
+}
+
+// DeleteCollection deletes a collection and its membership.
+func (r *Repo) DeleteCollection(ctx context.Context, id ID) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM bbl_work_collections WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("DeleteCollection: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// GetCollectionWorkIDs returns the works of a manual collection in order.
+func (r *Repo) GetCollectionWorkIDs(ctx context.Context, collectionID ID) ([]ID, error) {
+	ids, err := r.queryIDs(ctx, `
+		SELECT work_id FROM bbl_work_collection_works
+		WHERE collection_id = $1
+		ORDER BY pos`, collectionID)
+	if err != nil {
+		return nil, fmt.Errorf("GetCollectionWorkIDs: %w", err)
+	}
+	return ids, nil
+}
+
+// collectionSnapshotTTL is how long a collection snapshot can be paged
+// through. Harvests of a query collection must finish within it.
+const collectionSnapshotTTL = 24 * time.Hour
+
+// CreateCollectionSnapshot stores the given works of a collection under a new
+// snapshot id, so that a query collection can be paged through without
+// evaluating the query again for every page. Expired snapshots are removed.
+func (r *Repo) CreateCollectionSnapshot(ctx context.Context, collectionID ID, workIDs []ID) (ID, error) {
+	id := newID()
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return id, fmt.Errorf("CreateCollectionSnapshot: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `
+		DELETE FROM bbl_work_collection_snapshots
+		WHERE created_at < transaction_timestamp() - make_interval(secs => $1)`,
+		collectionSnapshotTTL.Seconds()); err != nil {
+		return id, fmt.Errorf("CreateCollectionSnapshot: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO bbl_work_collection_snapshots (id, collection_id)
+		VALUES ($1, $2)`, id, collectionID); err != nil {
+		return id, fmt.Errorf("CreateCollectionSnapshot: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO bbl_work_collection_snapshot_works (snapshot_id, work_id)
+		SELECT $1, id FROM bbl_works WHERE id = ANY($2)`, id, workIDs); err != nil {
+		return id, fmt.Errorf("CreateCollectionSnapshot: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return id, fmt.Errorf("CreateCollectionSnapshot: %w", err)
+	}
+	return id, nil
+}
+
+// HasCollectionSnapshot reports whether a snapshot of the collection exists
+// and has not expired.
+func (r *Repo) HasCollectionSnapshot(ctx context.Context, collectionID, id ID) (bool, error) {
+	var ok bool
+	err := r.db.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM bbl_work_collection_snapshots
+			WHERE id = $1 AND collection_id = $2
+			  AND created_at >= transaction_timestamp() - make_interval(secs => $3)
+		)`, id, collectionID, collectionSnapshotTTL.Seconds()).Scan(&ok)
+	if err != nil {
+		return false, fmt.Errorf("HasCollectionSnapshot: %w", err)
+	}
+	return ok, nil
+}
+
+// getPublicCollectionNames returns the names of the public manual
+// collections each of the given works is in.
+func (r *Repo) getPublicCollectionNames(ctx context.Context, workIDs []ID) (map[ID][]string, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT cw.work_id, c.name
+		FROM bbl_work_collection_works cw
+		JOIN bbl_work_collections c ON c.id = cw.collection_id
+		WHERE c.public AND cw.work_id = ANY($1)
+		ORDER BY c.name`, workIDs)
+	if err != nil {
+		return nil, fmt.Errorf("getPublicCollectionNames: %w", err)
+	}
+	defer rows.Close()
+
+	names := make(map[ID][]string)
+	for rows.Next() {
+		var id ID
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, fmt.Errorf("getPublicCollectionNames: %w", err)
+		}
+		names[id] = append(names[id], name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("getPublicCollectionNames: %w", err)
+	}
+	return names, nil
+}
+
+type collectionWork struct {
+	workID ID
+	pos    string
+}
+
+// AddCollectionWorks appends works to the end of a manual collection, in the
+// given order. Works already in the collection keep their place. Returns the
+// number of works added.
+func (r *Repo) AddCollectionWorks(ctx context.Context, collectionID ID, workIDs []ID) (int, error) {
+	var n int
+	err := r.updateCollectionWorks(ctx, collectionID, func(tx pgx.Tx, members []collectionWork) error {
+		inCollection := make(map[ID]bool, len(members))
+		last := ""
+		for _, m := range members {
+			inCollection[m.workID] = true
+			last = m.pos
+		}
+		batch := &pgx.Batch{}
+		for _, id := range workIDs {
+			if inCollection[id] {
+				continue
+			}
+			inCollection[id] = true
+			pos, err := posBetween(last, "")
+			if err != nil {
+				return err
+			}
+			last = pos
+			batch.Queue(`
+				INSERT INTO bbl_work_collection_works (collection_id, work_id, pos)
+				SELECT $1, id, $3 FROM bbl_works WHERE id = $2`,
+				collectionID, id, pos)
+		}
+		if batch.Len() == 0 {
+			return nil
+		}
+		res := tx.SendBatch(ctx, batch)
+		for range batch.Len() {
+			tag, err := res.Exec()
+			if err != nil {
+				res.Close()
+				return err
+			}
+			n += int(tag.RowsAffected())
+		}
+		return res.Close()
+	})
+	if err != nil {
+		return 0, fmt.Errorf("AddCollectionWorks: %w", err)
+	}
+	return n, nil
+}
+
+// RemoveCollectionWork removes a work from a manual collection. Removing a
+// work that is not in the collection is a noop.
+func (r *Repo) RemoveCollectionWork(ctx context.Context, collectionID, workID ID) error {
+	err := r.updateCollectionWorks(ctx, collectionID, func(tx pgx.Tx, _ []collectionWork) error {
+		_, err := tx.Exec(ctx, `
+			DELETE FROM bbl_work_collection_works
+			WHERE collection_id = $1 AND work_id = $2`,
+			collectionID, workID)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("RemoveCollectionWork: %w", err)
+	}
+	return nil
+}
+
+// MoveCollectionWork moves a work to index i of a manual collection.
+// Indexes are clamped to the collection bounds.
+func (r *Repo) MoveCollectionWork(ctx context.Context, collectionID, workID ID, i int) error {
+	err := r.updateCollectionWorks(ctx, collectionID, func(tx pgx.Tx, members []collectionWork) error {
+		j := slices.IndexFunc(members, func(m collectionWork) bool { return m.workID == workID })
+		if j < 0 {
+			return ErrNotFound
+		}
+		others := slices.Delete(slices.Clone(members), j, j+1)
+		i = max(0, min(i, len(others)))
+		var before, after string
+		if i > 0 {
+			before = others[i-1].pos
+		}
+		if i < len(others) {
+			after = others[i].pos
+		}
+		pos, err := posBetween(before, after)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `
+			UPDATE bbl_work_collection_works SET pos = $3
+			WHERE collection_id = $1 AND work_id = $2`,
+			collectionID, workID, pos)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("MoveCollectionWork: %w", err)
+	}
+	return nil
+}
+
+// updateCollectionWorks locks a manual collection, passes its current
+// members to fn and bumps updated_at, all in one transaction.
+func (r *Repo) updateCollectionWorks(ctx context.Context, collectionID ID, fn func(pgx.Tx, []collectionWork) error) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var kind string
+	err = tx.QueryRow(ctx, `
+		UPDATE bbl_work_collections SET updated_at = transaction_timestamp()
+		WHERE id = $1
+		RETURNING kind`, collectionID).Scan(&kind)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if kind != CollectionKindManual {
+		return fmt.Errorf("collection %s is a %s collection", collectionID, kind)
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT work_id, pos FROM bbl_work_collection_works
+		WHERE collection_id = $1
+		ORDER BY pos`, collectionID)
+	if err != nil {
+		return err
+	}
+	members, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (collectionWork, error) {
+		var m collectionWork
+		err := row.Scan(&m.workID, &m.pos)
+		return m, err
+	})
+	if err != nil {
+		return err
+	}
+	if err := fn(tx, members); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// scopeToCollection restricts opts to the public works of a collection.
+// Returns false if the collection is known to be empty.
+func (s *Services) scopeToCollection(ctx context.Context, c *Collection, opts *SearchOpts) (bool, error) {
+	opts.WithFilter("status", WorkStatusPublic)
+	switch c.Kind {
+	case CollectionKindQuery:
+		opts.Within = c.Query
+		if c.Filter != "" {
+			f, err := ParseQueryFilter(c.Filter)
+			if err != nil {
+				return false, fmt.Errorf("collection %s: invalid filter: %w", c.Name, err)
+			}
+			opts.Filter.And = append(opts.Filter.And, f.And...)
+		}
+	default:
+		ids, err := s.Repo.GetCollectionWorkIDs(ctx, c.ID)
+		if err != nil || len(ids) == 0 {
+			return false, err
+		}
+		opts.IDs = ids
+	}
+	return true, nil
+}
+
+// SearchCollectionWorks searches the public works of a collection and
+// fetches full records.
+func (s *Services) SearchCollectionWorks(ctx context.Context, c *Collection, opts *SearchOpts) (*WorkRecordHits, error) {
+	ok, err := s.scopeToCollection(ctx, c, opts)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return &WorkRecordHits{}, nil
+	}
+	return s.SearchWorkRecords(ctx, opts)
+}
+
+// CollectionWorkIDs returns the IDs of the public works of a collection.
+// Manual collections are returned in order.
+func (s *Services) CollectionWorkIDs(ctx context.Context, c *Collection) ([]ID, error) {
+	if c.Kind == CollectionKindManual {
+		// Harvesters need to see works that left the public status, so
+		// status is left to the caller.
+		return s.Repo.GetCollectionWorkIDs(ctx, c.ID)
+	}
+	if s.Index == nil {
+		return nil, fmt.Errorf("no search index configured")
+	}
+	opts := &SearchOpts{}
+	if _, err := s.scopeToCollection(ctx, c, opts); err != nil {
+		return nil, err
+	}
+	var ids []ID
+	for h, err := range SearchAllWorks(ctx, s.Index.Works(), opts) {
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, h.ID)
+	}
+	return ids, nil
+}
+
+// PublicCollectionNames returns the names of the public collections each of
+// the given works is in, ordered by name. Query collections are evaluated
+// through the index against the given works only.
+func (s *Services) PublicCollectionNames(ctx context.Context, workIDs []ID) (map[ID][]string, error) {
+	if len(workIDs) == 0 {
+		return map[ID][]string{}, nil
+	}
+	names, err := s.Repo.getPublicCollectionNames(ctx, workIDs)
+	if err != nil {
+		return nil, err
+	}
+	collections, err := s.Repo.GetCollections(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range collections {
+		if c.Kind != CollectionKindQuery {
+			continue
+		}
+		if s.Index == nil {
+			return nil, fmt.Errorf("no search index configured")
+		}
+		opts := &SearchOpts{IDs: workIDs}
+		if _, err := s.scopeToCollection(ctx, c, opts); err != nil {
+			return nil, err
+		}
+		for h, err := range SearchAllWorks(ctx, s.Index.Works(), opts) {
+			if err != nil {
+				return nil, err
+			}
+			names[h.ID] = append(names[h.ID], c.Name)
+		}
+	}
+	for _, n := range names {
+		slices.Sort(n)
+	}
+	return names, nil
+}
+
+// EachCollectionWork iterates over the public works of a collection. Manual
+// collections are iterated in order.
+func (s *Services) EachCollectionWork(ctx context.Context, c *Collection) iter.Seq2[*Work, error] {
+	if c.Kind == CollectionKindQuery {
+		return func(yield func(*Work, error) bool) {
+			opts := &SearchOpts{}
+			if _, err := s.scopeToCollection(ctx, c, opts); err != nil {
+				yield(nil, err)
+				return
+			}
+			for w, err := range s.SearchAllWorkRecords(ctx, opts) {
+				if !yield(w, err) || err != nil {
+					return
+				}
+			}
+		}
+	}
+	return func(yield func(*Work, error) bool) {
+		ids, err := s.Repo.GetCollectionWorkIDs(ctx, c.ID)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		for chunk := range slices.Chunk(ids, searchAllSize) {
+			works, err := s.Repo.GetWorks(ctx, chunk)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for _, w := range works {
+				if w.Status != WorkStatusPublic {
+					continue
+				}
+				if !yield(w, nil) {
+					return
+				}
+			}
+		}
+	}
+}
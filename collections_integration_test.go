@@ -0,0 +1,194 @@
+package bbl
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCollections(t *testing.T) {
+	repo := testRepo(t)
+	ctx := context.Background()
+	user := createTestUser(t, repo, RoleCurator)
+
+	var ids []ID
+	for range 3 {
+		id := newID()
+		if _, _, err := repo.Update(ctx, user,
+			&CreateWork{ID: id, Kind: "journal_article", Status: WorkStatusPublic},
+			&Set{RecordType: RecordTypeWork, RecordID: id, Field: "titles", Val: []Title{{Lang: "eng", Val: "Paper"}}},
+			&Set{RecordType: RecordTypeWork, RecordID: id, Field: "journal_title", Val: "Journal"},
+			&Set{RecordType: RecordTypeWork, RecordID: id, Field: "publication_year", Val: "2024"},
+		); err != nil {
+			t.Fatalf("create work: %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	manual, err := repo.CreateCollection(ctx, CollectionAttrs{Name: "theses", Kind: CollectionKindManual, Public: true})
+	if err != nil {
+		t.Fatalf("create collection: %v", err)
+	}
+	if _, err := repo.CreateCollection(ctx, CollectionAttrs{Name: "theses", Kind: CollectionKindManual}); err == nil {
+		t.Error("expected an error for a duplicate collection name")
+	}
+	query, err := repo.CreateCollection(ctx, CollectionAttrs{Name: "articles", Kind: CollectionKindQuery, Filter: "kind=journal_article"})
+	if err != nil {
+		t.Fatalf("create collection: %v", err)
+	}
+
+	// Works already in the collection and unknown works are skipped.
+	n, err := repo.AddCollectionWorks(ctx, manual.ID, []ID{ids[0], ids[1], ids[0], newID()})
+	if err != nil {
+		t.Fatalf("add works: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("added %d, want 2", n)
+	}
+	if _, err := repo.AddCollectionWorks(ctx, manual.ID, []ID{ids[2]}); err != nil {
+		t.Fatalf("add works: %v", err)
+	}
+	assertCollectionOrder(t, repo, manual.ID, ids[0], ids[1], ids[2])
+
+	if err := repo.MoveCollectionWork(ctx, manual.ID, ids[2], 0); err != nil {
+		t.Fatalf("move: %v", err)
+	}
+	assertCollectionOrder(t, repo, manual.ID, ids[2], ids[0], ids[1])
+	if err := repo.MoveCollectionWork(ctx, manual.ID, newID(), 0); !errors.Is(err, ErrNotFound) {
+		t.Errorf("move unknown work: err = %v, want ErrNotFound", err)
+	}
+	if err := repo.RemoveCollectionWork(ctx, manual.ID, ids[0]); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	assertCollectionOrder(t, repo, manual.ID, ids[2], ids[1])
+
+	// Query collections have no explicit membership.
+	if _, err := repo.AddCollectionWorks(ctx, query.ID, ids); err == nil {
+		t.Error("expected an error adding works to a query collection")
+	}
+
+	// The kind of a collection is fixed.
+	if err := repo.UpdateCollection(ctx, query.ID, CollectionAttrs{Name: "articles", Kind: CollectionKindManual}); err == nil {
+		t.Error("expected an error changing the collection kind")
+	}
+	if err := repo.UpdateCollection(ctx, query.ID, CollectionAttrs{Name: "articles", Title: "Articles", Filter: "kind=journal_article", Public: true}); err != nil {
+		t.Fatalf("update collection: %v", err)
+	}
+	query, err = repo.GetCollectionByName(ctx, "articles")
+	if err != nil {
+		t.Fatalf("get collection: %v", err)
+	}
+	if query.Title != "Articles" || !query.Public || query.Kind != CollectionKindQuery {
+		t.Errorf("collection = %+v", query)
+	}
+
+	if err := repo.UpdateCollection(ctx, manual.ID, CollectionAttrs{Name: "theses"}); err != nil {
+		t.Fatalf("update collection: %v", err)
+	}
+	public, err := repo.GetCollections(ctx, true)
+	if err != nil {
+		t.Fatalf("get collections: %v", err)
+	}
+	if len(public) != 1 || public[0].ID != query.ID {
+		t.Errorf("public collections = %v, want [%s]", public, query.Name)
+	}
+	all, err := repo.GetCollections(ctx, false)
+	if err != nil {
+		t.Fatalf("get collections: %v", err)
+	}
+	if len(all) != 2 || all[1].WorkCount != 2 {
+		t.Errorf("collections = %v", all)
+	}
+
+	if err := repo.DeleteCollection(ctx, manual.ID); err != nil {
+		t.Fatalf("delete collection: %v", err)
+	}
+	if _, err := repo.GetCollection(ctx, manual.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("get deleted collection: err = %v, want ErrNotFound", err)
+	}
+}
+
+func assertCollectionOrder(t *testing.T, repo *Repo, collectionID ID, want ...ID) {
+	t.Helper()
+	got, err := repo.GetCollectionWorkIDs(context.Background(), collectionID)
+	if err != nil {
+		t.Fatalf("get work ids: %v", err)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("works = %v, want %v", got, want)
+	}
+}
+
+func TestCollectionListing(t *testing.T) {
+	repo := testRepo(t)
+	ctx := context.Background()
+	user := createTestUser(t, repo, RoleCurator)
+
+	var ids []ID
+	for range 3 {
+		id := newID()
+		if _, _, err := repo.Update(ctx, user,
+			&CreateWork{ID: id, Kind: "journal_article", Status: WorkStatusPublic},
+			&Set{RecordType: RecordTypeWork, RecordID: id, Field: "titles", Val: []Title{{Lang: "eng", Val: "Paper"}}},
+			&Set{RecordType: RecordTypeWork, RecordID: id, Field: "journal_title", Val: "Journal"},
+			&Set{RecordType: RecordTypeWork, RecordID: id, Field: "publication_year", Val: "2024"},
+		); err != nil {
+			t.Fatalf("create work: %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	manual, err := repo.CreateCollection(ctx, CollectionAttrs{Name: "listed", Kind: CollectionKindManual, Public: true})
+	if err != nil {
+		t.Fatalf("create collection: %v", err)
+	}
+	if _, err := repo.AddCollectionWorks(ctx, manual.ID, ids[:2]); err != nil {
+		t.Fatalf("add works: %v", err)
+	}
+	query, err := repo.CreateCollection(ctx, CollectionAttrs{Name: "evaluated", Kind: CollectionKindQuery})
+	if err != nil {
+		t.Fatalf("create collection: %v", err)
+	}
+
+	assertListed := func(opts ListPublicWorksOpts, want ...ID) {
+		t.Helper()
+		opts.Limit = 10
+		res, err := repo.ListPublicWorks(ctx, opts)
+		if err != nil {
+			t.Fatalf("list works: %v", err)
+		}
+		var got []ID
+		for _, w := range res.Works {
+			got = append(got, w.ID)
+		}
+		slices.SortFunc(got, func(a, b ID) int { return strings.Compare(a.String(), b.String()) })
+		slices.SortFunc(want, func(a, b ID) int { return strings.Compare(a.String(), b.String()) })
+		if !slices.Equal(got, want) {
+			t.Errorf("works = %v, want %v", got, want)
+		}
+	}
+
+	assertListed(ListPublicWorksOpts{CollectionID: &manual.ID}, ids[0], ids[1])
+
+	snapshotID, err := repo.CreateCollectionSnapshot(ctx, query.ID, []ID{ids[1], ids[2], newID()})
+	if err != nil {
+		t.Fatalf("create snapshot: %v", err)
+	}
+	if ok, err := repo.HasCollectionSnapshot(ctx, query.ID, snapshotID); err != nil || !ok {
+		t.Errorf("has snapshot = %v (err %v), want true", ok, err)
+	}
+	if ok, err := repo.HasCollectionSnapshot(ctx, manual.ID, snapshotID); err != nil || ok {
+		t.Errorf("has snapshot of other collection = %v (err %v), want false", ok, err)
+	}
+	assertListed(ListPublicWorksOpts{SnapshotID: &snapshotID}, ids[1], ids[2])
+
+	names, err := repo.getPublicCollectionNames(ctx, ids)
+	if err != nil {
+		t.Fatalf("get collection names: %v", err)
+	}
+	if !slices.Equal(names[ids[0]], []string{"listed"}) || len(names[ids[2]]) != 0 {
+		t.Errorf("collection names = %v", names)
+	}
+}
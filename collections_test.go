@@ -0,0 +1,49 @@
+package bbl
+
+import "testing"
+
+func TestValidateCollectionAttrs(t *testing.T) {
+	tests := []struct {
+		name  string
+		attrs CollectionAttrs
+		ok    bool
+	}{
+		{"manual", CollectionAttrs{Name: "theses", Kind: CollectionKindManual}, true},
+		{"manual with query", CollectionAttrs{Name: "theses", Kind: CollectionKindManual, Query: "biology"}, false},
+		{"query", CollectionAttrs{Name: "biology", Kind: CollectionKindQuery, Query: "biology"}, true},
+		{"query with filter", CollectionAttrs{Name: "articles", Kind: CollectionKindQuery, Filter: "kind=journal_article"}, true},
+		{"query without query or filter", CollectionAttrs{Name: "empty", Kind: CollectionKindQuery}, false},
+		{"query with bad filter", CollectionAttrs{Name: "bad", Kind: CollectionKindQuery, Filter: "kind="}, false},
+		{"unknown kind", CollectionAttrs{Name: "theses", Kind: "smart"}, false},
+		{"uppercase name", CollectionAttrs{Name: "Theses", Kind: CollectionKindManual}, false},
+		{"name with space", CollectionAttrs{Name: "my theses", Kind: CollectionKindManual}, false},
+		{"name with colon", CollectionAttrs{Name: "ugent:theses", Kind: CollectionKindManual}, false},
+		{"empty name", CollectionAttrs{Name: " ", Kind: CollectionKindManual}, false},
+		{"dotted name", CollectionAttrs{Name: "ugent.theses-2024", Kind: CollectionKindManual}, true},
+	}
+	for _, tt := range tests {
+		attrs := tt.attrs
+		err := validateCollectionAttrs(&attrs)
+		if tt.ok && err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+		}
+		if !tt.ok && err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+func TestCanManageCollections(t *testing.T) {
+	for role, want := range map[string]bool{
+		RoleUser:    false,
+		RoleCurator: true,
+		RoleAdmin:   true,
+	} {
+		if got := CanManageCollections(&User{Role: role}); got != want {
+			t.Errorf("CanManageCollections(%s) = %v, want %v", role, got, want)
+		}
+	}
+	if CanManageCollections(nil) {
+		t.Error("CanManageCollections(nil) = true, want false")
+	}
+}
@@ -0,0 +1,365 @@
+// Package crossrefsource imports works from the Crossref REST API.
+package crossrefsource
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+	"github.com/ugent-library/bbl"
+)
+
+const (
+	defaultURL = "https://api.crossref.org"
+	pageSize   = 100
+)
+
+type Config struct {
+	URL          string `yaml:"url"`            // API base URL, defaults to https://api.crossref.org
+	Mailto       string `yaml:"mailto"`         // contact address for the polite pool
+	ROR          string `yaml:"ror"`            // iterate works with an author affiliated with this ROR ID
+	Affiliation  string `yaml:"affiliation"`    // iterate works matching this affiliation text
+	FromPubDate  string `yaml:"from_pub_date"`  // iterate works published on or after this date (YYYY[-MM[-DD]])
+	UntilPubDate string `yaml:"until_pub_date"` // iterate works published on or before this date
+}
+
+// WorkSource fetches works by DOI and iterates the works of an
+// institution.
+type WorkSource struct {
+	url          *url.URL
+	mailto       string
+	ror          string
+	affiliation  string
+	fromPubDate  string
+	untilPubDate string
+	client       *http.Client
+}
+
+func New(c Config) (*WorkSource, error) {
+	if c.URL == "" {
+		c.URL = defaultURL
+	}
+	u, err := url.ParseRequestURI(strings.TrimSuffix(c.URL, "/"))
+	if err != nil {
+		return nil, err
+	}
+	return &WorkSource{
+		url:          u,
+		mailto:       c.Mailto,
+		ror:          c.ROR,
+		affiliation:  c.Affiliation,
+		fromPubDate:  c.FromPubDate,
+		untilPubDate: c.UntilPubDate,
+		client:       &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// IdentifierScheme implements bbl.WorkIdentifierSource; records are looked
+// up by DOI.
+func (ws *WorkSource) IdentifierScheme() string {
+	return "doi"
+}
+
+// Partial implements bbl.WorkSourcePartial. Works looked up by DOI need not
+// be in the affiliation harvest, so a harvest never has all records.
+func (ws *WorkSource) Partial() bool {
+	return true
+}
+
+func (ws *WorkSource) Get(ctx context.Context, doi string) (*bbl.ImportWorkInput, error) {
+	doi = normalizeDOI(doi)
+	body, err := ws.get(ctx, "/works/"+doi, nil)
+	if err != nil {
+		return nil, fmt.Errorf("crossref.Get: %w", err)
+	}
+	msg := gjson.GetBytes(body, "message")
+	if !msg.Exists() {
+		return nil, fmt.Errorf("crossref.Get: no message in response")
+	}
+	return mapWork(msg, []byte(msg.Raw)), nil
+}
+
+// Map implements bbl.WorkSourceMapper; record is a stored Crossref work
+// message.
+func (ws *WorkSource) Map(record []byte) (*bbl.ImportWorkInput, error) {
+	if !gjson.ValidBytes(record) {
+		return nil, fmt.Errorf("crossref.Map: invalid json")
+	}
+	return mapWork(gjson.ParseBytes(record), record), nil
+}
+
+// Iter iterates the works matching the configured ROR ID, affiliation and
+// publication dates with a deep paging cursor.
+func (ws *WorkSource) Iter(ctx context.Context) (iter.Seq2[*bbl.ImportWorkInput, error], error) {
+	if ws.ror == "" && ws.affiliation == "" {
+		return nil, fmt.Errorf("crossref.Iter: ror or affiliation is required")
+	}
+	var filters []string
+	if ws.ror != "" {
+		filters = append(filters, "ror-id:"+ws.ror)
+	}
+	if ws.fromPubDate != "" {
+		filters = append(filters, "from-pub-date:"+ws.fromPubDate)
+	}
+	if ws.untilPubDate != "" {
+		filters = append(filters, "until-pub-date:"+ws.untilPubDate)
+	}
+
+	seq := func(yield func(*bbl.ImportWorkInput, error) bool) {
+		cursor := "*"
+		for {
+			q := url.Values{}
+			if len(filters) > 0 {
+				q.Set("filter", strings.Join(filters, ","))
+			}
+			if ws.affiliation != "" {
+				q.Set("query.affiliation", ws.affiliation)
+			}
+			q.Set("rows", fmt.Sprint(pageSize))
+			q.Set("cursor", cursor)
+
+			body, err := ws.get(ctx, "/works", q)
+			if err != nil {
+				yield(nil, fmt.Errorf("crossref.Iter: %w", err))
+				return
+			}
+
+			items := gjson.GetBytes(body, "message.items").Array()
+			for _, item := range items {
+				if !yield(mapWork(item, []byte(item.Raw)), nil) {
+					return
+				}
+			}
+
+			next := gjson.GetBytes(body, "message.next-cursor").String()
+			if len(items) < pageSize || next == "" {
+				return
+			}
+			cursor = next
+		}
+	}
+	return seq, nil
+}
+
+func (ws *WorkSource) get(ctx context.Context, path string, q url.Values) ([]byte, error) {
+	u := *ws.url
+	u.Path += path
+	if q == nil {
+		q = url.Values{}
+	}
+	if ws.mailto != "" {
+		q.Set("mailto", ws.mailto)
+	}
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := ws.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %q: %s", u.String(), res.Status)
+	}
+	return io.ReadAll(res.Body)
+}
+
+// kinds maps Crossref types to profile kinds.
+var kinds = map[string]string{
+	"journal-article":     "journal_article",
+	"book":                "book",
+	"monograph":           "book",
+	"reference-book":      "book",
+	"edited-book":         "edited_book",
+	"book-chapter":        "book_chapter",
+	"book-section":        "book_chapter",
+	"book-part":           "book_chapter",
+	"reference-entry":     "encyclopedia_article",
+	"proceedings-article": "conference_paper",
+	"proceedings":         "conference_proceeding",
+	"dissertation":        "dissertation",
+	"report":              "report",
+	"report-component":    "report",
+	"posted-content":      "preprint",
+}
+
+// langs maps Crossref language codes to the codes used in titles and
+// abstracts.
+var langs = map[string]string{
+	"en": "eng",
+	"nl": "dut",
+	"fr": "fre",
+	"de": "ger",
+	"es": "spa",
+	"it": "ita",
+}
+
+// mapWork maps a Crossref work. Works have no funding or license field, so
+// funders and license URLs are kept as notes of kind "funding" and
+// "license".
+func mapWork(res gjson.Result, sourceRecord []byte) *bbl.ImportWorkInput {
+	doi := normalizeDOI(res.Get("DOI").String())
+	typ := res.Get("type").String()
+
+	kind, ok := kinds[typ]
+	if !ok {
+		kind = "miscellaneous"
+	}
+
+	lang := langs[res.Get("language").String()]
+	if lang == "" {
+		lang = "und"
+	}
+
+	rec := &bbl.ImportWorkInput{
+		SourceID:           doi,
+		Kind:               kind,
+		SourceRecord:       sourceRecord,
+		Identifiers:        []bbl.Identifier{{Scheme: "doi", Val: doi}},
+		Volume:             res.Get("volume").String(),
+		Issue:              res.Get("issue").String(),
+		ArticleNumber:      res.Get("article-number").String(),
+		Publisher:          res.Get("publisher").String(),
+		PlaceOfPublication: res.Get("publisher-location").String(),
+	}
+
+	title := firstString(res.Get("title"))
+	if sub := firstString(res.Get("subtitle")); sub != "" && title != "" {
+		title += ": " + sub
+	}
+	if title != "" {
+		rec.Titles = append(rec.Titles, bbl.Title{Lang: lang, Val: title})
+	}
+
+	if v := stripTags(res.Get("abstract").String()); v != "" {
+		rec.Abstracts = append(rec.Abstracts, bbl.Text{Lang: lang, Val: v})
+	}
+
+	switch container := firstString(res.Get("container-title")); kind {
+	case "journal_article":
+		rec.JournalTitle = container
+		rec.JournalAbbreviation = firstString(res.Get("short-container-title"))
+	case "book_chapter", "conference_paper", "encyclopedia_article":
+		rec.BookTitle = container
+	}
+
+	if parts := strings.SplitN(res.Get("page").String(), "-", 2); parts[0] != "" {
+		rec.Pages.Start = parts[0]
+		if len(parts) == 2 {
+			rec.Pages.End = parts[1]
+		}
+	}
+
+	for _, date := range []string{"published", "issued", "published-print", "published-online"} {
+		if y := res.Get(date + ".date-parts.0.0"); y.Exists() && y.Int() > 0 {
+			rec.PublicationYear = fmt.Sprint(y.Int())
+			break
+		}
+	}
+
+	if typ == "posted-content" {
+		rec.PublicationStatus = "unpublished"
+	} else {
+		rec.PublicationStatus = "published"
+	}
+
+	for _, v := range res.Get("ISSN").Array() {
+		rec.Identifiers = append(rec.Identifiers, bbl.Identifier{Scheme: "issn", Val: v.String()})
+	}
+	for _, v := range res.Get("ISBN").Array() {
+		rec.Identifiers = append(rec.Identifiers, bbl.Identifier{Scheme: "isbn", Val: v.String()})
+	}
+
+	for _, role := range []string{"author", "editor"} {
+		for _, a := range res.Get(role).Array() {
+			rec.Contributors = append(rec.Contributors, mapContributor(a, role))
+		}
+	}
+
+	for _, f := range res.Get("funder").Array() {
+		note := f.Get("name").String()
+		var awards []string
+		for _, a := range f.Get("award").Array() {
+			awards = append(awards, a.String())
+		}
+		if len(awards) > 0 {
+			note += " (" + strings.Join(awards, ", ") + ")"
+		}
+		if note != "" {
+			rec.Notes = append(rec.Notes, bbl.Note{Kind: "funding", Val: note})
+		}
+	}
+
+	for _, l := range res.Get("license").Array() {
+		if v := l.Get("URL").String(); v != "" {
+			rec.Notes = append(rec.Notes, bbl.Note{Kind: "license", Val: v})
+		}
+	}
+
+	return rec
+}
+
+// mapContributor maps a Crossref author or editor. Contributors with an
+// ORCID are linked to the person with that ORCID, if any.
+func mapContributor(res gjson.Result, role string) bbl.ImportWorkContributor {
+	c := bbl.ImportWorkContributor{
+		Roles:      []string{role},
+		GivenName:  res.Get("given").String(),
+		FamilyName: res.Get("family").String(),
+	}
+	if name := res.Get("name").String(); name != "" {
+		// Crossref uses name for organizations and consortia.
+		c.Kind = "organization"
+		c.Name = name
+		return c
+	}
+	c.Kind = "person"
+	c.Name = strings.TrimSpace(c.GivenName + " " + c.FamilyName)
+	for _, a := range res.Get("affiliation").Array() {
+		if name := strings.TrimSpace(a.Get("name").String()); name != "" {
+			c.Affiliations = append(c.Affiliations, name)
+		}
+	}
+	if orcid := normalizeORCID(res.Get("ORCID").String()); orcid != "" {
+		c.PersonRef = &bbl.Ref{Identifier: &bbl.Identifier{Scheme: "orcid", Val: orcid}}
+		c.PersonRefOptional = true
+	}
+	return c
+}
+
+func firstString(res gjson.Result) string {
+	return strings.TrimSpace(res.Get("0").String())
+}
+
+var (
+	reJATSTitle   = regexp.MustCompile(`(?s)<jats:title>.*?</jats:title>`)
+	reTags        = regexp.MustCompile(`<[^>]+>`)
+	reSpace       = regexp.MustCompile(`\s+`)
+	reDOIPrefix   = regexp.MustCompile(`(?i)^(https?://(dx\.)?doi\.org/|doi:)`)
+	reORCIDPrefix = regexp.MustCompile(`(?i)^https?://orcid\.org/`)
+)
+
+// stripTags turns a JATS abstract into plain text.
+func stripTags(s string) string {
+	s = reJATSTitle.ReplaceAllString(s, " ")
+	s = reTags.ReplaceAllString(s, " ")
+	return strings.TrimSpace(reSpace.ReplaceAllString(s, " "))
+}
+
+func normalizeDOI(s string) string {
+	return strings.ToLower(reDOIPrefix.ReplaceAllString(strings.TrimSpace(s), ""))
+}
+
+func normalizeORCID(s string) string {
+	return reORCIDPrefix.ReplaceAllString(strings.TrimSpace(s), "")
+}
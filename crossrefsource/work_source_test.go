@@ -0,0 +1,166 @@
+package crossrefsource
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/ugent-library/bbl"
+)
+
+// fixtureServer serves recorded Crossref responses from testdata.
+func fixtureServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /works/10.1000/xyz123", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/work.json")
+	})
+	mux.HandleFunc("GET /works", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("filter") != "ror-id:https://ror.org/00cv9y106,from-pub-date:2022" || q.Get("cursor") != "*" || q.Get("mailto") != "test@example.org" {
+			http.Error(w, "unexpected query "+r.URL.RawQuery, http.StatusBadRequest)
+			return
+		}
+		http.ServeFile(w, r, "testdata/works.json")
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGet(t *testing.T) {
+	srv := fixtureServer(t)
+	ws, err := New(Config{URL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec, err := ws.Get(context.Background(), "https://doi.org/10.1000/XYZ123")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rec.SourceID != "10.1000/xyz123" || rec.Kind != "journal_article" {
+		t.Errorf("source id, kind = %q, %q", rec.SourceID, rec.Kind)
+	}
+	wantTitle := bbl.Title{Lang: "eng", Val: "Soil carbon in temperate grasslands: A ten-year field study"}
+	if len(rec.Titles) != 1 || rec.Titles[0] != wantTitle {
+		t.Errorf("titles = %v", rec.Titles)
+	}
+	if len(rec.Abstracts) != 1 || rec.Abstracts[0].Val != "We measured soil carbon over ten years." {
+		t.Errorf("abstracts = %v", rec.Abstracts)
+	}
+	if rec.JournalTitle != "Journal of Soil Science" || rec.JournalAbbreviation != "J. Soil Sci." {
+		t.Errorf("journal = %q, %q", rec.JournalTitle, rec.JournalAbbreviation)
+	}
+	if rec.Volume != "42" || rec.Issue != "3" || rec.Pages != (bbl.Extent{Start: "101", End: "117"}) {
+		t.Errorf("volume, issue, pages = %q, %q, %v", rec.Volume, rec.Issue, rec.Pages)
+	}
+	if rec.PublicationYear != "2023" || rec.PublicationStatus != "published" {
+		t.Errorf("year, status = %q, %q", rec.PublicationYear, rec.PublicationStatus)
+	}
+	wantIDs := []bbl.Identifier{
+		{Scheme: "doi", Val: "10.1000/xyz123"},
+		{Scheme: "issn", Val: "1234-5678"},
+		{Scheme: "issn", Val: "8765-4321"},
+	}
+	if !slices.Equal(rec.Identifiers, wantIDs) {
+		t.Errorf("identifiers = %v", rec.Identifiers)
+	}
+
+	if len(rec.Contributors) != 3 {
+		t.Fatalf("contributors = %v", rec.Contributors)
+	}
+	doe := rec.Contributors[0]
+	if doe.Name != "Jane Doe" || doe.PersonRef == nil || *doe.PersonRef.Identifier != (bbl.Identifier{Scheme: "orcid", Val: "0000-0002-1825-0097"}) {
+		t.Errorf("first author = %+v", doe)
+	}
+	if !slices.Equal(doe.Affiliations, []string{"Department of Biology, Ghent University, Ghent, Belgium"}) {
+		t.Errorf("first author affiliations = %v", doe.Affiliations)
+	}
+	if rec.Contributors[1].PersonRef != nil {
+		t.Errorf("author without ORCID has a person ref")
+	}
+	if c := rec.Contributors[2]; c.Kind != "organization" || c.Name != "Grassland Consortium" {
+		t.Errorf("organization author = %+v", c)
+	}
+
+	wantNotes := []bbl.Note{
+		{Kind: "funding", Val: "Research Foundation Flanders (G012345N, 1234567N)"},
+		{Kind: "license", Val: "http://creativecommons.org/licenses/by/4.0/"},
+	}
+	if !slices.Equal(rec.Notes, wantNotes) {
+		t.Errorf("notes = %v", rec.Notes)
+	}
+	if len(rec.SourceRecord) == 0 {
+		t.Error("source record is empty")
+	}
+}
+
+func TestMap(t *testing.T) {
+	srv := fixtureServer(t)
+	ws, err := New(Config{URL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec, err := ws.Get(context.Background(), "10.1000/xyz123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mapped, err := ws.Map(rec.SourceRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(mapped, rec) {
+		t.Errorf("Map(SourceRecord) = %+v, want %+v", mapped, rec)
+	}
+	if _, err := ws.Map([]byte("{")); err == nil {
+		t.Error("expected an error for invalid json")
+	}
+}
+
+func TestIter(t *testing.T) {
+	srv := fixtureServer(t)
+	ws, err := New(Config{
+		URL:         srv.URL,
+		Mailto:      "test@example.org",
+		ROR:         "https://ror.org/00cv9y106",
+		FromPubDate: "2022",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	seq, err := ws.Iter(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var recs []*bbl.ImportWorkInput
+	for rec, err := range seq {
+		if err != nil {
+			t.Fatal(err)
+		}
+		recs = append(recs, rec)
+	}
+	if len(recs) != 2 {
+		t.Fatalf("got %d records, want 2", len(recs))
+	}
+	if r := recs[0]; r.Kind != "book_chapter" || r.BookTitle != "Handbook of Soils" || r.PublicationYear != "2022" ||
+		len(r.Contributors) != 1 || r.Contributors[0].Roles[0] != "editor" {
+		t.Errorf("chapter = %+v", r)
+	}
+	if r := recs[1]; r.Kind != "preprint" || r.PublicationStatus != "unpublished" {
+		t.Errorf("preprint = %+v", r)
+	}
+}
+
+func TestIterRequiresFilter(t *testing.T) {
+	ws, err := New(Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ws.Iter(context.Background()); err == nil {
+		t.Error("expected error without ror or affiliation")
+	}
+}
@@ -0,0 +1,12 @@
+#!/bin/sh
+# Creates the file bucket used by ugent/config.yaml.example. Browsers upload
+# straight to presigned URLs, so the bucket needs CORS for the dev server.
+awslocal s3 mb s3://bbl-files 2>/dev/null || true
+awslocal s3api put-bucket-cors --bucket bbl-files --cors-configuration '{
+  "CORSRules": [{
+    "AllowedOrigins": ["http://localhost:3000"],
+    "AllowedMethods": ["GET", "PUT"],
+    "AllowedHeaders": ["*"],
+    "MaxAgeSeconds": 3000
+  }]
+}'
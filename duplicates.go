@@ -0,0 +1,759 @@
+package bbl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Duplicate detection. Every work and person gets a set of match keys
+// (normalized identifiers, title and name blocks) in bbl_duplicate_keys.
+// Records sharing a key are candidate pairs; pairs that score high enough
+// are stored in bbl_duplicates and clustered on read.
+
+// Duplicate match reasons. Each is also the kind of the match key.
+const (
+	DuplicateReasonDOI         = "doi"
+	DuplicateReasonISBN        = "isbn"
+	DuplicateReasonPMID        = "pmid"
+	DuplicateReasonTitle       = "title" // fuzzy title + year + first author
+	DuplicateReasonIdentifier  = "identifier"
+	DuplicateReasonName        = "name"
+	DuplicateReasonNameInitial = "name_initial"
+)
+
+// duplicateConfidence is the confidence of a pair that shares a key.
+// Title pairs are scored individually.
+var duplicateConfidence = map[string]float64{
+	DuplicateReasonDOI:         1,
+	DuplicateReasonPMID:        0.95,
+	DuplicateReasonISBN:        0.7,
+	DuplicateReasonIdentifier:  1,
+	DuplicateReasonName:        0.6,
+	DuplicateReasonNameInitial: 0.3,
+}
+
+const (
+	duplicateTitleThreshold = 0.8
+	duplicateBatchSize      = 500
+)
+
+// DuplicateCluster is a group of records that probably describe the same
+// entity. Confidence is that of the weakest pair holding the cluster
+// together.
+type DuplicateCluster struct {
+	RecordType string   `json:"record_type"`
+	IDs        []ID     `json:"ids"`
+	Confidence float64  `json:"confidence"`
+	Reasons    []string `json:"reasons"`
+}
+
+// DuplicateClusterOpts holds parameters for GetDuplicateClusters.
+type DuplicateClusterOpts struct {
+	RecordType    string
+	MinConfidence float64
+	Limit         int // 0 = all
+	Offset        int
+}
+
+type duplicateKey struct {
+	kind string
+	key  string
+}
+
+type duplicatePair struct {
+	id         ID
+	otherID    ID
+	confidence float64
+	reasons    []string
+}
+
+// DetectDuplicates refreshes the match keys and duplicate pairs of the given
+// records. Deleted and missing records drop out of the report.
+func (r *Repo) DetectDuplicates(ctx context.Context, recordType string, ids []ID) error {
+	if _, err := duplicateTable(recordType); err != nil {
+		return fmt.Errorf("DetectDuplicates: %w", err)
+	}
+	// Refresh all keys first so pairs between records in different batches
+	// are found.
+	for batch := range slices.Chunk(ids, duplicateBatchSize) {
+		if err := r.refreshDuplicateKeys(ctx, recordType, batch); err != nil {
+			return fmt.Errorf("DetectDuplicates: %w", err)
+		}
+	}
+	for batch := range slices.Chunk(ids, duplicateBatchSize) {
+		if err := r.refreshDuplicatePairs(ctx, recordType, batch); err != nil {
+			return fmt.Errorf("DetectDuplicates: %w", err)
+		}
+	}
+	return nil
+}
+
+// DetectAllDuplicates rebuilds the match keys and duplicate pairs of all
+// records of the given type.
+func (r *Repo) DetectAllDuplicates(ctx context.Context, recordType string) error {
+	table, err := duplicateTable(recordType)
+	if err != nil {
+		return fmt.Errorf("DetectAllDuplicates: %w", err)
+	}
+	batch := &pgx.Batch{}
+	batch.Queue(`DELETE FROM bbl_duplicates WHERE record_type = $1`, recordType)
+	batch.Queue(`DELETE FROM bbl_duplicate_keys WHERE record_type = $1`, recordType)
+	if err := r.db.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("DetectAllDuplicates: %w", err)
+	}
+	ids, err := r.queryIDs(ctx, `SELECT id FROM `+table+` WHERE status <> 'deleted' ORDER BY id`)
+	if err != nil {
+		return fmt.Errorf("DetectAllDuplicates: %w", err)
+	}
+	return r.DetectDuplicates(ctx, recordType, ids)
+}
+
+// GetDuplicateClusters returns duplicate clusters ordered by confidence and
+// size, and the total number of clusters.
+func (r *Repo) GetDuplicateClusters(ctx context.Context, opts DuplicateClusterOpts) ([]DuplicateCluster, int, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, other_id, confidence, reasons
+		FROM bbl_duplicates
+		WHERE record_type = $1 AND confidence >= $2`,
+		opts.RecordType, opts.MinConfidence)
+	if err != nil {
+		return nil, 0, fmt.Errorf("GetDuplicateClusters: %w", err)
+	}
+	defer rows.Close()
+	var pairs []duplicatePair
+	for rows.Next() {
+		var p duplicatePair
+		if err := rows.Scan(&p.id, &p.otherID, &p.confidence, &p.reasons); err != nil {
+			return nil, 0, fmt.Errorf("GetDuplicateClusters: %w", err)
+		}
+		pairs = append(pairs, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("GetDuplicateClusters: %w", err)
+	}
+
+	clusters := clusterDuplicates(opts.RecordType, pairs)
+	total := len(clusters)
+	if opts.Offset >= total {
+		return nil, total, nil
+	}
+	clusters = clusters[opts.Offset:]
+	if opts.Limit > 0 && len(clusters) > opts.Limit {
+		clusters = clusters[:opts.Limit]
+	}
+	return clusters, total, nil
+}
+
+func duplicateTable(recordType string) (string, error) {
+	switch recordType {
+	case RecordTypeWork:
+		return "bbl_works", nil
+	case RecordTypePerson:
+		return "bbl_people", nil
+	}
+	return "", fmt.Errorf("duplicate detection not supported for %q", recordType)
+}
+
+// duplicateIDsSince returns the records of the given type updated since t.
+func (r *Repo) duplicateIDsSince(ctx context.Context, recordType string, t time.Time) ([]ID, error) {
+	table, err := duplicateTable(recordType)
+	if err != nil {
+		return nil, err
+	}
+	return r.queryIDs(ctx, `SELECT id FROM `+table+` WHERE updated_at >= $1 ORDER BY id`, t)
+}
+
+func (r *Repo) queryIDs(ctx context.Context, query string, args ...any) ([]ID, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	ids, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (ID, error) {
+		var id ID
+		err := row.Scan(&id)
+		return id, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (r *Repo) refreshDuplicateKeys(ctx context.Context, recordType string, ids []ID) error {
+	var keys map[ID][]duplicateKey
+	var err error
+	switch recordType {
+	case RecordTypeWork:
+		keys, err = r.workDuplicateKeys(ctx, ids)
+	case RecordTypePerson:
+		keys, err = r.personDuplicateKeys(ctx, ids)
+	}
+	if err != nil {
+		return err
+	}
+
+	var recordIDs []ID
+	var kinds, vals []string
+	for id, kk := range keys {
+		for _, k := range kk {
+			recordIDs = append(recordIDs, id)
+			kinds = append(kinds, k.kind)
+			vals = append(vals, k.key)
+		}
+	}
+
+	batch := &pgx.Batch{}
+	batch.Queue(`DELETE FROM bbl_duplicate_keys WHERE record_type = $1 AND record_id = ANY($2)`, recordType, ids)
+	if len(recordIDs) > 0 {
+		batch.Queue(`
+			INSERT INTO bbl_duplicate_keys (record_type, record_id, kind, key)
+			SELECT $1, unnest($2::uuid[]), unnest($3::text[]), unnest($4::text[])
+			ON CONFLICT DO NOTHING`,
+			recordType, recordIDs, kinds, vals)
+	}
+	return r.sendBatchTx(ctx, batch)
+}
+
+func (r *Repo) refreshDuplicatePairs(ctx context.Context, recordType string, ids []ID) error {
+	rows, err := r.db.Query(ctx, `
+		SELECT a.record_id, b.record_id, a.kind
+		FROM bbl_duplicate_keys a
+		JOIN bbl_duplicate_keys b
+		  ON b.record_type = a.record_type AND b.kind = a.kind AND b.key = a.key
+		 AND b.record_id <> a.record_id
+		WHERE a.record_type = $1 AND a.record_id = ANY($2)`,
+		recordType, ids)
+	if err != nil {
+		return err
+	}
+	type pairKey struct{ id, otherID ID }
+	candidates := make(map[pairKey][]string)
+	var candidateIDs []ID
+	seen := make(map[ID]bool)
+	for rows.Next() {
+		var id, otherID ID
+		var kind string
+		if err := rows.Scan(&id, &otherID, &kind); err != nil {
+			rows.Close()
+			return err
+		}
+		if bytes.Compare(id[:], otherID[:]) > 0 {
+			id, otherID = otherID, id
+		}
+		k := pairKey{id, otherID}
+		if !slices.Contains(candidates[k], kind) {
+			candidates[k] = append(candidates[k], kind)
+		}
+		for _, id := range []ID{id, otherID} {
+			if !seen[id] {
+				seen[id] = true
+				candidateIDs = append(candidateIDs, id)
+			}
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Title and ISBN matches need the works themselves to be scored.
+	works := make(map[ID]*Work)
+	if recordType == RecordTypeWork && len(candidateIDs) > 0 {
+		recs, err := r.GetWorks(ctx, candidateIDs)
+		if err != nil {
+			return err
+		}
+		for _, w := range recs {
+			works[w.ID] = w
+		}
+	}
+
+	var pairIDs, otherIDs []ID
+	var confidences []float64
+	var reasons []string
+	for k, kinds := range candidates {
+		var p duplicatePair
+		if recordType == RecordTypeWork {
+			p = scoreWorkDuplicate(works[k.id], works[k.otherID], kinds)
+		} else {
+			p = scoreDuplicate(kinds)
+		}
+		if p.confidence == 0 {
+			continue
+		}
+		pairIDs = append(pairIDs, k.id)
+		otherIDs = append(otherIDs, k.otherID)
+		confidences = append(confidences, p.confidence)
+		reasons = append(reasons, strings.Join(p.reasons, ","))
+	}
+
+	batch := &pgx.Batch{}
+	batch.Queue(`
+		DELETE FROM bbl_duplicates
+		WHERE record_type = $1 AND (id = ANY($2) OR other_id = ANY($2))`,
+		recordType, ids)
+	if len(pairIDs) > 0 {
+		batch.Queue(`
+			INSERT INTO bbl_duplicates (record_type, id, other_id, confidence, reasons)
+			SELECT $1, p.id, p.other_id, p.confidence, string_to_array(p.reasons, ',')
+			FROM unnest($2::uuid[], $3::uuid[], $4::real[], $5::text[]) AS p(id, other_id, confidence, reasons)
+			ON CONFLICT (record_type, id, other_id) DO UPDATE
+			SET confidence = EXCLUDED.confidence,
+			    reasons = EXCLUDED.reasons,
+			    updated_at = transaction_timestamp()`,
+			recordType, pairIDs, otherIDs, confidences, reasons)
+	}
+	return r.sendBatchTx(ctx, batch)
+}
+
+func (r *Repo) sendBatchTx(ctx context.Context, batch *pgx.Batch) error {
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// workDuplicateKeys derives match keys from all identifier assertions
+// (pinned or not) and from the pinned title, year and first author.
+func (r *Repo) workDuplicateKeys(ctx context.Context, ids []ID) (map[ID][]duplicateKey, error) {
+	works, err := r.GetWorks(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[ID][]duplicateKey, len(works))
+	for _, w := range works {
+		if w.Status == WorkStatusDeleted {
+			continue
+		}
+		keys[w.ID] = workTitleKeys(w)
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT work_id, val->>'scheme', val->>'val'
+		FROM bbl_work_assertions
+		WHERE work_id = ANY($1) AND field = 'identifiers' AND NOT hidden`, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id ID
+		var scheme, val string
+		if err := rows.Scan(&id, &scheme, &val); err != nil {
+			return nil, err
+		}
+		if _, ok := keys[id]; !ok {
+			continue // deleted
+		}
+		if k, ok := workIdentifierKey(scheme, val); ok && !slices.Contains(keys[id], k) {
+			keys[id] = append(keys[id], k)
+		}
+	}
+	return keys, rows.Err()
+}
+
+// personDuplicateKeys derives match keys from all identifier assertions and
+// from the names asserted by each source and by humans.
+func (r *Repo) personDuplicateKeys(ctx context.Context, ids []ID) (map[ID][]duplicateKey, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT a.person_id, COALESCE(a.person_source_id::text, ''), a.field, a.val
+		FROM bbl_person_assertions a
+		JOIN bbl_people p ON p.id = a.person_id
+		WHERE a.person_id = ANY($1) AND p.status <> 'deleted' AND NOT a.hidden
+		  AND a.field IN ('identifiers', 'name', 'given_name', 'family_name')
+		ORDER BY a.person_id`, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type personName struct{ name, given, family string }
+	keys := make(map[ID][]duplicateKey)
+	names := make(map[ID]map[string]*personName)
+	add := func(id ID, k duplicateKey) {
+		if !slices.Contains(keys[id], k) {
+			keys[id] = append(keys[id], k)
+		}
+	}
+	for rows.Next() {
+		var id ID
+		var origin, field string
+		var val []byte
+		if err := rows.Scan(&id, &origin, &field, &val); err != nil {
+			return nil, err
+		}
+		if field == "identifiers" {
+			var ident Identifier
+			if err := json.Unmarshal(val, &ident); err != nil {
+				return nil, err
+			}
+			if v := strings.ToLower(strings.TrimSpace(ident.Val)); v != "" {
+				add(id, duplicateKey{DuplicateReasonIdentifier, ident.Scheme + ":" + v})
+			}
+			continue
+		}
+		var s string
+		if err := json.Unmarshal(val, &s); err != nil {
+			return nil, err
+		}
+		if names[id] == nil {
+			names[id] = make(map[string]*personName)
+		}
+		n := names[id][origin]
+		if n == nil {
+			n = &personName{}
+			names[id][origin] = n
+		}
+		switch field {
+		case "name":
+			n.name = s
+		case "given_name":
+			n.given = s
+		case "family_name":
+			n.family = s
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for id, byOrigin := range names {
+		for _, n := range byOrigin {
+			for _, k := range personNameKeys(n.name, n.given, n.family) {
+				add(id, k)
+			}
+		}
+	}
+	return keys, nil
+}
+
+func workIdentifierKey(scheme, val string) (duplicateKey, bool) {
+	var v string
+	switch strings.ToLower(scheme) {
+	case "doi":
+		v = normalizeDOI(val)
+		scheme = DuplicateReasonDOI
+	case "isbn":
+		v = normalizeISBN(val)
+		scheme = DuplicateReasonISBN
+	case "pmid", "pubmed":
+		v = normalizePMID(val)
+		scheme = DuplicateReasonPMID
+	case "arxiv":
+		if v = strings.ToLower(normalizeArxiv(val)); v != "" {
+			v = "arxiv:" + v
+		}
+		scheme = DuplicateReasonIdentifier
+	}
+	if v == "" {
+		return duplicateKey{}, false
+	}
+	return duplicateKey{scheme, v}, true
+}
+
+// workTitleKeys blocks works for fuzzy title matching: by year and first
+// author, and by year and the first significant title words.
+func workTitleKeys(w *Work) []duplicateKey {
+	year := strings.TrimSpace(w.PublicationYear)
+	if year == "" {
+		return nil
+	}
+	var keys []duplicateKey
+	if family := workFirstAuthor(w); family != "" {
+		keys = append(keys, duplicateKey{DuplicateReasonTitle, year + "|a:" + family})
+	}
+	for _, t := range w.Titles {
+		var words []string
+		for _, word := range strings.Fields(normalizeMatchText(t.Val)) {
+			if len(word) > 3 {
+				words = append(words, word)
+			}
+			if len(words) == 2 {
+				break
+			}
+		}
+		if len(words) == 0 {
+			continue
+		}
+		k := duplicateKey{DuplicateReasonTitle, year + "|t:" + strings.Join(words, " ")}
+		if !slices.Contains(keys, k) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// workFirstAuthor returns the normalized family name of the first person
+// contributor with an author role (or no role).
+func workFirstAuthor(w *Work) string {
+	for _, c := range w.Contributors {
+		if c.Kind == "organization" {
+			continue
+		}
+		if len(c.Roles) > 0 && !slices.Contains(c.Roles, "author") {
+			continue
+		}
+		family := c.FamilyName
+		if family == "" {
+			family, _ = splitPersonName(c.Name)
+		}
+		return normalizeMatchText(family)
+	}
+	return ""
+}
+
+func scoreDuplicate(kinds []string) duplicatePair {
+	var p duplicatePair
+	for _, kind := range kinds {
+		if c := duplicateConfidence[kind]; c > 0 {
+			p.confidence = max(p.confidence, c)
+			p.reasons = append(p.reasons, kind)
+		}
+	}
+	slices.Sort(p.reasons)
+	return p
+}
+
+// scoreWorkDuplicate scores a candidate work pair. ISBN matches only count
+// between works of the same kind, since chapters share the book's ISBN.
+func scoreWorkDuplicate(a, b *Work, kinds []string) duplicatePair {
+	var p duplicatePair
+	if a == nil || b == nil || a.Status == WorkStatusDeleted || b.Status == WorkStatusDeleted {
+		return p
+	}
+	for _, kind := range kinds {
+		var c float64
+		switch kind {
+		case DuplicateReasonTitle:
+			c = scoreWorkTitles(a, b)
+		case DuplicateReasonISBN:
+			if a.Kind == b.Kind {
+				c = duplicateConfidence[kind]
+			}
+		default:
+			c = duplicateConfidence[kind]
+		}
+		if c > 0 {
+			p.confidence = max(p.confidence, c)
+			p.reasons = append(p.reasons, kind)
+		}
+	}
+	slices.Sort(p.reasons)
+	return p
+}
+
+// scoreWorkTitles combines title and first author similarity for works
+// published in the same year. Returns 0 below the threshold.
+func scoreWorkTitles(a, b *Work) float64 {
+	if a.PublicationYear == "" || a.PublicationYear != b.PublicationYear {
+		return 0
+	}
+	var titleSim float64
+	for _, ta := range a.Titles {
+		for _, tb := range b.Titles {
+			titleSim = max(titleSim, similarity(normalizeMatchText(ta.Val), normalizeMatchText(tb.Val)))
+		}
+	}
+	if titleSim < duplicateTitleThreshold {
+		return 0
+	}
+	authorSim := 0.5 // unknown
+	if fa, fb := workFirstAuthor(a), workFirstAuthor(b); fa != "" && fb != "" {
+		authorSim = similarity(fa, fb)
+	}
+	score := 0.7*titleSim + 0.3*authorSim
+	if score < duplicateTitleThreshold {
+		return 0
+	}
+	// Fuzzy matches never reach the confidence of a shared identifier.
+	return float64(int(score*90+0.5)) / 100
+}
+
+// personNameKeys returns the full name and initial keys for a name. Names
+// without a given name are too ambiguous to match on.
+func personNameKeys(name, given, family string) []duplicateKey {
+	if family == "" {
+		family, given = splitPersonName(name)
+	}
+	f, g := normalizeMatchText(family), normalizeMatchText(given)
+	if f == "" || g == "" {
+		return nil
+	}
+	initial, _ := utf8.DecodeRuneInString(g)
+	return []duplicateKey{
+		{DuplicateReasonName, f + "|" + g},
+		{DuplicateReasonNameInitial, f + "|" + string(initial)},
+	}
+}
+
+// splitPersonName splits "Family, Given" or "Given Family" into family and
+// given name.
+func splitPersonName(name string) (family, given string) {
+	if f, g, ok := strings.Cut(name, ","); ok {
+		return strings.TrimSpace(f), strings.TrimSpace(g)
+	}
+	parts := strings.Fields(name)
+	if len(parts) == 0 {
+		return "", ""
+	}
+	return parts[len(parts)-1], strings.Join(parts[:len(parts)-1], " ")
+}
+
+var matchTextFolder = transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+// normalizeMatchText lowercases s, strips diacritics and reduces it to
+// space separated words of letters and digits.
+func normalizeMatchText(s string) string {
+	folded, _, err := transform.String(matchTextFolder, s)
+	if err != nil {
+		folded = s
+	}
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(folded) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			space = false
+			b.WriteRune(r)
+		} else {
+			space = true
+		}
+	}
+	return b.String()
+}
+
+// similarity is the Dice coefficient of the character trigrams of a and b.
+func similarity(a, b string) float64 {
+	if a == b {
+		if a == "" {
+			return 0
+		}
+		return 1
+	}
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+	var shared int
+	for t, n := range ta {
+		shared += min(n, tb[t])
+	}
+	var total int
+	for _, n := range ta {
+		total += n
+	}
+	for _, n := range tb {
+		total += n
+	}
+	return 2 * float64(shared) / float64(total)
+}
+
+func trigrams(s string) map[string]int {
+	r := []rune("  " + s + " ")
+	t := make(map[string]int, len(r))
+	for i := 0; i+3 <= len(r); i++ {
+		t[string(r[i:i+3])]++
+	}
+	return t
+}
+
+// clusterDuplicates groups pairs into connected clusters. Pairs are joined
+// strongest first, so the last join is the weakest link.
+func clusterDuplicates(recordType string, pairs []duplicatePair) []DuplicateCluster {
+	pairs = slices.Clone(pairs)
+	slices.SortStableFunc(pairs, func(a, b duplicatePair) int {
+		if a.confidence != b.confidence {
+			if a.confidence > b.confidence {
+				return -1
+			}
+			return 1
+		}
+		if c := bytes.Compare(a.id[:], b.id[:]); c != 0 {
+			return c
+		}
+		return bytes.Compare(a.otherID[:], b.otherID[:])
+	})
+
+	parent := make(map[ID]ID)
+	var find func(ID) ID
+	find = func(id ID) ID {
+		p, ok := parent[id]
+		if !ok {
+			parent[id] = id
+			return id
+		}
+		if p == id {
+			return id
+		}
+		root := find(p)
+		parent[id] = root
+		return root
+	}
+	confidence := make(map[ID]float64)
+	reasons := make(map[ID][]string)
+	for _, p := range pairs {
+		ra, rb := find(p.id), find(p.otherID)
+		if ra != rb {
+			parent[rb] = ra
+			confidence[ra] = p.confidence
+			reasons[ra] = append(reasons[ra], reasons[rb]...)
+			delete(reasons, rb)
+			delete(confidence, rb)
+		}
+		for _, reason := range p.reasons {
+			if !slices.Contains(reasons[ra], reason) {
+				reasons[ra] = append(reasons[ra], reason)
+			}
+		}
+	}
+
+	members := make(map[ID][]ID)
+	for id := range parent {
+		root := find(id)
+		members[root] = append(members[root], id)
+	}
+	clusters := make([]DuplicateCluster, 0, len(members))
+	for root, ids := range members {
+		slices.SortFunc(ids, func(a, b ID) int { return bytes.Compare(a[:], b[:]) })
+		rr := slices.Clone(reasons[root])
+		slices.Sort(rr)
+		rr = slices.Compact(rr)
+		clusters = append(clusters, DuplicateCluster{
+			RecordType: recordType,
+			IDs:        ids,
+			Confidence: confidence[root],
+			Reasons:    rr,
+		})
+	}
+	slices.SortFunc(clusters, func(a, b DuplicateCluster) int {
+		if a.Confidence != b.Confidence {
+			if a.Confidence > b.Confidence {
+				return -1
+			}
+			return 1
+		}
+		if len(a.IDs) != len(b.IDs) {
+			return len(b.IDs) - len(a.IDs)
+		}
+		return bytes.Compare(a.IDs[0][:], b.IDs[0][:])
+	})
+	return clusters
+}
@@ -0,0 +1,127 @@
+package bbl
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestDetectDuplicates(t *testing.T) {
+	repo := testRepo(t)
+	repo.Profiles = testProfiles(t)
+	ctx := context.Background()
+	user := createTestUser(t, repo, RoleUser)
+	curator := createTestUser(t, repo, RoleCurator)
+
+	a, b, c := newID(), newID(), newID()
+	var updates []any
+	for _, id := range []ID{a, b, c} {
+		updates = append(updates,
+			&CreateWork{ID: id, Kind: "journal_article"},
+			&Set{RecordType: RecordTypeWork, RecordID: id, Field: "titles", Val: []Title{{Lang: "eng", Val: "Paper " + id.String()}}},
+		)
+	}
+	updates = append(updates,
+		&Set{RecordType: RecordTypeWork, RecordID: a, Field: "identifiers", Val: []Identifier{{Scheme: "doi", Val: "10.1000/dup"}}},
+		&Set{RecordType: RecordTypeWork, RecordID: b, Field: "identifiers", Val: []Identifier{{Scheme: "doi", Val: "https://doi.org/10.1000/DUP"}}},
+	)
+	_, effects, err := repo.Update(ctx, user, updates...)
+	if err != nil {
+		t.Fatalf("create works: %v", err)
+	}
+	var ids []ID
+	for _, e := range effects {
+		ids = append(ids, e.RecordID)
+	}
+	if err := repo.DetectDuplicates(ctx, RecordTypeWork, ids); err != nil {
+		t.Fatalf("detect: %v", err)
+	}
+
+	clusters, _, err := repo.GetDuplicateClusters(ctx, DuplicateClusterOpts{RecordType: RecordTypeWork, MinConfidence: 0.5})
+	if err != nil {
+		t.Fatalf("clusters: %v", err)
+	}
+	var found *DuplicateCluster
+	for i := range clusters {
+		if slices.Contains(clusters[i].IDs, a) {
+			found = &clusters[i]
+		}
+	}
+	if found == nil || len(found.IDs) != 2 || !slices.Contains(found.IDs, b) || found.Confidence != 1 {
+		t.Fatalf("expected a cluster of a and b at confidence 1, got %+v", found)
+	}
+	for _, cl := range clusters {
+		if slices.Contains(cl.IDs, c) {
+			t.Errorf("work without shared identifier is clustered: %+v", cl)
+		}
+	}
+
+	// Merging resolves the cluster.
+	if _, _, err := repo.Update(ctx, curator, &MergeWorks{WorkID: a, MergedID: b}); err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	if err := repo.DetectDuplicates(ctx, RecordTypeWork, []ID{a, b}); err != nil {
+		t.Fatalf("detect after merge: %v", err)
+	}
+	clusters, _, err = repo.GetDuplicateClusters(ctx, DuplicateClusterOpts{RecordType: RecordTypeWork})
+	if err != nil {
+		t.Fatalf("clusters after merge: %v", err)
+	}
+	for _, cl := range clusters {
+		if slices.Contains(cl.IDs, b) {
+			t.Errorf("merged work still clustered: %+v", cl)
+		}
+	}
+}
+
+func TestMergePeople(t *testing.T) {
+	repo := testRepo(t)
+	repo.Profiles = testProfiles(t)
+	ctx := context.Background()
+	user := createTestUser(t, repo, RoleUser)
+	curator := createTestUser(t, repo, RoleCurator)
+
+	a, b, workID := newID(), newID(), newID()
+	if _, _, err := repo.Update(ctx, user,
+		&CreatePerson{ID: a},
+		&Set{RecordType: RecordTypePerson, RecordID: a, Field: "name", Val: "Jane Doe"},
+		&CreatePerson{ID: b},
+		&Set{RecordType: RecordTypePerson, RecordID: b, Field: "name", Val: "J. Doe"},
+		&Set{RecordType: RecordTypePerson, RecordID: b, Field: "given_name", Val: "Jane"},
+		&CreateWork{ID: workID, Kind: "journal_article"},
+		&Set{RecordType: RecordTypeWork, RecordID: workID, Field: "titles", Val: []Title{{Lang: "eng", Val: "Paper"}}},
+		&Set{RecordType: RecordTypeWork, RecordID: workID, Field: "contributors", Val: []WorkContributor{{Kind: "person", Name: "J. Doe", PersonID: &b}}},
+	); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	_, effects, err := repo.Update(ctx, curator, &MergePeople{PersonID: a, MergedID: b})
+	if err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	if !slices.ContainsFunc(effects, func(e RevEffect) bool { return e.RecordID == workID }) {
+		t.Errorf("expected linked work in effects, got %+v", effects)
+	}
+
+	survivor, err := repo.GetPerson(ctx, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if survivor.Name != "Jane Doe" || survivor.GivenName != "Jane" {
+		t.Errorf("expected survivor name kept and given name moved, got %q/%q", survivor.Name, survivor.GivenName)
+	}
+	merged, err := repo.GetPerson(ctx, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merged.Status != PersonStatusDeleted {
+		t.Errorf("expected merged person deleted, got %q", merged.Status)
+	}
+	work, err := repo.GetWork(ctx, workID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(work.Contributors) != 1 || work.Contributors[0].PersonID == nil || *work.Contributors[0].PersonID != a {
+		t.Errorf("expected contributor linked to survivor, got %+v", work.Contributors)
+	}
+}
@@ -0,0 +1,120 @@
+package bbl
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNormalizeDuplicateIdentifiers(t *testing.T) {
+	tests := []struct {
+		scheme, val, want string
+	}{
+		{"doi", "https://doi.org/10.1000/ABC.1", "10.1000/abc.1"},
+		{"DOI", "doi:10.1000/abc.1", "10.1000/abc.1"},
+		{"doi", "not a doi", ""},
+		{"isbn", "0-306-40615-2", "9780306406157"},
+		{"isbn", "978-0-306-40615-7", "9780306406157"},
+		{"isbn", "123", ""},
+		{"pmid", "PMID: 000123", "123"},
+		{"pubmed", "12a", ""},
+		{"issn", "1234-5678", ""},
+	}
+	for _, tt := range tests {
+		k, ok := workIdentifierKey(tt.scheme, tt.val)
+		if got := k.key; got != tt.want || ok != (tt.want != "") {
+			t.Errorf("workIdentifierKey(%q, %q) = %q, %t; want %q", tt.scheme, tt.val, got, ok, tt.want)
+		}
+	}
+}
+
+func TestNormalizeMatchText(t *testing.T) {
+	if got, want := normalizeMatchText("  Études: the   Café-Problem! "), "etudes the cafe problem"; got != want {
+		t.Errorf("normalizeMatchText = %q, want %q", got, want)
+	}
+}
+
+func TestScoreWorkTitles(t *testing.T) {
+	a := &Work{
+		PublicationYear: "2020",
+		Titles:          []Title{{Lang: "eng", Val: "Deep learning for citation matching"}},
+		Contributors:    []WorkContributor{{GivenName: "Ann", FamilyName: "Smith", Roles: []string{"author"}}},
+	}
+	b := &Work{
+		PublicationYear: "2020",
+		Titles:          []Title{{Lang: "eng", Val: "Deep Learning for Citation Matching."}},
+		Contributors:    []WorkContributor{{Name: "Ann Smith"}},
+	}
+	if c := scoreWorkTitles(a, b); c != 0.9 {
+		t.Errorf("identical title and author: confidence = %v, want 0.9", c)
+	}
+
+	b.Titles = []Title{{Lang: "eng", Val: "Deep learning for citation matchng"}}
+	if c := scoreWorkTitles(a, b); c < 0.72 || c >= 0.9 {
+		t.Errorf("near title: confidence = %v, want between 0.72 and 0.9", c)
+	}
+
+	b.PublicationYear = "2021"
+	if c := scoreWorkTitles(a, b); c != 0 {
+		t.Errorf("different year: confidence = %v, want 0", c)
+	}
+
+	b.PublicationYear = "2020"
+	b.Titles = []Title{{Lang: "eng", Val: "A survey of library systems"}}
+	if c := scoreWorkTitles(a, b); c != 0 {
+		t.Errorf("different title: confidence = %v, want 0", c)
+	}
+}
+
+func TestScoreWorkDuplicate_ISBNNeedsSameKind(t *testing.T) {
+	book := &Work{Kind: "book"}
+	chapter := &Work{Kind: "book_chapter"}
+	if p := scoreWorkDuplicate(book, chapter, []string{DuplicateReasonISBN}); p.confidence != 0 {
+		t.Errorf("book and chapter: confidence = %v, want 0", p.confidence)
+	}
+	p := scoreWorkDuplicate(book, &Work{Kind: "book"}, []string{DuplicateReasonISBN, DuplicateReasonDOI})
+	if p.confidence != 1 || !slices.Equal(p.reasons, []string{DuplicateReasonDOI, DuplicateReasonISBN}) {
+		t.Errorf("got %v %v, want 1 [doi isbn]", p.confidence, p.reasons)
+	}
+}
+
+func TestPersonNameKeys(t *testing.T) {
+	want := []duplicateKey{
+		{DuplicateReasonName, "peeters|jan"},
+		{DuplicateReasonNameInitial, "peeters|j"},
+	}
+	for _, keys := range [][]duplicateKey{
+		personNameKeys("", "Jan", "Peeters"),
+		personNameKeys("Jan Peeters", "", ""),
+		personNameKeys("Peeters, Jan", "", ""),
+	} {
+		if !slices.Equal(keys, want) {
+			t.Errorf("got %v, want %v", keys, want)
+		}
+	}
+	if keys := personNameKeys("Peeters", "", ""); keys != nil {
+		t.Errorf("family name only: got %v, want nil", keys)
+	}
+}
+
+func TestClusterDuplicates(t *testing.T) {
+	a, b, c, d, e := ID{1}, ID{2}, ID{3}, ID{4}, ID{5}
+	clusters := clusterDuplicates(RecordTypeWork, []duplicatePair{
+		{id: b, otherID: c, confidence: 0.8, reasons: []string{DuplicateReasonTitle}},
+		{id: a, otherID: b, confidence: 1, reasons: []string{DuplicateReasonDOI}},
+		{id: d, otherID: e, confidence: 0.95, reasons: []string{DuplicateReasonPMID}},
+		{id: a, otherID: c, confidence: 0.7, reasons: []string{DuplicateReasonISBN}},
+	})
+	if len(clusters) != 2 {
+		t.Fatalf("got %d clusters, want 2", len(clusters))
+	}
+	if got := clusters[0]; got.Confidence != 0.95 || !slices.Equal(got.IDs, []ID{d, e}) {
+		t.Errorf("first cluster = %+v, want d,e at 0.95", got)
+	}
+	got := clusters[1]
+	if got.Confidence != 0.8 || !slices.Equal(got.IDs, []ID{a, b, c}) {
+		t.Errorf("second cluster = %+v, want a,b,c at 0.8", got)
+	}
+	if want := []string{DuplicateReasonDOI, DuplicateReasonISBN, DuplicateReasonTitle}; !slices.Equal(got.Reasons, want) {
+		t.Errorf("reasons = %v, want %v", got.Reasons, want)
+	}
+}
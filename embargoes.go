@@ -0,0 +1,185 @@
+package bbl
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Embargoes. File embargoes are asserted in the "files" field of a work.
+// The embargo job lifts expired embargoes in a revision by the system user,
+// so lifts show up in the work history, and warns depositors ahead of time.
+
+// expiringEmbargo is an embargo that ends soon and has not been announced.
+type expiringEmbargo struct {
+	WorkID            ID
+	FileID            ID
+	FileName          string
+	EmbargoUntil      time.Time
+	EmbargoAccessKind string
+	UserID            ID // the depositor
+}
+
+// expiredEmbargoWorkIDs returns the works with a file embargo that ended
+// before t and has not been lifted.
+func (r *Repo) expiredEmbargoWorkIDs(ctx context.Context, t time.Time) ([]ID, error) {
+	ids, err := r.queryIDs(ctx, `
+		SELECT DISTINCT a.work_id
+		FROM bbl_work_assertions a
+		JOIN bbl_works w ON w.id = a.work_id
+		WHERE a.field = 'files' AND a.pinned
+		  AND a.val ? 'embargo_until' AND NOT a.val ? 'embargo_lifted_at'
+		  AND (a.val->>'embargo_until')::timestamptz <= $1
+		  AND w.status <> $2`,
+		t, WorkStatusDeleted)
+	if err != nil {
+		return nil, fmt.Errorf("expiredEmbargoWorkIDs: %w", err)
+	}
+	return ids, nil
+}
+
+// expiringEmbargoes returns the unannounced file embargoes that end between
+// from and to, soonest first. Works without a depositor are skipped.
+func (r *Repo) expiringEmbargoes(ctx context.Context, from, to time.Time) ([]expiringEmbargo, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT a.work_id, af.file_id, a.val->>'name',
+		       (a.val->>'embargo_until')::timestamptz,
+		       COALESCE(a.val->>'embargo_access_kind', ''),
+		       w.created_by_id
+		FROM bbl_work_assertions a
+		JOIN bbl_work_assertion_files af ON af.assertion_id = a.id
+		JOIN bbl_works w ON w.id = a.work_id
+		WHERE a.field = 'files' AND a.pinned
+		  AND a.val ? 'embargo_until' AND NOT a.val ? 'embargo_lifted_at'
+		  AND (a.val->>'embargo_until')::timestamptz > $1
+		  AND (a.val->>'embargo_until')::timestamptz <= $2
+		  AND w.status <> $3
+		  AND w.created_by_id IS NOT NULL
+		  AND NOT EXISTS (
+		      SELECT 1 FROM bbl_embargo_notices n
+		      WHERE n.work_id = a.work_id AND n.file_id = af.file_id
+		        AND n.embargo_until = (a.val->>'embargo_until')::timestamptz
+		  )
+		ORDER BY 4`,
+		from, to, WorkStatusDeleted)
+	if err != nil {
+		return nil, fmt.Errorf("expiringEmbargoes: %w", err)
+	}
+	defer rows.Close()
+
+	var embargoes []expiringEmbargo
+	for rows.Next() {
+		var e expiringEmbargo
+		if err := rows.Scan(&e.WorkID, &e.FileID, &e.FileName, &e.EmbargoUntil, &e.EmbargoAccessKind, &e.UserID); err != nil {
+			return nil, fmt.Errorf("expiringEmbargoes: %w", err)
+		}
+		embargoes = append(embargoes, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("expiringEmbargoes: %w", err)
+	}
+	return embargoes, nil
+}
+
+// addEmbargoNotice records that an embargo was announced.
+func (r *Repo) addEmbargoNotice(ctx context.Context, e expiringEmbargo) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO bbl_embargo_notices (work_id, file_id, embargo_until, user_id)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT DO NOTHING`,
+		e.WorkID, e.FileID, e.EmbargoUntil, e.UserID)
+	if err != nil {
+		return fmt.Errorf("addEmbargoNotice: %w", err)
+	}
+	return nil
+}
+
+// LiftEmbargoes lifts the file embargoes that ended before now, in one
+// revision per work by the system user, and reindexes the works. Works that
+// fail are logged and skipped. Returns the number of works changed.
+func (s *Services) LiftEmbargoes(ctx context.Context, now time.Time) (int, error) {
+	ids, err := s.Repo.expiredEmbargoWorkIDs(ctx, now)
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+	user, err := s.Repo.SystemUser(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("LiftEmbargoes: %w", err)
+	}
+	var n int
+	for _, id := range ids {
+		ok, err := s.UpdateAndIndex(ctx, user, &LiftWorkFileEmbargoes{WorkID: id, At: now})
+		if err != nil {
+			slog.Error("LiftEmbargoes", "work_id", id, "err", err)
+			continue
+		}
+		if ok {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// NotifyExpiringEmbargoes tells depositors about file embargoes that end
+// within d after now. Each embargo date is announced once; failed deliveries
+// are logged and retried on the next run. A noop without a Notifier. Returns
+// the number of notifications sent.
+func (s *Services) NotifyExpiringEmbargoes(ctx context.Context, now time.Time, d time.Duration) (int, error) {
+	if s.Notifier == nil || d <= 0 {
+		return 0, nil
+	}
+	embargoes, err := s.Repo.expiringEmbargoes(ctx, now, now.Add(d))
+	if err != nil {
+		return 0, err
+	}
+	var n int
+	for _, e := range embargoes {
+		user, err := s.Repo.GetUser(ctx, e.UserID)
+		if err != nil {
+			slog.Error("NotifyExpiringEmbargoes", "user_id", e.UserID, "err", err)
+			continue
+		}
+		work, err := s.Repo.GetWork(ctx, e.WorkID)
+		if err != nil {
+			slog.Error("NotifyExpiringEmbargoes", "work_id", e.WorkID, "err", err)
+			continue
+		}
+		if err := s.Notifier.Notify(ctx, embargoNotification(user, work, e, s.Repo.Profiles.FileAccessKinds())); err != nil {
+			slog.Error("NotifyExpiringEmbargoes", "work_id", e.WorkID, "user_id", e.UserID, "err", err)
+			continue
+		}
+		if err := s.Repo.addEmbargoNotice(ctx, e); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+func embargoNotification(user *User, work *Work, e expiringEmbargo, kinds []FileAccessKind) *Notification {
+	title := work.ID.String()
+	if len(work.Titles) > 0 {
+		title = work.Titles[0].Val
+	}
+	access := "closed access"
+	for _, k := range kinds {
+		if k.Name != e.EmbargoAccessKind {
+			continue
+		}
+		switch k.Download {
+		case FileDownloadEveryone:
+			access = "open access"
+		case FileDownloadUsers:
+			access = "accessible to logged in users"
+		}
+	}
+	return &Notification{
+		Kind:    NotificationEmbargoExpiring,
+		User:    user,
+		WorkID:  work.ID,
+		Subject: fmt.Sprintf("Embargo on %s ends on %s", e.FileName, e.EmbargoUntil.Format(time.DateOnly)),
+		Body: fmt.Sprintf("The embargo on the file %q of %q ends on %s. The file will then be %s.",
+			e.FileName, title, e.EmbargoUntil.Format(time.DateOnly), access),
+	}
+}
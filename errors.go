@@ -6,4 +6,10 @@ var (
 	ErrNotFound    = errors.New("not found")
 	ErrConflict    = errors.New("conflict")
 	ErrCuratorLock = errors.New("field is locked by a curator")
+	ErrForbidden   = errors.New("forbidden")
+
+	ErrInvalidTransition = errors.New("invalid transition")
+	ErrSweepThreshold    = errors.New("too many source records vanished")
+	ErrSweepPartialRun   = errors.New("sweep needs a full run")
+	ErrInvalidIdentifier = errors.New("invalid identifier")
 )
@@ -41,6 +41,7 @@ var workFieldTypes = map[string]string{
 	"projects":      "workProject",
 	"organizations": "workOrganization",
 	"rels":          "workRel",
+	"files":         "workFile",
 }
 
 // personFieldTypes maps person field names to their fieldType names.
@@ -86,6 +86,25 @@ func buildWorkRelRelation(assertionIDs []int64, val any) (string, []any) {
 	return b.String(), args
 }
 
+func buildWorkFileRelation(assertionIDs []int64, val any) (string, []any) {
+	items := val.([]WorkFile)
+	if len(assertionIDs) == 0 {
+		return "", nil
+	}
+	var b strings.Builder
+	b.WriteString("INSERT INTO bbl_work_assertion_files (assertion_id, file_id) VALUES ")
+	args := make([]any, 0, len(assertionIDs)*2)
+	for i, f := range items {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		p := i * 2
+		fmt.Fprintf(&b, "($%d, $%d)", p+1, p+2)
+		args = append(args, assertionIDs[i], f.FileID)
+	}
+	return b.String(), args
+}
+
 func buildPersonAffiliationRelation(assertionIDs []int64, val any) (string, []any) {
 	items := val.([]PersonAffiliation)
 	if len(assertionIDs) == 0 {
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"slices"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/ugent-library/vo"
@@ -22,6 +23,10 @@ type fieldType struct {
 	// rules beyond presence — the engine skips nil validate.
 	validate func(val any, def *FieldDef) []*vo.Error
 
+	// normalize returns the canonical form of a value before it is
+	// asserted. nil means values are asserted as given.
+	normalize func(val any) any
+
 	equal     func(a, b any) bool
 	marshal   func(val any) ([]json.RawMessage, error)
 	unmarshal func(raw json.RawMessage) (any, error)
@@ -287,6 +292,11 @@ var ftKeyword = fieldType{
 	},
 }
 
+const (
+	ruleIdentifier    = "identifier"
+	messageIdentifier = "must be a valid %s identifier"
+)
+
 var ftIdentifier = fieldType{
 	name:       "identifier",
 	entities:   allEntities,
@@ -303,9 +313,20 @@ var ftIdentifier = fieldType{
 					vo.RuleOneOf, def.Schemes,
 				).WithMessage(fmt.Sprintf(vo.MessageOneOf, vo.FormatSlice(def.Schemes))))
 			}
+			if id.Scheme != "" && id.Val != "" {
+				if _, err := NormalizeIdentifier(id.Scheme, id.Val); err != nil {
+					errs = append(errs, vo.NewError(
+						fmt.Sprintf("%s[%d].val", def.Name, i),
+						ruleIdentifier, id.Scheme,
+					).WithMessage(fmt.Sprintf(messageIdentifier, id.Scheme)))
+				}
+			}
 		}
 		return errs
 	},
+	normalize: func(val any) any {
+		return normalizeIdentifiers(val.([]Identifier))
+	},
 	equal: func(a, b any) bool {
 		return slices.Equal(a.([]Identifier), b.([]Identifier))
 	},
@@ -382,7 +403,8 @@ var ftWorkContributor = fieldType{
 			if aa[i].Kind != bb[i].Kind || aa[i].Name != bb[i].Name ||
 				aa[i].GivenName != bb[i].GivenName || aa[i].FamilyName != bb[i].FamilyName ||
 				!idPtrEqual(aa[i].PersonID, bb[i].PersonID) ||
-				!slices.Equal(aa[i].Roles, bb[i].Roles) {
+				!slices.Equal(aa[i].Roles, bb[i].Roles) ||
+				!slices.Equal(aa[i].Affiliations, bb[i].Affiliations) {
 				return false
 			}
 		}
@@ -393,12 +415,13 @@ var ftWorkContributor = fieldType{
 		out := make([]json.RawMessage, len(items))
 		for i, v := range items {
 			b, err := json.Marshal(struct {
-				Kind       string   `json:"kind,omitempty"`
-				Name       string   `json:"name,omitempty"`
-				GivenName  string   `json:"given_name,omitempty"`
-				FamilyName string   `json:"family_name,omitempty"`
-				Roles      []string `json:"roles,omitempty"`
-			}{v.Kind, v.Name, v.GivenName, v.FamilyName, v.Roles})
+				Kind         string   `json:"kind,omitempty"`
+				Name         string   `json:"name,omitempty"`
+				GivenName    string   `json:"given_name,omitempty"`
+				FamilyName   string   `json:"family_name,omitempty"`
+				Roles        []string `json:"roles,omitempty"`
+				Affiliations []string `json:"affiliations,omitempty"`
+			}{v.Kind, v.Name, v.GivenName, v.FamilyName, v.Roles, v.Affiliations})
 			if err != nil {
 				return nil, err
 			}
@@ -540,6 +563,103 @@ var ftWorkRel = fieldType{
 	},
 }
 
+var ftWorkFile = fieldType{
+	name:       "workFile",
+	entities:   []string{"work"},
+	collection: true,
+	validate: func(val any, def *FieldDef) []*vo.Error {
+		files := val.([]WorkFile)
+		kinds := def.AccessKinds
+		if kinds == nil {
+			kinds = fileAccessKindNames(DefaultFileAccessKinds)
+		}
+		var errs []*vo.Error
+		for i, f := range files {
+			errs = append(errs, vo.NotBlank(fmt.Sprintf("%s[%d].name", def.Name, i), f.Name))
+			errs = append(errs, vo.OneOf(fmt.Sprintf("%s[%d].access_kind", def.Name, i), f.AccessKind, kinds))
+			if f.EmbargoUntil != nil {
+				errs = append(errs, vo.OneOf(fmt.Sprintf("%s[%d].embargo_access_kind", def.Name, i), f.EmbargoAccessKind, kinds))
+			} else if f.EmbargoAccessKind != "" {
+				errs = append(errs, vo.NewError(fmt.Sprintf("%s[%d].embargo_until", def.Name, i), vo.RuleNotEmpty).WithMessage(vo.MessageNotEmpty))
+			}
+		}
+		return errs
+	},
+	equal: func(a, b any) bool {
+		return slices.EqualFunc(a.([]WorkFile), b.([]WorkFile), workFileEqual)
+	},
+	marshal: func(val any) ([]json.RawMessage, error) {
+		items := val.([]WorkFile)
+		out := make([]json.RawMessage, len(items))
+		for i, v := range items {
+			b, err := json.Marshal(struct {
+				Name              string     `json:"name"`
+				AccessKind        string     `json:"access_kind"`
+				EmbargoUntil      *time.Time `json:"embargo_until,omitempty"`
+				EmbargoAccessKind string     `json:"embargo_access_kind,omitempty"`
+				EmbargoLiftedAt   *time.Time `json:"embargo_lifted_at,omitempty"`
+				License           string     `json:"license,omitempty"`
+			}{v.Name, v.AccessKind, v.EmbargoUntil, v.EmbargoAccessKind, v.EmbargoLiftedAt, v.License})
+			if err != nil {
+				return nil, err
+			}
+			out[i] = b
+		}
+		return out, nil
+	},
+	unmarshal: func(raw json.RawMessage) (any, error) {
+		var v []WorkFile
+		err := json.Unmarshal(raw, &v)
+		return v, err
+	},
+	relation: &relation{
+		buildInsert: func(assertionIDs []int64, val any) (string, []any) {
+			return buildWorkFileRelation(assertionIDs, val)
+		},
+		joinSQL:   "LEFT JOIN bbl_work_assertion_files _waf ON _waf.assertion_id = a.id LEFT JOIN bbl_files _f ON _f.id = _waf.file_id",
+		cols:      []string{"_waf.file_id", "_f.content_type", "_f.size", "_f.sha256"},
+		scanDests: func() []any { return []any{new(pgtype.UUID), new(pgtype.Text), new(pgtype.Int8), new(pgtype.Text)} },
+		enrichVal: func(val json.RawMessage, scanned []any) json.RawMessage {
+			uid := scanned[0].(*pgtype.UUID)
+			if !uid.Valid {
+				return val
+			}
+			idJSON, _ := json.Marshal(ID(uid.Bytes))
+			val = jsonSet(val, "file_id", idJSON)
+			if ct := scanned[1].(*pgtype.Text); ct.Valid {
+				b, _ := json.Marshal(ct.String)
+				val = jsonSet(val, "content_type", b)
+			}
+			if size := scanned[2].(*pgtype.Int8); size.Valid {
+				b, _ := json.Marshal(size.Int64)
+				val = jsonSet(val, "size", b)
+			}
+			if sum := scanned[3].(*pgtype.Text); sum.Valid {
+				b, _ := json.Marshal(sum.String)
+				val = jsonSet(val, "sha256", b)
+			}
+			return val
+		},
+	},
+}
+
+// workFileEqual compares the asserted parts of two work files. Content type,
+// size and checksum belong to the file record and are not compared.
+func workFileEqual(a, b WorkFile) bool {
+	if a.FileID != b.FileID || a.Name != b.Name || a.AccessKind != b.AccessKind ||
+		a.EmbargoAccessKind != b.EmbargoAccessKind || a.License != b.License {
+		return false
+	}
+	return timePtrEqual(a.EmbargoUntil, b.EmbargoUntil) && timePtrEqual(a.EmbargoLiftedAt, b.EmbargoLiftedAt)
+}
+
+func timePtrEqual(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
 var ftPersonAffiliation = fieldType{
 	name:       "personAffiliation",
 	entities:   []string{"person"},
@@ -698,6 +818,7 @@ var fieldTypeRegistry = map[string]*fieldType{
 	"workProject":        &ftWorkProject,
 	"workOrganization":   &ftWorkOrganization,
 	"workRel":            &ftWorkRel,
+	"workFile":           &ftWorkFile,
 	"personAffiliation":  &ftPersonAffiliation,
 	"projectParticipant": &ftProjectParticipant,
 	"organizationRel":    &ftOrganizationRel,
@@ -24,6 +24,10 @@ func (m *Set) apply(state updateState, user *User) (*updateEffect, error) {
 		return nil, fmt.Errorf("%s: %w", m.name(), err)
 	}
 
+	if ft.normalize != nil && m.Val != nil {
+		m.Val = ft.normalize(m.Val)
+	}
+
 	rs := state.records[m.RecordID]
 
 	// Noop: compare against current pinned value.
@@ -34,12 +38,15 @@ func (m *Set) apply(state updateState, user *User) (*updateEffect, error) {
 	}
 
 	// Curator lock.
-	if rs != nil {
+	if rs != nil && !passesCuratorLocks(user) {
 		if p := firstPinned(rs.assertions[m.Field]); p != nil {
-			if user.Role != "curator" && p.userID != nil && p.role == "curator" {
+			if p.userID != nil && p.role == RoleCurator {
 				return nil, ErrCuratorLock
 			}
 		}
+		if rs.locked(m.Field) {
+			return nil, ErrCuratorLock
+		}
 	}
 
 	// Mutate record state.
@@ -84,10 +91,13 @@ func (m *Hide) apply(state updateState, user *User) (*updateEffect, error) {
 				return nil, nil
 			}
 			// Curator lock.
-			if user.Role != "curator" && p.userID != nil && p.role == "curator" {
+			if !passesCuratorLocks(user) && p.userID != nil && p.role == RoleCurator {
 				return nil, ErrCuratorLock
 			}
 		}
+		if !passesCuratorLocks(user) && rs.locked(m.Field) {
+			return nil, ErrCuratorLock
+		}
 		delete(rs.fields, m.Field)
 	}
 
@@ -132,7 +142,7 @@ func (m *Unset) apply(state updateState, user *User) (*updateEffect, error) {
 	}
 
 	// Curator lock.
-	if user.Role != "curator" && h.role == "curator" {
+	if !passesCuratorLocks(user) && (h.role == RoleCurator || rs.locked(m.Field)) {
 		return nil, ErrCuratorLock
 	}
 
@@ -580,3 +580,30 @@ func TestUpdateHideField(t *testing.T) {
 		t.Error("hide volume noop: expected no rev")
 	}
 }
+
+func TestUpdateValidatesExistingRecord(t *testing.T) {
+	repo := testRepo(t)
+	repo.Profiles = testProfiles(t)
+	ctx := context.Background()
+	user := createTestUser(t, repo, RoleUser)
+
+	workID := newID()
+	if _, _, err := repo.Update(ctx, user,
+		&CreateWork{ID: workID, Kind: "journal_article"},
+		&Set{RecordType: RecordTypeWork, RecordID: workID, Field: "titles", Val: []Title{{Lang: "eng", Val: "Valid"}}},
+	); err != nil {
+		t.Fatalf("create work: %v", err)
+	}
+
+	// Fields the update doesn't touch are validated too.
+	if _, _, err := repo.Update(ctx, user, &Set{RecordType: RecordTypeWork, RecordID: workID, Field: "volume", Val: "1"}); err != nil {
+		t.Fatalf("set volume: %v", err)
+	}
+	if _, err := repo.db.Exec(ctx, `
+		DELETE FROM bbl_work_assertions WHERE work_id = $1 AND field = 'titles'`, workID); err != nil {
+		t.Fatalf("delete titles: %v", err)
+	}
+	if _, _, err := repo.Update(ctx, user, &Set{RecordType: RecordTypeWork, RecordID: workID, Field: "volume", Val: "2"}); err == nil {
+		t.Error("expected an update of a work without titles to fail validation")
+	}
+}
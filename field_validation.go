@@ -50,6 +50,8 @@ func fieldEmpty(val any) bool {
 		return len(v) == 0
 	case []WorkRel:
 		return len(v) == 0
+	case []WorkFile:
+		return len(v) == 0
 	case []PersonAffiliation:
 		return len(v) == 0
 	case []ProjectParticipant:
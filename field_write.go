@@ -17,13 +17,23 @@ func executeFieldWrites(ctx context.Context, tx pgx.Tx, revID int64, user *User,
 		mut updater
 		eff *updateEffect
 	}
+	// A later op on the same field supersedes an earlier one: each op
+	// carries the full field value as applied on top of the previous ones.
 	var ops []fieldOp
+	opIdx := make(map[string]int)
 	for i, eff := range effects {
 		if eff == nil {
 			continue
 		}
 		switch muts[i].(type) {
-		case *Set, *Hide, *Unset:
+		case *Set, *Hide, *Unset, *AttachWorkFile, *DetachWorkFile, *LiftWorkFileEmbargoes:
+			rt, id, field := fieldOpTarget(muts[i])
+			key := rt + ":" + id.String() + ":" + field
+			if j, ok := opIdx[key]; ok {
+				ops[j] = fieldOp{muts[i], eff}
+				continue
+			}
+			opIdx[key] = len(ops)
 			ops = append(ops, fieldOp{muts[i], eff})
 		}
 	}
@@ -56,7 +66,8 @@ func executeFieldWrites(ctx context.Context, tx pgx.Tx, revID int64, user *User,
 
 	preCount := batch.Len()
 
-	// Build assertion rows for Set/Hide (Unset = delete only, no insert).
+	// Build assertion rows for Set/Hide and file updaters
+	// (Unset = delete only, no insert).
 	var rows []assertionRow
 	for _, op := range ops {
 		rt, id, field := fieldOpTarget(op.mut)
@@ -79,6 +90,33 @@ func executeFieldWrites(ctx context.Context, tx pgx.Tx, revID int64, user *User,
 				userID:     &user.ID,
 				role:       &user.Role,
 			})
+		case *AttachWorkFile:
+			rows = append(rows, assertionRow{
+				recordType: rt,
+				recordID:   id,
+				field:      field,
+				val:        m.files,
+				userID:     &user.ID,
+				role:       &user.Role,
+			})
+		case *DetachWorkFile:
+			rows = append(rows, assertionRow{
+				recordType: rt,
+				recordID:   id,
+				field:      field,
+				val:        m.files,
+				userID:     &user.ID,
+				role:       &user.Role,
+			})
+		case *LiftWorkFileEmbargoes:
+			rows = append(rows, assertionRow{
+				recordType: rt,
+				recordID:   id,
+				field:      field,
+				val:        m.files,
+				userID:     &user.ID,
+				role:       &user.Role,
+			})
 		}
 	}
 
@@ -94,6 +132,12 @@ func fieldOpTarget(m updater) (string, ID, string) {
 		return u.RecordType, u.RecordID, u.Field
 	case *Unset:
 		return u.RecordType, u.RecordID, u.Field
+	case *AttachWorkFile:
+		return RecordTypeWork, u.WorkID, "files"
+	case *DetachWorkFile:
+		return RecordTypeWork, u.WorkID, "files"
+	case *LiftWorkFileEmbargoes:
+		return RecordTypeWork, u.WorkID, "files"
 	}
 	return "", ID{}, ""
 }
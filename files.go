@@ -0,0 +1,372 @@
+package bbl
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// File status values.
+const (
+	FileStatusPending  = "pending"
+	FileStatusUploaded = "uploaded"
+)
+
+// Built-in work file access levels. Other levels are configured in the
+// profiles file.
+const (
+	WorkFileAccessOpen   = "open"
+	WorkFileAccessClosed = "closed"
+)
+
+// Who may download the files of an access level.
+const (
+	FileDownloadEveryone = "everyone"
+	FileDownloadUsers    = "users"    // any logged in user
+	FileDownloadCurators = "curators" // curators and admins only
+)
+
+// FileAccessKind is a work file access level and who may download files
+// with it.
+type FileAccessKind struct {
+	Name     string `yaml:"name"`
+	Download string `yaml:"download"`
+}
+
+// DefaultFileAccessKinds are used if the profiles file configures none.
+var DefaultFileAccessKinds = []FileAccessKind{
+	{Name: WorkFileAccessOpen, Download: FileDownloadEveryone},
+	{Name: WorkFileAccessClosed, Download: FileDownloadCurators},
+}
+
+func fileAccessKindNames(kinds []FileAccessKind) []string {
+	names := make([]string, len(kinds))
+	for i, k := range kinds {
+		names[i] = k.Name
+	}
+	return names
+}
+
+// Presigned URL lifetimes.
+const (
+	fileUploadTTL   = 15 * time.Minute
+	fileDownloadTTL = 5 * time.Minute
+)
+
+// FileStore stores file objects by key. Uploads and downloads go directly
+// between the client and the store through presigned URLs; Get is only
+// used server side to checksum a finished upload.
+type FileStore interface {
+	// PresignPut returns a URL the client can PUT the object body to.
+	PresignPut(ctx context.Context, key, contentType string, ttl time.Duration) (string, error)
+	// PresignGet returns a URL that serves the object as an attachment
+	// named filename.
+	PresignGet(ctx context.Context, key, filename string, ttl time.Duration) (string, error)
+	// Get opens the object. Returns ErrNotFound if it does not exist.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Put(ctx context.Context, key string, r io.Reader, contentType string) error
+	Delete(ctx context.Context, key string) error
+}
+
+// File is a stored object registered in bbl_files. The object key in the
+// FileStore is the file ID.
+type File struct {
+	ID          ID         `json:"id"`
+	Name        string     `json:"name"`
+	ContentType string     `json:"content_type"`
+	Size        int64      `json:"size"`
+	SHA256      string     `json:"sha256,omitempty"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CreatedByID *ID        `json:"created_by_id,omitempty"`
+	UploadedAt  *time.Time `json:"uploaded_at,omitempty"`
+}
+
+// WorkFile is a file attached to a work. Name, access level, embargo and
+// license are asserted per work; content type, size and checksum are read
+// from the file record.
+type WorkFile struct {
+	FileID      ID     `json:"file_id"`
+	Name        string `json:"name"`
+	ContentType string `json:"content_type,omitempty"`
+	Size        int64  `json:"size,omitempty"`
+	SHA256      string `json:"sha256,omitempty"`
+	AccessKind  string `json:"access_kind"`
+	// EmbargoUntil is the end of the embargo. Once it has passed, the file
+	// is accessible at EmbargoAccessKind.
+	EmbargoUntil      *time.Time `json:"embargo_until,omitempty"`
+	EmbargoAccessKind string     `json:"embargo_access_kind,omitempty"`
+	// EmbargoLiftedAt is set when the embargo job has copied
+	// EmbargoAccessKind to AccessKind. From then on AccessKind is
+	// authoritative again.
+	EmbargoLiftedAt *time.Time `json:"embargo_lifted_at,omitempty"`
+	License         string     `json:"license,omitempty"`
+}
+
+// Embargoed reports whether the file is under embargo at t.
+func (f WorkFile) Embargoed(t time.Time) bool {
+	return f.EmbargoUntil != nil && t.Before(*f.EmbargoUntil)
+}
+
+// EmbargoExpired reports whether the embargo has ended at t but has not been
+// lifted yet.
+func (f WorkFile) EmbargoExpired(t time.Time) bool {
+	return f.EmbargoUntil != nil && f.EmbargoLiftedAt == nil && !f.Embargoed(t)
+}
+
+// AccessKindAt returns the access level in effect at t. An expired embargo
+// takes effect immediately, even before the embargo job lifts it.
+func (f WorkFile) AccessKindAt(t time.Time) string {
+	if f.EmbargoExpired(t) && f.EmbargoAccessKind != "" {
+		return f.EmbargoAccessKind
+	}
+	return f.AccessKind
+}
+
+// CanDownload reports whether user may download the file at t, given the
+// configured access levels. Curators and admins may download every file;
+// files with an unknown access level are closed to everyone else.
+func (f WorkFile) CanDownload(user *User, t time.Time, kinds []FileAccessKind) bool {
+	if user != nil && (user.Role == RoleCurator || user.Role == RoleAdmin) {
+		return true
+	}
+	access := f.AccessKindAt(t)
+	for _, k := range kinds {
+		if k.Name != access {
+			continue
+		}
+		switch k.Download {
+		case FileDownloadEveryone:
+			return true
+		case FileDownloadUsers:
+			return user != nil
+		}
+		return false
+	}
+	return false
+}
+
+// CreateFile registers a pending file with a fresh ID.
+func (r *Repo) CreateFile(ctx context.Context, user *User, name, contentType string) (*File, error) {
+	f := &File{
+		ID:          newID(),
+		Name:        name,
+		ContentType: contentType,
+		Status:      FileStatusPending,
+	}
+	if f.ContentType == "" {
+		f.ContentType = "application/octet-stream"
+	}
+	if user != nil {
+		f.CreatedByID = &user.ID
+	}
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO bbl_files (id, name, content_type, status, created_by_id)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at`,
+		f.ID, f.Name, f.ContentType, f.Status, f.CreatedByID).Scan(&f.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("CreateFile: %w", err)
+	}
+	return f, nil
+}
+
+// GetFile fetches a file by ID. Returns ErrNotFound if it does not exist.
+func (r *Repo) GetFile(ctx context.Context, id ID) (*File, error) {
+	var f File
+	err := r.db.QueryRow(ctx, `
+		SELECT id, name, content_type, size, COALESCE(sha256, ''), status, created_at, created_by_id, uploaded_at
+		FROM bbl_files
+		WHERE id = $1`, id).Scan(
+		&f.ID, &f.Name, &f.ContentType, &f.Size, &f.SHA256, &f.Status, &f.CreatedAt, &f.CreatedByID, &f.UploadedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("GetFile: %w", err)
+	}
+	return &f, nil
+}
+
+// markFileUploaded records the checksum, size and sniffed content type of an
+// uploaded object. Returns ErrNotFound if the file is not pending.
+func (r *Repo) markFileUploaded(ctx context.Context, f *File) error {
+	err := r.db.QueryRow(ctx, `
+		UPDATE bbl_files
+		SET content_type = $2, size = $3, sha256 = $4, status = $5, uploaded_at = transaction_timestamp()
+		WHERE id = $1 AND status = $6
+		RETURNING uploaded_at`,
+		f.ID, f.ContentType, f.Size, f.SHA256, FileStatusUploaded, FileStatusPending).Scan(&f.UploadedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("markFileUploaded: %w", err)
+	}
+	f.Status = FileStatusUploaded
+	return nil
+}
+
+// fetchAttachFiles locks the files that are being attached and records
+// their status, uploader and the works they are attached to on the
+// AttachWorkFile updaters, so apply can check them. Attachments of deleted
+// works don't count.
+func fetchAttachFiles(ctx context.Context, tx pgx.Tx, muts []updater) error {
+	attaches := make(map[ID][]*AttachWorkFile)
+	var fileIDs []ID
+	for _, m := range muts {
+		if u, ok := m.(*AttachWorkFile); ok {
+			attaches[u.File.FileID] = append(attaches[u.File.FileID], u)
+			fileIDs = append(fileIDs, u.File.FileID)
+		}
+	}
+	if len(fileIDs) == 0 {
+		return nil
+	}
+
+	// Lock the files so that concurrent revisions can't attach them to
+	// different works.
+	if _, err := tx.Exec(ctx, `SELECT 1 FROM bbl_files WHERE id = ANY($1) FOR UPDATE`, fileIDs); err != nil {
+		return fmt.Errorf("fetchAttachFiles: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT f.id, f.status, f.created_by_id,
+		       COALESCE(array_agg(DISTINCT a.work_id) FILTER (WHERE a.work_id IS NOT NULL), '{}')
+		FROM bbl_files f
+		LEFT JOIN bbl_work_assertion_files af ON af.file_id = f.id
+		LEFT JOIN bbl_work_assertions a ON a.id = af.assertion_id
+		    AND NOT EXISTS (SELECT 1 FROM bbl_works w WHERE w.id = a.work_id AND w.status = 'deleted')
+		WHERE f.id = ANY($1)
+		GROUP BY f.id`, fileIDs)
+	if err != nil {
+		return fmt.Errorf("fetchAttachFiles: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id ID
+		var status string
+		var createdByID *ID
+		var workIDs []ID
+		if err := rows.Scan(&id, &status, &createdByID, &workIDs); err != nil {
+			return fmt.Errorf("fetchAttachFiles: %w", err)
+		}
+		for _, u := range attaches[id] {
+			u.fileStatus = status
+			u.fileCreatedByID = createdByID
+			u.fileWorkIDs = workIDs
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("fetchAttachFiles: %w", err)
+	}
+	return nil
+}
+
+// StartFileUpload registers a pending file and returns it with a presigned
+// URL the client uploads the file body to. Call FinishFileUpload afterwards.
+func (s *Services) StartFileUpload(ctx context.Context, user *User, name, contentType string) (*File, string, error) {
+	if s.Files == nil {
+		return nil, "", fmt.Errorf("StartFileUpload: no file store configured")
+	}
+	f, err := s.Repo.CreateFile(ctx, user, name, contentType)
+	if err != nil {
+		return nil, "", err
+	}
+	u, err := s.Files.PresignPut(ctx, f.ID.String(), f.ContentType, fileUploadTTL)
+	if err != nil {
+		return nil, "", fmt.Errorf("StartFileUpload: %w", err)
+	}
+	return f, u, nil
+}
+
+// FinishFileUpload reads back an uploaded object, records its SHA-256
+// checksum and size, and checks the client supplied content type against the
+// sniffed one. Only the user who started the upload can finish it, and only
+// once. Returns ErrForbidden for another user's file.
+func (s *Services) FinishFileUpload(ctx context.Context, user *User, id ID) (*File, error) {
+	if s.Files == nil {
+		return nil, fmt.Errorf("FinishFileUpload: no file store configured")
+	}
+	f, err := s.Repo.GetFile(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || f.CreatedByID == nil || *f.CreatedByID != user.ID {
+		return nil, ErrForbidden
+	}
+	if f.Status != FileStatusPending {
+		return nil, fmt.Errorf("FinishFileUpload: file %s is already uploaded", f.ID)
+	}
+	rc, err := s.Files.Get(ctx, f.ID.String())
+	if err != nil {
+		return nil, fmt.Errorf("FinishFileUpload: %w", err)
+	}
+	defer rc.Close()
+	size, sum, sniffed, err := inspectFile(rc)
+	if err != nil {
+		return nil, fmt.Errorf("FinishFileUpload: %w", err)
+	}
+	f.Size = size
+	f.SHA256 = sum
+	f.ContentType = sniffContentType(f.ContentType, sniffed)
+	if err := s.Repo.markFileUploaded(ctx, f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// FileDownloadURL returns a short-lived presigned URL for an attached file.
+// Returns ErrForbidden if user may not download it.
+func (s *Services) FileDownloadURL(ctx context.Context, user *User, f WorkFile) (string, error) {
+	if s.Files == nil {
+		return "", fmt.Errorf("FileDownloadURL: no file store configured")
+	}
+	if !f.CanDownload(user, time.Now(), s.Repo.Profiles.FileAccessKinds()) {
+		return "", ErrForbidden
+	}
+	u, err := s.Files.PresignGet(ctx, f.FileID.String(), f.Name, fileDownloadTTL)
+	if err != nil {
+		return "", fmt.Errorf("FileDownloadURL: %w", err)
+	}
+	return u, nil
+}
+
+// inspectFile streams r once, returning its size, hex SHA-256 checksum and
+// the content type sniffed from the first 512 bytes.
+func inspectFile(r io.Reader) (int64, string, string, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return 0, "", "", err
+	}
+	head = head[:n]
+	h := sha256.New()
+	h.Write(head)
+	rest, err := io.Copy(h, r)
+	if err != nil {
+		return 0, "", "", err
+	}
+	return int64(n) + rest, hex.EncodeToString(h.Sum(nil)), http.DetectContentType(head), nil
+}
+
+// sniffContentType picks between the declared and the sniffed content type.
+// The sniffer only knows a handful of formats and falls back to generic
+// types (office documents sniff as zip), so a declared type wins over a
+// generic sniffed one.
+func sniffContentType(declared, sniffed string) string {
+	switch sniffed {
+	case "application/octet-stream", "application/zip", "text/plain; charset=utf-8":
+		if declared != "" {
+			return declared
+		}
+	}
+	return sniffed
+}
@@ -0,0 +1,119 @@
+package bbl
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ugent-library/vo"
+)
+
+func TestInspectFile(t *testing.T) {
+	body := []byte("%PDF-1.7\n" + strings.Repeat("x", 1000))
+	size, sum, sniffed, err := inspectFile(bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != int64(len(body)) {
+		t.Errorf("size = %d, want %d", size, len(body))
+	}
+	want := sha256.Sum256(body)
+	if sum != hex.EncodeToString(want[:]) {
+		t.Errorf("sha256 = %s, want %x", sum, want)
+	}
+	if sniffed != "application/pdf" {
+		t.Errorf("sniffed = %q, want application/pdf", sniffed)
+	}
+
+	// Shorter than the sniff window.
+	size, _, _, err = inspectFile(strings.NewReader("hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != 2 {
+		t.Errorf("size = %d, want 2", size)
+	}
+}
+
+func TestSniffContentType(t *testing.T) {
+	tests := []struct {
+		declared, sniffed, want string
+	}{
+		{"application/octet-stream", "application/pdf", "application/pdf"},
+		{"application/pdf", "text/html; charset=utf-8", "text/html; charset=utf-8"},
+		{"text/csv", "text/plain; charset=utf-8", "text/csv"},
+		{"application/vnd.openxmlformats-officedocument.wordprocessingml.document", "application/zip", "application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+		{"", "application/octet-stream", "application/octet-stream"},
+	}
+	for _, tt := range tests {
+		if got := sniffContentType(tt.declared, tt.sniffed); got != tt.want {
+			t.Errorf("sniffContentType(%q, %q) = %q, want %q", tt.declared, tt.sniffed, got, tt.want)
+		}
+	}
+}
+
+func TestWorkFile_Access(t *testing.T) {
+	kinds := append(DefaultFileAccessKinds, FileAccessKind{Name: "members", Download: FileDownloadUsers})
+	now := time.Now()
+	until := now.Add(24 * time.Hour)
+	f := WorkFile{AccessKind: WorkFileAccessClosed, EmbargoUntil: &until, EmbargoAccessKind: WorkFileAccessOpen}
+
+	if got := f.AccessKindAt(now); got != WorkFileAccessClosed {
+		t.Errorf("during embargo = %q, want closed", got)
+	}
+	if got := f.AccessKindAt(until); got != WorkFileAccessOpen {
+		t.Errorf("after embargo = %q, want open", got)
+	}
+	if f.CanDownload(nil, now, kinds) {
+		t.Error("anonymous user can download embargoed file")
+	}
+	if !f.CanDownload(&User{Role: RoleCurator}, now, kinds) {
+		t.Error("curator cannot download embargoed file")
+	}
+	if !f.CanDownload(nil, until, kinds) {
+		t.Error("anonymous user cannot download file after embargo")
+	}
+
+	// Once lifted, AccessKind is authoritative again, e.g. after a curator
+	// closed the file.
+	lifted := f
+	lifted.EmbargoLiftedAt = &until
+	if got := lifted.AccessKindAt(until); got != WorkFileAccessClosed {
+		t.Errorf("after lift = %q, want closed", got)
+	}
+
+	members := WorkFile{AccessKind: "members"}
+	if members.CanDownload(nil, now, kinds) {
+		t.Error("anonymous user can download file for logged in users")
+	}
+	if !members.CanDownload(&User{Role: RoleUser}, now, kinds) {
+		t.Error("logged in user cannot download file for logged in users")
+	}
+	if (WorkFile{AccessKind: "unknown"}).CanDownload(&User{Role: RoleUser}, now, kinds) {
+		t.Error("user can download file with unknown access level")
+	}
+}
+
+func TestWorkFileValidation(t *testing.T) {
+	def := &FieldDef{Name: "files"}
+	until := time.Now()
+	errs := vo.Validate(ftWorkFile.validate([]WorkFile{
+		{FileID: newID(), Name: "ok.pdf", AccessKind: WorkFileAccessOpen},
+		{FileID: newID(), Name: "", AccessKind: "secret"},
+		{FileID: newID(), Name: "embargo.pdf", AccessKind: WorkFileAccessClosed, EmbargoUntil: &until},
+		{FileID: newID(), Name: "no-date.pdf", AccessKind: WorkFileAccessClosed, EmbargoAccessKind: WorkFileAccessOpen},
+	}, def)...)
+	for _, path := range []string{"files[1].name", "files[1].access_kind", "files[2].embargo_access_kind", "files[3].embargo_until"} {
+		if !hasError(errs, path, "") {
+			t.Errorf("expected error at %s, got %v", path, errs)
+		}
+	}
+	for _, e := range errs {
+		if strings.HasPrefix(e.Path, "files[0]") {
+			t.Errorf("unexpected error for valid file: %v", e)
+		}
+	}
+}
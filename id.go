@@ -68,6 +68,12 @@ func ParseID(s string) (ID, error) {
 	return id, nil
 }
 
+// NewID generates a new time-ordered ID, e.g. for a record created through
+// Update.
+func NewID() ID {
+	return newID()
+}
+
 // newID generates a time-ordered ID. Monotonic ordering reduces B-tree
 // fragmentation on sequential inserts.
 func newID() ID {
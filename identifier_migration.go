@@ -0,0 +1,244 @@
+package bbl
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// IdentifierChange is a stored identifier whose value differs from its
+// normalized form. If the value is not valid, Error is set instead of New
+// and the value is left as it is. If the normalized value equals another
+// identifier of the record from the same source or user, the identifier
+// is Removed instead.
+type IdentifierChange struct {
+	RecordType string `json:"record_type"`
+	RecordID   ID     `json:"record_id"`
+	Scheme     string `json:"scheme"`
+	Old        string `json:"old"`
+	New        string `json:"new,omitempty"`
+	Removed    bool   `json:"removed,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// identifierRecordType is a record type with an identifiers field.
+type identifierRecordType struct {
+	recordType   string
+	table        string
+	rebuildCache func(context.Context, pgx.Tx, []ID) error
+}
+
+var identifierRecordTypes = []identifierRecordType{
+	{RecordTypeWork, "bbl_works", rebuildWorkCache},
+	{RecordTypePerson, "bbl_people", rebuildPersonCache},
+	{RecordTypeProject, "bbl_projects", rebuildProjectCache},
+	{RecordTypeOrganization, "bbl_organizations", rebuildOrganizationCache},
+}
+
+// NormalizeStoredIdentifiers rewrites the stored identifiers of all records
+// to their normalized form, as NormalizeIdentifier returns it, and rebuilds
+// the cache of the records that change. It yields every identifier that
+// changes or is not valid. With dryRun nothing is written. Records are
+// processed in batches, each in its own transaction. The search index is
+// not updated, see Services.NormalizeStoredIdentifiersAndIndex.
+func (r *Repo) NormalizeStoredIdentifiers(ctx context.Context, dryRun bool) iter.Seq2[*IdentifierChange, error] {
+	return func(yield func(*IdentifierChange, error) bool) {
+		for _, t := range identifierRecordTypes {
+			var after ID
+			for {
+				changes, last, done, err := r.normalizeIdentifierBatch(ctx, t, after, dryRun)
+				if err != nil {
+					yield(nil, fmt.Errorf("NormalizeStoredIdentifiers: %w", err))
+					return
+				}
+				for _, c := range changes {
+					if !yield(c, nil) {
+						return
+					}
+				}
+				if done {
+					break
+				}
+				after = last
+			}
+		}
+	}
+}
+
+// normalizeIdentifierBatch normalizes the identifiers of the records after
+// the given one. A batch holds all identifiers of its records, so that the
+// ones that become equal can be removed.
+func (r *Repo) normalizeIdentifierBatch(ctx context.Context, t identifierRecordType, after ID, dryRun bool) ([]*IdentifierChange, ID, bool, error) {
+	const batchSize = 500
+
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, after, false, err
+	}
+	defer tx.Rollback(ctx)
+
+	idCol, table := entityIDCol(t.recordType), assertionsTable(t.recordType)
+	rows, err := tx.Query(ctx, fmt.Sprintf(`
+		SELECT DISTINCT %s FROM %s
+		WHERE field = 'identifiers' AND val IS NOT NULL AND %s > $1
+		ORDER BY 1
+		LIMIT $2`,
+		idCol, table, idCol),
+		after, batchSize)
+	if err != nil {
+		return nil, after, false, err
+	}
+	recordIDs, err := pgx.CollectRows(rows, pgx.RowTo[ID])
+	if err != nil {
+		return nil, after, false, err
+	}
+	if len(recordIDs) == 0 {
+		return nil, after, true, nil
+	}
+
+	rows, err = tx.Query(ctx, fmt.Sprintf(`
+		SELECT id, %s, coalesce(%s::text, user_id::text, ''),
+		       coalesce(val->>'scheme', ''), coalesce(val->>'val', '')
+		FROM %s
+		WHERE field = 'identifiers' AND val IS NOT NULL AND %s = ANY($1)
+		ORDER BY %s, id
+		FOR UPDATE`,
+		idCol, sourceIDCol(t.recordType), table, idCol, idCol),
+		recordIDs)
+	if err != nil {
+		return nil, after, false, err
+	}
+	type identifierRow struct {
+		id     int64
+		origin string // source record or user
+		change IdentifierChange
+	}
+	recs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (identifierRow, error) {
+		rec := identifierRow{change: IdentifierChange{RecordType: t.recordType}}
+		err := row.Scan(&rec.id, &rec.change.RecordID, &rec.origin, &rec.change.Scheme, &rec.change.Old)
+		return rec, err
+	})
+	if err != nil {
+		return nil, after, false, err
+	}
+
+	var changes []*IdentifierChange
+	var changedIDs []ID
+	type identifierKey struct {
+		recordID              ID
+		origin, scheme, value string
+	}
+	seen := make(map[identifierKey]bool)
+	batch := &pgx.Batch{}
+	for _, rec := range recs {
+		c := rec.change
+		v, err := NormalizeIdentifier(c.Scheme, c.Old)
+		if err != nil {
+			c.Error = err.Error()
+			changes = append(changes, &c)
+			continue
+		}
+		key := identifierKey{c.RecordID, rec.origin, c.Scheme, v}
+		if seen[key] {
+			c.Removed = true
+			batch.Queue(fmt.Sprintf(`DELETE FROM %s WHERE id = $1`, table), rec.id)
+		} else {
+			seen[key] = true
+			if v == c.Old {
+				continue
+			}
+			c.New = v
+			batch.Queue(fmt.Sprintf(`
+				UPDATE %s SET val = jsonb_set(val, '{val}', to_jsonb($2::text))
+				WHERE id = $1`,
+				table),
+				rec.id, v)
+		}
+		changes = append(changes, &c)
+		if !slices.Contains(changedIDs, c.RecordID) {
+			changedIDs = append(changedIDs, c.RecordID)
+		}
+	}
+
+	last := recordIDs[len(recordIDs)-1]
+	done := len(recordIDs) < batchSize
+	if dryRun || batch.Len() == 0 {
+		return changes, last, done, nil
+	}
+	// Bump the records, so that they are reindexed like other changes.
+	batch.Queue(fmt.Sprintf(`
+		UPDATE %s SET version = version + 1, updated_at = transaction_timestamp()
+		WHERE id = ANY($1)`,
+		t.table),
+		changedIDs)
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return nil, after, false, err
+	}
+	if err := t.rebuildCache(ctx, tx, changedIDs); err != nil {
+		return nil, after, false, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, after, false, err
+	}
+	return changes, last, done, nil
+}
+
+// NormalizeStoredIdentifiersAndIndex normalizes stored identifiers like
+// Repo.NormalizeStoredIdentifiers and best-effort reindexes the records
+// that change and refreshes their duplicate candidates once the run stops.
+func (s *Services) NormalizeStoredIdentifiersAndIndex(ctx context.Context, dryRun bool) iter.Seq2[*IdentifierChange, error] {
+	return func(yield func(*IdentifierChange, error) bool) {
+		before, err := s.Repo.now(ctx)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		changed := make(map[string]bool)
+		for c, err := range s.Repo.NormalizeStoredIdentifiers(ctx, dryRun) {
+			if err == nil && c.Error == "" {
+				changed[c.RecordType] = true
+			}
+			if !yield(c, err) || err != nil {
+				break
+			}
+		}
+		if dryRun {
+			return
+		}
+		// Earlier batches are committed even if the run failed.
+		if changed[RecordTypeWork] {
+			indexSince(s, ctx, before, func(ctx context.Context, since time.Time) iter.Seq2[*Work, error] {
+				return s.Repo.EachWorkSince(ctx, since)
+			}, func(ctx context.Context, w *Work) error {
+				return s.Index.Works().Add(ctx, w)
+			})
+			s.detectDuplicatesSince(ctx, RecordTypeWork, before)
+		}
+		if changed[RecordTypePerson] {
+			indexSince(s, ctx, before, func(ctx context.Context, since time.Time) iter.Seq2[*Person, error] {
+				return s.Repo.EachPersonSince(ctx, since)
+			}, func(ctx context.Context, p *Person) error {
+				return s.Index.People().Add(ctx, p)
+			})
+			s.detectDuplicatesSince(ctx, RecordTypePerson, before)
+		}
+		if changed[RecordTypeProject] {
+			indexSince(s, ctx, before, func(ctx context.Context, since time.Time) iter.Seq2[*Project, error] {
+				return s.Repo.EachProjectSince(ctx, since)
+			}, func(ctx context.Context, p *Project) error {
+				return s.Index.Projects().Add(ctx, p)
+			})
+		}
+		if changed[RecordTypeOrganization] {
+			indexSince(s, ctx, before, func(ctx context.Context, since time.Time) iter.Seq2[*Organization, error] {
+				return s.Repo.EachOrganizationSince(ctx, since)
+			}, func(ctx context.Context, o *Organization) error {
+				return s.Index.Organizations().Add(ctx, o)
+			})
+		}
+	}
+}
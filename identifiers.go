@@ -0,0 +1,267 @@
+package bbl
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// identifierNormalizers return the canonical form of an identifier value of
+// their scheme, or "" if the value is not valid. Values of other schemes
+// are only trimmed.
+var identifierNormalizers = map[string]func(string) string{
+	"doi":    normalizeDOI,
+	"isbn":   normalizeISBN,
+	"issn":   normalizeISSN,
+	"orcid":  normalizeORCID,
+	"pubmed": normalizePMID,
+	"pmid":   normalizePMID,
+	"arxiv":  normalizeArxiv,
+	"handle": normalizeHandle,
+	"ror":    normalizeROR,
+}
+
+// NormalizeIdentifier returns the canonical form of an identifier value:
+//
+//   - doi: lowercase, without resolver URL or doi: prefix (10.1000/abc)
+//   - isbn: ISBN-13 without hyphens; ISBN-10s are converted
+//   - issn: hyphenated, uppercase check digit (1234-567X)
+//   - orcid: hyphenated, without resolver URL (0000-0002-1825-0097)
+//   - pubmed, pmid: digits without leading zeros
+//   - arxiv: without abs URL or arXiv: prefix (2101.00001v2, math.GT/0309136)
+//   - handle: without resolver URL or hdl: prefix (1854/LU-123)
+//   - ror: resolver URL (https://ror.org/00cv9y106)
+//
+// Check digits are verified where the scheme has them. It returns
+// ErrInvalidIdentifier if the value is not valid. Values of other schemes
+// are returned trimmed.
+func NormalizeIdentifier(scheme, val string) (string, error) {
+	normalize, ok := identifierNormalizers[strings.ToLower(scheme)]
+	if !ok {
+		return strings.TrimSpace(val), nil
+	}
+	v := normalize(val)
+	if v == "" {
+		return "", fmt.Errorf("%w: %s %q", ErrInvalidIdentifier, scheme, val)
+	}
+	return v, nil
+}
+
+// normalizeIdentifiers returns identifiers with their values normalized.
+// Invalid values are kept as they are, validation reports them. Identifiers
+// that become equal are dropped.
+func normalizeIdentifiers(ids []Identifier) []Identifier {
+	if len(ids) == 0 {
+		return ids
+	}
+	out := make([]Identifier, 0, len(ids))
+	for _, id := range ids {
+		if v, err := NormalizeIdentifier(id.Scheme, id.Val); err == nil {
+			id.Val = v
+		}
+		if !slices.Contains(out, id) {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// stripPrefix removes the first of prefixes s starts with, ignoring case.
+func stripPrefix(s string, prefixes ...string) string {
+	for _, prefix := range prefixes {
+		if len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix) {
+			return strings.TrimSpace(s[len(prefix):])
+		}
+	}
+	return s
+}
+
+var reDOI = regexp.MustCompile(`^10\.\d{4,9}(\.\d+)*/\S+$`)
+
+func normalizeDOI(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = stripPrefix(s, "https://doi.org/", "http://doi.org/", "https://dx.doi.org/", "http://dx.doi.org/", "doi:")
+	if !reDOI.MatchString(s) {
+		return ""
+	}
+	return s
+}
+
+// isbnDigits returns the digits of an ISBN, without hyphens or spaces.
+func isbnDigits(s string) []byte {
+	s = stripPrefix(strings.TrimSpace(s), "isbn:", "isbn")
+	var b []byte
+	for _, r := range strings.ToUpper(s) {
+		switch {
+		case r >= '0' && r <= '9', r == 'X':
+			b = append(b, byte(r))
+		case r == '-', r == ' ':
+		default:
+			return nil
+		}
+	}
+	return b
+}
+
+// normalizeISBN returns the ISBN-13 form of a valid ISBN-10 or ISBN-13.
+func normalizeISBN(s string) string {
+	b := isbnDigits(s)
+	switch len(b) {
+	case 13:
+		if !validISBN13(b) {
+			return ""
+		}
+		return string(b)
+	case 10:
+		if !validISBN10(b) {
+			return ""
+		}
+		b = append([]byte("978"), b[:9]...)
+		return string(append(b, isbn13Check(b)))
+	}
+	return ""
+}
+
+func validISBN10(b []byte) bool {
+	var sum int
+	for i, c := range b {
+		d := int(c - '0')
+		if c == 'X' {
+			if i != 9 {
+				return false
+			}
+			d = 10
+		}
+		sum += (10 - i) * d
+	}
+	return sum%11 == 0
+}
+
+func validISBN13(b []byte) bool {
+	if slices.Contains(b, 'X') {
+		return false
+	}
+	return isbn13Check(b[:12]) == b[12]
+}
+
+func isbn13Check(b []byte) byte {
+	var sum int
+	for i, c := range b {
+		d := int(c - '0')
+		if i%2 == 1 {
+			d *= 3
+		}
+		sum += d
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+// checkChar returns the character of a mod 11 check digit.
+func checkChar(d int) byte {
+	if d == 10 {
+		return 'X'
+	}
+	return byte('0' + d)
+}
+
+var reISSN = regexp.MustCompile(`^(\d{4})-?(\d{3}[\dX])$`)
+
+func normalizeISSN(s string) string {
+	s = stripPrefix(strings.ToUpper(strings.TrimSpace(s)), "ISSN:", "ISSN")
+	m := reISSN.FindStringSubmatch(s)
+	if m == nil {
+		return ""
+	}
+	b := m[1] + m[2]
+	var sum int
+	for i := range 7 {
+		sum += (8 - i) * int(b[i]-'0')
+	}
+	if b[7] != checkChar((11-sum%11)%11) {
+		return ""
+	}
+	return m[1] + "-" + m[2]
+}
+
+var reORCID = regexp.MustCompile(`^(\d{4})-?(\d{4})-?(\d{4})-?(\d{3}[\dX])$`)
+
+func normalizeORCID(s string) string {
+	s = stripPrefix(strings.ToUpper(strings.TrimSpace(s)), "https://orcid.org/", "http://orcid.org/", "orcid.org/", "orcid:")
+	m := reORCID.FindStringSubmatch(s)
+	if m == nil {
+		return ""
+	}
+	b := m[1] + m[2] + m[3] + m[4]
+	// ISO 7064 MOD 11-2.
+	var total int
+	for _, c := range b[:15] {
+		total = (total + int(c-'0')) * 2
+	}
+	if b[15] != checkChar((12-total%11)%11) {
+		return ""
+	}
+	return m[1] + "-" + m[2] + "-" + m[3] + "-" + m[4]
+}
+
+func normalizePMID(s string) string {
+	s = stripPrefix(strings.TrimSpace(s), "https://pubmed.ncbi.nlm.nih.gov/", "pmid:", "pubmed:")
+	s = strings.TrimLeft(strings.TrimSuffix(s, "/"), "0")
+	if s == "" {
+		return ""
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return ""
+		}
+	}
+	return s
+}
+
+var reArxiv = regexp.MustCompile(`^(?i:(\d{4}\.\d{4,5})|([a-z][a-z\-]*)(\.[a-z]{2})?/(\d{7}))(?i:(v\d+))?$`)
+
+// normalizeArxiv returns a new style arXiv ID as is and an old style one
+// with a lowercase archive and an uppercase subject class.
+func normalizeArxiv(s string) string {
+	s = stripPrefix(strings.TrimSpace(s), "https://arxiv.org/abs/", "http://arxiv.org/abs/", "arxiv:")
+	m := reArxiv.FindStringSubmatch(s)
+	if m == nil {
+		return ""
+	}
+	version := strings.ToLower(m[5])
+	if m[1] != "" {
+		return m[1] + version
+	}
+	return strings.ToLower(m[2]) + strings.ToUpper(m[3]) + "/" + m[4] + version
+}
+
+var reHandle = regexp.MustCompile(`^\d+(\.\d+)*/\S+$`)
+
+func normalizeHandle(s string) string {
+	s = stripPrefix(strings.TrimSpace(s), "https://hdl.handle.net/", "http://hdl.handle.net/", "hdl:", "info:hdl/")
+	if !reHandle.MatchString(s) {
+		return ""
+	}
+	return s
+}
+
+const rorAlphabet = "0123456789abcdefghjkmnpqrstvwxyz"
+
+var reROR = regexp.MustCompile(`^0[` + rorAlphabet + `]{6}\d{2}$`)
+
+func normalizeROR(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = stripPrefix(s, "https://ror.org/", "http://ror.org/", "ror.org/")
+	if !reROR.MatchString(s) {
+		return ""
+	}
+	// ISO 7064 MOD 97-10 over the base32 value of the first 7 characters.
+	var n int
+	for _, c := range s[:7] {
+		n = n*32 + strings.IndexRune(rorAlphabet, c)
+	}
+	if fmt.Sprintf("%02d", 98-(n*100)%97) != s[7:] {
+		return ""
+	}
+	return "https://ror.org/" + s
+}
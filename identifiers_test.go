@@ -0,0 +1,65 @@
+package bbl
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizeIdentifier(t *testing.T) {
+	tests := []struct {
+		scheme, val, want string
+	}{
+		{"doi", "https://doi.org/10.1000/ABC.1", "10.1000/abc.1"},
+		{"doi", " DOI:10.1000/abc.1", "10.1000/abc.1"},
+		{"doi", "10.abc/1", ""},
+		{"isbn", "0-306-40615-2", "9780306406157"},
+		{"isbn", "ISBN 978-0-306-40615-7", "9780306406157"},
+		{"isbn", "080442957X", "9780804429573"},
+		{"isbn", "978-0-306-40615-8", ""},
+		{"isbn", "0-306-40615-3", ""},
+		{"issn", "0317-8471", "0317-8471"},
+		{"issn", "2434561x", "2434-561X"},
+		{"issn", "1234-5678", ""},
+		{"orcid", "https://orcid.org/0000-0002-1825-0097", "0000-0002-1825-0097"},
+		{"orcid", "000000021694233x", "0000-0002-1694-233X"},
+		{"orcid", "0000-0002-1825-0098", ""},
+		{"pubmed", "PMID: 000123", "123"},
+		{"pmid", "https://pubmed.ncbi.nlm.nih.gov/36912345/", "36912345"},
+		{"pubmed", "12a", ""},
+		{"arxiv", "arXiv:2101.00001V2", "2101.00001v2"},
+		{"arxiv", "https://arxiv.org/abs/Math.gt/0309136", "math.GT/0309136"},
+		{"arxiv", "2101.1", ""},
+		{"handle", "https://hdl.handle.net/1854/LU-123", "1854/LU-123"},
+		{"handle", "hdl:1854", ""},
+		{"ror", "00CV9Y106", "https://ror.org/00cv9y106"},
+		{"ror", "https://ror.org/00cv9y107", ""},
+		{"mesh", " D055864 ", "D055864"},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeIdentifier(tt.scheme, tt.val)
+		if tt.want == "" {
+			if !errors.Is(err, ErrInvalidIdentifier) {
+				t.Errorf("NormalizeIdentifier(%q, %q) = %q, %v; want ErrInvalidIdentifier", tt.scheme, tt.val, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("NormalizeIdentifier(%q, %q) = %q, %v; want %q", tt.scheme, tt.val, got, err, tt.want)
+		}
+	}
+}
+
+func TestNormalizeIdentifiers(t *testing.T) {
+	got := normalizeIdentifiers([]Identifier{
+		{Scheme: "doi", Val: "10.1000/abc"},
+		{Scheme: "doi", Val: "https://doi.org/10.1000/ABC"},
+		{Scheme: "isbn", Val: "not an isbn"},
+	})
+	want := []Identifier{
+		{Scheme: "doi", Val: "10.1000/abc"},
+		{Scheme: "isbn", Val: "not an isbn"},
+	}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("normalizeIdentifiers = %v, want %v", got, want)
+	}
+}
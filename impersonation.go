@@ -0,0 +1,105 @@
+package bbl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// User event kinds.
+const (
+	UserEventImpersonationStarted = "impersonation_started"
+	UserEventImpersonationStopped = "impersonation_stopped"
+)
+
+// UserEvent is an audit log entry about a user. PerformedByID is the user
+// who caused the event, if not the user itself.
+type UserEvent struct {
+	ID            ID              `json:"id"`
+	UserID        ID              `json:"user_id"`
+	Kind          string          `json:"kind"`
+	PerformedByID *ID             `json:"performed_by_id,omitempty"`
+	Payload       json.RawMessage `json:"payload,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// CanImpersonate reports whether admin may act as user. Only admins can
+// impersonate, and only users who are not admins themselves.
+func CanImpersonate(admin, user *User) bool {
+	return admin != nil && user != nil &&
+		admin.Role == RoleAdmin &&
+		user.Role != RoleAdmin &&
+		admin.ID != user.ID
+}
+
+// Impersonate returns a copy of user that acts on behalf of admin. Revisions
+// written with it record admin as the impersonator.
+func Impersonate(admin, user *User) *User {
+	u := *user
+	u.ImpersonatorID = &admin.ID
+	return &u
+}
+
+// StartImpersonation logs that admin started acting as user. Returns
+// ErrForbidden if admin may not impersonate user.
+func (r *Repo) StartImpersonation(ctx context.Context, admin, user *User) error {
+	if !CanImpersonate(admin, user) {
+		return ErrForbidden
+	}
+	if err := r.AddUserEvent(ctx, user.ID, UserEventImpersonationStarted, &admin.ID); err != nil {
+		return fmt.Errorf("StartImpersonation: %w", err)
+	}
+	return nil
+}
+
+// StopImpersonation logs that admin stopped acting as user.
+func (r *Repo) StopImpersonation(ctx context.Context, admin, user *User) error {
+	if err := r.AddUserEvent(ctx, user.ID, UserEventImpersonationStopped, &admin.ID); err != nil {
+		return fmt.Errorf("StopImpersonation: %w", err)
+	}
+	return nil
+}
+
+// AddUserEvent appends an event to the audit log of a user.
+func (r *Repo) AddUserEvent(ctx context.Context, userID ID, kind string, performedByID *ID) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO bbl_user_events (id, user_id, kind, performed_by_id)
+		VALUES ($1, $2, $3, $4)`,
+		newID(), userID, kind, performedByID)
+	if err != nil {
+		return fmt.Errorf("AddUserEvent: %w", err)
+	}
+	return nil
+}
+
+// GetUserEvents returns the audit log of a user, oldest first.
+func (r *Repo) GetUserEvents(ctx context.Context, userID ID) ([]UserEvent, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, user_id, kind, performed_by_id, payload, created_at
+		FROM bbl_user_events
+		WHERE user_id = $1
+		ORDER BY created_at, id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("GetUserEvents: %w", err)
+	}
+	events, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (UserEvent, error) {
+		var e UserEvent
+		var performedByID pgtype.UUID
+		if err := row.Scan(&e.ID, &e.UserID, &e.Kind, &performedByID, &e.Payload, &e.CreatedAt); err != nil {
+			return e, err
+		}
+		if performedByID.Valid {
+			id := ID(performedByID.Bytes)
+			e.PerformedByID = &id
+		}
+		return e, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetUserEvents: %w", err)
+	}
+	return events, nil
+}
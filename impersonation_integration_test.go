@@ -0,0 +1,65 @@
+package bbl
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestImpersonation(t *testing.T) {
+	repo := testRepo(t)
+	ctx := context.Background()
+	admin := createTestUser(t, repo, RoleAdmin)
+	user := createTestUser(t, repo, RoleUser)
+
+	if err := repo.StartImpersonation(ctx, user, admin); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("user impersonating admin: got %v, want ErrForbidden", err)
+	}
+	if err := repo.StartImpersonation(ctx, admin, user); err != nil {
+		t.Fatal(err)
+	}
+
+	// Revisions record both the impersonated user and the admin.
+	workID := newID()
+	if _, _, err := repo.Update(ctx, Impersonate(admin, user),
+		&CreateWork{ID: workID, Kind: "journal_article"},
+		&Set{RecordType: "work", RecordID: workID, Field: "keywords", Val: []Keyword{{Val: "a"}}},
+	); err != nil {
+		t.Fatal(err)
+	}
+	history, err := repo.GetWorkHistory(ctx, workID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) == 0 {
+		t.Fatal("expected history entries")
+	}
+	for _, e := range history {
+		if e.RevUserID == nil || *e.RevUserID != user.ID {
+			t.Errorf("%s: RevUserID = %v, want %v", e.Field, e.RevUserID, user.ID)
+		}
+		if e.RevImpersonatorID == nil || *e.RevImpersonatorID != admin.ID {
+			t.Errorf("%s: RevImpersonatorID = %v, want %v", e.Field, e.RevImpersonatorID, admin.ID)
+		}
+	}
+
+	if err := repo.StopImpersonation(ctx, admin, user); err != nil {
+		t.Fatal(err)
+	}
+	events, err := repo.GetUserEvents(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantKinds := []string{UserEventImpersonationStarted, UserEventImpersonationStopped}
+	if len(events) != len(wantKinds) {
+		t.Fatalf("got %d events, want %d", len(events), len(wantKinds))
+	}
+	for i, e := range events {
+		if e.Kind != wantKinds[i] {
+			t.Errorf("event %d: kind = %q, want %q", i, e.Kind, wantKinds[i])
+		}
+		if e.PerformedByID == nil || *e.PerformedByID != admin.ID {
+			t.Errorf("event %d: performed_by_id = %v, want %v", i, e.PerformedByID, admin.ID)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package bbl
+
+import "testing"
+
+func TestCanImpersonate(t *testing.T) {
+	admin := &User{ID: newID(), Role: RoleAdmin}
+	otherAdmin := &User{ID: newID(), Role: RoleAdmin}
+	curator := &User{ID: newID(), Role: RoleCurator}
+	user := &User{ID: newID(), Role: RoleUser}
+
+	tests := []struct {
+		name  string
+		admin *User
+		user  *User
+		want  bool
+	}{
+		{"admin as user", admin, user, true},
+		{"admin as curator", admin, curator, true},
+		{"admin as admin", admin, otherAdmin, false},
+		{"admin as self", admin, admin, false},
+		{"curator as user", curator, user, false},
+		{"nil admin", nil, user, false},
+	}
+	for _, tt := range tests {
+		if got := CanImpersonate(tt.admin, tt.user); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestImpersonate(t *testing.T) {
+	admin := &User{ID: newID(), Role: RoleAdmin}
+	user := &User{ID: newID(), Role: RoleUser}
+
+	u := Impersonate(admin, user)
+	if u.ID != user.ID || u.Role != RoleUser {
+		t.Errorf("impersonated user should keep the identity and role of user")
+	}
+	if u.ImpersonatorID == nil || *u.ImpersonatorID != admin.ID {
+		t.Errorf("ImpersonatorID: got %v, want %v", u.ImpersonatorID, admin.ID)
+	}
+	if user.ImpersonatorID != nil {
+		t.Error("Impersonate should not modify user")
+	}
+}
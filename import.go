@@ -2,12 +2,125 @@ package bbl
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"iter"
+	"strings"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/ugent-library/vo"
 )
 
+var (
+	errEmptyRef      = errors.New("empty ref")
+	errInvalidRecord = errors.New("invalid record")
+)
+
+// isRecordFailure reports whether an import error is due to the record
+// itself: it is invalid or fails validation, one of its refs doesn't
+// resolve, or the database rejects one of its values.
+func isRecordFailure(err error) bool {
+	var verrs vo.Errors
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.Is(err, pgx.ErrNoRows), errors.Is(err, errEmptyRef),
+		errors.Is(err, errInvalidRecord), errors.Is(err, ErrInvalidIdentifier),
+		errors.As(err, &verrs):
+		return true
+	case errors.As(err, &pgErr):
+		// Data exceptions and integrity constraint violations, like text
+		// with a NUL byte or a value a check constraint doesn't allow.
+		return strings.HasPrefix(pgErr.Code, "22") || strings.HasPrefix(pgErr.Code, "23")
+	}
+	return false
+}
+
+// ImportFailure is a record that was left out of an import because it
+// failed validation or a ref didn't resolve.
+type ImportFailure struct {
+	SourceID string `json:"source_id"`
+	Reason   string `json:"reason"`
+}
+
+// ImportOpts controls a long running import.
+type ImportOpts struct {
+	// Skip is the number of records at the start of seq that an earlier,
+	// interrupted run already processed.
+	Skip int
+	// OnFailure is called with the records that fail validation or ref
+	// resolution once their batch is committed; the import continues
+	// with the other records. If nil, such a record aborts the import.
+	OnFailure func(*ImportFailure) error
+	// OnCheckpoint is called after each committed batch with the number
+	// of records of seq processed so far, including skipped ones. Pass it
+	// as Skip to resume.
+	OnCheckpoint func(processed int) error
+}
+
+// ImportResult counts the records of an import.
+type ImportResult struct {
+	Imported int // records that resulted in a create or update
+	Failed   int // records left out, see ImportOpts.OnFailure
+	Skipped  int // records skipped to resume an earlier run
+}
+
+// importBatches imports seq in batches with importBatch, which returns the
+// number of records imported and the records left out. The result is never
+// nil.
+func importBatches[T any](seq iter.Seq2[T, error], opts ImportOpts, importBatch func([]T) (int, []*ImportFailure, error)) (*ImportResult, error) {
+	const batchSize = 250
+	var pending []T
+	var processed int
+	res := &ImportResult{}
+
+	flush := func() error {
+		n, failures, err := importBatch(pending)
+		res.Imported += n
+		if err != nil {
+			return err
+		}
+		processed += len(pending)
+		pending = pending[:0]
+		res.Failed += len(failures)
+		for _, f := range failures {
+			if err := opts.OnFailure(f); err != nil {
+				return err
+			}
+		}
+		if opts.OnCheckpoint != nil {
+			if err := opts.OnCheckpoint(processed); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for in, err := range seq {
+		if err != nil {
+			return res, err
+		}
+		if res.Skipped < opts.Skip {
+			res.Skipped++
+			processed++
+			continue
+		}
+		pending = append(pending, in)
+		if len(pending) == batchSize {
+			if err := flush(); err != nil {
+				return res, err
+			}
+		}
+	}
+	if len(pending) > 0 {
+		if err := flush(); err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
+
 // refSubquery builds the subquery part of a ref resolution.
 func refSubquery(ref Ref, source, entityTable, sourcesTable, sourceFK, assertionsTable, entityIDCol string) (string, []any, error) {
 	switch {
@@ -16,11 +129,15 @@ func refSubquery(ref Ref, source, entityTable, sourcesTable, sourceFK, assertion
 	case ref.SourceID != "":
 		return fmt.Sprintf(`SELECT %s FROM %s WHERE source = $1 AND source_id = $2`, sourceFK, sourcesTable), []any{source, ref.SourceID}, nil
 	case ref.Identifier != nil:
+		val := ref.Identifier.Val
+		if v, err := NormalizeIdentifier(ref.Identifier.Scheme, val); err == nil {
+			val = v
+		}
 		return fmt.Sprintf(
 			`SELECT %s FROM %s WHERE field = 'identifiers' AND val->>'scheme' = $1 AND val->>'val' = $2 LIMIT 1`,
-			entityIDCol, assertionsTable), []any{ref.Identifier.Scheme, ref.Identifier.Val}, nil
+			entityIDCol, assertionsTable), []any{ref.Identifier.Scheme, val}, nil
 	default:
-		return "", nil, fmt.Errorf("empty ref")
+		return "", nil, errEmptyRef
 	}
 }
 
@@ -157,12 +274,17 @@ func autoPinRecord(ctx context.Context, tx pgx.Tx, rt string, recordID ID, prior
 	return results.Close()
 }
 
-// deleteSourceAssertions deletes all assertions linked to a source record.
+// deleteSourceAssertions deletes all assertions linked to a source record,
+// except those for keepFields.
 // CASCADE on the assertions table handles relation table cleanup.
-func deleteSourceAssertions(ctx context.Context, tx pgx.Tx, assertionsTable, sourceIDCol string, sourceRecordID ID) error {
-	if _, err := tx.Exec(ctx, fmt.Sprintf(
-		`DELETE FROM %s WHERE %s = $1`, assertionsTable, sourceIDCol),
-		sourceRecordID); err != nil {
+func deleteSourceAssertions(ctx context.Context, tx pgx.Tx, assertionsTable, sourceIDCol string, sourceRecordID ID, keepFields ...string) error {
+	q := fmt.Sprintf(`DELETE FROM %s WHERE %s = $1`, assertionsTable, sourceIDCol)
+	args := []any{sourceRecordID}
+	if len(keepFields) > 0 {
+		q += ` AND field <> ALL($2)`
+		args = append(args, keepFields)
+	}
+	if _, err := tx.Exec(ctx, q, args...); err != nil {
 		return fmt.Errorf("deleteSourceAssertions(%s): %w", assertionsTable, err)
 	}
 	return nil
@@ -240,7 +362,7 @@ func rebuildWorkCache(ctx context.Context, tx pgx.Tx, workIDs []ID) error {
 	_, err := tx.Exec(ctx, `
 		UPDATE bbl_works w
 		SET cache = json_build_object(
-			'str_fields', (SELECT json_agg(json_build_object('field', a.field, 'val', a.val) ORDER BY a.field) FROM bbl_work_assertions a WHERE a.work_id = w.id AND a.pinned = true AND NOT a.hidden AND a.field NOT IN ('identifiers', 'classifications', 'titles', 'abstracts', 'lay_summaries', 'notes', 'keywords', 'contributors', 'projects', 'organizations', 'rels')),
+			'str_fields', (SELECT json_agg(json_build_object('field', a.field, 'val', a.val) ORDER BY a.field) FROM bbl_work_assertions a WHERE a.work_id = w.id AND a.pinned = true AND NOT a.hidden AND a.field NOT IN ('identifiers', 'classifications', 'titles', 'abstracts', 'lay_summaries', 'notes', 'keywords', 'contributors', 'projects', 'organizations', 'rels', 'files')),
 			'identifiers', (SELECT json_agg(a.val ORDER BY a.id) FROM bbl_work_assertions a WHERE a.work_id = w.id AND a.field = 'identifiers' AND a.pinned = true AND NOT a.hidden),
 			'classifications', (SELECT json_agg(a.val ORDER BY a.id) FROM bbl_work_assertions a WHERE a.work_id = w.id AND a.field = 'classifications' AND a.pinned = true AND NOT a.hidden),
 			'titles', (SELECT json_agg(a.val ORDER BY a.id) FROM bbl_work_assertions a WHERE a.work_id = w.id AND a.field = 'titles' AND a.pinned = true AND NOT a.hidden),
@@ -252,6 +374,7 @@ func rebuildWorkCache(ctx context.Context, tx pgx.Tx, workIDs []ID) error {
 			'projects', (SELECT json_agg(p.project_id ORDER BY a.id) FROM bbl_work_assertions a JOIN bbl_work_assertion_projects p ON p.assertion_id = a.id WHERE a.work_id = w.id AND a.field = 'projects' AND a.pinned = true AND NOT a.hidden),
 			'organizations', (SELECT json_agg(o.organization_id ORDER BY a.id) FROM bbl_work_assertions a JOIN bbl_work_assertion_organizations o ON o.assertion_id = a.id WHERE a.work_id = w.id AND a.field = 'organizations' AND a.pinned = true AND NOT a.hidden),
 			'rels', (SELECT json_agg(json_build_object('related_work_id', r.related_work_id, 'kind', r.kind) ORDER BY a.id) FROM bbl_work_assertions a JOIN bbl_work_assertion_rels r ON r.assertion_id = a.id WHERE a.work_id = w.id AND a.field = 'rels' AND a.pinned = true AND NOT a.hidden),
+			'files', (SELECT json_agg(json_build_object('val', a.val, 'file_id', f.id, 'content_type', f.content_type, 'size', f.size, 'sha256', f.sha256) ORDER BY a.id) FROM bbl_work_assertions a JOIN bbl_work_assertion_files af ON af.assertion_id = a.id JOIN bbl_files f ON f.id = af.file_id WHERE a.work_id = w.id AND a.field = 'files' AND a.pinned = true AND NOT a.hidden),
 			'assertions_info', (SELECT json_object_agg(sub.field, sub.infos) FROM (SELECT a.field, json_agg(json_build_object('human', a.user_id IS NOT NULL, 'role', a.role, 'hidden', a.hidden, 'pinned', a.pinned, 'source', s.source) ORDER BY a.id) AS infos FROM bbl_work_assertions a LEFT JOIN bbl_work_sources s ON s.id = a.work_source_id WHERE a.work_id = w.id AND a.pinned = true GROUP BY a.field) sub)
 		)
 		WHERE w.id = ANY($1)`, dedupIDs(workIDs))
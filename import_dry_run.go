@@ -0,0 +1,286 @@
+package bbl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"maps"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Actions an import would take on a record, as reported by a dry run.
+const (
+	ImportActionNew       = "new"
+	ImportActionMatched   = "matched"   // new to the source, attached to an existing work
+	ImportActionUpdated   = "updated"   // pinned values change
+	ImportActionUnchanged = "unchanged" // pinned values stay the same
+	ImportActionWithdrawn = "withdrawn" // the source reports the record as deleted
+	ImportActionRefFailed = "ref_failed"
+	ImportActionFailed    = "failed"
+)
+
+// ImportDiff reports what an import would do with a source record. Fields
+// lists the pinned values of the record that would change; for a new
+// record, all of them. ID is not set for new records. Matches lists the
+// existing works a new work would be flagged as a possible duplicate of.
+type ImportDiff struct {
+	SourceID string      `json:"source_id"`
+	ID       *ID         `json:"id,omitempty"`
+	Action   string      `json:"action"`
+	Fields   []FieldDiff `json:"fields,omitempty"`
+	Matches  []ID        `json:"matches,omitempty"`
+	Error    string      `json:"error,omitempty"`
+}
+
+// dryRunImporter adapts the import of a record type to a dry run.
+type dryRunImporter[T any] struct {
+	recordType string
+	sourceID   func(T) string
+	// match returns the existing record a record that is new to the
+	// source would be attached to, if any.
+	match func(ctx context.Context, tx pgx.Tx, in T) (*ID, error)
+	// importRecord imports a single record in tx. ok is false if nothing
+	// was written; it may set d.Action to override the action derived
+	// from the diff.
+	importRecord func(ctx context.Context, tx pgx.Tx, in T, revID int64, priorities map[string]int, d *ImportDiff) (id ID, ok bool, err error)
+}
+
+// DryRunImportWorks runs the import of seq as ImportWorks would, in a
+// transaction that is rolled back, and yields a diff for every record.
+// A record that fails is reported instead of aborting the run. The works
+// it touches stay locked until the run ends.
+func (r *Repo) DryRunImportWorks(ctx context.Context, source string, seq iter.Seq2[*ImportWorkInput, error]) iter.Seq2[*ImportDiff, error] {
+	return dryRunImport(ctx, r, source, seq, dryRunImporter[*ImportWorkInput]{
+		recordType: RecordTypeWork,
+		sourceID:   func(in *ImportWorkInput) string { return in.SourceID },
+		match: func(ctx context.Context, tx pgx.Tx, in *ImportWorkInput) (*ID, error) {
+			if in.Deleted || in.ID != nil {
+				return nil, nil
+			}
+			m, err := matchWork(ctx, tx, source, in)
+			if err != nil || !m.attach {
+				return nil, err
+			}
+			return &m.workIDs[0], nil
+		},
+		importRecord: func(ctx context.Context, tx pgx.Tx, in *ImportWorkInput, revID int64, priorities map[string]int, d *ImportDiff) (ID, bool, error) {
+			if in.Deleted {
+				workID, ok, err := withdrawWorkSourceRecord(ctx, tx, source, in.SourceID, priorities)
+				if ok {
+					d.Action = ImportActionWithdrawn
+				}
+				return workID, ok, err
+			}
+			res, err := r.importWorkRecord(ctx, tx, source, in, revID, priorities)
+			if res.matched {
+				d.Action = ImportActionMatched
+			}
+			d.Matches = res.matches
+			return res.workID, err == nil, err
+		},
+	})
+}
+
+// DryRunImportPeople runs the import of seq as ImportPeople would, in a
+// transaction that is rolled back, and yields a diff for every record.
+// A record that fails is reported instead of aborting the run. The people
+// it touches stay locked until the run ends.
+func (r *Repo) DryRunImportPeople(ctx context.Context, source string, seq iter.Seq2[*ImportPersonInput, error]) iter.Seq2[*ImportDiff, error] {
+	return dryRunImport(ctx, r, source, seq, dryRunImporter[*ImportPersonInput]{
+		recordType: RecordTypePerson,
+		sourceID:   func(in *ImportPersonInput) string { return in.SourceID },
+		match: func(context.Context, pgx.Tx, *ImportPersonInput) (*ID, error) {
+			return nil, nil
+		},
+		importRecord: func(ctx context.Context, tx pgx.Tx, in *ImportPersonInput, revID int64, priorities map[string]int, d *ImportDiff) (ID, bool, error) {
+			personID, err := r.importPersonRecord(ctx, tx, source, in, revID, priorities)
+			return personID, err == nil, err
+		},
+	})
+}
+
+// dryRunImport runs the whole import in a single transaction that is rolled
+// back, so that records see the effect of the records before them as in a
+// real run, whatever the number of records. Diffs are yielded as the records
+// are processed. The row locks the import takes are held until the run
+// ends; stop iterating to end it early.
+func dryRunImport[T any](ctx context.Context, r *Repo, source string, seq iter.Seq2[T, error], imp dryRunImporter[T]) iter.Seq2[*ImportDiff, error] {
+	return func(yield func(*ImportDiff, error) bool) {
+		tx, err := r.db.BeginTx(ctx, pgx.TxOptions{})
+		if err != nil {
+			yield(nil, fmt.Errorf("DryRunImport: %w", err))
+			return
+		}
+		// Nothing is ever committed.
+		defer tx.Rollback(ctx)
+
+		// The source may not go live before the dry run.
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO bbl_sources (id) VALUES ($1)
+			ON CONFLICT (id) DO NOTHING`,
+			source); err != nil {
+			yield(nil, fmt.Errorf("DryRunImport: %w", err))
+			return
+		}
+		priorities, err := fetchSourcePriorities(ctx, tx)
+		if err != nil {
+			yield(nil, fmt.Errorf("DryRunImport: %w", err))
+			return
+		}
+		var revID int64
+		if err := tx.QueryRow(ctx, `
+			INSERT INTO bbl_revs (source) VALUES ($1) RETURNING id`,
+			source).Scan(&revID); err != nil {
+			yield(nil, fmt.Errorf("DryRunImport: %w", err))
+			return
+		}
+
+		for in, err := range seq {
+			if err != nil {
+				yield(nil, fmt.Errorf("DryRunImport: %w", err))
+				return
+			}
+			d, err := dryRunRecord(ctx, tx, source, in, revID, priorities, imp)
+			if err != nil {
+				yield(nil, fmt.Errorf("DryRunImport: %w", err))
+				return
+			}
+			if !yield(d, nil) {
+				return
+			}
+		}
+	}
+}
+
+// dryRunRecord imports a single record in tx and reports what changed. A
+// record that fails is rolled back to a savepoint and reported in the diff;
+// the returned error is for database errors only.
+func dryRunRecord[T any](ctx context.Context, tx pgx.Tx, source string, in T, revID int64, priorities map[string]int, imp dryRunImporter[T]) (*ImportDiff, error) {
+	d := &ImportDiff{SourceID: imp.sourceID(in)}
+
+	rt := imp.recordType
+	var id ID
+	err := tx.QueryRow(ctx, fmt.Sprintf(`
+		SELECT %s FROM %s WHERE source = $1 AND source_id = $2`,
+		entityIDCol(rt), sourceTable(rt)),
+		source, d.SourceID).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		d.ID, err = imp.match(ctx, tx, in)
+	} else if err == nil {
+		d.ID = &id
+	}
+	if err != nil {
+		return nil, err
+	}
+	isNew := d.ID == nil
+	var before map[string]json.RawMessage
+	if !isNew {
+		if before, err = pinnedValues(ctx, tx, rt, *d.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	sp, err := tx.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	id, ok, err := imp.importRecord(ctx, sp, in, revID, priorities, d)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		d.Action = ImportActionFailed
+		if errors.Is(err, pgx.ErrNoRows) || errors.Is(err, errEmptyRef) {
+			d.Action = ImportActionRefFailed
+		}
+		d.Error = err.Error()
+		if err := sp.Rollback(ctx); err != nil {
+			return nil, err
+		}
+		return d, nil
+	}
+	if err := sp.Commit(ctx); err != nil {
+		return nil, err
+	}
+	if !ok {
+		d.Action = ImportActionUnchanged
+		return d, nil
+	}
+
+	after, err := pinnedValues(ctx, tx, rt, id)
+	if err != nil {
+		return nil, err
+	}
+	d.Fields = diffValues(before, after)
+	switch {
+	case d.Action != "":
+	case isNew:
+		d.Action = ImportActionNew
+	case len(d.Fields) > 0:
+		d.Action = ImportActionUpdated
+	default:
+		d.Action = ImportActionUnchanged
+	}
+	return d, nil
+}
+
+// pinnedValues returns the pinned, visible values of a record by field, as
+// joinItems encodes them.
+func pinnedValues(ctx context.Context, tx pgx.Tx, rt string, id ID) (map[string]json.RawMessage, error) {
+	rows, err := tx.Query(ctx, fmt.Sprintf(`
+		SELECT field, val FROM %s
+		WHERE %s = $1 AND pinned AND NOT hidden
+		ORDER BY id`,
+		assertionsTable(rt), entityIDCol(rt)), id)
+	if err != nil {
+		return nil, err
+	}
+	items := make(map[string][]json.RawMessage)
+	var field string
+	var val json.RawMessage
+	if _, err := pgx.ForEachRow(rows, []any{&field, &val}, func() error {
+		items[field] = append(items[field], canonicalJSON(val))
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	vals := make(map[string]json.RawMessage, len(items))
+	for f, fieldItems := range items {
+		ft, err := resolveFieldType(rt, f)
+		if err != nil {
+			return nil, err
+		}
+		vals[f] = joinItems(ft, fieldItems)
+	}
+	return vals, nil
+}
+
+// diffValues compares the values of two pinnedValues results by field.
+func diffValues(before, after map[string]json.RawMessage) []FieldDiff {
+	fields := slices.Collect(maps.Keys(before))
+	for f := range after {
+		if _, ok := before[f]; !ok {
+			fields = append(fields, f)
+		}
+	}
+	slices.Sort(fields)
+
+	var diffs []FieldDiff
+	for _, f := range fields {
+		oldVal, newVal := before[f], after[f]
+		if oldVal == nil {
+			oldVal = json.RawMessage("null")
+		}
+		if newVal == nil {
+			newVal = json.RawMessage("null")
+		}
+		if string(oldVal) != string(newVal) {
+			diffs = append(diffs, FieldDiff{Field: f, Old: oldVal, New: newVal})
+		}
+	}
+	return diffs
+}
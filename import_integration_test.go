@@ -2,8 +2,15 @@ package bbl
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"iter"
+	"maps"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestImportWorksWithContributors(t *testing.T) {
@@ -96,3 +103,915 @@ func TestImportWorksWithContributors(t *testing.T) {
 		t.Errorf("extension person_id = %v, want %s", extPersonID, personID)
 	}
 }
+
+func TestImportWorksOptionalPersonRef(t *testing.T) {
+	repo := testRepo(t)
+	ctx := context.Background()
+
+	if err := repo.UpsertSource(ctx, "test-source"); err != nil {
+		t.Fatalf("upsert source: %v", err)
+	}
+	orcid := &Ref{Identifier: &Identifier{Scheme: "orcid", Val: "0000-0002-1825-0097"}}
+	work := &ImportWorkInput{
+		SourceID:     "work-001",
+		Kind:         "journal_article",
+		SourceRecord: []byte(`{}`),
+		Titles:       []Title{{Lang: "eng", Val: "Test Article"}},
+		Contributors: []ImportWorkContributor{{PersonRef: orcid, Name: "Jane Doe", Roles: []string{"author"}}},
+	}
+	seq := func(yield func(*ImportWorkInput, error) bool) { yield(work, nil) }
+
+	// An unknown person fails the import unless the ref is optional.
+	if _, err := repo.ImportWorks(ctx, "test-source", seq); err == nil {
+		t.Fatal("expected an error for an unknown person")
+	}
+	work.Contributors[0].PersonRefOptional = true
+	if _, err := repo.ImportWorks(ctx, "test-source", seq); err != nil {
+		t.Fatalf("import works: %v", err)
+	}
+
+	var workID ID
+	if err := repo.db.QueryRow(ctx, `
+		SELECT work_id FROM bbl_work_sources
+		WHERE source = $1 AND source_id = $2`, "test-source", "work-001").Scan(&workID); err != nil {
+		t.Fatalf("lookup work by source: %v", err)
+	}
+	got, err := repo.GetWork(ctx, workID)
+	if err != nil {
+		t.Fatalf("get work: %v", err)
+	}
+	if len(got.Contributors) != 1 || got.Contributors[0].PersonID != nil || got.Contributors[0].Name != "Jane Doe" {
+		t.Errorf("contributors = %+v, want one unlinked contributor", got.Contributors)
+	}
+}
+
+func TestImportWorksHoldsLockedFields(t *testing.T) {
+	repo := testRepo(t)
+	ctx := context.Background()
+
+	if err := repo.UpsertSource(ctx, "test-source"); err != nil {
+		t.Fatalf("upsert source: %v", err)
+	}
+	curator := createTestUser(t, repo, RoleCurator)
+
+	importVolume := func(volume string) {
+		t.Helper()
+		rec := &ImportWorkInput{
+			SourceID:     "work-locked",
+			Kind:         "journal_article",
+			Volume:       volume,
+			SourceRecord: []byte(`{}`),
+			Titles:       []Title{{Lang: "eng", Val: "Locked Article"}},
+		}
+		seq := func(yield func(*ImportWorkInput, error) bool) { yield(rec, nil) }
+		if _, err := repo.ImportWorks(ctx, "test-source", iter.Seq2[*ImportWorkInput, error](seq)); err != nil {
+			t.Fatalf("import works: %v", err)
+		}
+	}
+
+	importVolume("1")
+
+	var workID ID
+	err := repo.db.QueryRow(ctx, `
+		SELECT work_id FROM bbl_work_sources
+		WHERE source = $1 AND source_id = $2`, "test-source", "work-locked").Scan(&workID)
+	if err != nil {
+		t.Fatalf("lookup work by source: %v", err)
+	}
+
+	if _, _, err := repo.Update(ctx, curator, &LockWork{WorkID: workID, Fields: []string{"volume"}}); err != nil {
+		t.Fatalf("lock work: %v", err)
+	}
+
+	// A disagreeing re-import is held back and queued.
+	importVolume("2")
+
+	work, err := repo.GetWork(ctx, workID)
+	if err != nil {
+		t.Fatalf("get work: %v", err)
+	}
+	if work.Volume != "1" {
+		t.Errorf("volume = %q, want %q", work.Volume, "1")
+	}
+	conflicts, err := repo.GetWorkLockConflicts(ctx, workID)
+	if err != nil {
+		t.Fatalf("get lock conflicts: %v", err)
+	}
+	if len(conflicts) != 1 {
+		t.Fatalf("conflicts count = %d, want 1", len(conflicts))
+	}
+	if string(conflicts[0].SourceVal) != `"2"` {
+		t.Errorf("source_val = %s, want %q", conflicts[0].SourceVal, `"2"`)
+	}
+
+	// Accepting the conflict applies the source value.
+	updates, err := conflicts[0].AcceptUpdates()
+	if err != nil {
+		t.Fatalf("accept updates: %v", err)
+	}
+	if _, _, err := repo.Update(ctx, curator, updates...); err != nil {
+		t.Fatalf("accept conflict: %v", err)
+	}
+	work, err = repo.GetWork(ctx, workID)
+	if err != nil {
+		t.Fatalf("get work: %v", err)
+	}
+	if work.Volume != "2" {
+		t.Errorf("volume = %q, want %q", work.Volume, "2")
+	}
+	if conflicts, _ := repo.GetWorkLockConflicts(ctx, workID); len(conflicts) != 0 {
+		t.Errorf("open conflicts = %d, want 0", len(conflicts))
+	}
+}
+
+func TestGetWorkByIdentifier(t *testing.T) {
+	repo := testRepo(t)
+	ctx := context.Background()
+
+	if err := repo.UpsertSource(ctx, "test-source"); err != nil {
+		t.Fatalf("upsert source: %v", err)
+	}
+	rec := &ImportWorkInput{
+		SourceID:     "work-001",
+		Kind:         "journal_article",
+		SourceRecord: []byte(`{}`),
+		Titles:       []Title{{Lang: "eng", Val: "Test Article"}},
+		Identifiers:  []Identifier{{Scheme: "doi", Val: "10.1000/xyz123"}},
+	}
+	seq := func(yield func(*ImportWorkInput, error) bool) { yield(rec, nil) }
+	if _, err := repo.ImportWorks(ctx, "test-source", iter.Seq2[*ImportWorkInput, error](seq)); err != nil {
+		t.Fatalf("import works: %v", err)
+	}
+
+	work, err := repo.GetWorkByIdentifier(ctx, "doi", "10.1000/xyz123")
+	if err != nil {
+		t.Fatalf("get work by identifier: %v", err)
+	}
+	if len(work.Titles) != 1 || work.Titles[0].Val != "Test Article" {
+		t.Errorf("titles = %v, want Test Article", work.Titles)
+	}
+	if _, err := repo.GetWorkByIdentifier(ctx, "doi", "10.1000/other"); err != ErrNotFound {
+		t.Errorf("unknown identifier: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestImportWorksDeleted(t *testing.T) {
+	repo := testRepo(t)
+	ctx := context.Background()
+
+	if err := repo.UpsertSource(ctx, "test-source"); err != nil {
+		t.Fatalf("upsert source: %v", err)
+	}
+	importOne := func(rec *ImportWorkInput) int {
+		t.Helper()
+		seq := func(yield func(*ImportWorkInput, error) bool) { yield(rec, nil) }
+		n, err := repo.ImportWorks(ctx, "test-source", iter.Seq2[*ImportWorkInput, error](seq))
+		if err != nil {
+			t.Fatalf("import works: %v", err)
+		}
+		return n
+	}
+
+	importOne(&ImportWorkInput{
+		SourceID:     "work-001",
+		Kind:         "journal_article",
+		SourceRecord: []byte(`{}`),
+		Titles:       []Title{{Lang: "eng", Val: "Test Article"}},
+	})
+	if n := importOne(&ImportWorkInput{SourceID: "work-001", Deleted: true}); n != 1 {
+		t.Fatalf("withdrew %d works, want 1", n)
+	}
+	if n := importOne(&ImportWorkInput{SourceID: "never-imported", Deleted: true}); n != 0 {
+		t.Errorf("withdrew %d works for an unknown record, want 0", n)
+	}
+
+	var workID ID
+	if err := repo.db.QueryRow(ctx, `
+		SELECT work_id FROM bbl_work_sources
+		WHERE source = $1 AND source_id = $2`, "test-source", "work-001").Scan(&workID); err != nil {
+		t.Fatalf("source record is gone: %v", err)
+	}
+	work, err := repo.GetWork(ctx, workID)
+	if err != nil {
+		t.Fatalf("get work: %v", err)
+	}
+	if len(work.Titles) != 0 {
+		t.Errorf("titles = %v, want none", work.Titles)
+	}
+}
+
+func TestSourceCheckpoint(t *testing.T) {
+	repo := testRepo(t)
+	ctx := context.Background()
+
+	if err := repo.UpsertSource(ctx, "test-source"); err != nil {
+		t.Fatalf("upsert source: %v", err)
+	}
+	if cp, err := repo.GetSourceCheckpoint(ctx, "test-source"); err != nil || cp != "" {
+		t.Errorf("checkpoint = %q, %v, want none", cp, err)
+	}
+	if err := repo.SetSourceCheckpoint(ctx, "test-source", "2024-03-01T12:00:00Z"); err != nil {
+		t.Fatalf("set checkpoint: %v", err)
+	}
+	if cp, err := repo.GetSourceCheckpoint(ctx, "test-source"); err != nil || cp != "2024-03-01T12:00:00Z" {
+		t.Errorf("checkpoint = %q, %v", cp, err)
+	}
+	if err := repo.SetSourceCheckpoint(ctx, "test-source", ""); err != nil {
+		t.Fatalf("clear checkpoint: %v", err)
+	}
+	if cp, err := repo.GetSourceCheckpoint(ctx, "test-source"); err != nil || cp != "" {
+		t.Errorf("checkpoint = %q, %v, want none", cp, err)
+	}
+}
+
+func TestSweepWorkSource(t *testing.T) {
+	repo := testRepo(t)
+	ctx := context.Background()
+
+	if err := repo.UpsertSource(ctx, "test-source"); err != nil {
+		t.Fatalf("upsert source: %v", err)
+	}
+	harvest := func(ids ...string) time.Time {
+		t.Helper()
+		startedAt, err := repo.StartSourceSweep(ctx)
+		if err != nil {
+			t.Fatalf("start sweep: %v", err)
+		}
+		seq := func(yield func(*ImportWorkInput, error) bool) {
+			for _, id := range ids {
+				if !yield(&ImportWorkInput{
+					SourceID:     id,
+					Kind:         "journal_article",
+					SourceRecord: []byte(`{}`),
+					Titles:       []Title{{Lang: "eng", Val: "Article " + id}},
+				}, nil) {
+					return
+				}
+			}
+		}
+		if _, err := repo.ImportWorks(ctx, "test-source", iter.Seq2[*ImportWorkInput, error](seq)); err != nil {
+			t.Fatalf("import works: %v", err)
+		}
+		return startedAt
+	}
+
+	var ids []string
+	for i := range 10 {
+		ids = append(ids, fmt.Sprintf("work-%03d", i))
+	}
+	harvest(ids...)
+
+	// One record vanished.
+	startedAt := harvest(ids[1:]...)
+	res, err := repo.SweepWorkSource(ctx, "test-source", startedAt, SweepOpts{})
+	if err != nil {
+		t.Fatalf("sweep: %v", err)
+	}
+	if res.Total != 10 || res.Withdrawn != 1 || res.Flagged != 1 || len(res.WorkIDs) != 1 {
+		t.Fatalf("sweep result = %+v", res)
+	}
+	work, err := repo.GetWork(ctx, res.WorkIDs[0])
+	if err != nil {
+		t.Fatalf("get work: %v", err)
+	}
+	if len(work.Titles) != 0 || work.ReviewStatus != WorkReviewPending {
+		t.Errorf("swept work: titles = %v, review status = %q", work.Titles, work.ReviewStatus)
+	}
+	reviews, err := repo.GetWorkReviews(ctx, work.ID)
+	if err != nil || len(reviews) != 1 || reviews[0].Kind != WorkReviewKindFlagged {
+		t.Errorf("reviews = %+v, %v", reviews, err)
+	}
+
+	// Withdrawn records don't count towards the next sweep; half of the
+	// others vanishing is over the threshold.
+	startedAt = harvest(ids[1:5]...)
+	if _, err := repo.SweepWorkSource(ctx, "test-source", startedAt, SweepOpts{}); !errors.Is(err, ErrSweepThreshold) {
+		t.Fatalf("sweep over threshold: err = %v", err)
+	}
+	res, err = repo.SweepWorkSource(ctx, "test-source", startedAt, SweepOpts{MaxVanished: 1})
+	if err != nil {
+		t.Fatalf("sweep without threshold: %v", err)
+	}
+	if res.Total != 9 || res.Withdrawn != 5 {
+		t.Errorf("sweep result = %+v", res)
+	}
+
+	// A record that comes back is imported into the same work.
+	harvest(ids[0])
+	var withdrawn bool
+	if err := repo.db.QueryRow(ctx, `
+		SELECT withdrawn_at IS NOT NULL FROM bbl_work_sources
+		WHERE source = $1 AND source_id = $2`, "test-source", ids[0]).Scan(&withdrawn); err != nil || withdrawn {
+		t.Errorf("returned record: withdrawn = %v, err = %v", withdrawn, err)
+	}
+	if work, err := repo.GetWork(ctx, work.ID); err != nil || len(work.Titles) != 1 {
+		t.Errorf("returned record: work = %+v, err = %v", work, err)
+	}
+
+	// A record that fails is left out of the import, but it is still in
+	// the source and isn't withdrawn.
+	startedAt, err = repo.StartSourceSweep(ctx)
+	if err != nil {
+		t.Fatalf("start sweep: %v", err)
+	}
+	seq := func(yield func(*ImportWorkInput, error) bool) {
+		for _, id := range ids[:5] {
+			in := &ImportWorkInput{
+				SourceID:     id,
+				Kind:         "journal_article",
+				SourceRecord: []byte(`{}`),
+				Titles:       []Title{{Lang: "eng", Val: "Article " + id}},
+			}
+			if id == ids[1] {
+				in.Kind = ""
+			}
+			if !yield(in, nil) {
+				return
+			}
+		}
+	}
+	var failed []string
+	if _, err := repo.ImportWorksWithOpts(ctx, "test-source", iter.Seq2[*ImportWorkInput, error](seq), ImportOpts{
+		OnFailure: func(f *ImportFailure) error {
+			failed = append(failed, f.SourceID)
+			return nil
+		},
+	}); err != nil {
+		t.Fatalf("import works: %v", err)
+	}
+	if !slices.Equal(failed, ids[1:2]) {
+		t.Fatalf("failed = %v, want %v", failed, ids[1:2])
+	}
+	res, err = repo.SweepWorkSource(ctx, "test-source", startedAt, SweepOpts{})
+	if err != nil {
+		t.Fatalf("sweep with a failed record: %v", err)
+	}
+	if res.Total != 5 || res.Withdrawn != 0 {
+		t.Errorf("sweep with a failed record: result = %+v", res)
+	}
+}
+
+// upperTitleMapper maps a stored {"title": ...} record with an upper case
+// title, as a fixed mapping would.
+type upperTitleMapper struct{}
+
+func (upperTitleMapper) Map(record []byte) (*ImportWorkInput, error) {
+	var r struct{ Title string }
+	if err := json.Unmarshal(record, &r); err != nil {
+		return nil, err
+	}
+	return &ImportWorkInput{
+		Kind:   "journal_article",
+		Titles: []Title{{Lang: "eng", Val: strings.ToUpper(r.Title)}},
+	}, nil
+}
+
+func TestRemapWorks(t *testing.T) {
+	repo := testRepo(t)
+	ctx := context.Background()
+
+	if err := repo.UpsertSource(ctx, "test-source"); err != nil {
+		t.Fatalf("upsert source: %v", err)
+	}
+	records := map[string]string{
+		"work-001": `{"title": "first article"}`,
+		"work-002": `{"title": "SECOND ARTICLE"}`,
+		"work-003": `not json`,
+	}
+	seq := func(yield func(*ImportWorkInput, error) bool) {
+		for _, id := range slices.Sorted(maps.Keys(records)) {
+			if !yield(&ImportWorkInput{
+				SourceID:     id,
+				Kind:         "journal_article",
+				SourceRecord: []byte(records[id]),
+				Titles:       []Title{{Lang: "eng", Val: "first article"}},
+			}, nil) {
+				return
+			}
+		}
+	}
+	if _, err := repo.ImportWorks(ctx, "test-source", iter.Seq2[*ImportWorkInput, error](seq)); err != nil {
+		t.Fatalf("import works: %v", err)
+	}
+
+	remap := func(opts RemapOpts) map[string]*RemapDiff {
+		t.Helper()
+		diffs := map[string]*RemapDiff{}
+		for d, err := range repo.RemapWorks(ctx, "test-source", upperTitleMapper{}, opts) {
+			if err != nil {
+				t.Fatalf("remap works: %v", err)
+			}
+			diffs[d.SourceID] = d
+		}
+		return diffs
+	}
+	title := func(sourceID string) string {
+		t.Helper()
+		var workID ID
+		if err := repo.db.QueryRow(ctx, `
+			SELECT work_id FROM bbl_work_sources WHERE source = $1 AND source_id = $2`,
+			"test-source", sourceID).Scan(&workID); err != nil {
+			t.Fatalf("get work id: %v", err)
+		}
+		work, err := repo.GetWork(ctx, workID)
+		if err != nil {
+			t.Fatalf("get work: %v", err)
+		}
+		return work.Titles[0].Val
+	}
+
+	diffs := remap(RemapOpts{DryRun: true})
+	if len(diffs) != 3 || diffs["work-003"].Error == "" {
+		t.Fatalf("dry run diffs = %+v", diffs)
+	}
+	d := diffs["work-001"]
+	if len(d.Fields) != 1 || d.Fields[0].Field != "titles" ||
+		string(d.Fields[0].Old) != `[{"lang":"eng","val":"first article"}]` ||
+		string(d.Fields[0].New) != `[{"lang":"eng","val":"FIRST ARTICLE"}]` {
+		t.Errorf("diff = %+v", d)
+	}
+	if got := title("work-001"); got != "first article" {
+		t.Errorf("dry run changed title to %q", got)
+	}
+
+	remap(RemapOpts{})
+	if got := title("work-001"); got != "FIRST ARTICLE" {
+		t.Errorf("title = %q", got)
+	}
+	if got := title("work-002"); got != "SECOND ARTICLE" {
+		t.Errorf("title = %q", got)
+	}
+
+	// Remapping again changes nothing.
+	if diffs := remap(RemapOpts{}); len(diffs) != 1 || diffs["work-003"] == nil {
+		t.Errorf("second remap diffs = %+v", diffs)
+	}
+}
+
+func TestDryRunImportWorks(t *testing.T) {
+	repo := testRepo(t)
+	ctx := context.Background()
+
+	if err := repo.UpsertSource(ctx, "test-source"); err != nil {
+		t.Fatalf("upsert source: %v", err)
+	}
+	work := func(id, title string) *ImportWorkInput {
+		return &ImportWorkInput{
+			SourceID:     id,
+			Kind:         "journal_article",
+			SourceRecord: []byte(`{}`),
+			Titles:       []Title{{Lang: "eng", Val: title}},
+		}
+	}
+	seqOf := func(works ...*ImportWorkInput) iter.Seq2[*ImportWorkInput, error] {
+		return func(yield func(*ImportWorkInput, error) bool) {
+			for _, w := range works {
+				if !yield(w, nil) {
+					return
+				}
+			}
+		}
+	}
+	if _, err := repo.ImportWorks(ctx, "test-source", seqOf(
+		work("work-001", "Unchanged"),
+		work("work-002", "Old title"),
+		work("work-003", "Deleted"),
+	)); err != nil {
+		t.Fatalf("import works: %v", err)
+	}
+
+	refFailed := work("work-005", "Unknown author")
+	refFailed.Contributors = []ImportWorkContributor{{
+		Roles: []string{"author"}, Name: "Jane Doe", PersonRef: &Ref{SourceID: "no-such-person"},
+	}}
+	diffs := map[string]*ImportDiff{}
+	for d, err := range repo.DryRunImportWorks(ctx, "test-source", seqOf(
+		work("work-001", "Unchanged"),
+		work("work-002", "New title"),
+		&ImportWorkInput{SourceID: "work-003", Deleted: true},
+		work("work-004", "New work"),
+		refFailed,
+	)) {
+		if err != nil {
+			t.Fatalf("dry run: %v", err)
+		}
+		diffs[d.SourceID] = d
+	}
+
+	want := map[string]string{
+		"work-001": ImportActionUnchanged,
+		"work-002": ImportActionUpdated,
+		"work-003": ImportActionWithdrawn,
+		"work-004": ImportActionNew,
+		"work-005": ImportActionRefFailed,
+	}
+	for id, action := range want {
+		if d := diffs[id]; d == nil || d.Action != action {
+			t.Errorf("%s: diff = %+v, want action %s", id, d, action)
+		}
+	}
+	if d := diffs["work-002"]; d != nil && (len(d.Fields) != 1 || d.Fields[0].Field != "titles" ||
+		string(d.Fields[0].Old) != `[{"lang":"eng","val":"Old title"}]` ||
+		string(d.Fields[0].New) != `[{"lang":"eng","val":"New title"}]`) {
+		t.Errorf("work-002: fields = %+v", d.Fields)
+	}
+	if d := diffs["work-004"]; d != nil && d.ID != nil {
+		t.Errorf("work-004: new record has id %s", d.ID)
+	}
+
+	// Nothing was written.
+	var n int
+	if err := repo.db.QueryRow(ctx, `
+		SELECT count(*) FROM bbl_work_sources WHERE source = $1 AND withdrawn_at IS NULL`,
+		"test-source").Scan(&n); err != nil || n != 3 {
+		t.Errorf("source records = %d, err = %v", n, err)
+	}
+	if d := diffs["work-002"]; d != nil && d.ID != nil {
+		if w, err := repo.GetWork(ctx, *d.ID); err != nil || w.Titles[0].Val != "Old title" {
+			t.Errorf("work-002 after dry run: %+v, err = %v", w, err)
+		}
+	}
+}
+
+// TestDryRunImportWorksOneTransaction checks that a dry run sees its own
+// earlier records, however many records come in between.
+func TestDryRunImportWorksOneTransaction(t *testing.T) {
+	repo := testRepo(t)
+	ctx := context.Background()
+
+	if err := repo.UpsertSource(ctx, "test-source"); err != nil {
+		t.Fatalf("upsert source: %v", err)
+	}
+	var works []*ImportWorkInput
+	for i := range 600 {
+		works = append(works, &ImportWorkInput{
+			SourceID:     fmt.Sprintf("work-%03d", i),
+			Kind:         "journal_article",
+			SourceRecord: []byte(`{}`),
+			Titles:       []Title{{Lang: "eng", Val: fmt.Sprintf("Article %d", i)}},
+		})
+	}
+	// The first record comes again, changed, after more than one batch.
+	works = append(works, &ImportWorkInput{
+		SourceID:     "work-000",
+		Kind:         "journal_article",
+		SourceRecord: []byte(`{}`),
+		Titles:       []Title{{Lang: "eng", Val: "Article 0, revised"}},
+	})
+	seq := func(yield func(*ImportWorkInput, error) bool) {
+		for _, w := range works {
+			if !yield(w, nil) {
+				return
+			}
+		}
+	}
+
+	var diffs []*ImportDiff
+	for d, err := range repo.DryRunImportWorks(ctx, "test-source", seq) {
+		if err != nil {
+			t.Fatalf("dry run: %v", err)
+		}
+		diffs = append(diffs, d)
+	}
+	if len(diffs) != len(works) {
+		t.Fatalf("got %d diffs, want %d", len(diffs), len(works))
+	}
+	first, last := diffs[0], diffs[len(diffs)-1]
+	if first.Action != ImportActionNew {
+		t.Errorf("first diff = %+v, want action new", first)
+	}
+	if last.Action != ImportActionUpdated || last.ID == nil || len(last.Fields) != 1 ||
+		string(last.Fields[0].Old) != `[{"lang":"eng","val":"Article 0"}]` {
+		t.Errorf("last diff = %+v, want an update of the record created by the dry run", last)
+	}
+
+	var n int
+	if err := repo.db.QueryRow(ctx, `
+		SELECT count(*) FROM bbl_work_sources WHERE source = $1`,
+		"test-source").Scan(&n); err != nil || n != 0 {
+		t.Errorf("source records = %d, err = %v", n, err)
+	}
+}
+
+func TestImportWorksWithOpts(t *testing.T) {
+	repo := testRepo(t)
+	ctx := context.Background()
+
+	if err := repo.UpsertSource(ctx, "test-source"); err != nil {
+		t.Fatalf("upsert source: %v", err)
+	}
+	var works []*ImportWorkInput
+	for i := range 600 {
+		w := &ImportWorkInput{
+			SourceID:     fmt.Sprintf("work-%03d", i),
+			Kind:         "journal_article",
+			SourceRecord: []byte(`{}`),
+			Titles:       []Title{{Lang: "eng", Val: fmt.Sprintf("Article %d", i)}},
+		}
+		if i%100 == 7 {
+			w.Contributors = []ImportWorkContributor{{
+				Roles: []string{"author"}, Name: "Jane Doe", PersonRef: &Ref{SourceID: "no-such-person"},
+			}}
+		}
+		works = append(works, w)
+	}
+	// The run is interrupted in the third batch.
+	seq := func(n int) iter.Seq2[*ImportWorkInput, error] {
+		return func(yield func(*ImportWorkInput, error) bool) {
+			for i, w := range works {
+				if i == n {
+					yield(nil, errors.New("interrupted"))
+					return
+				}
+				if !yield(w, nil) {
+					return
+				}
+			}
+		}
+	}
+
+	var failures []*ImportFailure
+	var checkpoint int
+	opts := ImportOpts{
+		OnFailure: func(f *ImportFailure) error {
+			failures = append(failures, f)
+			return nil
+		},
+		OnCheckpoint: func(processed int) error {
+			checkpoint = processed
+			return nil
+		},
+	}
+	res, err := repo.ImportWorksWithOpts(ctx, "test-source", seq(550), opts)
+	if err == nil {
+		t.Fatal("expected the interruption to fail the import")
+	}
+	if checkpoint != 500 || res.Imported != 495 || res.Failed != 5 || len(failures) != 5 {
+		t.Fatalf("checkpoint = %d, result = %+v, failures = %d", checkpoint, res, len(failures))
+	}
+	if failures[0].SourceID != "work-007" || failures[0].Reason == "" {
+		t.Errorf("failure = %+v", failures[0])
+	}
+
+	opts.Skip = checkpoint
+	res, err = repo.ImportWorksWithOpts(ctx, "test-source", seq(-1), opts)
+	if err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	if checkpoint != 600 || res.Skipped != 500 || res.Imported != 99 || res.Failed != 1 {
+		t.Errorf("checkpoint = %d, result = %+v", checkpoint, res)
+	}
+
+	// Without OnFailure a failing record aborts the import.
+	if _, err := repo.ImportWorks(ctx, "test-source", seq(-1)); err == nil {
+		t.Error("expected ImportWorks to fail on an unresolvable ref")
+	}
+}
+
+func TestImportPeopleWithOpts(t *testing.T) {
+	repo := testRepo(t)
+	ctx := context.Background()
+
+	if err := repo.UpsertSource(ctx, "test-source"); err != nil {
+		t.Fatalf("upsert source: %v", err)
+	}
+	people := []*ImportPersonInput{
+		{SourceID: "person-001", Name: "Jane Doe", SourceRecord: []byte(`{}`)},
+		{Name: "No Source ID", SourceRecord: []byte(`{}`)},
+		{SourceID: "person-002", Name: "John Doe", SourceRecord: []byte(`{}`)},
+	}
+	seq := func(yield func(*ImportPersonInput, error) bool) {
+		for _, p := range people {
+			if !yield(p, nil) {
+				return
+			}
+		}
+	}
+
+	var failures []*ImportFailure
+	var checkpoint int
+	res, err := repo.ImportPeopleWithOpts(ctx, "test-source", iter.Seq2[*ImportPersonInput, error](seq), ImportOpts{
+		OnFailure: func(f *ImportFailure) error {
+			failures = append(failures, f)
+			return nil
+		},
+		OnCheckpoint: func(processed int) error {
+			checkpoint = processed
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("import people: %v", err)
+	}
+	if res.Imported != 2 || res.Failed != 1 || checkpoint != 3 || len(failures) != 1 || failures[0].Reason == "" {
+		t.Errorf("result = %+v, checkpoint = %d, failures = %+v", res, checkpoint, failures)
+	}
+
+	// Without OnFailure a failing record aborts the import.
+	if _, err := repo.ImportPeople(ctx, "test-source", iter.Seq2[*ImportPersonInput, error](seq)); err == nil {
+		t.Error("expected ImportPeople to fail on a record without source_id")
+	}
+}
+
+func TestImportWorksMatch(t *testing.T) {
+	repo := testRepo(t)
+	ctx := context.Background()
+
+	for _, source := range []string{"source-a", "source-b"} {
+		if err := repo.UpsertSource(ctx, source); err != nil {
+			t.Fatalf("upsert source: %v", err)
+		}
+	}
+	if err := repo.SetSourceMatchPolicy(ctx, "source-b", WorkMatchPolicy{Mode: WorkMatchAttach}); err != nil {
+		t.Fatalf("set match policy: %v", err)
+	}
+	importWorks := func(source string, recs ...*ImportWorkInput) {
+		t.Helper()
+		seq := func(yield func(*ImportWorkInput, error) bool) {
+			for _, rec := range recs {
+				if !yield(rec, nil) {
+					return
+				}
+			}
+		}
+		if _, err := repo.ImportWorks(ctx, source, iter.Seq2[*ImportWorkInput, error](seq)); err != nil {
+			t.Fatalf("import works: %v", err)
+		}
+	}
+	rec := func(sourceID, doi string) *ImportWorkInput {
+		return &ImportWorkInput{
+			SourceID:     sourceID,
+			Kind:         "journal_article",
+			SourceRecord: []byte(`{}`),
+			Titles:       []Title{{Lang: "eng", Val: "Article " + sourceID}},
+			Identifiers:  []Identifier{{Scheme: "doi", Val: doi}},
+		}
+	}
+	workID := func(source, sourceID string) ID {
+		t.Helper()
+		var id ID
+		if err := repo.db.QueryRow(ctx, `
+			SELECT work_id FROM bbl_work_sources WHERE source = $1 AND source_id = $2`,
+			source, sourceID).Scan(&id); err != nil {
+			t.Fatalf("work of %s/%s: %v", source, sourceID, err)
+		}
+		return id
+	}
+
+	importWorks("source-a",
+		rec("a1", "10.1000/abc"),
+		rec("a2", "10.1000/dup"),
+		rec("a3", "10.1000/dup"),
+	)
+	importWorks("source-b",
+		rec("b1", "https://doi.org/10.1000/ABC"),
+		rec("b2", "10.1000/dup"),
+	)
+
+	// A single match is attached.
+	if a1, b1 := workID("source-a", "a1"), workID("source-b", "b1"); a1 != b1 {
+		t.Errorf("b1 not attached to the work of a1")
+	}
+
+	// An ambiguous match gets a new work that is reported as a duplicate
+	// of the works it matches.
+	b2 := workID("source-b", "b2")
+	if b2 == workID("source-a", "a2") || b2 == workID("source-a", "a3") {
+		t.Fatal("b2 attached to an ambiguous match")
+	}
+	work, err := repo.GetWork(ctx, b2)
+	if err != nil {
+		t.Fatalf("get work: %v", err)
+	}
+	if work.ReviewStatus != "" {
+		t.Errorf("review status = %q, want none", work.ReviewStatus)
+	}
+	clusters, _, err := repo.GetDuplicateClusters(ctx, DuplicateClusterOpts{RecordType: RecordTypeWork})
+	if err != nil {
+		t.Fatalf("get duplicate clusters: %v", err)
+	}
+	var cluster *DuplicateCluster
+	for i := range clusters {
+		if slices.Contains(clusters[i].IDs, b2) {
+			cluster = &clusters[i]
+		}
+	}
+	if cluster == nil || len(cluster.IDs) != 3 || cluster.Confidence != 1 || !slices.Equal(cluster.Reasons, []string{DuplicateReasonDOI}) {
+		t.Errorf("duplicate cluster of b2 = %+v", cluster)
+	}
+
+	// ISBNs match in any form, but only works of the same kind.
+	book := func(sourceID, kind, isbn string) *ImportWorkInput {
+		r := rec(sourceID, "")
+		r.Kind, r.Identifiers = kind, []Identifier{{Scheme: "isbn", Val: isbn}}
+		return r
+	}
+	importWorks("source-a", book("a5", "book", "0-306-40615-2"))
+	importWorks("source-b",
+		book("b5", "book", "978-0-306-40615-7"),
+		book("b6", "journal_article", "9780306406157"),
+	)
+	if workID("source-b", "b5") != workID("source-a", "a5") {
+		t.Error("b5 not attached to the work of a5 by ISBN")
+	}
+	if workID("source-b", "b6") == workID("source-a", "a5") {
+		t.Error("b6 attached to a work of another kind by ISBN")
+	}
+
+	// Without a policy, source-a never matches.
+	importWorks("source-a", rec("a4", "10.1000/abc"))
+	if workID("source-a", "a4") == workID("source-a", "a1") {
+		t.Error("a4 matched without a match policy")
+	}
+}
+
+func TestNormalizeStoredIdentifiers(t *testing.T) {
+	repo := testRepo(t)
+	ctx := context.Background()
+
+	if err := repo.UpsertSource(ctx, "test-source"); err != nil {
+		t.Fatalf("upsert source: %v", err)
+	}
+	rec := &ImportWorkInput{
+		SourceID:     "work-001",
+		Kind:         "journal_article",
+		SourceRecord: []byte(`{}`),
+		Titles:       []Title{{Lang: "eng", Val: "Test Article"}},
+		Identifiers:  []Identifier{{Scheme: "doi", Val: "https://doi.org/10.1000/ABC"}},
+	}
+	seq := func(yield func(*ImportWorkInput, error) bool) { yield(rec, nil) }
+	if _, err := repo.ImportWorks(ctx, "test-source", iter.Seq2[*ImportWorkInput, error](seq)); err != nil {
+		t.Fatalf("import works: %v", err)
+	}
+
+	// Imports store the normalized value and lookups normalize theirs.
+	work, err := repo.GetWorkByIdentifier(ctx, "doi", "doi:10.1000/Abc")
+	if err != nil {
+		t.Fatalf("get work by identifier: %v", err)
+	}
+	if len(work.Identifiers) != 1 || work.Identifiers[0].Val != "10.1000/abc" {
+		t.Fatalf("identifiers = %v, want 10.1000/abc", work.Identifiers)
+	}
+
+	// A value stored before normalization.
+	if _, err := repo.db.Exec(ctx, `
+		UPDATE bbl_work_assertions SET val = '{"scheme": "doi", "val": "https://doi.org/10.1000/ABC"}'
+		WHERE work_id = $1 AND field = 'identifiers'`, work.ID); err != nil {
+		t.Fatalf("update assertion: %v", err)
+	}
+	normalize := func(dryRun bool) []*IdentifierChange {
+		t.Helper()
+		var changes []*IdentifierChange
+		for c, err := range repo.NormalizeStoredIdentifiers(ctx, dryRun) {
+			if err != nil {
+				t.Fatalf("normalize identifiers: %v", err)
+			}
+			changes = append(changes, c)
+		}
+		return changes
+	}
+	storedVal := func() string {
+		t.Helper()
+		var val string
+		if err := repo.db.QueryRow(ctx, `
+			SELECT val->>'val' FROM bbl_work_assertions
+			WHERE work_id = $1 AND field = 'identifiers'`, work.ID).Scan(&val); err != nil {
+			t.Fatalf("stored value: %v", err)
+		}
+		return val
+	}
+
+	if changes := normalize(true); len(changes) != 1 || changes[0].New != "10.1000/abc" {
+		t.Fatalf("dry run changes = %+v", changes)
+	}
+	if val := storedVal(); val != "https://doi.org/10.1000/ABC" {
+		t.Errorf("dry run wrote %q", val)
+	}
+	if changes := normalize(false); len(changes) != 1 || changes[0].RecordID != work.ID {
+		t.Fatalf("changes = %+v", changes)
+	}
+	if val := storedVal(); val != "10.1000/abc" {
+		t.Errorf("stored value = %q, want 10.1000/abc", val)
+	}
+	if changes := normalize(false); len(changes) != 0 {
+		t.Errorf("second run changes = %+v", changes)
+	}
+
+	// A value of the same source that becomes equal is removed.
+	if _, err := repo.db.Exec(ctx, `
+		INSERT INTO bbl_work_assertions (rev_id, work_id, field, val, work_source_id, pinned)
+		SELECT rev_id, work_id, field, '{"scheme": "doi", "val": "doi:10.1000/ABC"}', work_source_id, pinned
+		FROM bbl_work_assertions
+		WHERE work_id = $1 AND field = 'identifiers'`, work.ID); err != nil {
+		t.Fatalf("insert assertion: %v", err)
+	}
+	if changes := normalize(false); len(changes) != 1 || !changes[0].Removed || changes[0].Old != "doi:10.1000/ABC" {
+		t.Fatalf("duplicate changes = %+v", changes)
+	}
+	if val := storedVal(); val != "10.1000/abc" {
+		t.Errorf("stored value = %q, want 10.1000/abc", val)
+	}
+	if w, err := repo.GetWork(ctx, work.ID); err != nil || len(w.Identifiers) != 1 || w.Version <= work.Version {
+		t.Errorf("work = %+v, err = %v", w, err)
+	}
+}
@@ -26,6 +26,12 @@ type SearchOpts struct {
 	Size   int          `json:"size"`
 	Cursor string       `json:"cursor,omitempty"` // base64-encoded search_after; mutually exclusive with Offset
 	Offset int          `json:"offset,omitempty"` // for UI pagination; hard max enforced by implementation
+	// Within is a text query that hits must also match, without affecting
+	// scoring. Query collections use it to scope searches.
+	Within string `json:"within,omitempty"`
+	// IDs restricts hits to these records. Empty means no restriction.
+	// Manual collections use it to scope searches.
+	IDs []ID `json:"ids,omitempty"`
 }
 
 // WithFilter adds a terms filter to the search opts and returns them for chaining.
@@ -218,6 +224,8 @@ func searchAll[H any](ctx context.Context, opts *SearchOpts, search func(context
 		o := &SearchOpts{
 			Query:  opts.Query,
 			Filter: opts.Filter,
+			Within: opts.Within,
+			IDs:    opts.IDs,
 			Size:   searchAllSize,
 		}
 		for {
@@ -50,6 +50,13 @@ func truncateTables(t *testing.T, repo *Repo) {
 			bbl_work_assertion_projects,
 			bbl_work_assertion_organizations,
 			bbl_work_assertion_rels,
+			bbl_work_assertion_files,
+			bbl_files,
+			bbl_embargo_notices,
+			bbl_work_collection_works,
+			bbl_work_collections,
+			bbl_list_items,
+			bbl_lists,
 			bbl_person_assertions,
 			bbl_person_assertion_affiliations,
 			bbl_project_assertions,
@@ -0,0 +1,378 @@
+package bbl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// List is a user curated, ordered list of works. Public lists can be viewed
+// by anyone with the link; only the owner and admins can change a list.
+type List struct {
+	ID          ID        `json:"id"`
+	Version     int       `json:"version"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Public      bool      `json:"public"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	CreatedByID *ID       `json:"created_by_id,omitempty"`
+	ItemCount   int       `json:"item_count"`
+}
+
+// ListAttrs are the editable attributes of a list.
+type ListAttrs struct {
+	Name        string
+	Description string
+	Public      bool
+}
+
+// ListItem is a work on a list.
+type ListItem struct {
+	WorkID  ID        `json:"work_id"`
+	Note    string    `json:"note,omitempty"`
+	AddedAt time.Time `json:"added_at"`
+	pos     string
+}
+
+// CanView reports whether user may view the list.
+func (l *List) CanView(user *User) bool {
+	return l.Public || l.CanEdit(user)
+}
+
+// CanEdit reports whether user may change the list and its items.
+func (l *List) CanEdit(user *User) bool {
+	if user == nil {
+		return false
+	}
+	return user.Role == RoleAdmin || (l.CreatedByID != nil && *l.CreatedByID == user.ID)
+}
+
+const listCols = `l.id, l.version, l.name, l.description, l.public, l.created_at, l.updated_at, l.created_by_id,
+	(SELECT count(*) FROM bbl_list_items i WHERE i.list_id = l.id)`
+
+func scanList(row pgx.Row) (*List, error) {
+	var l List
+	err := row.Scan(&l.ID, &l.Version, &l.Name, &l.Description, &l.Public, &l.CreatedAt, &l.UpdatedAt, &l.CreatedByID, &l.ItemCount)
+	if err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+func validateListAttrs(attrs *ListAttrs) error {
+	attrs.Name = strings.TrimSpace(attrs.Name)
+	attrs.Description = strings.TrimSpace(attrs.Description)
+	if attrs.Name == "" {
+		return fmt.Errorf("list name is required")
+	}
+	return nil
+}
+
+// CreateList creates an empty list owned by user.
+func (r *Repo) CreateList(ctx context.Context, user *User, attrs ListAttrs) (*List, error) {
+	if err := validateListAttrs(&attrs); err != nil {
+		return nil, fmt.Errorf("CreateList: %w", err)
+	}
+	var createdByID *ID
+	if user != nil {
+		createdByID = &user.ID
+	}
+	id := newID()
+	if _, err := r.db.Exec(ctx, `
+		INSERT INTO bbl_lists (id, version, name, description, public, entity_type, created_by_id)
+		VALUES ($1, 1, $2, $3, $4, $5, $6)`,
+		id, attrs.Name, attrs.Description, attrs.Public, RecordTypeWork, createdByID); err != nil {
+		return nil, fmt.Errorf("CreateList: %w", err)
+	}
+	return r.GetList(ctx, id)
+}
+
+// GetList fetches a list by ID. Returns ErrNotFound if it does not exist.
+func (r *Repo) GetList(ctx context.Context, id ID) (*List, error) {
+	l, err := scanList(r.db.QueryRow(ctx, `SELECT `+listCols+` FROM bbl_lists l WHERE l.id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("GetList: %w", err)
+	}
+	return l, nil
+}
+
+// GetUserLists returns the lists owned by a user, most recently updated first.
+func (r *Repo) GetUserLists(ctx context.Context, userID ID) ([]*List, error) {
+	return r.queryLists(ctx, `
+		SELECT `+listCols+`
+		FROM bbl_lists l
+		WHERE l.created_by_id = $1
+		ORDER BY l.updated_at DESC`, userID)
+}
+
+// GetAllLists returns all lists, most recently updated first.
+func (r *Repo) GetAllLists(ctx context.Context) ([]*List, error) {
+	return r.queryLists(ctx, `SELECT `+listCols+` FROM bbl_lists l ORDER BY l.updated_at DESC`)
+}
+
+func (r *Repo) queryLists(ctx context.Context, query string, args ...any) ([]*List, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("queryLists: %w", err)
+	}
+	lists, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*List, error) {
+		return scanList(row)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("queryLists: %w", err)
+	}
+	return lists, nil
+}
+
+// UpdateList changes the name, description and visibility of a list.
+func (r *Repo) UpdateList(ctx context.Context, id ID, attrs ListAttrs) error {
+	if err := validateListAttrs(&attrs); err != nil {
+		return fmt.Errorf("UpdateList: %w", err)
+	}
+	tag, err := r.db.Exec(ctx, `
+		UPDATE bbl_lists
+		SET name = $2, description = $3, public = $4,
+		    version = version + 1, updated_at = transaction_timestamp()
+		WHERE id = $1`,
+		id, attrs.Name, attrs.Description, attrs.Public)
+	if err != nil {
+		return fmt.Errorf("UpdateList: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeleteList deletes a list and its items.
+func (r *Repo) DeleteList(ctx context.Context, id ID) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM bbl_lists WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("DeleteList: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// GetListItems returns the items of a list in order.
+func (r *Repo) GetListItems(ctx context.Context, listID ID) ([]ListItem, error) {
+	items, err := getListItems(ctx, r.db, listID)
+	if err != nil {
+		return nil, fmt.Errorf("GetListItems: %w", err)
+	}
+	return items, nil
+}
+
+// listQueryer is satisfied by both the pool and a transaction.
+type listQueryer interface {
+	Query(context.Context, string, ...any) (pgx.Rows, error)
+}
+
+func getListItems(ctx context.Context, conn listQueryer, listID ID) ([]ListItem, error) {
+	rows, err := conn.Query(ctx, `
+		SELECT entity_id, note, added_at, pos
+		FROM bbl_list_items
+		WHERE list_id = $1 AND entity_type = $2
+		ORDER BY pos`, listID, RecordTypeWork)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (ListItem, error) {
+		var item ListItem
+		err := row.Scan(&item.WorkID, &item.Note, &item.AddedAt, &item.pos)
+		return item, err
+	})
+}
+
+// AddListItems appends works to the end of a list, in the given order.
+// Works already on the list keep their place. Returns the number of works
+// added.
+func (r *Repo) AddListItems(ctx context.Context, listID ID, workIDs []ID) (int, error) {
+	var n int
+	err := r.updateList(ctx, listID, func(tx pgx.Tx, items []ListItem) error {
+		onList := make(map[ID]bool, len(items))
+		last := ""
+		for _, item := range items {
+			onList[item.WorkID] = true
+			last = item.pos
+		}
+		batch := &pgx.Batch{}
+		for _, id := range workIDs {
+			if onList[id] {
+				continue
+			}
+			onList[id] = true
+			pos, err := posBetween(last, "")
+			if err != nil {
+				return err
+			}
+			last = pos
+			batch.Queue(`
+				INSERT INTO bbl_list_items (list_id, entity_type, entity_id, pos)
+				SELECT $1, $2, id, $4 FROM bbl_works WHERE id = $3`,
+				listID, RecordTypeWork, id, pos)
+		}
+		if batch.Len() == 0 {
+			return nil
+		}
+		res := tx.SendBatch(ctx, batch)
+		for range batch.Len() {
+			tag, err := res.Exec()
+			if err != nil {
+				res.Close()
+				return err
+			}
+			n += int(tag.RowsAffected())
+		}
+		return res.Close()
+	})
+	if err != nil {
+		return 0, fmt.Errorf("AddListItems: %w", err)
+	}
+	return n, nil
+}
+
+// RemoveListItem removes a work from a list. Removing a work that is not on
+// the list is a noop.
+func (r *Repo) RemoveListItem(ctx context.Context, listID, workID ID) error {
+	err := r.updateList(ctx, listID, func(tx pgx.Tx, items []ListItem) error {
+		_, err := tx.Exec(ctx, `
+			DELETE FROM bbl_list_items
+			WHERE list_id = $1 AND entity_type = $2 AND entity_id = $3`,
+			listID, RecordTypeWork, workID)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("RemoveListItem: %w", err)
+	}
+	return nil
+}
+
+// SetListItemNote sets the note on a list item.
+func (r *Repo) SetListItemNote(ctx context.Context, listID, workID ID, note string) error {
+	err := r.updateList(ctx, listID, func(tx pgx.Tx, items []ListItem) error {
+		tag, err := tx.Exec(ctx, `
+			UPDATE bbl_list_items SET note = $4
+			WHERE list_id = $1 AND entity_type = $2 AND entity_id = $3`,
+			listID, RecordTypeWork, workID, strings.TrimSpace(note))
+		if err == nil && tag.RowsAffected() == 0 {
+			return ErrNotFound
+		}
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("SetListItemNote: %w", err)
+	}
+	return nil
+}
+
+// MoveListItem moves a work to index i of the list. Indexes are clamped to
+// the list bounds.
+func (r *Repo) MoveListItem(ctx context.Context, listID, workID ID, i int) error {
+	err := r.updateList(ctx, listID, func(tx pgx.Tx, items []ListItem) error {
+		var others []ListItem
+		var found bool
+		for _, item := range items {
+			if item.WorkID == workID {
+				found = true
+				continue
+			}
+			others = append(others, item)
+		}
+		if !found {
+			return ErrNotFound
+		}
+		i = max(0, min(i, len(others)))
+		var before, after string
+		if i > 0 {
+			before = others[i-1].pos
+		}
+		if i < len(others) {
+			after = others[i].pos
+		}
+		pos, err := posBetween(before, after)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `
+			UPDATE bbl_list_items SET pos = $4
+			WHERE list_id = $1 AND entity_type = $2 AND entity_id = $3`,
+			listID, RecordTypeWork, workID, pos)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("MoveListItem: %w", err)
+	}
+	return nil
+}
+
+// updateList locks a list, passes its current items to fn and bumps the list
+// version, all in one transaction.
+func (r *Repo) updateList(ctx context.Context, listID ID, fn func(pgx.Tx, []ListItem) error) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE bbl_lists SET version = version + 1, updated_at = transaction_timestamp()
+		WHERE id = $1`, listID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	items, err := getListItems(ctx, tx, listID)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx, items); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// EachListWork iterates over the works of a list in list order. Works that
+// are not public are skipped unless all is set.
+func (r *Repo) EachListWork(ctx context.Context, listID ID, all bool) iter.Seq2[*Work, error] {
+	return func(yield func(*Work, error) bool) {
+		items, err := r.GetListItems(ctx, listID)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		for chunk := range slices.Chunk(items, searchAllSize) {
+			ids := make([]ID, len(chunk))
+			for i, item := range chunk {
+				ids[i] = item.WorkID
+			}
+			works, err := r.GetWorks(ctx, ids)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for _, w := range works {
+				if !all && w.Status != WorkStatusPublic {
+					continue
+				}
+				if !yield(w, nil) {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package bbl
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestLists(t *testing.T) {
+	repo := testRepo(t)
+	ctx := context.Background()
+	user := createTestUser(t, repo, RoleUser)
+
+	var ids []ID
+	for i := range 4 {
+		id := newID()
+		status := WorkStatusPrivate
+		if i%2 == 0 {
+			status = WorkStatusPublic
+		}
+		if _, _, err := repo.Update(ctx, user,
+			&CreateWork{ID: id, Kind: "journal_article", Status: status},
+			&Set{RecordType: RecordTypeWork, RecordID: id, Field: "titles", Val: []Title{{Lang: "eng", Val: "Paper"}}},
+			&Set{RecordType: RecordTypeWork, RecordID: id, Field: "journal_title", Val: "Journal"},
+			&Set{RecordType: RecordTypeWork, RecordID: id, Field: "publication_year", Val: "2024"},
+		); err != nil {
+			t.Fatalf("create work: %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if _, err := repo.CreateList(ctx, user, ListAttrs{Name: "  "}); err == nil {
+		t.Error("expected an error for an empty list name")
+	}
+	list, err := repo.CreateList(ctx, user, ListAttrs{Name: "Reading list", Public: true})
+	if err != nil {
+		t.Fatalf("create list: %v", err)
+	}
+	if list.CreatedByID == nil || *list.CreatedByID != user.ID || list.Version != 1 {
+		t.Errorf("list = %+v", list)
+	}
+
+	// Works already on the list and unknown works are skipped.
+	n, err := repo.AddListItems(ctx, list.ID, []ID{ids[0], ids[1], ids[0], newID()})
+	if err != nil {
+		t.Fatalf("add items: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("added %d, want 2", n)
+	}
+	if n, err = repo.AddListItems(ctx, list.ID, []ID{ids[2], ids[1], ids[3]}); err != nil || n != 2 {
+		t.Fatalf("add items: n = %d, err = %v", n, err)
+	}
+	assertListOrder(t, repo, list.ID, ids[0], ids[1], ids[2], ids[3])
+
+	if err := repo.MoveListItem(ctx, list.ID, ids[3], 0); err != nil {
+		t.Fatalf("move: %v", err)
+	}
+	if err := repo.MoveListItem(ctx, list.ID, ids[0], 2); err != nil {
+		t.Fatalf("move: %v", err)
+	}
+	assertListOrder(t, repo, list.ID, ids[3], ids[1], ids[0], ids[2])
+	if err := repo.MoveListItem(ctx, list.ID, newID(), 0); !errors.Is(err, ErrNotFound) {
+		t.Errorf("move unknown item: err = %v, want ErrNotFound", err)
+	}
+
+	if err := repo.SetListItemNote(ctx, list.ID, ids[1], " must read "); err != nil {
+		t.Fatalf("set note: %v", err)
+	}
+	items, err := repo.GetListItems(ctx, list.ID)
+	if err != nil {
+		t.Fatalf("get items: %v", err)
+	}
+	if items[1].Note != "must read" {
+		t.Errorf("note = %q, want %q", items[1].Note, "must read")
+	}
+
+	var public []ID
+	for w, err := range repo.EachListWork(ctx, list.ID, false) {
+		if err != nil {
+			t.Fatalf("each list work: %v", err)
+		}
+		public = append(public, w.ID)
+	}
+	if len(public) != 2 || public[0] != ids[0] || public[1] != ids[2] {
+		t.Errorf("public works = %v, want [%s %s]", public, ids[0], ids[2])
+	}
+
+	if err := repo.RemoveListItem(ctx, list.ID, ids[3]); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	assertListOrder(t, repo, list.ID, ids[1], ids[0], ids[2])
+
+	if err := repo.UpdateList(ctx, list.ID, ListAttrs{Name: "Renamed"}); err != nil {
+		t.Fatalf("update list: %v", err)
+	}
+	list, err = repo.GetList(ctx, list.ID)
+	if err != nil {
+		t.Fatalf("get list: %v", err)
+	}
+	if list.Name != "Renamed" || list.Public || list.ItemCount != 3 || list.Version < 2 {
+		t.Errorf("list = %+v", list)
+	}
+
+	lists, err := repo.GetUserLists(ctx, user.ID)
+	if err != nil || len(lists) != 1 {
+		t.Fatalf("user lists = %v, err = %v", lists, err)
+	}
+
+	if err := repo.DeleteList(ctx, list.ID); err != nil {
+		t.Fatalf("delete list: %v", err)
+	}
+	if _, err := repo.GetList(ctx, list.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("get deleted list: err = %v, want ErrNotFound", err)
+	}
+}
+
+func assertListOrder(t *testing.T, repo *Repo, listID ID, want ...ID) {
+	t.Helper()
+	items, err := repo.GetListItems(context.Background(), listID)
+	if err != nil {
+		t.Fatalf("get items: %v", err)
+	}
+	if len(items) != len(want) {
+		t.Fatalf("got %d items, want %d", len(items), len(want))
+	}
+	for i, item := range items {
+		if item.WorkID != want[i] {
+			t.Errorf("item %d = %s, want %s", i, item.WorkID, want[i])
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package bbl
+
+import "testing"
+
+func TestList_Permissions(t *testing.T) {
+	owner := &User{ID: newID(), Role: RoleUser}
+	other := &User{ID: newID(), Role: RoleUser}
+	curator := &User{ID: newID(), Role: RoleCurator}
+	admin := &User{ID: newID(), Role: RoleAdmin}
+
+	private := &List{CreatedByID: &owner.ID}
+	public := &List{CreatedByID: &owner.ID, Public: true}
+
+	tests := []struct {
+		name             string
+		list             *List
+		user             *User
+		canView, canEdit bool
+	}{
+		{"owner private", private, owner, true, true},
+		{"other private", private, other, false, false},
+		{"curator private", private, curator, false, false},
+		{"admin private", private, admin, true, true},
+		{"anonymous private", private, nil, false, false},
+		{"other public", public, other, true, false},
+		{"anonymous public", public, nil, true, false},
+		{"owner public", public, owner, true, true},
+	}
+	for _, tt := range tests {
+		if got := tt.list.CanView(tt.user); got != tt.canView {
+			t.Errorf("%s: CanView = %v, want %v", tt.name, got, tt.canView)
+		}
+		if got := tt.list.CanEdit(tt.user); got != tt.canEdit {
+			t.Errorf("%s: CanEdit = %v, want %v", tt.name, got, tt.canEdit)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package localfilestore
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ugent-library/bbl"
+)
+
+// Config holds the storage directory and the URL the store's handler is
+// served at.
+type Config struct {
+	Dir     string `yaml:"dir"`
+	BaseURL string `yaml:"base_url"` // e.g. "http://localhost:3000/files"
+	Secret  string `yaml:"secret"`   // HMAC key for signing URLs
+}
+
+// FileStore stores objects as files in a directory. Presigned URLs point to
+// the store itself; mount it as an http.Handler under BaseURL. Meant for
+// tests and development without S3.
+type FileStore struct {
+	dir     string
+	baseURL string
+	secret  []byte
+}
+
+// New returns a FileStore for the given Config, creating Dir if needed.
+func New(c Config) (*FileStore, error) {
+	if c.Dir == "" {
+		return nil, fmt.Errorf("localfilestore: dir is required")
+	}
+	if err := os.MkdirAll(c.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("localfilestore: %w", err)
+	}
+	return &FileStore{
+		dir:     c.Dir,
+		baseURL: strings.TrimRight(c.BaseURL, "/"),
+		secret:  []byte(c.Secret),
+	}, nil
+}
+
+func (s *FileStore) PresignPut(ctx context.Context, key, contentType string, ttl time.Duration) (string, error) {
+	return s.presign(http.MethodPut, key, contentType, ttl)
+}
+
+func (s *FileStore) PresignGet(ctx context.Context, key, filename string, ttl time.Duration) (string, error) {
+	return s.presign(http.MethodGet, key, filename, ttl)
+}
+
+func (s *FileStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, bbl.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("localfilestore: get %s: %w", key, err)
+	}
+	return f, nil
+}
+
+func (s *FileStore) Put(ctx context.Context, key string, r io.Reader, contentType string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	// Write to a temp file first so readers never see a partial object.
+	tmp, err := os.CreateTemp(s.dir, ".upload-*")
+	if err != nil {
+		return fmt.Errorf("localfilestore: put %s: %w", key, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return fmt.Errorf("localfilestore: put %s: %w", key, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("localfilestore: put %s: %w", key, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("localfilestore: put %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *FileStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("localfilestore: delete %s: %w", key, err)
+	}
+	return nil
+}
+
+// ServeHTTP serves presigned GET and PUT requests. The path below the mount
+// point is the object key.
+func (s *FileStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, "/")
+	q := r.URL.Query()
+	expires, err := strconv.ParseInt(q.Get("expires"), 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		http.Error(w, "expired", http.StatusForbidden)
+		return
+	}
+	// PUT signs the content type, GET the download filename.
+	param := q.Get("filename")
+	if r.Method == http.MethodPut {
+		param = r.Header.Get("Content-Type")
+	}
+	if !hmac.Equal([]byte(q.Get("sig")), []byte(s.sign(r.Method, key, param, expires))) {
+		http.Error(w, "invalid signature", http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		if err := s.Put(r.Context(), key, r.Body, param); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet:
+		path, err := s.path(key)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": param}))
+		http.ServeFile(w, r, path)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *FileStore) presign(method, key, param string, ttl time.Duration) (string, error) {
+	if _, err := s.path(key); err != nil {
+		return "", err
+	}
+	expires := time.Now().Add(ttl).Unix()
+	q := url.Values{}
+	q.Set("expires", strconv.FormatInt(expires, 10))
+	if method == http.MethodGet {
+		q.Set("filename", param)
+	}
+	q.Set("sig", s.sign(method, key, param, expires))
+	return s.baseURL + "/" + url.PathEscape(key) + "?" + q.Encode(), nil
+}
+
+func (s *FileStore) sign(method, key, param string, expires int64) string {
+	mac := hmac.New(sha256.New, s.secret)
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%d", method, key, param, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// path maps a key to a file in the store directory. Keys are flat names;
+// anything that could escape the directory is rejected.
+func (s *FileStore) path(key string) (string, error) {
+	if key == "" || strings.ContainsAny(key, `/\`) || !filepath.IsLocal(key) {
+		return "", fmt.Errorf("localfilestore: invalid key %q", key)
+	}
+	return filepath.Join(s.dir, key), nil
+}
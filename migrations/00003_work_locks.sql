@@ -0,0 +1,46 @@
+-- +goose up
+
+-- ============================================================
+-- WORK LOCKS
+-- Explicit curator endorsement, separate from assertions. A lock on
+-- field '' covers the whole work. Source re-imports that disagree with
+-- a locked field are queued in bbl_work_lock_conflicts instead of
+-- being written.
+-- ============================================================
+
+CREATE TABLE bbl_work_locks (
+    work_id      uuid NOT NULL REFERENCES bbl_works (id) ON DELETE CASCADE,
+    field        text NOT NULL DEFAULT '',   -- '' = whole work
+    rev_id       bigint REFERENCES bbl_revs (id) ON DELETE SET NULL,
+    locked_by_id uuid REFERENCES bbl_users (id) ON DELETE SET NULL,
+    locked_at    timestamptz NOT NULL DEFAULT transaction_timestamp(),
+    note         text,
+    PRIMARY KEY (work_id, field)
+);
+
+CREATE TABLE bbl_work_lock_conflicts (
+    id              bigint GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    work_id         uuid NOT NULL REFERENCES bbl_works (id) ON DELETE CASCADE,
+    field           text NOT NULL,
+    work_source_id  uuid NOT NULL REFERENCES bbl_work_sources (id) ON DELETE CASCADE,
+    rev_id          bigint REFERENCES bbl_revs (id) ON DELETE SET NULL,
+    locked_val      jsonb,             -- pinned value at the time of import
+    source_val      jsonb,             -- value asserted by the source; NULL = source dropped the field
+    created_at      timestamptz NOT NULL DEFAULT transaction_timestamp(),
+    updated_at      timestamptz NOT NULL DEFAULT transaction_timestamp(),
+    resolution      text,              -- NULL | 'accepted' | 'dismissed'
+    resolved_at     timestamptz,
+    resolved_by_id  uuid REFERENCES bbl_users (id) ON DELETE SET NULL,
+    resolved_rev_id bigint REFERENCES bbl_revs (id) ON DELETE SET NULL,
+    CHECK (field <> ''),
+    CHECK (resolution <> ''),
+    CHECK ((resolution IS NULL) = (resolved_at IS NULL))
+);
+
+CREATE UNIQUE INDEX ON bbl_work_lock_conflicts (work_id, field, work_source_id)
+  WHERE resolved_at IS NULL;
+CREATE INDEX ON bbl_work_lock_conflicts (created_at) WHERE resolved_at IS NULL;
+
+-- +goose down
+DROP TABLE IF EXISTS bbl_work_lock_conflicts CASCADE;
+DROP TABLE IF EXISTS bbl_work_locks CASCADE;
@@ -0,0 +1,23 @@
+-- +goose up
+
+-- ============================================================
+-- WORK REDIRECTS
+-- A merged work is soft-deleted and redirects to the surviving work.
+-- status is the merged work's status at merge time, so OAI-PMH only
+-- reports merged records that were public as deleted.
+-- ============================================================
+
+CREATE TABLE bbl_work_redirects (
+    id         uuid PRIMARY KEY REFERENCES bbl_works (id) ON DELETE CASCADE,
+    work_id    uuid NOT NULL REFERENCES bbl_works (id) ON DELETE CASCADE,
+    rev_id     bigint REFERENCES bbl_revs (id) ON DELETE SET NULL,
+    status     text NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT transaction_timestamp(),
+    CHECK (id <> work_id),
+    CHECK (status <> '')
+);
+
+CREATE INDEX ON bbl_work_redirects (work_id);
+
+-- +goose down
+DROP TABLE IF EXISTS bbl_work_redirects CASCADE;
@@ -0,0 +1,37 @@
+-- +goose up
+
+-- ============================================================
+-- DUPLICATE DETECTION
+-- Match keys (normalized identifiers, title and name blocks) per
+-- record, and the candidate pairs derived from them. Pairs are
+-- clustered on read. Both tables are derived data: a full run
+-- rebuilds them, revisions refresh the records they touch.
+-- ============================================================
+
+CREATE TABLE bbl_duplicate_keys (
+    record_type text NOT NULL,
+    record_id   uuid NOT NULL,
+    kind        text NOT NULL,   -- doi | isbn | pmid | title | identifier | name | name_initial
+    key         text NOT NULL,
+    PRIMARY KEY (record_type, record_id, kind, key)
+);
+
+CREATE INDEX ON bbl_duplicate_keys (record_type, kind, key);
+
+CREATE TABLE bbl_duplicates (
+    record_type text NOT NULL,
+    id          uuid NOT NULL,
+    other_id    uuid NOT NULL,
+    confidence  real NOT NULL,
+    reasons     text[] NOT NULL,
+    updated_at  timestamptz NOT NULL DEFAULT transaction_timestamp(),
+    PRIMARY KEY (record_type, id, other_id),
+    CHECK (id < other_id),
+    CHECK (confidence > 0 AND confidence <= 1)
+);
+
+CREATE INDEX ON bbl_duplicates (record_type, other_id);
+
+-- +goose down
+DROP TABLE IF EXISTS bbl_duplicates CASCADE;
+DROP TABLE IF EXISTS bbl_duplicate_keys CASCADE;
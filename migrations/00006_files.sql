@@ -0,0 +1,38 @@
+-- +goose up
+
+-- ============================================================
+-- FILES
+-- bbl_files registers every stored object. Rows start pending when
+-- an upload URL is handed out and become uploaded once the object
+-- has been checksummed. Works reference files through the "files"
+-- field: the assertion val holds access level, embargo and license,
+-- the extension table holds the file FK.
+-- ============================================================
+
+CREATE TABLE bbl_files (
+    id            uuid PRIMARY KEY,
+    name          text NOT NULL,
+    content_type  text NOT NULL DEFAULT 'application/octet-stream',
+    size          bigint NOT NULL DEFAULT 0,
+    sha256        text,
+    status        text NOT NULL DEFAULT 'pending',  -- pending | uploaded
+    created_at    timestamptz NOT NULL DEFAULT transaction_timestamp(),
+    created_by_id uuid REFERENCES bbl_users (id) ON DELETE SET NULL,
+    uploaded_at   timestamptz,
+    CHECK (name <> ''),
+    CHECK (status IN ('pending', 'uploaded'))
+);
+
+CREATE TABLE bbl_work_assertion_files (
+    assertion_id bigint PRIMARY KEY REFERENCES bbl_work_assertions (id) ON DELETE CASCADE,
+    file_id      uuid NOT NULL REFERENCES bbl_files (id) ON DELETE RESTRICT
+);
+
+CREATE INDEX ON bbl_work_assertion_files (file_id);
+
+-- bbl_work_files is superseded by the files field and no longer written.
+-- It is kept with its rows so they can still be migrated to files.
+
+-- +goose down
+DROP TABLE IF EXISTS bbl_work_assertion_files CASCADE;
+DROP TABLE IF EXISTS bbl_files CASCADE;
@@ -0,0 +1,21 @@
+-- +goose up
+
+-- ============================================================
+-- EMBARGOES
+-- Embargoes live in the "files" assertion vals; the embargo job
+-- lifts expired ones in a system revision. Notices sent to
+-- depositors before expiry are recorded here so each embargo date
+-- is announced once. Changing the date allows a new notice.
+-- ============================================================
+
+CREATE TABLE bbl_embargo_notices (
+    work_id       uuid NOT NULL REFERENCES bbl_works (id) ON DELETE CASCADE,
+    file_id       uuid NOT NULL REFERENCES bbl_files (id) ON DELETE CASCADE,
+    embargo_until timestamptz NOT NULL,
+    user_id       uuid REFERENCES bbl_users (id) ON DELETE SET NULL,
+    sent_at       timestamptz NOT NULL DEFAULT transaction_timestamp(),
+    PRIMARY KEY (work_id, file_id, embargo_until)
+);
+
+-- +goose down
+DROP TABLE IF EXISTS bbl_embargo_notices CASCADE;
@@ -0,0 +1,25 @@
+-- +goose up
+
+-- ============================================================
+-- LISTS
+-- User curated, ordered lists of works. Items are ordered by a
+-- fractional pos key (see position.go) so moving an item updates
+-- one row. Public lists can be viewed by anyone with the link.
+-- ============================================================
+
+ALTER TABLE bbl_lists
+    ADD COLUMN description text NOT NULL DEFAULT '',
+    ADD CONSTRAINT bbl_lists_name_check CHECK (name <> '');
+
+ALTER TABLE bbl_list_items
+    ADD COLUMN note     text NOT NULL DEFAULT '',
+    ADD COLUMN added_at timestamptz NOT NULL DEFAULT transaction_timestamp();
+
+-- +goose down
+ALTER TABLE bbl_list_items
+    DROP COLUMN IF EXISTS added_at,
+    DROP COLUMN IF EXISTS note;
+
+ALTER TABLE bbl_lists
+    DROP CONSTRAINT IF EXISTS bbl_lists_name_check,
+    DROP COLUMN IF EXISTS description;
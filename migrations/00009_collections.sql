@@ -0,0 +1,46 @@
+-- +goose up
+
+-- ============================================================
+-- COLLECTIONS
+-- Named sets of works, exposed on public pages, as OAI-PMH sets
+-- and as SRU databases. Manual collections list their works in
+-- bbl_work_collection_works; query collections store a search
+-- query and filter expression that are evaluated through the
+-- search index. name is the OAI-PMH setSpec and SRU database
+-- name, so it is restricted to URL and setSpec safe characters.
+-- ============================================================
+
+ALTER TABLE bbl_work_collections
+    ALTER COLUMN description SET DEFAULT '',
+    ADD COLUMN title      text NOT NULL DEFAULT '',
+    ADD COLUMN kind       text NOT NULL DEFAULT 'manual',
+    ADD COLUMN query      text NOT NULL DEFAULT '',
+    ADD COLUMN filter     text NOT NULL DEFAULT '',
+    ADD COLUMN public     boolean NOT NULL DEFAULT false,
+    ADD COLUMN created_at timestamptz NOT NULL DEFAULT transaction_timestamp(),
+    ADD COLUMN updated_at timestamptz NOT NULL DEFAULT transaction_timestamp(),
+    ADD CONSTRAINT bbl_work_collections_name_check CHECK (name ~ '^[a-z0-9][a-z0-9_.-]*$'),
+    ADD CONSTRAINT bbl_work_collections_kind_check CHECK (kind IN ('manual', 'query'));
+
+UPDATE bbl_work_collections SET description = '' WHERE description IS NULL;
+ALTER TABLE bbl_work_collections ALTER COLUMN description SET NOT NULL;
+
+ALTER TABLE bbl_work_collection_works
+    ADD COLUMN added_at timestamptz NOT NULL DEFAULT transaction_timestamp();
+
+-- +goose down
+ALTER TABLE bbl_work_collection_works
+    DROP COLUMN IF EXISTS added_at;
+
+ALTER TABLE bbl_work_collections
+    DROP CONSTRAINT IF EXISTS bbl_work_collections_kind_check,
+    DROP CONSTRAINT IF EXISTS bbl_work_collections_name_check,
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS created_at,
+    DROP COLUMN IF EXISTS public,
+    DROP COLUMN IF EXISTS filter,
+    DROP COLUMN IF EXISTS query,
+    DROP COLUMN IF EXISTS kind,
+    DROP COLUMN IF EXISTS title,
+    ALTER COLUMN description DROP NOT NULL,
+    ALTER COLUMN description DROP DEFAULT;
@@ -0,0 +1,30 @@
+-- +goose up
+
+-- ============================================================
+-- BATCH EDITS
+-- Batch-edit CSVs uploaded in the backoffice. An upload is first
+-- checked (diffed against current values) and, once confirmed,
+-- applied. Large files are checked and applied by the background
+-- worker; processed/total report progress. result holds the
+-- changes, conflicts and errors of the last check or apply.
+-- ============================================================
+
+CREATE TABLE bbl_batch_edits (
+    id         uuid PRIMARY KEY,
+    user_id    uuid NOT NULL REFERENCES bbl_users (id) ON DELETE CASCADE,
+    filename   text NOT NULL DEFAULT '',
+    data       bytea NOT NULL,
+    status     text NOT NULL CHECK (status IN ('checking', 'checked', 'applying', 'applied', 'failed')),
+    total      int NOT NULL DEFAULT 0,
+    processed  int NOT NULL DEFAULT 0,
+    applied    int NOT NULL DEFAULT 0,
+    result     jsonb,
+    error      text NOT NULL DEFAULT '',
+    created_at timestamptz NOT NULL DEFAULT transaction_timestamp(),
+    updated_at timestamptz NOT NULL DEFAULT transaction_timestamp()
+);
+
+CREATE INDEX ON bbl_batch_edits (user_id, created_at);
+
+-- +goose down
+DROP TABLE IF EXISTS bbl_batch_edits CASCADE;
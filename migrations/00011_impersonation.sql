@@ -0,0 +1,18 @@
+-- +goose up
+
+-- ============================================================
+-- IMPERSONATION
+-- Admins can act as another user in the backoffice. Revisions
+-- written meanwhile have user_id set to the impersonated user and
+-- impersonator_id to the admin. Starting and stopping are logged
+-- in bbl_user_events on the impersonated user, with the admin as
+-- performed_by_id.
+-- ============================================================
+
+ALTER TABLE bbl_revs
+    ADD COLUMN impersonator_id uuid REFERENCES bbl_users (id) ON DELETE SET NULL;
+
+CREATE INDEX ON bbl_revs (impersonator_id) WHERE impersonator_id IS NOT NULL;
+
+-- +goose down
+ALTER TABLE bbl_revs DROP COLUMN IF EXISTS impersonator_id;
@@ -0,0 +1,17 @@
+-- +goose up
+
+-- ============================================================
+-- SOURCE CHECKPOINTS
+-- Sources that harvest incrementally store where the last
+-- successful run left off: a datestamp or an opaque token that
+-- only the source interprets.
+-- ============================================================
+
+ALTER TABLE bbl_sources
+    ADD COLUMN checkpoint    text,
+    ADD COLUMN checkpoint_at timestamptz;
+
+-- +goose down
+ALTER TABLE bbl_sources
+    DROP COLUMN IF EXISTS checkpoint_at,
+    DROP COLUMN IF EXISTS checkpoint;
@@ -0,0 +1,20 @@
+-- +goose up
+
+-- ============================================================
+-- WORK SOURCE SWEEPS
+-- Every import stamps last_seen_at. After a full harvest, source
+-- records not seen since the start of the run are withdrawn: their
+-- assertions are deleted and withdrawn_at is set. The row itself
+-- stays, so a record that comes back lands in the same work.
+-- ============================================================
+
+ALTER TABLE bbl_work_sources
+    ADD COLUMN last_seen_at timestamptz NOT NULL DEFAULT transaction_timestamp(),
+    ADD COLUMN withdrawn_at timestamptz;
+
+CREATE INDEX ON bbl_work_sources (source, last_seen_at) WHERE withdrawn_at IS NULL;
+
+-- +goose down
+ALTER TABLE bbl_work_sources
+    DROP COLUMN IF EXISTS withdrawn_at,
+    DROP COLUMN IF EXISTS last_seen_at;
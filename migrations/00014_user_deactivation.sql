@@ -0,0 +1,22 @@
+-- +goose up
+
+-- ============================================================
+-- USER DEACTIVATION
+-- A sweep after a full user source harvest schedules the
+-- deactivation of users the source no longer has: deactivate_at is
+-- set to the end of their grace period and deactivate_source to the
+-- source, so that the source can lift it again when the user comes
+-- back. deactivated_at records when a deactivation took effect:
+-- grants revoked and sessions issued before it invalidated.
+-- ============================================================
+
+ALTER TABLE bbl_users
+    ADD COLUMN deactivate_source text REFERENCES bbl_sources (id),
+    ADD COLUMN deactivated_at    timestamptz;
+
+CREATE INDEX ON bbl_users (deactivate_at) WHERE deactivate_at IS NOT NULL;
+
+-- +goose down
+ALTER TABLE bbl_users
+    DROP COLUMN IF EXISTS deactivated_at,
+    DROP COLUMN IF EXISTS deactivate_source;
@@ -0,0 +1,24 @@
+-- +goose up
+
+-- ============================================================
+-- WORK MATCHING
+-- A source's match policy decides what happens to a record that
+-- is new to the source and shares a strong identifier with an
+-- existing work: 'none' creates a new work, 'attach' attaches the
+-- record to the work it matches and 'review' creates a new work
+-- reported as a possible duplicate. match_schemes limits the identifier schemes
+-- matched by; NULL means all of them.
+-- ============================================================
+
+ALTER TABLE bbl_sources
+    ADD COLUMN match_mode    text   NOT NULL DEFAULT 'none',
+    ADD COLUMN match_schemes text[];
+
+CREATE INDEX bbl_work_assertions_identifier_idx ON bbl_work_assertions (lower(val->>'scheme'), lower(val->>'val'))
+    WHERE field = 'identifiers';
+
+-- +goose down
+DROP INDEX IF EXISTS bbl_work_assertions_identifier_idx;
+ALTER TABLE bbl_sources
+    DROP COLUMN IF EXISTS match_schemes,
+    DROP COLUMN IF EXISTS match_mode;
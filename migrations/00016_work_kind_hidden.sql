@@ -0,0 +1,16 @@
+-- +goose up
+
+-- ============================================================
+-- KIND-HIDDEN ASSERTIONS
+-- A kind change hides the human assertions of fields the new
+-- kind doesn't have, keeping their values, and hides fields with
+-- only source assertions behind a placeholder human assertion.
+-- kind_hidden marks these rows, so changing back restores them.
+-- ============================================================
+
+ALTER TABLE bbl_work_assertions
+    ADD COLUMN kind_hidden boolean NOT NULL DEFAULT false;
+
+-- +goose down
+ALTER TABLE bbl_work_assertions
+    DROP COLUMN IF EXISTS kind_hidden;
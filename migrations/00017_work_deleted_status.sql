@@ -0,0 +1,20 @@
+-- +goose up
+
+-- ============================================================
+-- DELETED WORK STATUS
+-- deleted_status is a deleted work's status at deletion time, so
+-- OAI-PMH can keep reporting deleted and merged works that were
+-- public as deleted records (deletedRecord = persistent).
+-- ============================================================
+
+ALTER TABLE bbl_works
+    ADD COLUMN deleted_status text;
+
+UPDATE bbl_works w
+SET deleted_status = r.status
+FROM bbl_work_redirects r
+WHERE r.id = w.id AND w.status = 'deleted';
+
+-- +goose down
+ALTER TABLE bbl_works
+    DROP COLUMN IF EXISTS deleted_status;
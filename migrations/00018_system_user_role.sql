@@ -0,0 +1,23 @@
+-- +goose up
+
+-- ============================================================
+-- SYSTEM USER ROLE
+-- The system user had the curator role, which turned its edits
+-- into curator locks. It now has its own role, and so do the
+-- assertions it wrote.
+-- ============================================================
+
+UPDATE bbl_users SET role = 'system' WHERE username = 'system';
+
+UPDATE bbl_work_assertions a
+SET role = 'system'
+FROM bbl_users u
+WHERE u.id = a.user_id AND u.username = 'system';
+
+-- +goose down
+UPDATE bbl_work_assertions a
+SET role = 'curator'
+FROM bbl_users u
+WHERE u.id = a.user_id AND u.username = 'system';
+
+UPDATE bbl_users SET role = 'curator' WHERE username = 'system';
@@ -0,0 +1,28 @@
+-- +goose up
+
+-- ============================================================
+-- COLLECTION SNAPSHOTS
+-- A query collection is evaluated through the search index.
+-- OAI-PMH harvests of a query collection evaluate it once and
+-- page through the resulting works here, referenced from the
+-- resumption token. Snapshots expire and are removed when new
+-- ones are taken.
+-- ============================================================
+
+CREATE TABLE bbl_work_collection_snapshots (
+    id            uuid PRIMARY KEY,
+    collection_id uuid NOT NULL REFERENCES bbl_work_collections (id) ON DELETE CASCADE,
+    created_at    timestamptz NOT NULL DEFAULT transaction_timestamp()
+);
+
+CREATE INDEX ON bbl_work_collection_snapshots (created_at);
+
+CREATE TABLE bbl_work_collection_snapshot_works (
+    snapshot_id uuid NOT NULL REFERENCES bbl_work_collection_snapshots (id) ON DELETE CASCADE,
+    work_id     uuid NOT NULL REFERENCES bbl_works (id) ON DELETE CASCADE,
+    PRIMARY KEY (snapshot_id, work_id)
+);
+
+-- +goose down
+DROP TABLE IF EXISTS bbl_work_collection_snapshot_works;
+DROP TABLE IF EXISTS bbl_work_collection_snapshots;
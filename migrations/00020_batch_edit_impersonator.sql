@@ -0,0 +1,14 @@
+-- +goose up
+
+-- ============================================================
+-- BATCH EDIT IMPERSONATION
+-- A batch edit confirmed by an admin acting as another user is
+-- applied, possibly by the background worker, with the admin as
+-- impersonator_id on its revisions.
+-- ============================================================
+
+ALTER TABLE bbl_batch_edits
+    ADD COLUMN impersonator_id uuid REFERENCES bbl_users (id) ON DELETE SET NULL;
+
+-- +goose down
+ALTER TABLE bbl_batch_edits DROP COLUMN IF EXISTS impersonator_id;
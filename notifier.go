@@ -0,0 +1,22 @@
+package bbl
+
+import "context"
+
+// Notification kinds.
+const (
+	NotificationEmbargoExpiring = "embargo_expiring"
+)
+
+// Notification is a message for a user about a record.
+type Notification struct {
+	Kind    string
+	User    *User
+	WorkID  ID
+	Subject string
+	Body    string
+}
+
+// Notifier delivers notifications to users, e.g. by mail.
+type Notifier interface {
+	Notify(ctx context.Context, n *Notification) error
+}
@@ -0,0 +1,176 @@
+package oaipmh
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Harvester is an OAI-PMH client.
+type Harvester struct {
+	BaseURL string
+	Client  *http.Client // defaults to a client with a 60s timeout
+}
+
+// HarvestQuery holds the arguments of a ListRecords request. From and Until
+// are datestamps in the granularity of the repository.
+type HarvestQuery struct {
+	MetadataPrefix string
+	Set            string
+	From           string
+	Until          string
+}
+
+// Identity is the Identify response of a repository.
+type Identity struct {
+	RepositoryName    string
+	Granularity       string
+	EarliestDatestamp string
+	DeletedRecord     string
+}
+
+type harvestResponse struct {
+	ResponseDate string          `xml:"responseDate"`
+	Errors       []*Error        `xml:"error"`
+	Identify     *xmlIdentify    `xml:"Identify"`
+	ListRecords  *xmlListRecords `xml:"ListRecords"`
+	GetRecord    *xmlGetRecord   `xml:"GetRecord"`
+}
+
+// Identify fetches the description of the repository.
+func (h *Harvester) Identify(ctx context.Context) (*Identity, error) {
+	res, err := h.do(ctx, url.Values{"verb": {"Identify"}})
+	if err != nil {
+		return nil, err
+	}
+	if res.Identify == nil {
+		return nil, errors.New("oaipmh: Identify response is empty")
+	}
+	return &Identity{
+		RepositoryName:    res.Identify.RepositoryName,
+		Granularity:       res.Identify.Granularity,
+		EarliestDatestamp: res.Identify.EarliestDatestamp,
+		DeletedRecord:     res.Identify.DeletedRecord,
+	}, nil
+}
+
+// GetRecord fetches a single record.
+func (h *Harvester) GetRecord(ctx context.Context, identifier, metadataPrefix string) (*Record, error) {
+	res, err := h.do(ctx, url.Values{
+		"verb":           {"GetRecord"},
+		"identifier":     {identifier},
+		"metadataPrefix": {metadataPrefix},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.GetRecord == nil || res.GetRecord.Record == nil {
+		return nil, errors.New("oaipmh: GetRecord response is empty")
+	}
+	return res.GetRecord.Record, nil
+}
+
+// ListRecords fetches the first page of records matching q and returns the
+// response date of that request together with a sequence over all records,
+// following resumption tokens. The response date is the datestamp to
+// harvest from in the next incremental run. A noRecordsMatch error yields
+// an empty sequence.
+func (h *Harvester) ListRecords(ctx context.Context, q HarvestQuery) (string, iter.Seq2[*Record, error], error) {
+	args := url.Values{"verb": {"ListRecords"}, "metadataPrefix": {q.MetadataPrefix}}
+	if q.Set != "" {
+		args.Set("set", q.Set)
+	}
+	if q.From != "" {
+		args.Set("from", q.From)
+	}
+	if q.Until != "" {
+		args.Set("until", q.Until)
+	}
+
+	first, err := h.do(ctx, args)
+	if errors.Is(err, ErrNoRecordsMatch) {
+		return first.ResponseDate, func(func(*Record, error) bool) {}, nil
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	seq := func(yield func(*Record, error) bool) {
+		res := first
+		for {
+			if res.ListRecords == nil {
+				return
+			}
+			for _, rec := range res.ListRecords.Records {
+				if !yield(rec, nil) {
+					return
+				}
+			}
+			token := res.ListRecords.ResumptionToken
+			if token == nil || token.Value == "" {
+				return
+			}
+			res, err = h.do(ctx, url.Values{"verb": {"ListRecords"}, "resumptionToken": {token.Value}})
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+		}
+	}
+	return first.ResponseDate, seq, nil
+}
+
+// do sends a request and decodes the response. OAI-PMH errors are returned
+// as *Error and match the exported errors with errors.Is; the response is
+// returned along with them.
+func (h *Harvester) do(ctx context.Context, args url.Values) (*harvestResponse, error) {
+	u, err := url.Parse(h.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+	u.RawQuery = args.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	client := h.Client
+	if client == nil {
+		client = &http.Client{Timeout: 60 * time.Second}
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %q: %s", u.String(), res.Status)
+	}
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var r harvestResponse
+	if err := xml.Unmarshal(body, &r); err != nil {
+		return nil, fmt.Errorf("GET %q: %w", u.String(), err)
+	}
+	if len(r.Errors) > 0 {
+		return &r, r.Errors[0]
+	}
+	return &r, nil
+}
+
+// Is reports whether an error returned by a repository has the same code as
+// target, so that errors.Is(err, ErrNoRecordsMatch) works for harvested
+// errors.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
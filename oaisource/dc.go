@@ -0,0 +1,128 @@
+package oaisource
+
+import (
+	"encoding/xml"
+	"regexp"
+	"strings"
+
+	"github.com/ugent-library/bbl"
+)
+
+// dcDecoder maps simple Dublin Core (oai_dc) metadata. DC has no structure
+// for journals or pages, so those fields stay empty; creators become
+// authors.
+type dcDecoder struct{}
+
+type dcRecord struct {
+	Titles       []dcValue `xml:"title"`
+	Creators     []string  `xml:"creator"`
+	Subjects     []string  `xml:"subject"`
+	Descriptions []dcValue `xml:"description"`
+	Publishers   []string  `xml:"publisher"`
+	Dates        []string  `xml:"date"`
+	Types        []string  `xml:"type"`
+	Identifiers  []string  `xml:"identifier"`
+	Languages    []string  `xml:"language"`
+}
+
+type dcValue struct {
+	Lang string `xml:"lang,attr"`
+	Val  string `xml:",chardata"`
+}
+
+func (d *dcDecoder) Decode(data []byte) (*bbl.ImportWorkInput, error) {
+	var r dcRecord
+	if err := xml.Unmarshal(data, &r); err != nil {
+		return nil, err
+	}
+
+	rec := &bbl.ImportWorkInput{
+		Kind:         kindFor(r.Types),
+		SourceRecord: data,
+	}
+
+	lang := "und"
+	if len(r.Languages) > 0 {
+		lang = langFor(r.Languages[0])
+	}
+
+	for _, t := range r.Titles {
+		if v := strings.TrimSpace(t.Val); v != "" {
+			rec.Titles = append(rec.Titles, bbl.Title{Lang: valueLang(t.Lang, lang), Val: v})
+		}
+	}
+	for _, d := range r.Descriptions {
+		if v := strings.TrimSpace(d.Val); v != "" {
+			rec.Abstracts = append(rec.Abstracts, bbl.Text{Lang: valueLang(d.Lang, lang), Val: v})
+		}
+	}
+	for _, v := range r.Creators {
+		if c, ok := personName(v); ok {
+			c.Roles = []string{"author"}
+			rec.Contributors = append(rec.Contributors, c)
+		}
+	}
+	for _, v := range r.Subjects {
+		if v = strings.TrimSpace(v); v != "" {
+			rec.Keywords = append(rec.Keywords, bbl.Keyword{Val: v})
+		}
+	}
+	if len(r.Publishers) > 0 {
+		rec.Publisher = strings.TrimSpace(r.Publishers[0])
+	}
+	for _, v := range r.Dates {
+		if y := reYear.FindString(v); y != "" {
+			rec.PublicationYear = y
+			break
+		}
+	}
+	for _, v := range r.Identifiers {
+		if id, ok := parseIdentifier(v); ok {
+			rec.Identifiers = append(rec.Identifiers, id)
+		}
+	}
+
+	return rec, nil
+}
+
+func valueLang(attr, fallback string) string {
+	if attr == "" {
+		return fallback
+	}
+	return langFor(attr)
+}
+
+// personName splits a name in "Family, Given" form.
+func personName(v string) (bbl.ImportWorkContributor, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return bbl.ImportWorkContributor{}, false
+	}
+	c := bbl.ImportWorkContributor{Kind: "person", Name: v}
+	if family, given, ok := strings.Cut(v, ","); ok {
+		c.FamilyName = strings.TrimSpace(family)
+		c.GivenName = strings.TrimSpace(given)
+		c.Name = strings.TrimSpace(c.GivenName + " " + c.FamilyName)
+	}
+	return c, true
+}
+
+var (
+	reYear      = regexp.MustCompile(`\d{4}`)
+	reDOI       = regexp.MustCompile(`(?i)^(?:doi:|https?://(?:dx\.)?doi\.org/)?(10\.\d{4,9}/\S+)$`)
+	reURNPrefix = regexp.MustCompile(`(?i)^urn:(isbn|issn):`)
+)
+
+// parseIdentifier recognizes DOIs, with or without resolver, and URN
+// ISBNs and ISSNs. Other identifiers, mostly landing page URLs, are
+// skipped.
+func parseIdentifier(v string) (bbl.Identifier, bool) {
+	v = strings.TrimSpace(v)
+	if m := reDOI.FindStringSubmatch(v); m != nil {
+		return bbl.Identifier{Scheme: "doi", Val: strings.ToLower(m[1])}, true
+	}
+	if m := reURNPrefix.FindStringSubmatch(v); m != nil {
+		return bbl.Identifier{Scheme: strings.ToLower(m[1]), Val: v[len(m[0]):]}, true
+	}
+	return bbl.Identifier{}, false
+}
@@ -0,0 +1,91 @@
+package oaisource
+
+import (
+	"regexp"
+	"strings"
+)
+
+// kinds maps normalized DC type and MODS genre values to profile kinds.
+// Values are lowercased with the info:eu-repo/semantics/ prefix removed and
+// spaces and dashes dropped.
+var kinds = map[string]string{
+	"article":                  "journal_article",
+	"journalarticle":           "journal_article",
+	"contributiontoperiodical": "journal_article",
+	"book":                     "book",
+	"monograph":                "book",
+	"editedbook":               "edited_book",
+	"bookpart":                 "book_chapter",
+	"bookchapter":              "book_chapter",
+	"chapter":                  "book_chapter",
+	"conferenceobject":         "conference_paper",
+	"conferencepaper":          "conference_paper",
+	"conferenceproceedings":    "conference_proceeding",
+	"conferenceposter":         "conference_poster",
+	"doctoralthesis":           "dissertation",
+	"dissertation":             "dissertation",
+	"thesis":                   "dissertation",
+	"preprint":                 "preprint",
+	"report":                   "report",
+	"technicalreport":          "report",
+	"workingpaper":             "working_paper",
+	"patent":                   "patent",
+	"newspaperarticle":         "newspaper_article",
+	"encyclopediaarticle":      "encyclopedia_article",
+	"bookreview":               "book_review",
+	"review":                   "book_review",
+}
+
+var reTypeNoise = regexp.MustCompile(`[\s\-_]+`)
+
+// kindFor returns the kind of the first type value that is recognized.
+// Values that already are profile kinds are kept, so records harvested from
+// another bbl instance keep their kind.
+func kindFor(types []string) string {
+	for _, t := range types {
+		t = strings.TrimSpace(t)
+		if _, ok := profileKinds[t]; ok {
+			return t
+		}
+		t = strings.ToLower(strings.TrimPrefix(t, "info:eu-repo/semantics/"))
+		if kind, ok := kinds[reTypeNoise.ReplaceAllString(t, "")]; ok {
+			return kind
+		}
+	}
+	return "miscellaneous"
+}
+
+// profileKinds is the set of target kinds.
+var profileKinds = func() map[string]struct{} {
+	m := map[string]struct{}{"miscellaneous": {}}
+	for _, kind := range kinds {
+		m[kind] = struct{}{}
+	}
+	return m
+}()
+
+// langs maps ISO 639-1 codes to the ISO 639-2 codes used in titles and
+// abstracts.
+var langs = map[string]string{
+	"en": "eng",
+	"nl": "dut",
+	"fr": "fre",
+	"de": "ger",
+	"es": "spa",
+	"it": "ita",
+}
+
+// langFor returns the ISO 639-2 code of a language code, und if unknown.
+func langFor(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if lang, ok := langs[code]; ok {
+		return lang
+	}
+	if len(code) == 3 {
+		return code
+	}
+	if i := strings.IndexAny(code, "-_"); i > 0 {
+		return langFor(code[:i])
+	}
+	return "und"
+}
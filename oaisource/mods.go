@@ -0,0 +1,314 @@
+package oaisource
+
+import (
+	"encoding/xml"
+	"slices"
+	"strings"
+
+	"github.com/ugent-library/bbl"
+)
+
+// modsDecoder maps MODS metadata. The host item of an article or chapter
+// gives the journal or book title, volume, issue and pages.
+type modsDecoder struct{}
+
+type modsRecord struct {
+	TitleInfos      []modsTitleInfo      `xml:"titleInfo"`
+	Names           []modsName           `xml:"name"`
+	Genres          []string             `xml:"genre"`
+	OriginInfo      modsOriginInfo       `xml:"originInfo"`
+	Languages       []modsLanguage       `xml:"language>languageTerm"`
+	Abstracts       []dcValue            `xml:"abstract"`
+	Topics          []string             `xml:"subject>topic"`
+	Classifications []modsClassification `xml:"classification"`
+	Identifiers     []modsIdentifier     `xml:"identifier"`
+	RelatedItems    []modsRelatedItem    `xml:"relatedItem"`
+	Part            modsPart             `xml:"part"`
+}
+
+type modsTitleInfo struct {
+	Type     string `xml:"type,attr"`
+	Lang     string `xml:"lang,attr"`
+	NonSort  string `xml:"nonSort"`
+	Title    string `xml:"title"`
+	SubTitle string `xml:"subTitle"`
+}
+
+func (t modsTitleInfo) String() string {
+	s := strings.TrimSpace(t.Title)
+	if np := strings.TrimSpace(t.NonSort); np != "" {
+		s = np + " " + s
+	}
+	if sub := strings.TrimSpace(t.SubTitle); sub != "" && s != "" {
+		s += ": " + sub
+	}
+	return s
+}
+
+type modsName struct {
+	Type      string `xml:"type,attr"`
+	NameParts []struct {
+		Type string `xml:"type,attr"`
+		Val  string `xml:",chardata"`
+	} `xml:"namePart"`
+	RoleTerms       []string `xml:"role>roleTerm"`
+	Affiliations    []string `xml:"affiliation"`
+	NameIdentifiers []struct {
+		Type string `xml:"type,attr"`
+		Val  string `xml:",chardata"`
+	} `xml:"nameIdentifier"`
+}
+
+type modsOriginInfo struct {
+	DatesIssued []string `xml:"dateIssued"`
+	Publishers  []string `xml:"publisher"`
+	PlaceTerms  []struct {
+		Type string `xml:"type,attr"`
+		Val  string `xml:",chardata"`
+	} `xml:"place>placeTerm"`
+}
+
+type modsLanguage struct {
+	Type string `xml:"type,attr"`
+	Val  string `xml:",chardata"`
+}
+
+type modsClassification struct {
+	Authority string `xml:"authority,attr"`
+	Val       string `xml:",chardata"`
+}
+
+type modsIdentifier struct {
+	Type string `xml:"type,attr"`
+	Val  string `xml:",chardata"`
+}
+
+type modsRelatedItem struct {
+	Type        string           `xml:"type,attr"`
+	TitleInfos  []modsTitleInfo  `xml:"titleInfo"`
+	Identifiers []modsIdentifier `xml:"identifier"`
+	Part        modsPart         `xml:"part"`
+}
+
+type modsPart struct {
+	Details []struct {
+		Type   string `xml:"type,attr"`
+		Number string `xml:"number"`
+	} `xml:"detail"`
+	Extents []struct {
+		Unit  string `xml:"unit,attr"`
+		Start string `xml:"start"`
+		End   string `xml:"end"`
+		Total string `xml:"total"`
+	} `xml:"extent"`
+}
+
+// roles maps MARC relator codes and terms to contributor roles.
+var roles = map[string]string{
+	"aut":            "author",
+	"author":         "author",
+	"cre":            "author",
+	"creator":        "author",
+	"edt":            "editor",
+	"editor":         "editor",
+	"trl":            "translator",
+	"translator":     "translator",
+	"ths":            "supervisor",
+	"thesis advisor": "supervisor",
+	"ill":            "illustrator",
+	"illustrator":    "illustrator",
+}
+
+// identifierSchemes maps MODS identifier types to identifier schemes.
+var identifierSchemes = map[string]string{
+	"doi":    "doi",
+	"isbn":   "isbn",
+	"issn":   "issn",
+	"pmid":   "pubmed",
+	"pubmed": "pubmed",
+	"arxiv":  "arxiv",
+}
+
+func (d *modsDecoder) Decode(data []byte) (*bbl.ImportWorkInput, error) {
+	var r modsRecord
+	if err := xml.Unmarshal(data, &r); err != nil {
+		return nil, err
+	}
+
+	rec := &bbl.ImportWorkInput{
+		Kind:         kindFor(r.Genres),
+		SourceRecord: data,
+	}
+
+	lang := "und"
+	for _, l := range r.Languages {
+		if l.Type == "code" || l.Type == "" {
+			lang = langFor(l.Val)
+			break
+		}
+	}
+
+	for _, t := range r.TitleInfos {
+		if t.Type != "" && t.Type != "translated" {
+			continue
+		}
+		if v := t.String(); v != "" {
+			rec.Titles = append(rec.Titles, bbl.Title{Lang: valueLang(t.Lang, lang), Val: v})
+		}
+	}
+	for _, a := range r.Abstracts {
+		if v := strings.TrimSpace(a.Val); v != "" {
+			rec.Abstracts = append(rec.Abstracts, bbl.Text{Lang: valueLang(a.Lang, lang), Val: v})
+		}
+	}
+	for _, n := range r.Names {
+		if c, ok := modsContributor(n); ok {
+			rec.Contributors = append(rec.Contributors, c)
+		}
+	}
+	for _, v := range r.Topics {
+		if v = strings.TrimSpace(v); v != "" {
+			rec.Keywords = append(rec.Keywords, bbl.Keyword{Val: v})
+		}
+	}
+	for _, c := range r.Classifications {
+		if v := strings.TrimSpace(c.Val); v != "" && c.Authority != "" {
+			rec.Classifications = append(rec.Classifications, bbl.Identifier{Scheme: c.Authority, Val: v})
+		}
+	}
+	rec.Identifiers = appendModsIdentifiers(rec.Identifiers, r.Identifiers)
+
+	for _, v := range r.OriginInfo.DatesIssued {
+		if y := reYear.FindString(v); y != "" {
+			rec.PublicationYear = y
+			break
+		}
+	}
+	if len(r.OriginInfo.Publishers) > 0 {
+		rec.Publisher = strings.TrimSpace(r.OriginInfo.Publishers[0])
+	}
+	for _, p := range r.OriginInfo.PlaceTerms {
+		if p.Type != "code" {
+			rec.PlaceOfPublication = strings.TrimSpace(p.Val)
+			break
+		}
+	}
+
+	mapModsPart(rec, r.Part)
+	for _, item := range r.RelatedItems {
+		var title string
+		if len(item.TitleInfos) > 0 {
+			title = item.TitleInfos[0].String()
+		}
+		switch item.Type {
+		case "host":
+			switch rec.Kind {
+			case "journal_article", "newspaper_article", "book_review":
+				rec.JournalTitle = title
+			default:
+				rec.BookTitle = title
+			}
+			rec.Identifiers = appendModsIdentifiers(rec.Identifiers, item.Identifiers)
+			mapModsPart(rec, item.Part)
+		case "series":
+			rec.SeriesTitle = title
+		}
+	}
+
+	return rec, nil
+}
+
+func modsContributor(n modsName) (bbl.ImportWorkContributor, bool) {
+	var c bbl.ImportWorkContributor
+	var parts []string
+	for _, p := range n.NameParts {
+		v := strings.TrimSpace(p.Val)
+		switch p.Type {
+		case "given":
+			c.GivenName = strings.TrimSpace(c.GivenName + " " + v)
+		case "family":
+			c.FamilyName = v
+		case "":
+			parts = append(parts, v)
+		}
+	}
+
+	if n.Type == "corporate" {
+		c.Kind = "organization"
+		c.Name = strings.Join(parts, ". ")
+	} else if c.GivenName != "" || c.FamilyName != "" {
+		c.Kind = "person"
+		c.Name = strings.TrimSpace(c.GivenName + " " + c.FamilyName)
+	} else if len(parts) > 0 {
+		pc, _ := personName(strings.Join(parts, " "))
+		c.Kind, c.Name, c.GivenName, c.FamilyName = pc.Kind, pc.Name, pc.GivenName, pc.FamilyName
+	}
+	if c.Name == "" {
+		return c, false
+	}
+
+	for _, t := range n.RoleTerms {
+		if role, ok := roles[strings.ToLower(strings.TrimSpace(t))]; ok && !slices.Contains(c.Roles, role) {
+			c.Roles = append(c.Roles, role)
+		}
+	}
+	if len(c.Roles) == 0 {
+		c.Roles = []string{"author"}
+	}
+	for _, a := range n.Affiliations {
+		if a = strings.TrimSpace(a); a != "" {
+			c.Affiliations = append(c.Affiliations, a)
+		}
+	}
+	for _, id := range n.NameIdentifiers {
+		if strings.EqualFold(id.Type, "orcid") && c.Kind == "person" {
+			v := strings.TrimSpace(id.Val)
+			v = v[strings.LastIndex(v, "/")+1:]
+			c.PersonRef = &bbl.Ref{Identifier: &bbl.Identifier{Scheme: "orcid", Val: v}}
+			c.PersonRefOptional = true
+		}
+	}
+	return c, true
+}
+
+func appendModsIdentifiers(ids []bbl.Identifier, mids []modsIdentifier) []bbl.Identifier {
+	for _, id := range mids {
+		v := strings.TrimSpace(id.Val)
+		scheme, ok := identifierSchemes[strings.ToLower(id.Type)]
+		if !ok || v == "" {
+			continue
+		}
+		if scheme == "doi" {
+			if parsed, ok := parseIdentifier(v); ok {
+				v = parsed.Val
+			}
+		}
+		ids = append(ids, bbl.Identifier{Scheme: scheme, Val: v})
+	}
+	return ids
+}
+
+func mapModsPart(rec *bbl.ImportWorkInput, p modsPart) {
+	for _, d := range p.Details {
+		v := strings.TrimSpace(d.Number)
+		switch d.Type {
+		case "volume":
+			rec.Volume = v
+		case "issue":
+			rec.Issue = v
+		case "article", "article-number":
+			rec.ArticleNumber = v
+		}
+	}
+	for _, e := range p.Extents {
+		if e.Unit != "page" && e.Unit != "pages" && e.Unit != "" {
+			continue
+		}
+		if start := strings.TrimSpace(e.Start); start != "" {
+			rec.Pages = bbl.Extent{Start: start, End: strings.TrimSpace(e.End)}
+		}
+		if total := strings.TrimSpace(e.Total); total != "" {
+			rec.TotalPages = total
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package oaisource
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/ugent-library/bbl"
+)
+
+// fixtureServer serves recorded OAI-PMH responses from testdata.
+func fixtureServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		file := ""
+		switch {
+		case q.Get("verb") == "Identify":
+			file = "identify.xml"
+		case q.Get("resumptionToken") == "page2":
+			file = "list_dc_2.xml"
+		case q.Get("metadataPrefix") == "oai_dc" && q.Get("set") == "publications" && q.Get("from") == "":
+			file = "list_dc_1.xml"
+		case q.Get("metadataPrefix") == "mods" && q.Get("from") == "2024-03-01":
+			file = "list_mods.xml"
+		case q.Get("metadataPrefix") == "mods" && q.Get("from") == "2024-03-02":
+			file = "no_records.xml"
+		default:
+			http.Error(w, "unexpected query "+r.URL.RawQuery, http.StatusBadRequest)
+			return
+		}
+		http.ServeFile(w, r, "testdata/"+file)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func collect(t *testing.T, seq func(func(*bbl.ImportWorkInput, error) bool)) []*bbl.ImportWorkInput {
+	t.Helper()
+	var recs []*bbl.ImportWorkInput
+	for rec, err := range seq {
+		if err != nil {
+			t.Fatal(err)
+		}
+		recs = append(recs, rec)
+	}
+	return recs
+}
+
+func TestIterDC(t *testing.T) {
+	srv := fixtureServer(t)
+	ws, err := New(Config{URL: srv.URL, Set: "publications"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	seq, next, err := ws.IterSince(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next != "2024-03-01T12:00:00Z" {
+		t.Errorf("next checkpoint = %q", next)
+	}
+	recs := collect(t, seq)
+	if len(recs) != 3 {
+		t.Fatalf("got %d records, want 3", len(recs))
+	}
+
+	r := recs[0]
+	if r.SourceID != "oai:repo.example.org:1" || r.Kind != "journal_article" || r.PublicationYear != "2023" || r.Publisher != "Example Press" {
+		t.Errorf("record = %+v", r)
+	}
+	if want := []bbl.Title{{Lang: "eng", Val: "Grassland responses to drought"}}; !slices.Equal(r.Titles, want) {
+		t.Errorf("titles = %v", r.Titles)
+	}
+	wantIDs := []bbl.Identifier{{Scheme: "doi", Val: "10.1000/xyz123"}, {Scheme: "issn", Val: "1234-5678"}}
+	if !slices.Equal(r.Identifiers, wantIDs) {
+		t.Errorf("identifiers = %v", r.Identifiers)
+	}
+	if len(r.Contributors) != 2 || r.Contributors[0].GivenName != "Jane" || r.Contributors[0].FamilyName != "Doe" || r.Contributors[0].Name != "Jane Doe" {
+		t.Errorf("contributors = %+v", r.Contributors)
+	}
+	if len(r.SourceRecord) == 0 {
+		t.Error("source record is empty")
+	}
+
+	if r := recs[1]; !r.Deleted || r.SourceID != "oai:repo.example.org:2" {
+		t.Errorf("deleted record = %+v", r)
+	}
+	if r := recs[2]; r.Kind != "dissertation" || r.Titles[0].Lang != "dut" {
+		t.Errorf("thesis = %+v", r)
+	}
+}
+
+func TestMap(t *testing.T) {
+	srv := fixtureServer(t)
+	ws, err := New(Config{URL: srv.URL, Set: "publications"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	seq, _, err := ws.IterSince(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rec := range collect(t, seq) {
+		if rec.Deleted {
+			continue
+		}
+		mapped, err := ws.Map(rec.SourceRecord)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mapped.SourceID = rec.SourceID
+		if !reflect.DeepEqual(mapped, rec) {
+			t.Errorf("Map(SourceRecord) = %+v, want %+v", mapped, rec)
+		}
+	}
+}
+
+func TestIterSinceMODS(t *testing.T) {
+	srv := fixtureServer(t)
+	ws, err := New(Config{URL: srv.URL, MetadataPrefix: "mods"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The checkpoint is truncated to the day granularity of the repository.
+	seq, next, err := ws.IterSince(context.Background(), "2024-03-01T12:00:00Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next != "2024-03-02T08:30:00Z" {
+		t.Errorf("next checkpoint = %q", next)
+	}
+	recs := collect(t, seq)
+	if len(recs) != 1 {
+		t.Fatalf("got %d records, want 1", len(recs))
+	}
+
+	r := recs[0]
+	if r.Kind != "journal_article" || r.JournalTitle != "Soil Biology" || r.Volume != "12" || r.Issue != "3" ||
+		r.Pages != (bbl.Extent{Start: "101", End: "120"}) || r.PublicationYear != "2022" {
+		t.Errorf("record = %+v", r)
+	}
+	if want := []bbl.Title{{Lang: "eng", Val: "The soil microbiome: a review"}}; !slices.Equal(r.Titles, want) {
+		t.Errorf("titles = %v", r.Titles)
+	}
+	wantIDs := []bbl.Identifier{{Scheme: "doi", Val: "10.1000/soil.1"}, {Scheme: "issn", Val: "2345-6789"}}
+	if !slices.Equal(r.Identifiers, wantIDs) {
+		t.Errorf("identifiers = %v", r.Identifiers)
+	}
+	if want := []bbl.Identifier{{Scheme: "ddc", Val: "577"}}; !slices.Equal(r.Classifications, want) {
+		t.Errorf("classifications = %v", r.Classifications)
+	}
+	if len(r.Contributors) != 3 {
+		t.Fatalf("contributors = %+v", r.Contributors)
+	}
+	doe := r.Contributors[0]
+	if doe.Name != "Jane Doe" || !slices.Equal(doe.Affiliations, []string{"Ghent University"}) ||
+		doe.PersonRef == nil || doe.PersonRef.Identifier.Val != "0000-0002-1825-0097" {
+		t.Errorf("first contributor = %+v", doe)
+	}
+	if c := r.Contributors[1]; c.FamilyName != "Smith" || !slices.Equal(c.Roles, []string{"editor"}) {
+		t.Errorf("second contributor = %+v", c)
+	}
+	if c := r.Contributors[2]; c.Kind != "organization" || c.Name != "Soil Consortium" {
+		t.Errorf("third contributor = %+v", c)
+	}
+
+	// Nothing changed since the last run.
+	seq, next, err = ws.IterSince(context.Background(), next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recs := collect(t, seq); len(recs) != 0 || next != "2024-03-05T00:00:00Z" {
+		t.Errorf("got %d records, next %q", len(recs), next)
+	}
+}
+
+func TestNewMapper(t *testing.T) {
+	if _, err := New(Config{URL: "https://repo.example.org/oai", MetadataPrefix: "marc21"}); err == nil {
+		t.Error("expected an error for a metadata prefix without mapper")
+	}
+	if _, err := New(Config{URL: "https://repo.example.org/oai", MetadataPrefix: "bbl", Mapper: "json"}); err != nil {
+		t.Errorf("registered decoder: %v", err)
+	}
+}
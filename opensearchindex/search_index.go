@@ -135,11 +135,26 @@ func (idx *searchIndex[T, H]) search(ctx context.Context, opts *bbl.SearchOpts)
 	}
 
 	// Apply filters.
+	var filterClauses []any
 	if opts.Filter != nil {
-		filterClauses, err := idx.buildFilterClauses(opts.Filter.And)
+		clauses, err := idx.buildFilterClauses(opts.Filter.And)
 		if err != nil {
 			return nil, err
 		}
+		filterClauses = clauses
+	}
+	// Scope to a text query or a set of records (collections).
+	if opts.Within != "" {
+		filterClauses = append(filterClauses, idx.buildQuery(opts.Within))
+	}
+	if len(opts.IDs) > 0 {
+		ids := make([]string, len(opts.IDs))
+		for i, id := range opts.IDs {
+			ids[i] = id.String()
+		}
+		filterClauses = append(filterClauses, termsQuery("id", ids))
+	}
+	if len(filterClauses) > 0 {
 		// Add filter to bool query.
 		if b, ok := query["bool"].(map[string]any); ok {
 			b["filter"] = filterClauses
@@ -10,9 +10,10 @@ import (
 var workSettings string
 
 var workFilterDefs = map[string]string{
-	"kind":        "kind",
-	"status":      "status",
-	"contributor": "person_ids",
+	"kind":         "kind",
+	"status":       "status",
+	"contributor":  "person_ids",
+	"organization": "organization_ids",
 }
 
 var workFacetDefs = map[string]facetDef{
@@ -42,15 +43,21 @@ func workToDoc(w *bbl.Work) (id string, version int, doc map[string]any) {
 		}
 	}
 
+	orgIDs := make([]string, len(w.Organizations))
+	for i, id := range w.Organizations {
+		orgIDs[i] = id.String()
+	}
+
 	idStr := w.ID.String()
 	return idStr, w.Version, map[string]any{
-		"id":          idStr,
-		"kind":        w.Kind,
-		"status":      w.Status,
-		"title":       title,
-		"identifiers": identifiers,
-		"person_ids":  personIDs,
-		"completion":  completion,
+		"id":               idStr,
+		"kind":             w.Kind,
+		"status":           w.Status,
+		"title":            title,
+		"identifiers":      identifiers,
+		"person_ids":       personIDs,
+		"organization_ids": orgIDs,
+		"completion":       completion,
 	}
 }
 
@@ -31,6 +31,46 @@ func (r *Repo) GetOrganization(ctx context.Context, id ID) (*Organization, error
 	return o, nil
 }
 
+// GetOrganizations fetches multiple organizations by ID, preserving the
+// input order. Missing IDs are silently skipped.
+func (r *Repo) GetOrganizations(ctx context.Context, ids []ID) ([]*Organization, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	rows, err := r.db.Query(ctx, `
+		SELECT id, version, created_at, updated_at,
+		       created_by_id, updated_by_id,
+		       kind, status, start_date, end_date,
+		       deleted_at, deleted_by_id,
+		       cache
+		FROM bbl_organizations
+		WHERE id = ANY($1)`, ids)
+	if err != nil {
+		return nil, fmt.Errorf("GetOrganizations: %w", err)
+	}
+	defer rows.Close()
+
+	byID := make(map[ID]*Organization, len(ids))
+	for rows.Next() {
+		o, err := scanOrganization(rows)
+		if err != nil {
+			return nil, fmt.Errorf("GetOrganizations: %w", err)
+		}
+		byID[o.ID] = o
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetOrganizations: %w", err)
+	}
+
+	result := make([]*Organization, 0, len(ids))
+	for _, id := range ids {
+		if o, ok := byID[id]; ok {
+			result = append(result, o)
+		}
+	}
+	return result, nil
+}
+
 func (r *Repo) ImportOrganizations(ctx context.Context, source string, seq iter.Seq2[*ImportOrganizationInput, error]) (int, error) {
 	const batchSize = 250
 	var pending []*ImportOrganizationInput
@@ -183,7 +223,7 @@ func organizationImportAssertions(ctx context.Context, tx pgx.Tx, source string,
 	if len(in.Identifiers) > 0 {
 		rows = append(rows, assertionRow{
 			recordType: RecordTypeOrganization, recordID: orgID,
-			field: "identifiers", val: in.Identifiers, sourceRecordID: src,
+			field: "identifiers", val: normalizeIdentifiers(in.Identifiers), sourceRecordID: src,
 		})
 	}
 	if len(in.Names) > 0 {
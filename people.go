@@ -13,77 +13,94 @@ import (
 )
 
 func (r *Repo) ImportPeople(ctx context.Context, source string, seq iter.Seq2[*ImportPersonInput, error]) (int, error) {
-	const batchSize = 250
-	var pending []*ImportPersonInput
-	var total int
-
-	flush := func() error {
-		n, err := r.importPersonBatch(ctx, source, pending)
-		total += n
-		pending = pending[:0]
-		return err
-	}
+	res, err := r.ImportPeopleWithOpts(ctx, source, seq, ImportOpts{})
+	return res.Imported, err
+}
 
-	for in, err := range seq {
-		if err != nil {
-			return total, fmt.Errorf("ImportPeople: %w", err)
-		}
-		pending = append(pending, in)
-		if len(pending) == batchSize {
-			if err := flush(); err != nil {
-				return total, err
-			}
-		}
-	}
-	if len(pending) > 0 {
-		if err := flush(); err != nil {
-			return total, err
-		}
+// ImportPeopleWithOpts imports seq like ImportPeople, but can leave out the
+// records that fail and resume an interrupted run. The result is never nil.
+func (r *Repo) ImportPeopleWithOpts(ctx context.Context, source string, seq iter.Seq2[*ImportPersonInput, error], opts ImportOpts) (*ImportResult, error) {
+	res, err := importBatches(seq, opts, func(records []*ImportPersonInput) (int, []*ImportFailure, error) {
+		return r.importPersonBatch(ctx, source, records, opts.OnFailure != nil)
+	})
+	if err != nil {
+		return res, fmt.Errorf("ImportPeople: %w", err)
 	}
-	return total, nil
+	return res, nil
 }
 
-func (r *Repo) importPersonBatch(ctx context.Context, source string, records []*ImportPersonInput) (int, error) {
+func (r *Repo) importPersonBatch(ctx context.Context, source string, records []*ImportPersonInput, skipFailures bool) (int, []*ImportFailure, error) {
 	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
-		return 0, fmt.Errorf("importPersonBatch: %w", err)
+		return 0, nil, fmt.Errorf("importPersonBatch: %w", err)
 	}
 	defer tx.Rollback(ctx)
 
 	priorities, err := fetchSourcePriorities(ctx, tx)
 	if err != nil {
-		return 0, fmt.Errorf("importPersonBatch: %w", err)
+		return 0, nil, fmt.Errorf("importPersonBatch: %w", err)
 	}
 
 	var revID int64
 	if err := tx.QueryRow(ctx, `
 		INSERT INTO bbl_revs (source) VALUES ($1) RETURNING id`,
 		source).Scan(&revID); err != nil {
-		return 0, fmt.Errorf("importPersonBatch: %w", err)
+		return 0, nil, fmt.Errorf("importPersonBatch: %w", err)
 	}
 
 	var changedPersonIDs []ID
+	var failures []*ImportFailure
 	var n int
 	for _, in := range records {
-		personID, err := r.importPersonRecord(ctx, tx, source, in, revID, priorities)
+		var personID ID
+		var err error
+		if skipFailures {
+			personID, err = r.tryImportPersonRecord(ctx, tx, source, in, revID, priorities)
+			if isRecordFailure(err) {
+				failures = append(failures, &ImportFailure{SourceID: in.SourceID, Reason: err.Error()})
+				continue
+			}
+		} else {
+			personID, err = r.importPersonRecord(ctx, tx, source, in, revID, priorities)
+		}
 		if err != nil {
-			return n, fmt.Errorf("importPersonBatch: source_id=%s: %w", in.SourceID, err)
+			return n, nil, fmt.Errorf("importPersonBatch: source_id=%s: %w", in.SourceID, err)
 		}
 		changedPersonIDs = append(changedPersonIDs, personID)
 		n++
 	}
 
 	if err := rebuildPersonCache(ctx, tx, changedPersonIDs); err != nil {
-		return n, fmt.Errorf("importPersonBatch: %w", err)
+		return n, nil, fmt.Errorf("importPersonBatch: %w", err)
 	}
 
 	if err := tx.Commit(ctx); err != nil {
-		return 0, fmt.Errorf("importPersonBatch: %w", err)
+		return 0, nil, fmt.Errorf("importPersonBatch: %w", err)
 	}
-	return n, nil
+	return n, failures, nil
+}
+
+// tryImportPersonRecord imports a record in a savepoint, so that a record
+// failure leaves the rest of the batch intact.
+func (r *Repo) tryImportPersonRecord(ctx context.Context, tx pgx.Tx, source string, in *ImportPersonInput, revID int64, priorities map[string]int) (ID, error) {
+	sp, err := tx.Begin(ctx)
+	if err != nil {
+		return ID{}, err
+	}
+	personID, err := r.importPersonRecord(ctx, sp, source, in, revID, priorities)
+	if err != nil {
+		if rbErr := sp.Rollback(ctx); rbErr != nil {
+			return ID{}, rbErr
+		}
+		return ID{}, err
+	}
+	return personID, sp.Commit(ctx)
 }
 
 func (r *Repo) importPersonRecord(ctx context.Context, tx pgx.Tx, source string, in *ImportPersonInput, revID int64, priorities map[string]int) (ID, error) {
+	if in.SourceID == "" {
+		return ID{}, fmt.Errorf("%w: source_id is missing", errInvalidRecord)
+	}
 	var personID ID
 	var sourceRecordID ID
 	var isNew bool
@@ -178,7 +195,7 @@ func personImportAssertions(ctx context.Context, tx pgx.Tx, source string, perso
 	if len(in.Identifiers) > 0 {
 		rows = append(rows, assertionRow{
 			recordType: RecordTypePerson, recordID: personID,
-			field: "identifiers", val: in.Identifiers, sourceRecordID: src,
+			field: "identifiers", val: normalizeIdentifiers(in.Identifiers), sourceRecordID: src,
 		})
 	}
 
@@ -265,6 +282,45 @@ func (r *Repo) GetPerson(ctx context.Context, id ID) (*Person, error) {
 	return p, nil
 }
 
+// GetPeople fetches multiple people by ID, preserving the input order.
+// Missing IDs are silently skipped.
+func (r *Repo) GetPeople(ctx context.Context, ids []ID) ([]*Person, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	rows, err := r.db.Query(ctx, `
+		SELECT id, version, created_at, updated_at,
+		       created_by_id, updated_by_id,
+		       status, deleted_at, deleted_by_id,
+		       cache
+		FROM bbl_people
+		WHERE id = ANY($1)`, ids)
+	if err != nil {
+		return nil, fmt.Errorf("GetPeople: %w", err)
+	}
+	defer rows.Close()
+
+	byID := make(map[ID]*Person, len(ids))
+	for rows.Next() {
+		p, err := scanPerson(rows)
+		if err != nil {
+			return nil, fmt.Errorf("GetPeople: %w", err)
+		}
+		byID[p.ID] = p
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetPeople: %w", err)
+	}
+
+	result := make([]*Person, 0, len(ids))
+	for _, id := range ids {
+		if p, ok := byID[id]; ok {
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
 // scanPerson scans a single person row (including cache) from a QueryRow result.
 func scanPerson(row pgx.Row) (*Person, error) {
 	var p Person
@@ -0,0 +1,64 @@
+package bbl
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// fetchPersonMergeRelated finds the works and projects that link to a person
+// that is being merged, records them on the MergePeople updater and adds them
+// to needs so their rows are locked and their version and cache are updated
+// with the merge.
+func fetchPersonMergeRelated(ctx context.Context, tx pgx.Tx, needs *updateNeeds, muts []updater) error {
+	merges := make(map[ID][]*MergePeople)
+	var mergedIDs []ID
+	for _, m := range muts {
+		if u, ok := m.(*MergePeople); ok {
+			merges[u.MergedID] = append(merges[u.MergedID], u)
+			mergedIDs = append(mergedIDs, u.MergedID)
+		}
+	}
+	if len(mergedIDs) == 0 {
+		return nil
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT DISTINCT c.person_id, a.work_id, 'work'
+		FROM bbl_work_assertion_contributors c
+		JOIN bbl_work_assertions a ON a.id = c.assertion_id
+		WHERE c.person_id = ANY($1)
+		UNION
+		SELECT DISTINCT p.person_id, a.project_id, 'project'
+		FROM bbl_project_assertion_participants p
+		JOIN bbl_project_assertions a ON a.id = p.assertion_id
+		WHERE p.person_id = ANY($1)`, mergedIDs)
+	if err != nil {
+		return fmt.Errorf("fetchPersonMergeRelated: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var mergedID, id ID
+		var recordType string
+		if err := rows.Scan(&mergedID, &id, &recordType); err != nil {
+			return fmt.Errorf("fetchPersonMergeRelated: %w", err)
+		}
+		for _, u := range merges[mergedID] {
+			if recordType == RecordTypeWork {
+				u.relatedWorks = append(u.relatedWorks, id)
+			} else {
+				u.relatedProjects = append(u.relatedProjects, id)
+			}
+		}
+		if recordType == RecordTypeWork {
+			needs.workIDs = append(needs.workIDs, id)
+		} else {
+			needs.projectIDs = append(needs.projectIDs, id)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("fetchPersonMergeRelated: %w", err)
+	}
+	return nil
+}
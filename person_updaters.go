@@ -64,3 +64,95 @@ func (m *DeletePerson) write(revID int64, user *User) (string, []any) {
 		WHERE id = $1`,
 		[]any{m.PersonID, PersonStatusDeleted, &user.ID}
 }
+
+// MergePeople merges a duplicate person into the surviving person. Source
+// records, their assertions and the merged person's human assertions move
+// to the survivor (a human assertion the survivor already has for the same
+// field wins), contributor, participant and candidate links are repointed
+// and the survivor is re-pinned. A user linked to the merged person moves
+// to the survivor unless the survivor already has one. The merged person is
+// soft-deleted.
+type MergePeople struct {
+	PersonID ID `json:"id"`        // surviving person
+	MergedID ID `json:"merged_id"` // duplicate, deleted after the merge
+
+	relatedWorks    []ID // works with contributors linked to MergedID; set by fetchState
+	relatedProjects []ID // projects with participants linked to MergedID; set by fetchState
+}
+
+func (m *MergePeople) name() string { return "merge:person" }
+
+func (m *MergePeople) needs() updateNeeds {
+	return updateNeeds{personIDs: []ID{m.PersonID, m.MergedID}}
+}
+
+func (m *MergePeople) apply(state updateState, user *User) (*updateEffect, error) {
+	if user.Role != RoleCurator {
+		return nil, ErrForbidden
+	}
+	if m.PersonID == m.MergedID {
+		return nil, fmt.Errorf("MergePeople: cannot merge person %s into itself", m.PersonID)
+	}
+	rs := state.records[m.PersonID]
+	if rs == nil {
+		return nil, fmt.Errorf("MergePeople: person %s not found", m.PersonID)
+	}
+	merged := state.records[m.MergedID]
+	if merged == nil {
+		return nil, fmt.Errorf("MergePeople: person %s not found", m.MergedID)
+	}
+	if rs.status == PersonStatusDeleted {
+		return nil, fmt.Errorf("MergePeople: person %s is deleted", m.PersonID)
+	}
+	if merged.status == PersonStatusDeleted {
+		return nil, fmt.Errorf("MergePeople: person %s is deleted", m.MergedID)
+	}
+	merged.status = PersonStatusDeleted
+
+	return &updateEffect{
+		recordType:      RecordTypePerson,
+		recordID:        m.PersonID,
+		autoPinAll:      true,
+		relatedPeople:   []ID{m.MergedID},
+		related:         m.relatedWorks,
+		relatedProjects: m.relatedProjects,
+	}, nil
+}
+
+func (m *MergePeople) write(revID int64, user *User) (string, []any) {
+	// Data-modifying CTEs see the same snapshot, so every step is written
+	// against the pre-merge state.
+	return `WITH src AS (
+			UPDATE bbl_person_sources SET person_id = $1 WHERE person_id = $2
+		), moved AS (
+			UPDATE bbl_person_assertions a SET person_id = $1, pinned = false
+			WHERE a.person_id = $2
+			  AND (a.person_source_id IS NOT NULL OR NOT EXISTS (
+			      SELECT 1 FROM bbl_person_assertions s
+			      WHERE s.person_id = $1 AND s.field = a.field AND s.user_id IS NOT NULL))
+		), contributors AS (
+			UPDATE bbl_work_assertion_contributors SET person_id = $1 WHERE person_id = $2
+		), participants AS (
+			UPDATE bbl_project_assertion_participants SET person_id = $1 WHERE person_id = $2
+		), candidates AS (
+			UPDATE bbl_person_candidates SET person_id = $1 WHERE person_id = $2
+		), candidate_people AS (
+			UPDATE bbl_work_candidate_people c SET person_id = $1
+			WHERE c.person_id = $2 AND NOT EXISTS (
+			    SELECT 1 FROM bbl_work_candidate_people s
+			    WHERE s.candidate_id = c.candidate_id AND s.person_id = $1)
+		), candidate_people_dups AS (
+			DELETE FROM bbl_work_candidate_people c
+			WHERE c.person_id = $2 AND EXISTS (
+			    SELECT 1 FROM bbl_work_candidate_people s
+			    WHERE s.candidate_id = c.candidate_id AND s.person_id = $1)
+		), users AS (
+			UPDATE bbl_users SET person_id = $1
+			WHERE person_id = $2 AND NOT EXISTS (
+			    SELECT 1 FROM bbl_users WHERE person_id = $1)
+		)
+		UPDATE bbl_people
+		SET status = $3, deleted_at = transaction_timestamp(), deleted_by_id = $4
+		WHERE id = $2`,
+		[]any{m.PersonID, m.MergedID, PersonStatusDeleted, &user.ID}
+}
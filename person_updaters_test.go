@@ -1,6 +1,10 @@
 package bbl
 
-import "testing"
+import (
+	"errors"
+	"slices"
+	"testing"
+)
 
 func TestCreatePerson_Apply(t *testing.T) {
 	id := newID()
@@ -55,3 +59,55 @@ func TestDeletePerson_AlreadyDeleted(t *testing.T) {
 		t.Fatal("expected nil (noop) for already-deleted person")
 	}
 }
+
+func newTestPersonState(ids ...ID) updateState {
+	state := updateState{records: make(map[ID]*recordState)}
+	for _, id := range ids {
+		state.records[id] = &recordState{recordType: RecordTypePerson, id: id, version: 1, status: PersonStatusPublic,
+			fields: make(map[string]any), assertions: make(map[string][]assertion)}
+	}
+	return state
+}
+
+func TestMergePeople_Apply(t *testing.T) {
+	id, mergedID, workID, projectID := newID(), newID(), newID(), newID()
+	state := newTestPersonState(id, mergedID)
+
+	m := &MergePeople{PersonID: id, MergedID: mergedID, relatedWorks: []ID{workID}, relatedProjects: []ID{projectID}}
+	eff, err := m.apply(state, &User{Role: RoleCurator})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if eff == nil || eff.recordID != id || !eff.autoPinAll {
+		t.Fatalf("expected auto-pin effect on survivor, got %+v", eff)
+	}
+	if !slices.Equal(eff.relatedPeople, []ID{mergedID}) {
+		t.Errorf("relatedPeople = %v, want %v", eff.relatedPeople, []ID{mergedID})
+	}
+	if !slices.Equal(eff.related, []ID{workID}) || !slices.Equal(eff.relatedProjects, []ID{projectID}) {
+		t.Errorf("expected linked work and project to be related, got %v and %v", eff.related, eff.relatedProjects)
+	}
+	if state.records[mergedID].status != PersonStatusDeleted {
+		t.Errorf("expected merged person deleted, got %q", state.records[mergedID].status)
+	}
+}
+
+func TestMergePeople_Invalid(t *testing.T) {
+	id, mergedID := newID(), newID()
+	state := newTestPersonState(id, mergedID)
+	curator := &User{Role: RoleCurator}
+
+	if _, err := (&MergePeople{PersonID: id, MergedID: mergedID}).apply(state, &User{Role: RoleUser}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("expected ErrForbidden, got %v", err)
+	}
+	if _, err := (&MergePeople{PersonID: id, MergedID: id}).apply(state, curator); err == nil {
+		t.Error("expected error merging a person into itself")
+	}
+	if _, err := (&MergePeople{PersonID: id, MergedID: newID()}).apply(state, curator); err == nil {
+		t.Error("expected error for missing person")
+	}
+	state.records[mergedID].status = PersonStatusDeleted
+	if _, err := (&MergePeople{PersonID: id, MergedID: mergedID}).apply(state, curator); err == nil {
+		t.Error("expected error for deleted person")
+	}
+}
@@ -0,0 +1,221 @@
+package bbl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Positions order items in text columns with C collation (list items,
+// collection works). A key can always be generated between two others, so
+// moving an item rewrites one row.
+//
+// Keys are base-62 fractional indexes: a variable length integer part
+// whose head character encodes its length, followed by an optional
+// fraction. Appending only grows the integer part, keeping keys short.
+
+const (
+	posDigits      = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	posFirst       = "a0"
+	posSmallestInt = "A00000000000000000000000000"
+)
+
+// posBetween returns a key that sorts strictly between a and b. An empty a
+// means the start, an empty b the end.
+func posBetween(a, b string) (string, error) {
+	if a != "" {
+		if err := validatePos(a); err != nil {
+			return "", err
+		}
+	}
+	if b != "" {
+		if err := validatePos(b); err != nil {
+			return "", err
+		}
+	}
+	if a != "" && b != "" && a >= b {
+		return "", fmt.Errorf("posBetween: %q >= %q", a, b)
+	}
+
+	switch {
+	case a == "" && b == "":
+		return posFirst, nil
+	case a == "":
+		ib := posIntPart(b)
+		fb := b[len(ib):]
+		if ib == posSmallestInt {
+			return ib + posMidpoint("", fb), nil
+		}
+		if ib < b {
+			return ib, nil
+		}
+		res := posDecrementInt(ib)
+		if res == "" {
+			return "", fmt.Errorf("posBetween: cannot decrement %q", b)
+		}
+		return res, nil
+	case b == "":
+		ia := posIntPart(a)
+		fa := a[len(ia):]
+		if i := posIncrementInt(ia); i != "" {
+			return i, nil
+		}
+		return ia + posMidpoint(fa, ""), nil
+	}
+
+	ia := posIntPart(a)
+	fa := a[len(ia):]
+	ib := posIntPart(b)
+	fb := b[len(ib):]
+	if ia == ib {
+		return ia + posMidpoint(fa, fb), nil
+	}
+	i := posIncrementInt(ia)
+	if i == "" {
+		return "", fmt.Errorf("posBetween: cannot increment %q", a)
+	}
+	if i < b {
+		return i, nil
+	}
+	return ia + posMidpoint(fa, ""), nil
+}
+
+// posMidpoint returns a fraction between fractions a and b; an empty b means
+// no upper bound. Fractions never end in the zero digit.
+func posMidpoint(a, b string) string {
+	if b != "" {
+		// Skip the common prefix.
+		n := 0
+		for n < len(b) {
+			ca := byte(posDigits[0])
+			if n < len(a) {
+				ca = a[n]
+			}
+			if ca != b[n] {
+				break
+			}
+			n++
+		}
+		if n > 0 {
+			rest := ""
+			if n < len(a) {
+				rest = a[n:]
+			}
+			return b[:n] + posMidpoint(rest, b[n:])
+		}
+	}
+	da := 0
+	if a != "" {
+		da = strings.IndexByte(posDigits, a[0])
+	}
+	db := len(posDigits)
+	if b != "" {
+		db = strings.IndexByte(posDigits, b[0])
+	}
+	if db-da > 1 {
+		return string(posDigits[(da+db+1)/2])
+	}
+	// Adjacent digits.
+	if len(b) > 1 {
+		return b[:1]
+	}
+	rest := ""
+	if len(a) > 1 {
+		rest = a[1:]
+	}
+	return string(posDigits[da]) + posMidpoint(rest, "")
+}
+
+func posIntLen(head byte) int {
+	switch {
+	case head >= 'a' && head <= 'z':
+		return int(head-'a') + 2
+	case head >= 'A' && head <= 'Z':
+		return int('Z'-head) + 2
+	}
+	return 0
+}
+
+func posIntPart(key string) string {
+	return key[:posIntLen(key[0])]
+}
+
+func validatePos(key string) error {
+	n := posIntLen(key[0])
+	if n == 0 || n > len(key) {
+		return fmt.Errorf("invalid position %q", key)
+	}
+	if key == posSmallestInt {
+		return fmt.Errorf("invalid position %q", key)
+	}
+	for i := 1; i < len(key); i++ {
+		if strings.IndexByte(posDigits, key[i]) < 0 {
+			return fmt.Errorf("invalid position %q", key)
+		}
+	}
+	if len(key) > n && key[len(key)-1] == posDigits[0] {
+		return fmt.Errorf("invalid position %q", key)
+	}
+	return nil
+}
+
+// posIncrementInt returns the next integer part, or "" on overflow.
+func posIncrementInt(x string) string {
+	head, digs := x[0], []byte(x[1:])
+	carry := true
+	for i := len(digs) - 1; carry && i >= 0; i-- {
+		d := strings.IndexByte(posDigits, digs[i]) + 1
+		if d == len(posDigits) {
+			digs[i] = posDigits[0]
+		} else {
+			digs[i] = posDigits[d]
+			carry = false
+		}
+	}
+	if !carry {
+		return string(head) + string(digs)
+	}
+	switch head {
+	case 'Z':
+		return "a" + posDigits[:1]
+	case 'z':
+		return ""
+	}
+	h := head + 1
+	if h > 'a' {
+		digs = append(digs, posDigits[0])
+	} else {
+		digs = digs[:len(digs)-1]
+	}
+	return string(h) + string(digs)
+}
+
+// posDecrementInt returns the previous integer part, or "" on underflow.
+func posDecrementInt(x string) string {
+	head, digs := x[0], []byte(x[1:])
+	borrow := true
+	for i := len(digs) - 1; borrow && i >= 0; i-- {
+		d := strings.IndexByte(posDigits, digs[i]) - 1
+		if d == -1 {
+			digs[i] = posDigits[len(posDigits)-1]
+		} else {
+			digs[i] = posDigits[d]
+			borrow = false
+		}
+	}
+	if !borrow {
+		return string(head) + string(digs)
+	}
+	switch head {
+	case 'a':
+		return "Z" + posDigits[len(posDigits)-1:]
+	case 'A':
+		return ""
+	}
+	h := head - 1
+	if h < 'Z' {
+		digs = append(digs, posDigits[len(posDigits)-1])
+	} else {
+		digs = digs[:len(digs)-1]
+	}
+	return string(h) + string(digs)
+}
@@ -0,0 +1,79 @@
+package bbl
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestPosBetween(t *testing.T) {
+	tests := []struct {
+		a, b, want string
+	}{
+		{"", "", "a0"},
+		{"", "a0", "Zz"},
+		{"a0", "", "a1"},
+		{"a0", "a1", "a0V"},
+		{"a1", "a2", "a1V"},
+		{"a0V", "a1", "a0l"},
+		{"Zz", "a0", "ZzV"},
+		{"az", "", "b00"},
+		{"a0", "a0V", "a0G"},
+		{"", "A00000000000000000000000000V", "A00000000000000000000000000G"},
+		{"zzzzzzzzzzzzzzzzzzzzzzzzzzy", "", "zzzzzzzzzzzzzzzzzzzzzzzzzzz"},
+		{"zzzzzzzzzzzzzzzzzzzzzzzzzzz", "", "zzzzzzzzzzzzzzzzzzzzzzzzzzzV"},
+	}
+	for _, tt := range tests {
+		got, err := posBetween(tt.a, tt.b)
+		if err != nil {
+			t.Errorf("posBetween(%q, %q): %v", tt.a, tt.b, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("posBetween(%q, %q) = %q, want %q", tt.a, tt.b, got, tt.want)
+		}
+	}
+
+	for _, bad := range [][2]string{{"a1", "a0"}, {"a0", "a0"}, {"a00", ""}, {"", "b0"}, {"x", ""}} {
+		if _, err := posBetween(bad[0], bad[1]); err == nil {
+			t.Errorf("posBetween(%q, %q): expected error", bad[0], bad[1])
+		}
+	}
+}
+
+func TestPosBetween_Order(t *testing.T) {
+	// Insert at random places and check the keys stay sorted and unique.
+	rng := rand.New(rand.NewPCG(1, 2))
+	var keys []string
+	for range 2000 {
+		i := rng.IntN(len(keys) + 1)
+		var a, b string
+		if i > 0 {
+			a = keys[i-1]
+		}
+		if i < len(keys) {
+			b = keys[i]
+		}
+		k, err := posBetween(a, b)
+		if err != nil {
+			t.Fatalf("posBetween(%q, %q): %v", a, b, err)
+		}
+		if (a != "" && k <= a) || (b != "" && k >= b) {
+			t.Fatalf("posBetween(%q, %q) = %q out of order", a, b, k)
+		}
+		keys = slices.Insert(keys, i, k)
+	}
+
+	// Appending keeps keys short.
+	k := ""
+	for range 10000 {
+		next, err := posBetween(k, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		k = next
+	}
+	if len(k) > 4 {
+		t.Errorf("key after 10000 appends = %q, want at most 4 characters", k)
+	}
+}
@@ -14,6 +14,9 @@ type FieldDef struct {
 	Type     string   // resolved fieldType name (for views)
 	Required string   // "", "always", "public"
 	Schemes  []string // for identifier, classification
+	// AccessKinds are the valid access levels of a workFile field, set
+	// from the profiles' file access kinds.
+	AccessKinds []string
 }
 
 // IsRequired reports whether the field has any required constraint.
@@ -27,6 +30,7 @@ type Profiles struct {
 	Project      []FieldDef
 	workKinds    []string // ordered from YAML
 	orgKinds     []string
+	fileAccess   []FileAccessKind
 }
 
 // WorkKinds returns work kind names in definition order.
@@ -35,6 +39,15 @@ func (p *Profiles) WorkKinds() []string { return p.workKinds }
 // OrganizationKinds returns organization kind names in definition order.
 func (p *Profiles) OrganizationKinds() []string { return p.orgKinds }
 
+// FileAccessKinds returns the work file access levels, most open first.
+// Returns DefaultFileAccessKinds if none are configured.
+func (p *Profiles) FileAccessKinds() []FileAccessKind {
+	if p == nil || len(p.fileAccess) == 0 {
+		return DefaultFileAccessKinds
+	}
+	return p.fileAccess
+}
+
 // FieldDefs returns the field definitions for a record type and kind.
 // Returns nil if the record type or kind is unknown.
 func (p *Profiles) FieldDefs(recordType, kind string) []FieldDef {
@@ -61,6 +74,8 @@ type profilesFile struct {
 	OrgKinds  []profileKind `yaml:"organization_kinds"`
 	Person    profileKind   `yaml:"person"`
 	Project   profileKind   `yaml:"project"`
+
+	FileAccessKinds []FileAccessKind `yaml:"file_access_kinds"`
 }
 
 type profileKind struct {
@@ -92,6 +107,22 @@ func LoadProfiles(path string) (*Profiles, error) {
 		Organization: make(map[string][]FieldDef),
 	}
 
+	// File access kinds.
+	seen := make(map[string]bool)
+	for _, k := range f.FileAccessKinds {
+		if k.Name == "" || seen[k.Name] {
+			return nil, fmt.Errorf("parse profiles: missing or duplicate file access kind %q", k.Name)
+		}
+		switch k.Download {
+		case FileDownloadEveryone, FileDownloadUsers, FileDownloadCurators:
+		default:
+			return nil, fmt.Errorf("parse profiles: file access kind %q: invalid download value %q (must be \"everyone\", \"users\" or \"curators\")", k.Name, k.Download)
+		}
+		seen[k.Name] = true
+	}
+	p.fileAccess = f.FileAccessKinds
+	accessKinds := fileAccessKindNames(p.FileAccessKinds())
+
 	// Work kinds.
 	if len(f.WorkKinds) == 0 {
 		return nil, fmt.Errorf("parse profiles: no work kinds defined")
@@ -101,6 +132,11 @@ func LoadProfiles(path string) (*Profiles, error) {
 		if err != nil {
 			return nil, err
 		}
+		for i := range defs {
+			if defs[i].Type == ftWorkFile.name {
+				defs[i].AccessKinds = accessKinds
+			}
+		}
 		p.Work[wk.Name] = defs
 		p.workKinds = append(p.workKinds, wk.Name)
 	}
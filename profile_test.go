@@ -91,6 +91,26 @@ func TestLoadProfiles(t *testing.T) {
 	if len(p.Organization) == 0 {
 		t.Fatal("no organization kinds")
 	}
+
+	// No file access kinds configured.
+	if !reflect.DeepEqual(p.FileAccessKinds(), DefaultFileAccessKinds) {
+		t.Errorf("file access kinds = %v, want defaults", p.FileAccessKinds())
+	}
+}
+
+func TestLoadProfilesFileAccessKinds(t *testing.T) {
+	p, err := LoadProfiles("ugent/profiles.yaml")
+	if err != nil {
+		t.Fatalf("LoadProfiles: %v", err)
+	}
+	if got, want := fileAccessKindNames(p.FileAccessKinds()), []string{"open", "ugent", "closed"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("file access kinds = %v, want %v", got, want)
+	}
+	for _, def := range p.FieldDefs(RecordTypeWork, "journal_article") {
+		if def.Name == "files" && !reflect.DeepEqual(def.AccessKinds, []string{"open", "ugent", "closed"}) {
+			t.Errorf("files access kinds = %v", def.AccessKinds)
+		}
+	}
 }
 
 func TestLoadProfilesValidation(t *testing.T) {
@@ -119,6 +139,11 @@ func TestLoadProfilesValidation(t *testing.T) {
 			yaml:    "work_kinds:\n  - name: test\n    fields:\n      - name: titles\n        required: bogus\nperson:\n  fields:\n    - name: name\nproject:\n  fields:\n    - name: titles\norganization_kinds:\n  - name: dept\n    fields:\n      - name: names\n",
 			wantErr: "invalid required value",
 		},
+		{
+			name:    "invalid file access download",
+			yaml:    "file_access_kinds:\n  - name: open\n    download: bogus\nwork_kinds:\n  - name: test\n    fields:\n      - name: titles\nperson:\n  fields:\n    - name: name\nproject:\n  fields:\n    - name: titles\norganization_kinds:\n  - name: dept\n    fields:\n      - name: names\n",
+			wantErr: "invalid download value",
+		},
 	}
 
 	for _, tt := range tests {
@@ -31,6 +31,46 @@ func (r *Repo) GetProject(ctx context.Context, id ID) (*Project, error) {
 	return p, nil
 }
 
+// GetProjects fetches multiple projects by ID, preserving the input order.
+// Missing IDs are silently skipped.
+func (r *Repo) GetProjects(ctx context.Context, ids []ID) ([]*Project, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	rows, err := r.db.Query(ctx, `
+		SELECT id, version, created_at, updated_at,
+		       created_by_id, updated_by_id,
+		       status, start_date, end_date,
+		       deleted_at, deleted_by_id,
+		       cache
+		FROM bbl_projects
+		WHERE id = ANY($1)`, ids)
+	if err != nil {
+		return nil, fmt.Errorf("GetProjects: %w", err)
+	}
+	defer rows.Close()
+
+	byID := make(map[ID]*Project, len(ids))
+	for rows.Next() {
+		p, err := scanProject(rows)
+		if err != nil {
+			return nil, fmt.Errorf("GetProjects: %w", err)
+		}
+		byID[p.ID] = p
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetProjects: %w", err)
+	}
+
+	result := make([]*Project, 0, len(ids))
+	for _, id := range ids {
+		if p, ok := byID[id]; ok {
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
 func (r *Repo) ImportProjects(ctx context.Context, source string, seq iter.Seq2[*ImportProjectInput, error]) (int, error) {
 	const batchSize = 250
 	var pending []*ImportProjectInput
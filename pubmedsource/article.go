@@ -0,0 +1,285 @@
+package pubmedsource
+
+import (
+	"cmp"
+	"html"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/ugent-library/bbl"
+)
+
+// articleSet is the efetch response. Only the elements that are mapped are
+// decoded; the full record is kept as the source record.
+type articleSet struct {
+	Articles []article `xml:"PubmedArticle"`
+}
+
+type article struct {
+	Inner   string `xml:",innerxml"`
+	PMID    string `xml:"MedlineCitation>PMID"`
+	Journal struct {
+		ISSN            []string `xml:"ISSN"`
+		Volume          string   `xml:"JournalIssue>Volume"`
+		Issue           string   `xml:"JournalIssue>Issue"`
+		Year            string   `xml:"JournalIssue>PubDate>Year"`
+		MedlineDate     string   `xml:"JournalIssue>PubDate>MedlineDate"`
+		Title           string   `xml:"Title"`
+		ISOAbbreviation string   `xml:"ISOAbbreviation"`
+	} `xml:"MedlineCitation>Article>Journal"`
+	Title           markup `xml:"MedlineCitation>Article>ArticleTitle"`
+	VernacularTitle markup `xml:"MedlineCitation>Article>VernacularTitle"`
+	Pagination      struct {
+		StartPage  string `xml:"StartPage"`
+		EndPage    string `xml:"EndPage"`
+		MedlinePgn string `xml:"MedlinePgn"`
+	} `xml:"MedlineCitation>Article>Pagination"`
+	ELocationIDs []struct {
+		Type string `xml:"EIdType,attr"`
+		Val  string `xml:",chardata"`
+	} `xml:"MedlineCitation>Article>ELocationID"`
+	Abstract       []abstractText `xml:"MedlineCitation>Article>Abstract>AbstractText"`
+	OtherAbstracts []struct {
+		Lang  string         `xml:"Language,attr"`
+		Texts []abstractText `xml:"AbstractText"`
+	} `xml:"MedlineCitation>OtherAbstract"`
+	Authors []struct {
+		Valid          string `xml:"ValidYN,attr"`
+		LastName       string `xml:"LastName"`
+		ForeName       string `xml:"ForeName"`
+		CollectiveName markup `xml:"CollectiveName"`
+		Identifiers    []struct {
+			Source string `xml:"Source,attr"`
+			Val    string `xml:",chardata"`
+		} `xml:"Identifier"`
+		Affiliations []string `xml:"AffiliationInfo>Affiliation"`
+	} `xml:"MedlineCitation>Article>AuthorList>Author"`
+	Languages        []string `xml:"MedlineCitation>Article>Language"`
+	PublicationTypes []string `xml:"MedlineCitation>Article>PublicationTypeList>PublicationType"`
+	MeSH             []struct {
+		UI string `xml:"UI,attr"`
+	} `xml:"MedlineCitation>MeshHeadingList>MeshHeading>DescriptorName"`
+	Keywords   []markup `xml:"MedlineCitation>KeywordList>Keyword"`
+	ArticleIDs []struct {
+		Type string `xml:"IdType,attr"`
+		Val  string `xml:",chardata"`
+	} `xml:"PubmedData>ArticleIdList>ArticleId"`
+}
+
+// markup is an element that can contain inline formatting like <i> or
+// <sup>.
+type markup struct {
+	Inner string `xml:",innerxml"`
+}
+
+func (m markup) String() string {
+	s := reTags.ReplaceAllString(m.Inner, "")
+	s = html.UnescapeString(s)
+	return strings.TrimSpace(reSpace.ReplaceAllString(s, " "))
+}
+
+// abstractText is a paragraph of an abstract. Structured abstracts have one
+// labeled paragraph per section.
+type abstractText struct {
+	Label string `xml:"Label,attr"`
+	markup
+}
+
+var (
+	reTags  = regexp.MustCompile(`<[^>]+>`)
+	reSpace = regexp.MustCompile(`\s+`)
+	reYear  = regexp.MustCompile(`\d{4}`)
+	reORCID = regexp.MustCompile(`^(\d{4})-?(\d{4})-?(\d{4})-?(\d{3}[\dX])$`)
+)
+
+// kinds maps PubMed publication types to work kinds, in order of
+// precedence. Articles of other types are journal articles.
+var kinds = []struct{ pubType, kind string }{
+	{"Preprint", "preprint"},
+	{"Newspaper Article", "newspaper_article"},
+	{"Congress", "conference_paper"},
+	{"Technical Report", "report"},
+	{"Dataset", "miscellaneous"},
+}
+
+func articleKind(a article) string {
+	for _, k := range kinds {
+		if slices.Contains(a.PublicationTypes, k.pubType) {
+			return k.kind
+		}
+	}
+	return "journal_article"
+}
+
+func mapArticle(a article) *bbl.ImportWorkInput {
+	rec := &bbl.ImportWorkInput{
+		SourceID:            a.PMID,
+		Kind:                articleKind(a),
+		SourceRecord:        []byte("<PubmedArticle>" + a.Inner + "</PubmedArticle>"),
+		JournalTitle:        a.Journal.Title,
+		JournalAbbreviation: a.Journal.ISOAbbreviation,
+		Volume:              a.Journal.Volume,
+		Issue:               a.Journal.Issue,
+		PublicationYear:     a.Journal.Year,
+		PublicationStatus:   "published",
+		Identifiers:         []bbl.Identifier{{Scheme: "pubmed", Val: a.PMID}},
+	}
+	if rec.PublicationYear == "" {
+		rec.PublicationYear = reYear.FindString(a.Journal.MedlineDate)
+	}
+
+	lang := "und"
+	if len(a.Languages) > 0 {
+		lang = a.Languages[0]
+	}
+
+	// ArticleTitle is always English; the title in the language of the
+	// article is the vernacular title.
+	if v := title(a.Title); v != "" {
+		rec.Titles = append(rec.Titles, bbl.Title{Lang: "eng", Val: v})
+	}
+	if v := title(a.VernacularTitle); v != "" && lang != "eng" {
+		rec.Titles = append(rec.Titles, bbl.Title{Lang: lang, Val: v})
+	}
+
+	if v := abstract(a.Abstract); v != "" {
+		rec.Abstracts = append(rec.Abstracts, bbl.Text{Lang: "eng", Val: v})
+	}
+	for _, other := range a.OtherAbstracts {
+		if v := abstract(other.Texts); v != "" {
+			rec.Abstracts = append(rec.Abstracts, bbl.Text{Lang: cmp.Or(other.Lang, "und"), Val: v})
+		}
+	}
+
+	rec.Pages.Start = a.Pagination.StartPage
+	rec.Pages.End = a.Pagination.EndPage
+	if rec.Pages.Start == "" && a.Pagination.MedlinePgn != "" {
+		rec.Pages = medlinePages(a.Pagination.MedlinePgn)
+	}
+
+	for _, v := range a.Journal.ISSN {
+		rec.Identifiers = append(rec.Identifiers, bbl.Identifier{Scheme: "issn", Val: v})
+	}
+	doi := ""
+	for _, id := range a.ArticleIDs {
+		if id.Type == "doi" {
+			doi = id.Val
+		}
+	}
+	for _, id := range a.ELocationIDs {
+		if id.Type == "doi" && doi == "" {
+			doi = id.Val
+		}
+	}
+	if doi != "" {
+		rec.Identifiers = append(rec.Identifiers, bbl.Identifier{Scheme: "doi", Val: strings.ToLower(strings.TrimSpace(doi))})
+	}
+
+	for _, au := range a.Authors {
+		if au.Valid == "N" {
+			continue
+		}
+		c := bbl.ImportWorkContributor{
+			Roles:        []string{"author"},
+			GivenName:    au.ForeName,
+			FamilyName:   au.LastName,
+			Affiliations: au.Affiliations,
+		}
+		if name := au.CollectiveName.String(); name != "" {
+			c.Kind = "organization"
+			c.Name = name
+			c.GivenName, c.FamilyName = "", ""
+		} else {
+			c.Kind = "person"
+			c.Name = strings.TrimSpace(au.ForeName + " " + au.LastName)
+		}
+		for _, id := range au.Identifiers {
+			if id.Source != "ORCID" {
+				continue
+			}
+			if orcid := normalizeORCID(id.Val); orcid != "" {
+				c.PersonRef = &bbl.Ref{Identifier: &bbl.Identifier{Scheme: "orcid", Val: orcid}}
+				c.PersonRefOptional = true
+			}
+		}
+		rec.Contributors = append(rec.Contributors, c)
+	}
+
+	for _, m := range a.MeSH {
+		if m.UI != "" {
+			rec.Classifications = append(rec.Classifications, bbl.Identifier{Scheme: "mesh", Val: m.UI})
+		}
+	}
+	for _, kw := range a.Keywords {
+		if v := kw.String(); v != "" {
+			rec.Keywords = append(rec.Keywords, bbl.Keyword{Val: v})
+		}
+	}
+
+	return rec
+}
+
+// title drops the closing period of a MEDLINE title and the brackets around
+// a translated title.
+func title(m markup) string {
+	s := strings.TrimSuffix(m.String(), ".")
+	if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
+		s = s[1 : len(s)-1]
+	}
+	return s
+}
+
+// abstract joins the paragraphs of an abstract. Sections of a structured
+// abstract are prefixed with their label.
+func abstract(texts []abstractText) string {
+	var paras []string
+	for _, t := range texts {
+		v := t.String()
+		if v == "" {
+			continue
+		}
+		if t.Label != "" && !strings.EqualFold(t.Label, "UNLABELLED") {
+			v = t.Label + ": " + v
+		}
+		paras = append(paras, v)
+	}
+	return strings.Join(paras, "\n\n")
+}
+
+// medlinePages parses a MEDLINE page range like "123-9", where the end page
+// drops the leading digits it shares with the start page.
+func medlinePages(pgn string) bbl.Extent {
+	pgn, _, _ = strings.Cut(pgn, ",")
+	start, end, _ := strings.Cut(strings.TrimSpace(pgn), "-")
+	if len(end) < len(start) && isDigits(start) && isDigits(end) {
+		end = start[:len(start)-len(end)] + end
+	}
+	return bbl.Extent{Start: start, End: end}
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeORCID returns the ORCID iD in its hyphenated form; PubMed has
+// both resolver URLs and bare digits.
+func normalizeORCID(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.LastIndex(s, "/"); i >= 0 {
+		s = s[i+1:]
+	}
+	m := reORCID.FindStringSubmatch(s)
+	if m == nil {
+		return ""
+	}
+	return m[1] + "-" + m[2] + "-" + m[3] + "-" + m[4]
+}
@@ -0,0 +1,272 @@
+// Package pubmedsource imports works from PubMed with the NCBI E-utilities.
+package pubmedsource
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ugent-library/bbl"
+)
+
+const (
+	defaultURL       = "https://eutils.ncbi.nlm.nih.gov/entrez/eutils"
+	defaultIDConvURL = "https://www.ncbi.nlm.nih.gov/pmc/utils/idconv/v1.0/"
+	pageSize         = 200
+)
+
+type Config struct {
+	URL         string `yaml:"url"`         // E-utilities base URL, defaults to https://eutils.ncbi.nlm.nih.gov/entrez/eutils
+	IDConvURL   string `yaml:"idconv_url"`  // PMC ID converter URL, used to look up PMCIDs
+	APIKey      string `yaml:"api_key"`     // NCBI API key, raises the rate limit
+	Tool        string `yaml:"tool"`        // tool name reported to NCBI
+	Email       string `yaml:"email"`       // contact address reported to NCBI
+	Affiliation string `yaml:"affiliation"` // iterate works with an author affiliation matching this text
+	MinDate     string `yaml:"min_date"`    // iterate works published on or after this date (YYYY[/MM[/DD]])
+	MaxDate     string `yaml:"max_date"`    // iterate works published on or before this date
+}
+
+// WorkSource fetches works by PMID or PMCID and iterates the works of an
+// institution.
+type WorkSource struct {
+	url         *url.URL
+	idConvURL   *url.URL
+	apiKey      string
+	tool        string
+	email       string
+	affiliation string
+	minDate     string
+	maxDate     string
+	client      *http.Client
+}
+
+func New(c Config) (*WorkSource, error) {
+	if c.URL == "" {
+		c.URL = defaultURL
+	}
+	if c.IDConvURL == "" {
+		c.IDConvURL = defaultIDConvURL
+	}
+	u, err := url.ParseRequestURI(strings.TrimSuffix(c.URL, "/"))
+	if err != nil {
+		return nil, err
+	}
+	idConvURL, err := url.ParseRequestURI(c.IDConvURL)
+	if err != nil {
+		return nil, err
+	}
+	return &WorkSource{
+		url:         u,
+		idConvURL:   idConvURL,
+		apiKey:      c.APIKey,
+		tool:        c.Tool,
+		email:       c.Email,
+		affiliation: c.Affiliation,
+		minDate:     c.MinDate,
+		maxDate:     c.MaxDate,
+		client:      &http.Client{Timeout: 60 * time.Second},
+	}, nil
+}
+
+// IdentifierScheme implements bbl.WorkIdentifierSource; records are looked
+// up by PMID.
+func (ws *WorkSource) IdentifierScheme() string {
+	return "pubmed"
+}
+
+// Partial implements bbl.WorkSourcePartial. Works looked up by PMID need not
+// be in the affiliation search, so a harvest never has all records.
+func (ws *WorkSource) Partial() bool {
+	return true
+}
+
+// Get fetches a work by PMID. PMCIDs (PMC1234567) are converted to the
+// matching PMID first.
+func (ws *WorkSource) Get(ctx context.Context, id string) (*bbl.ImportWorkInput, error) {
+	pmid, err := ws.pmid(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("pubmed.Get: %w", err)
+	}
+	q := url.Values{}
+	q.Set("id", pmid)
+	recs, err := ws.fetch(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("pubmed.Get: %w", err)
+	}
+	if len(recs) == 0 {
+		return nil, fmt.Errorf("pubmed.Get: %s: %w", id, bbl.ErrNotFound)
+	}
+	return recs[0], nil
+}
+
+// Iter searches the works matching the configured affiliation and
+// publication dates and fetches them in batches from the search history.
+func (ws *WorkSource) Iter(ctx context.Context) (iter.Seq2[*bbl.ImportWorkInput, error], error) {
+	if ws.affiliation == "" {
+		return nil, fmt.Errorf("pubmed.Iter: affiliation is required")
+	}
+
+	q := url.Values{}
+	q.Set("db", "pubmed")
+	q.Set("term", fmt.Sprintf("%q[Affiliation]", ws.affiliation))
+	q.Set("usehistory", "y")
+	q.Set("retmax", "0")
+	q.Set("retmode", "json")
+	if ws.minDate != "" || ws.maxDate != "" {
+		q.Set("datetype", "pdat")
+		q.Set("mindate", cmp.Or(ws.minDate, "1800"))
+		q.Set("maxdate", cmp.Or(ws.maxDate, "3000"))
+	}
+	body, err := ws.get(ctx, ws.url.JoinPath("esearch.fcgi"), q)
+	if err != nil {
+		return nil, fmt.Errorf("pubmed.Iter: %w", err)
+	}
+	var search struct {
+		Result struct {
+			Count    int    `json:"count,string"`
+			WebEnv   string `json:"webenv"`
+			QueryKey string `json:"querykey"`
+		} `json:"esearchresult"`
+	}
+	if err := json.Unmarshal(body, &search); err != nil {
+		return nil, fmt.Errorf("pubmed.Iter: %w", err)
+	}
+
+	seq := func(yield func(*bbl.ImportWorkInput, error) bool) {
+		for start := 0; start < search.Result.Count; start += pageSize {
+			q := url.Values{}
+			q.Set("WebEnv", search.Result.WebEnv)
+			q.Set("query_key", search.Result.QueryKey)
+			q.Set("retstart", fmt.Sprint(start))
+			q.Set("retmax", fmt.Sprint(pageSize))
+			recs, err := ws.fetch(ctx, q)
+			if err != nil {
+				yield(nil, fmt.Errorf("pubmed.Iter: %w", err))
+				return
+			}
+			for _, rec := range recs {
+				if !yield(rec, nil) {
+					return
+				}
+			}
+			if len(recs) == 0 {
+				return
+			}
+		}
+	}
+	return seq, nil
+}
+
+var (
+	rePMID  = regexp.MustCompile(`^\d+$`)
+	rePMCID = regexp.MustCompile(`(?i)^pmc\d+$`)
+)
+
+// pmid returns the PMID for a PMID or PMCID.
+func (ws *WorkSource) pmid(ctx context.Context, id string) (string, error) {
+	id = strings.TrimSpace(id)
+	if rePMID.MatchString(id) {
+		return id, nil
+	}
+	if !rePMCID.MatchString(id) {
+		return "", fmt.Errorf("invalid PMID or PMCID %q", id)
+	}
+	q := url.Values{}
+	q.Set("ids", strings.ToUpper(id))
+	q.Set("format", "json")
+	body, err := ws.get(ctx, ws.idConvURL, q)
+	if err != nil {
+		return "", err
+	}
+	var res struct {
+		Records []struct {
+			PMID string `json:"pmid"`
+		} `json:"records"`
+	}
+	if err := json.Unmarshal(body, &res); err != nil {
+		return "", err
+	}
+	if len(res.Records) == 0 || res.Records[0].PMID == "" {
+		return "", fmt.Errorf("%s: %w", id, bbl.ErrNotFound)
+	}
+	return res.Records[0].PMID, nil
+}
+
+// Map implements bbl.WorkSourceMapper; record is a stored PubmedArticle.
+func (ws *WorkSource) Map(record []byte) (*bbl.ImportWorkInput, error) {
+	var a article
+	if err := xml.Unmarshal(record, &a); err != nil {
+		return nil, fmt.Errorf("pubmed.Map: %w", err)
+	}
+	return mapArticle(a), nil
+}
+
+func (ws *WorkSource) fetch(ctx context.Context, q url.Values) ([]*bbl.ImportWorkInput, error) {
+	q.Set("db", "pubmed")
+	q.Set("retmode", "xml")
+	body, err := ws.get(ctx, ws.url.JoinPath("efetch.fcgi"), q)
+	if err != nil {
+		return nil, err
+	}
+	var set articleSet
+	if err := xml.Unmarshal(body, &set); err != nil {
+		return nil, err
+	}
+	recs := make([]*bbl.ImportWorkInput, len(set.Articles))
+	for i, a := range set.Articles {
+		recs[i] = mapArticle(a)
+	}
+	return recs, nil
+}
+
+func (ws *WorkSource) get(ctx context.Context, base *url.URL, q url.Values) ([]byte, error) {
+	u := *base
+	if ws.apiKey != "" {
+		q.Set("api_key", ws.apiKey)
+	}
+	if ws.tool != "" {
+		q.Set("tool", ws.tool)
+	}
+	if ws.email != "" {
+		q.Set("email", ws.email)
+	}
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := ws.client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = redactURL(u)
+		}
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %q: %s", redactURL(u), res.Status)
+	}
+	return io.ReadAll(res.Body)
+}
+
+// redactURL returns u without the API key, for use in errors and logs.
+func redactURL(u url.URL) string {
+	q := u.Query()
+	if q.Has("api_key") {
+		q.Set("api_key", "REDACTED")
+		u.RawQuery = q.Encode()
+	}
+	return u.String()
+}
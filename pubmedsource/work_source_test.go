@@ -0,0 +1,232 @@
+package pubmedsource
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ugent-library/bbl"
+)
+
+// fixtureServer serves recorded E-utilities and ID converter responses from
+// testdata.
+func fixtureServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /eutils/efetch.fcgi", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch {
+		case q.Get("id") == "36912345":
+			http.ServeFile(w, r, "testdata/efetch.xml")
+		case q.Get("WebEnv") == "MCID_test" && q.Get("query_key") == "1" && q.Get("retstart") == "0":
+			http.ServeFile(w, r, "testdata/efetch_page.xml")
+		case q.Get("id") != "":
+			w.Write([]byte(`<?xml version="1.0" ?><PubmedArticleSet></PubmedArticleSet>`))
+		default:
+			http.Error(w, "unexpected query "+r.URL.RawQuery, http.StatusBadRequest)
+		}
+	})
+	mux.HandleFunc("GET /eutils/esearch.fcgi", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("term") != `"Ghent University"[Affiliation]` || q.Get("mindate") != "2022" || q.Get("maxdate") != "3000" || q.Get("email") != "test@example.org" {
+			http.Error(w, "unexpected query "+r.URL.RawQuery, http.StatusBadRequest)
+			return
+		}
+		http.ServeFile(w, r, "testdata/esearch.json")
+	})
+	mux.HandleFunc("GET /idconv/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("ids") != "PMC9876543" {
+			w.Write([]byte(`{"status":"ok","records":[{"pmcid":"PMC1","status":"error"}]}`))
+			return
+		}
+		http.ServeFile(w, r, "testdata/idconv.json")
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestSource(t *testing.T, c Config) *WorkSource {
+	t.Helper()
+	srv := fixtureServer(t)
+	c.URL = srv.URL + "/eutils"
+	c.IDConvURL = srv.URL + "/idconv/"
+	ws, err := New(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ws
+}
+
+func TestGet(t *testing.T) {
+	ws := newTestSource(t, Config{})
+	rec, err := ws.Get(context.Background(), "36912345")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rec.SourceID != "36912345" || rec.Kind != "journal_article" {
+		t.Errorf("source id, kind = %q, %q", rec.SourceID, rec.Kind)
+	}
+	if rec.JournalTitle != "The Journal of ecology" || rec.JournalAbbreviation != "J Ecol" ||
+		rec.Volume != "111" || rec.Issue != "4" || rec.PublicationYear != "2023" {
+		t.Errorf("journal fields = %+v", rec)
+	}
+	if rec.Pages != (bbl.Extent{Start: "812", End: "824"}) {
+		t.Errorf("pages = %+v", rec.Pages)
+	}
+	if want := []bbl.Title{{Lang: "eng", Val: "Grassland Festuca responses to drought"}}; !slices.Equal(rec.Titles, want) {
+		t.Errorf("titles = %v", rec.Titles)
+	}
+	wantAbstract := "BACKGROUND: Drought is increasing.\n\nMETHODS: We sampled 40 plots & measured CO2 flux.\n\nRESULTS: Biomass dropped."
+	if len(rec.Abstracts) != 1 || rec.Abstracts[0] != (bbl.Text{Lang: "eng", Val: wantAbstract}) {
+		t.Errorf("abstracts = %q", rec.Abstracts)
+	}
+	wantIDs := []bbl.Identifier{
+		{Scheme: "pubmed", Val: "36912345"},
+		{Scheme: "issn", Val: "1365-2745"},
+		{Scheme: "doi", Val: "10.1111/1365-2745.14000"},
+	}
+	if !slices.Equal(rec.Identifiers, wantIDs) {
+		t.Errorf("identifiers = %v", rec.Identifiers)
+	}
+	wantMeSH := []bbl.Identifier{{Scheme: "mesh", Val: "D055864"}, {Scheme: "mesh", Val: "D064209"}}
+	if !slices.Equal(rec.Classifications, wantMeSH) {
+		t.Errorf("classifications = %v", rec.Classifications)
+	}
+	if want := []bbl.Keyword{{Val: "climate change"}, {Val: "biomass"}}; !slices.Equal(rec.Keywords, want) {
+		t.Errorf("keywords = %v", rec.Keywords)
+	}
+
+	if len(rec.Contributors) != 3 {
+		t.Fatalf("contributors = %+v", rec.Contributors)
+	}
+	doe := rec.Contributors[0]
+	if doe.Name != "Jane Doe" || doe.GivenName != "Jane" || doe.FamilyName != "Doe" ||
+		doe.PersonRef == nil || *doe.PersonRef.Identifier != (bbl.Identifier{Scheme: "orcid", Val: "0000-0002-1825-0097"}) {
+		t.Errorf("first author = %+v", doe)
+	}
+	wantAffs := []string{
+		"Department of Biology, Ghent University, Ghent, Belgium.",
+		"VIB Center for Plant Systems Biology, Ghent, Belgium.",
+	}
+	if !slices.Equal(doe.Affiliations, wantAffs) {
+		t.Errorf("first author affiliations = %v", doe.Affiliations)
+	}
+	if c := rec.Contributors[1]; c.PersonRef != nil || len(c.Affiliations) != 0 {
+		t.Errorf("second author = %+v", c)
+	}
+	if c := rec.Contributors[2]; c.Kind != "organization" || c.Name != "Grassland Consortium" {
+		t.Errorf("collective author = %+v", c)
+	}
+
+	src := string(rec.SourceRecord)
+	if !strings.HasPrefix(src, "<PubmedArticle>") || !strings.HasSuffix(src, "</PubmedArticle>") || !strings.Contains(src, "PMC9876543") {
+		t.Errorf("source record = %s", src)
+	}
+}
+
+func TestGetPMCID(t *testing.T) {
+	ws := newTestSource(t, Config{})
+	rec, err := ws.Get(context.Background(), "pmc9876543")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.SourceID != "36912345" {
+		t.Errorf("source id = %q", rec.SourceID)
+	}
+
+	if _, err := ws.Get(context.Background(), "PMC1"); err == nil {
+		t.Error("expected an error for an unknown PMCID")
+	}
+	if _, err := ws.Get(context.Background(), "10.1000/xyz"); err == nil {
+		t.Error("expected an error for an invalid id")
+	}
+}
+
+func TestMap(t *testing.T) {
+	ws := newTestSource(t, Config{})
+	rec, err := ws.Get(context.Background(), "36912345")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mapped, err := ws.Map(rec.SourceRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(mapped, rec) {
+		t.Errorf("Map(SourceRecord) = %+v, want %+v", mapped, rec)
+	}
+}
+
+func TestIter(t *testing.T) {
+	ws := newTestSource(t, Config{Email: "test@example.org", Affiliation: "Ghent University", MinDate: "2022"})
+	seq, err := ws.Iter(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var recs []*bbl.ImportWorkInput
+	for rec, err := range seq {
+		if err != nil {
+			t.Fatal(err)
+		}
+		recs = append(recs, rec)
+	}
+	if len(recs) != 1 {
+		t.Fatalf("got %d records, want 1", len(recs))
+	}
+
+	r := recs[0]
+	wantTitles := []bbl.Title{{Lang: "eng", Val: "Drought and health"}, {Lang: "dut", Val: "Droogte en gezondheid"}}
+	if !slices.Equal(r.Titles, wantTitles) {
+		t.Errorf("titles = %v", r.Titles)
+	}
+	wantAbstracts := []bbl.Text{{Lang: "eng", Val: "An unstructured abstract."}, {Lang: "dut", Val: "Een samenvatting."}}
+	if !slices.Equal(r.Abstracts, wantAbstracts) {
+		t.Errorf("abstracts = %v", r.Abstracts)
+	}
+	if r.PublicationYear != "2022" || r.Pages != (bbl.Extent{Start: "5", End: "9"}) {
+		t.Errorf("year, pages = %q, %+v", r.PublicationYear, r.Pages)
+	}
+	if r.Kind != "preprint" {
+		t.Errorf("kind = %q, want preprint", r.Kind)
+	}
+}
+
+func TestIterRequiresAffiliation(t *testing.T) {
+	ws, err := New(Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ws.Iter(context.Background()); err == nil {
+		t.Error("expected an error without affiliation")
+	}
+}
+
+func TestErrorsRedactAPIKey(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(srv.Close)
+	ws, err := New(Config{URL: srv.URL, APIKey: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ws.Get(context.Background(), "36912345")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if strings.Contains(err.Error(), "secret") {
+		t.Errorf("error leaks the API key: %v", err)
+	}
+
+	srv.Close()
+	_, err = ws.Get(context.Background(), "36912345")
+	if err == nil || strings.Contains(err.Error(), "secret") {
+		t.Errorf("error leaks the API key: %v", err)
+	}
+}
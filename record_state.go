@@ -6,8 +6,17 @@ type recordState struct {
 	recordType string
 	id         ID
 	version    int
-	status     string
-	kind       string                   // empty for person, project
-	fields     map[string]any           // decoded pinned field values
-	assertions map[string][]assertion   // all assertion rows per field
+	status       string
+	reviewStatus string                   // works only; empty = not in review
+	kind         string                   // empty for person, project
+	fields       map[string]any           // decoded pinned field values
+	kindHidden   map[string]any           // works only; values of fields hidden by a kind change
+	fetched      map[string]bool          // fields whose assertions were fetched
+	assertions   map[string][]assertion   // all assertion rows per field
+	locks        map[string]bool          // curator-locked fields; "" = whole record (works only)
+}
+
+// locked reports whether field is covered by a curator lock.
+func (rs *recordState) locked(field string) bool {
+	return rs.locks[""] || rs.locks[field]
 }
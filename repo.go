@@ -2,6 +2,8 @@ package bbl
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -26,3 +28,13 @@ func NewRepo(ctx context.Context, connString string, tokenKey []byte) (*Repo, er
 func (r *Repo) Close() {
 	r.db.Close()
 }
+
+// now returns the database's current time, for comparing with timestamps the
+// database sets.
+func (r *Repo) now(ctx context.Context) (time.Time, error) {
+	var t time.Time
+	if err := r.db.QueryRow(ctx, `SELECT now()`).Scan(&t); err != nil {
+		return time.Time{}, fmt.Errorf("now: %w", err)
+	}
+	return t, nil
+}
@@ -0,0 +1,70 @@
+package bbl
+
+import (
+	"context"
+	"testing"
+)
+
+func TestUpdateReviewWorkflow(t *testing.T) {
+	repo := testRepo(t)
+	ctx := context.Background()
+	user := createTestUser(t, repo, RoleUser)
+	curator := createTestUser(t, repo, RoleCurator)
+
+	workID := newID()
+	if _, _, err := repo.Update(ctx, user,
+		&CreateWork{ID: workID, Kind: "journal_article"},
+		&Set{RecordType: RecordTypeWork, RecordID: workID, Field: "titles", Val: []Title{{Lang: "eng", Val: "Reviewed"}}},
+	); err != nil {
+		t.Fatalf("create work: %v", err)
+	}
+
+	for _, step := range []struct {
+		user   *User
+		update any
+	}{
+		{user, &SubmitWork{WorkID: workID}},
+		{curator, &PickUpWork{WorkID: workID}},
+		{curator, &ReturnWork{WorkID: workID, Message: "add a volume"}},
+		{user, &SubmitWork{WorkID: workID}},
+		{curator, &ApproveWork{WorkID: workID}},
+	} {
+		if _, _, err := repo.Update(ctx, step.user, step.update); err != nil {
+			t.Fatalf("%T: %v", step.update, err)
+		}
+	}
+
+	work, err := repo.GetWork(ctx, workID)
+	if err != nil {
+		t.Fatalf("get work: %v", err)
+	}
+	if work.Status != WorkStatusPublic {
+		t.Errorf("status = %q, want %q", work.Status, WorkStatusPublic)
+	}
+	if work.ReviewStatus != "" {
+		t.Errorf("review status = %q, want empty", work.ReviewStatus)
+	}
+
+	reviews, err := repo.GetWorkReviews(ctx, workID)
+	if err != nil {
+		t.Fatalf("get reviews: %v", err)
+	}
+	wantKinds := []string{
+		WorkReviewKindSubmitted, WorkReviewKindPickedUp, WorkReviewKindReturned,
+		WorkReviewKindSubmitted, WorkReviewKindApproved,
+	}
+	if len(reviews) != len(wantKinds) {
+		t.Fatalf("reviews count = %d, want %d", len(reviews), len(wantKinds))
+	}
+	for i, rv := range reviews {
+		if rv.Kind != wantKinds[i] {
+			t.Errorf("review %d kind = %q, want %q", i, rv.Kind, wantKinds[i])
+		}
+		if rv.Seq != i+1 {
+			t.Errorf("review %d seq = %d, want %d", i, rv.Seq, i+1)
+		}
+	}
+	if reviews[2].Body != "add a volume" {
+		t.Errorf("return message = %q, want %q", reviews[2].Body, "add a volume")
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"maps"
+	"slices"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
@@ -61,6 +63,7 @@ func (r *Repo) Update(ctx context.Context, user *User, updates ...any) (bool, []
 	if err != nil {
 		return false, nil, fmt.Errorf("Update: %w", err)
 	}
+	state.profiles = r.Profiles
 
 	// 3. Apply all updaters.
 	effects := make([]*updateEffect, len(muts))
@@ -102,6 +105,13 @@ func (r *Repo) Update(ctx context.Context, user *User, updates ...any) (bool, []
 			if defs == nil {
 				continue
 			}
+			// Existing records only have the fields touched by this batch
+			// in rs.fields; fetch the others. New records are complete.
+			if rs.version > 0 {
+				if err := fetchUntouchedFields(ctx, tx, state, rs, defs); err != nil {
+					return false, nil, fmt.Errorf("Update: %w", err)
+				}
+			}
 
 			if errs := validateRecord(rs.status, rs.fields, defs); errs != nil {
 				return false, nil, errs.ToError()
@@ -112,8 +122,8 @@ func (r *Repo) Update(ctx context.Context, user *User, updates ...any) (bool, []
 	// 6. Insert bbl_revs row.
 	var revID int64
 	if err := tx.QueryRow(ctx, `
-		INSERT INTO bbl_revs (user_id) VALUES ($1) RETURNING id`,
-		&user.ID).Scan(&revID); err != nil {
+		INSERT INTO bbl_revs (user_id, impersonator_id) VALUES ($1, $2) RETURNING id`,
+		&user.ID, user.ImpersonatorID).Scan(&revID); err != nil {
 		return false, nil, fmt.Errorf("Update: %w", err)
 	}
 
@@ -128,6 +138,15 @@ func (r *Repo) Update(ctx context.Context, user *User, updates ...any) (bool, []
 	for _, eff := range effects {
 		if eff != nil {
 			affected[eff.recordID] = eff.recordType
+			for _, id := range eff.related {
+				affected[id] = RecordTypeWork
+			}
+			for _, id := range eff.relatedPeople {
+				affected[id] = RecordTypePerson
+			}
+			for _, id := range eff.relatedProjects {
+				affected[id] = RecordTypeProject
+			}
 		}
 	}
 
@@ -141,7 +160,7 @@ func (r *Repo) Update(ctx context.Context, user *User, updates ...any) (bool, []
 				continue
 			}
 			switch muts[i].(type) {
-			case *Set, *Hide, *Unset:
+			case *Set, *Hide, *Unset, *AttachWorkFile, *DetachWorkFile, *LiftWorkFileEmbargoes:
 				continue
 			}
 			if sql, args := muts[i].write(revID, user); sql != "" {
@@ -211,6 +230,15 @@ func (r *Repo) Update(ctx context.Context, user *User, updates ...any) (bool, []
 				return false, nil, fmt.Errorf("Update: close write batch: %w", err)
 			}
 		}
+
+		// Whole-record auto-pin, after assertions were moved between records.
+		for _, eff := range effects {
+			if eff != nil && eff.autoPinAll {
+				if err := autoPinRecord(ctx, tx, eff.recordType, eff.recordID, state.priorities); err != nil {
+					return false, nil, fmt.Errorf("Update: %w", err)
+				}
+			}
+		}
 	}
 
 	// 9. Rebuild caches.
@@ -248,6 +276,46 @@ func (r *Repo) Update(ctx context.Context, user *User, updates ...any) (bool, []
 	return true, revEffects, nil
 }
 
+// fetchUntouchedFields adds the pinned values of the fields in defs that
+// the updates didn't fetch to rs.fields, so that the record is validated as
+// a whole. Values the updates set are kept.
+func fetchUntouchedFields(ctx context.Context, tx pgx.Tx, state updateState, rs *recordState, defs []FieldDef) error {
+	var fields []string
+	for _, def := range defs {
+		if !rs.fetched[def.Name] {
+			fields = append(fields, def.Name)
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	// Fetch into a scratch state, so that the assertions the updates
+	// worked with are left alone.
+	scratch := &recordState{
+		recordType: rs.recordType,
+		id:         rs.id,
+		fields:     make(map[string]any),
+		assertions: make(map[string][]assertion),
+	}
+	tmp := updateState{records: map[ID]*recordState{rs.id: scratch}, priorities: state.priorities}
+	if err := fetchFieldState(ctx, tx, tmp, map[recordKey][]string{{rs.recordType, rs.id}: fields}); err != nil {
+		return err
+	}
+	if rs.fetched == nil {
+		rs.fetched = make(map[string]bool)
+	}
+	for _, f := range fields {
+		rs.fetched[f] = true
+		if _, ok := rs.fields[f]; ok {
+			continue
+		}
+		if val, ok := scratch.fields[f]; ok {
+			rs.fields[f] = val
+		}
+	}
+	return nil
+}
+
 // queueAutoPinForField queues a pin UPDATE for a single field after a human edit.
 // Uses a SQL-only approach: the UPDATE itself determines the winner, so it
 // correctly reflects the post-write state (new human rows, deleted old ones).
@@ -313,6 +381,17 @@ func fetchState(ctx context.Context, tx pgx.Tx, needs updateNeeds, muts []update
 		records: make(map[ID]*recordState),
 	}
 
+	// Merges also change the records that link to a merged record; lock those too.
+	if err := fetchMergeRelated(ctx, tx, &needs, muts); err != nil {
+		return state, err
+	}
+	if err := fetchPersonMergeRelated(ctx, tx, &needs, muts); err != nil {
+		return state, err
+	}
+	if err := fetchAttachFiles(ctx, tx, muts); err != nil {
+		return state, err
+	}
+
 	// Phase 1: lock rows + fetch source priorities (single batch).
 	type lockQuery struct {
 		rt  string
@@ -323,25 +402,25 @@ func fetchState(ctx context.Context, tx pgx.Tx, needs updateNeeds, muts []update
 	if len(needs.workIDs) > 0 {
 		lockQueries = append(lockQueries, lockQuery{
 			rt: RecordTypeWork, ids: dedupIDs(needs.workIDs),
-			sql: `SELECT id, version, kind, status FROM bbl_works WHERE id = ANY($1) FOR UPDATE`,
+			sql: `SELECT id, version, kind, status, coalesce(review_status, '') FROM bbl_works WHERE id = ANY($1) FOR UPDATE`,
 		})
 	}
 	if len(needs.personIDs) > 0 {
 		lockQueries = append(lockQueries, lockQuery{
 			rt: RecordTypePerson, ids: dedupIDs(needs.personIDs),
-			sql: `SELECT id, version, '', status FROM bbl_people WHERE id = ANY($1) FOR UPDATE`,
+			sql: `SELECT id, version, '', status, '' FROM bbl_people WHERE id = ANY($1) FOR UPDATE`,
 		})
 	}
 	if len(needs.projectIDs) > 0 {
 		lockQueries = append(lockQueries, lockQuery{
 			rt: RecordTypeProject, ids: dedupIDs(needs.projectIDs),
-			sql: `SELECT id, version, '', status FROM bbl_projects WHERE id = ANY($1) FOR UPDATE`,
+			sql: `SELECT id, version, '', status, '' FROM bbl_projects WHERE id = ANY($1) FOR UPDATE`,
 		})
 	}
 	if len(needs.organizationIDs) > 0 {
 		lockQueries = append(lockQueries, lockQuery{
 			rt: RecordTypeOrganization, ids: dedupIDs(needs.organizationIDs),
-			sql: `SELECT id, version, kind, status FROM bbl_organizations WHERE id = ANY($1) FOR UPDATE`,
+			sql: `SELECT id, version, kind, status, '' FROM bbl_organizations WHERE id = ANY($1) FOR UPDATE`,
 		})
 	}
 
@@ -352,6 +431,10 @@ func fetchState(ctx context.Context, tx pgx.Tx, needs updateNeeds, muts []update
 		}
 		// Source priorities piggyback on the lock batch.
 		batch.Queue(`SELECT id, priority FROM bbl_sources`)
+		// So do curator work locks.
+		if len(needs.workIDs) > 0 {
+			batch.Queue(`SELECT work_id, field FROM bbl_work_locks WHERE work_id = ANY($1)`, dedupIDs(needs.workIDs))
+		}
 
 		results := tx.SendBatch(ctx, batch)
 		for _, lq := range lockQueries {
@@ -363,20 +446,21 @@ func fetchState(ctx context.Context, tx pgx.Tx, needs updateNeeds, muts []update
 			for rows.Next() {
 				var id ID
 				var version int
-				var kind, status string
-				if err := rows.Scan(&id, &version, &kind, &status); err != nil {
+				var kind, status, reviewStatus string
+				if err := rows.Scan(&id, &version, &kind, &status, &reviewStatus); err != nil {
 					rows.Close()
 					results.Close()
 					return state, fmt.Errorf("fetchState: scan %s: %w", lq.rt, err)
 				}
 				state.records[id] = &recordState{
-					recordType: lq.rt,
-					id:         id,
-					version:    version,
-					status:     status,
-					kind:       kind,
-					fields:     make(map[string]any),
-					assertions: make(map[string][]assertion),
+					recordType:   lq.rt,
+					id:           id,
+					version:      version,
+					status:       status,
+					reviewStatus: reviewStatus,
+					kind:         kind,
+					fields:       make(map[string]any),
+					assertions:   make(map[string][]assertion),
 				}
 			}
 			rows.Close()
@@ -399,40 +483,93 @@ func fetchState(ctx context.Context, tx pgx.Tx, needs updateNeeds, muts []update
 			state.priorities[id] = p
 		}
 		pRows.Close()
+		// Consume work locks.
+		if len(needs.workIDs) > 0 {
+			lRows, err := results.Query()
+			if err != nil {
+				results.Close()
+				return state, fmt.Errorf("fetchState: work locks: %w", err)
+			}
+			for lRows.Next() {
+				var id ID
+				var field string
+				if err := lRows.Scan(&id, &field); err != nil {
+					lRows.Close()
+					results.Close()
+					return state, fmt.Errorf("fetchState: scan work lock: %w", err)
+				}
+				if rs := state.records[id]; rs != nil {
+					if rs.locks == nil {
+						rs.locks = make(map[string]bool)
+					}
+					rs.locks[field] = true
+				}
+			}
+			lRows.Close()
+		}
 		if err := results.Close(); err != nil {
 			return state, fmt.Errorf("fetchState: close lock: %w", err)
 		}
 	}
 
 	// Phase 2: fetch all assertion rows for fields being updated.
-	// Fetches ALL assertions (not just pinned) so auto-pin can be computed
-	// without re-querying. Pinned values are decoded into rs.fields.
-	type entityKey struct {
-		rt string
-		id ID
-	}
-	grouped := make(map[entityKey][]string)
+	grouped := make(map[recordKey][]string)
 	for _, m := range muts {
 		switch u := m.(type) {
 		case *Set:
-			ek := entityKey{u.RecordType, u.RecordID}
-			grouped[ek] = append(grouped[ek], u.Field)
+			rk := recordKey{u.RecordType, u.RecordID}
+			grouped[rk] = append(grouped[rk], u.Field)
 		case *Hide:
-			ek := entityKey{u.RecordType, u.RecordID}
-			grouped[ek] = append(grouped[ek], u.Field)
+			rk := recordKey{u.RecordType, u.RecordID}
+			grouped[rk] = append(grouped[rk], u.Field)
 		case *Unset:
-			ek := entityKey{u.RecordType, u.RecordID}
-			grouped[ek] = append(grouped[ek], u.Field)
+			rk := recordKey{u.RecordType, u.RecordID}
+			grouped[rk] = append(grouped[rk], u.Field)
+		case *ApproveWork:
+			// Approval validates the whole record.
+			rk := recordKey{RecordTypeWork, u.WorkID}
+			grouped[rk] = append(grouped[rk], slices.Collect(maps.Keys(workFieldTypes))...)
+		case *AttachWorkFile:
+			rk := recordKey{RecordTypeWork, u.WorkID}
+			grouped[rk] = append(grouped[rk], "files")
+		case *DetachWorkFile:
+			rk := recordKey{RecordTypeWork, u.WorkID}
+			grouped[rk] = append(grouped[rk], "files")
+		case *LiftWorkFileEmbargoes:
+			rk := recordKey{RecordTypeWork, u.WorkID}
+			grouped[rk] = append(grouped[rk], "files")
+		case *ChangeWorkKind:
+			// A kind change drops fields and revalidates the whole record.
+			rk := recordKey{RecordTypeWork, u.WorkID}
+			grouped[rk] = append(grouped[rk], slices.Collect(maps.Keys(workFieldTypes))...)
 		}
 	}
 
+	if err := fetchFieldState(ctx, tx, state, grouped); err != nil {
+		return state, err
+	}
+
+	return state, nil
+}
+
+// recordKey identifies a single record across record types.
+type recordKey struct {
+	rt string
+	id ID
+}
+
+// fetchFieldState fetches all assertion rows for the given fields of each record
+// into state. Fetches ALL assertions (not just pinned) so auto-pin can be computed
+// without re-querying. Pinned values are decoded into rs.fields.
+// Records missing from state.records are skipped.
+func fetchFieldState(ctx context.Context, tx pgx.Tx, state updateState, grouped map[recordKey][]string) error {
 	if len(grouped) > 0 {
 		type rrInfo struct {
 			rr     *relation
 			offset int // start index in the extra scan slice
 		}
 		type queryInfo struct {
-			ek      entityKey
+			rk      recordKey
 			fields  []string
 			joins   string
 			sel     string             // full SELECT clause
@@ -443,23 +580,23 @@ func fetchState(ctx context.Context, tx pgx.Tx, needs updateNeeds, muts []update
 		batch := &pgx.Batch{}
 		var queries []queryInfo
 
-		for ek, fields := range grouped {
+		for rk, fields := range grouped {
 			qi := queryInfo{
-				ek:     ek,
+				rk:     rk,
 				fields: dedupStrings(fields),
 				rrByFT: make(map[string]*rrInfo),
 			}
 
 			// Source table join (for auto-pin source resolution).
 			qi.joins = fmt.Sprintf(" LEFT JOIN %s _st ON a.%s = _st.id",
-				sourceTable(ek.rt), sourceIDCol(ek.rt))
+				sourceTable(rk.rt), sourceIDCol(rk.rt))
 
 			// Collect unique relations for the requested fields.
 			extraOffset := 0
 			seen := make(map[*relation]*rrInfo)
 			var extraCols []string
 			for _, f := range qi.fields {
-				ft, err := resolveFieldType(ek.rt, f)
+				ft, err := resolveFieldType(rk.rt, f)
 				if err != nil || ft.relation == nil {
 					continue
 				}
@@ -476,15 +613,19 @@ func fetchState(ctx context.Context, tx pgx.Tx, needs updateNeeds, muts []update
 				qi.rrByFT[f] = ri
 			}
 
-			qi.sel = fmt.Sprintf("a.id, a.field, a.val, a.hidden, a.user_id, a.role, a.pinned, a.%s, _st.source",
-				sourceIDCol(ek.rt))
+			kindHiddenCol := "false"
+			if rk.rt == RecordTypeWork {
+				kindHiddenCol = "a.kind_hidden"
+			}
+			qi.sel = fmt.Sprintf("a.id, a.field, a.val, a.hidden, %s, a.user_id, a.role, a.pinned, a.%s, _st.source",
+				kindHiddenCol, sourceIDCol(rk.rt))
 			for _, c := range extraCols {
 				qi.sel += ", " + c
 			}
 			batch.Queue(fmt.Sprintf(
 				`SELECT %s FROM %s a%s WHERE a.%s = $1 AND a.field = ANY($2) ORDER BY a.id`,
-				qi.sel, assertionsTable(ek.rt), qi.joins, entityIDCol(ek.rt)),
-				ek.id, qi.fields)
+				qi.sel, assertionsTable(rk.rt), qi.joins, entityIDCol(rk.rt)),
+				rk.id, qi.fields)
 			queries = append(queries, qi)
 		}
 
@@ -493,15 +634,16 @@ func fetchState(ctx context.Context, tx pgx.Tx, needs updateNeeds, muts []update
 			rows, err := results.Query()
 			if err != nil {
 				results.Close()
-				return state, fmt.Errorf("fetchState: assertions: %w", err)
+				return fmt.Errorf("fetchFieldState: assertions: %w", err)
 			}
 
-			rs := state.records[qi.ek.id]
+			rs := state.records[qi.rk.id]
 
 			type rawAssertion struct {
 				id             int64
 				val            json.RawMessage
 				hidden         bool
+				kindHidden     bool
 				userID         *ID
 				role           string
 				pinned         bool
@@ -519,7 +661,7 @@ func fetchState(ctx context.Context, tx pgx.Tx, needs updateNeeds, muts []update
 				var rl, sourceName pgtype.Text
 				var pinned, hidden bool
 
-				baseDests := []any{&ra.id, &field, &valJSON, &hidden, &uid, &rl, &pinned, &srcRecID, &sourceName}
+				baseDests := []any{&ra.id, &field, &valJSON, &hidden, &ra.kindHidden, &uid, &rl, &pinned, &srcRecID, &sourceName}
 
 				// Fresh scan destinations for extension columns, in JOIN order.
 				var extraDests []any
@@ -530,7 +672,7 @@ func fetchState(ctx context.Context, tx pgx.Tx, needs updateNeeds, muts []update
 				if err := rows.Scan(append(baseDests, extraDests...)...); err != nil {
 					rows.Close()
 					results.Close()
-					return state, fmt.Errorf("fetchState: scan assertion: %w", err)
+					return fmt.Errorf("fetchFieldState: scan assertion: %w", err)
 				}
 
 				ra.val = valJSON
@@ -558,6 +700,12 @@ func fetchState(ctx context.Context, tx pgx.Tx, needs updateNeeds, muts []update
 			if rs == nil {
 				continue
 			}
+			if rs.fetched == nil {
+				rs.fetched = make(map[string]bool)
+			}
+			for _, f := range qi.fields {
+				rs.fetched[f] = true
+			}
 
 			for field, raws := range fieldRows {
 				// Build assertion slice (all rows).
@@ -571,6 +719,7 @@ func fetchState(ctx context.Context, tx pgx.Tx, needs updateNeeds, muts []update
 						source:         r.source,
 						pinned:         r.pinned,
 						hidden:         r.hidden,
+						kindHidden:     r.kindHidden,
 					})
 				}
 				rs.assertions[field] = fieldAssertions
@@ -587,11 +736,40 @@ func fetchState(ctx context.Context, tx pgx.Tx, needs updateNeeds, muts []update
 				}
 
 				first := pinnedRaws[0]
+				target := rs.fields
 				if first.hidden {
-					continue
+					if !first.kindHidden {
+						continue
+					}
+					// Hidden by a kind change: decode the value that
+					// changing back restores. A placeholder without a
+					// value hides source assertions, which are re-pinned.
+					if first.val == nil {
+						var srcRaws []rawAssertion
+						var srcAssertions []assertion
+						for i, r := range raws {
+							if r.userID == nil {
+								srcRaws = append(srcRaws, r)
+								srcAssertions = append(srcAssertions, fieldAssertions[i])
+							}
+						}
+						pinnedRaws = nil
+						for i, pin := range resolveExclusivePin(srcAssertions, state.priorities) {
+							if pin {
+								pinnedRaws = append(pinnedRaws, srcRaws[i])
+							}
+						}
+						if len(pinnedRaws) == 0 {
+							continue
+						}
+					}
+					if rs.kindHidden == nil {
+						rs.kindHidden = make(map[string]any)
+					}
+					target = rs.kindHidden
 				}
 
-				ft, err := resolveFieldType(qi.ek.rt, field)
+				ft, err := resolveFieldType(qi.rk.rt, field)
 				if err != nil {
 					continue
 				}
@@ -610,7 +788,7 @@ func fetchState(ctx context.Context, tx pgx.Tx, needs updateNeeds, muts []update
 					if err == nil {
 						val, err := ft.unmarshal(json.RawMessage(arrJSON))
 						if err == nil {
-							rs.fields[field] = val
+							target[field] = val
 						}
 					}
 				} else {
@@ -620,17 +798,17 @@ func fetchState(ctx context.Context, tx pgx.Tx, needs updateNeeds, muts []update
 					}
 					val, err := ft.unmarshal(v)
 					if err == nil {
-						rs.fields[field] = val
+						target[field] = val
 					}
 				}
 			}
 		}
 		if err := results.Close(); err != nil {
-			return state, fmt.Errorf("fetchState: close assertions: %w", err)
+			return fmt.Errorf("fetchFieldState: close assertions: %w", err)
 		}
 	}
 
-	return state, nil
+	return nil
 }
 
 // nilIfEmpty returns nil for empty strings (for nullable text columns).
@@ -15,6 +15,8 @@ type Services struct {
 	UserSources     map[string]UserSource
 	WorkIterSources map[string]WorkSourceIter
 	WorkGetSources  map[string]WorkSourceGetter
+	Files           FileStore // nil = no file uploads
+	Notifier        Notifier  // nil = no notifications
 }
 
 // UpdateAndIndex writes a revision to the DB and best-effort indexes affected records.
@@ -24,9 +26,38 @@ func (s *Services) UpdateAndIndex(ctx context.Context, user *User, updates ...an
 		return ok, err
 	}
 	s.indexEffects(ctx, effects)
+	s.detectDuplicates(ctx, effects)
 	return true, nil
 }
 
+// detectDuplicates best-effort refreshes duplicate candidates for the works
+// and people a revision touched.
+func (s *Services) detectDuplicates(ctx context.Context, effects []RevEffect) {
+	ids := make(map[string][]ID)
+	for _, e := range effects {
+		if e.RecordType == RecordTypeWork || e.RecordType == RecordTypePerson {
+			ids[e.RecordType] = append(ids[e.RecordType], e.RecordID)
+		}
+	}
+	for rt, ids := range ids {
+		if err := s.Repo.DetectDuplicates(ctx, rt, ids); err != nil {
+			slog.Error("detectDuplicates", "record_type", rt, "err", err)
+		}
+	}
+}
+
+// detectDuplicatesSince best-effort refreshes duplicate candidates for
+// records changed by an import.
+func (s *Services) detectDuplicatesSince(ctx context.Context, recordType string, since time.Time) {
+	ids, err := s.Repo.duplicateIDsSince(ctx, recordType, since)
+	if err == nil {
+		err = s.Repo.DetectDuplicates(ctx, recordType, ids)
+	}
+	if err != nil {
+		slog.Error("detectDuplicatesSince", "record_type", recordType, "err", err)
+	}
+}
+
 func (s *Services) indexEffects(ctx context.Context, effects []RevEffect) {
 	if s.Index == nil {
 		return
@@ -65,15 +96,75 @@ func (s *Services) indexEffects(ctx context.Context, effects []RevEffect) {
 			}
 		}
 	}
-	// TODO: add batch-read + index for people, projects, organizations
-	// when GetPeople/GetProjects/GetOrganizations are implemented.
+	// People and organizations have no status in the index; deleted ones
+	// are removed from it.
+	if len(personIDs) > 0 {
+		people, err := s.Repo.GetPeople(ctx, personIDs)
+		if err != nil {
+			slog.Error("indexEffects", "record_type", "person", "err", err)
+		} else {
+			for _, p := range people {
+				if p.Version > versions[p.ID] {
+					continue
+				}
+				if p.Status == PersonStatusDeleted {
+					err = s.Index.People().Delete(ctx, p.ID)
+				} else {
+					err = s.Index.People().Add(ctx, p)
+				}
+				if err != nil {
+					slog.Error("indexEffects", "record_type", "person", "err", err)
+				}
+			}
+		}
+	}
+	if len(projectIDs) > 0 {
+		projects, err := s.Repo.GetProjects(ctx, projectIDs)
+		if err != nil {
+			slog.Error("indexEffects", "record_type", "project", "err", err)
+		} else {
+			for _, p := range projects {
+				if p.Version > versions[p.ID] {
+					continue
+				}
+				if err := s.Index.Projects().Add(ctx, p); err != nil {
+					slog.Error("indexEffects", "record_type", "project", "err", err)
+				}
+			}
+		}
+	}
+	if len(orgIDs) > 0 {
+		orgs, err := s.Repo.GetOrganizations(ctx, orgIDs)
+		if err != nil {
+			slog.Error("indexEffects", "record_type", "organization", "err", err)
+		} else {
+			for _, o := range orgs {
+				if o.Version > versions[o.ID] {
+					continue
+				}
+				if o.Status == OrganizationStatusDeleted {
+					err = s.Index.Organizations().Delete(ctx, o.ID)
+				} else {
+					err = s.Index.Organizations().Add(ctx, o)
+				}
+				if err != nil {
+					slog.Error("indexEffects", "record_type", "organization", "err", err)
+				}
+			}
+		}
+	}
 }
 
 // ImportWorksAndIndex imports works and best-effort indexes changed records.
 // Uses a timestamp taken before the import to re-fetch changed works from the DB,
 // because the in-memory Work during import doesn't have the cache populated.
+// The timestamp comes from the database clock, like the updated_at it is
+// compared with.
 func (s *Services) ImportWorksAndIndex(ctx context.Context, source string, seq iter.Seq2[*ImportWorkInput, error]) (int, error) {
-	before := time.Now()
+	before, err := s.Repo.now(ctx)
+	if err != nil {
+		return 0, err
+	}
 	n, err := s.Repo.ImportWorks(ctx, source, seq)
 	if err != nil || n == 0 {
 		return n, err
@@ -83,12 +174,16 @@ func (s *Services) ImportWorksAndIndex(ctx context.Context, source string, seq i
 	}, func(ctx context.Context, w *Work) error {
 		return s.Index.Works().Add(ctx, w)
 	})
+	s.detectDuplicatesSince(ctx, RecordTypeWork, before)
 	return n, nil
 }
 
 // ImportPeopleAndIndex imports people and best-effort indexes changed records.
 func (s *Services) ImportPeopleAndIndex(ctx context.Context, source, authProvider string, seq iter.Seq2[*ImportPersonInput, error]) (int, error) {
-	before := time.Now()
+	before, err := s.Repo.now(ctx)
+	if err != nil {
+		return 0, err
+	}
 	n, err := s.Repo.ImportPeople(ctx, source, seq)
 	if err != nil || n == 0 {
 		return n, err
@@ -98,12 +193,16 @@ func (s *Services) ImportPeopleAndIndex(ctx context.Context, source, authProvide
 	}, func(ctx context.Context, p *Person) error {
 		return s.Index.People().Add(ctx, p)
 	})
+	s.detectDuplicatesSince(ctx, RecordTypePerson, before)
 	return n, nil
 }
 
 // ImportProjectsAndIndex imports projects and best-effort indexes changed records.
 func (s *Services) ImportProjectsAndIndex(ctx context.Context, source string, seq iter.Seq2[*ImportProjectInput, error]) (int, error) {
-	before := time.Now()
+	before, err := s.Repo.now(ctx)
+	if err != nil {
+		return 0, err
+	}
 	n, err := s.Repo.ImportProjects(ctx, source, seq)
 	if err != nil || n == 0 {
 		return n, err
@@ -118,7 +217,10 @@ func (s *Services) ImportProjectsAndIndex(ctx context.Context, source string, se
 
 // ImportOrganizationsAndIndex imports organizations and best-effort indexes changed records.
 func (s *Services) ImportOrganizationsAndIndex(ctx context.Context, source string, seq iter.Seq2[*ImportOrganizationInput, error]) (int, error) {
-	before := time.Now()
+	before, err := s.Repo.now(ctx)
+	if err != nil {
+		return 0, err
+	}
 	n, err := s.Repo.ImportOrganizations(ctx, source, seq)
 	if err != nil || n == 0 {
 		return n, err
@@ -177,6 +279,8 @@ func (s *Services) SearchAllWorkRecords(ctx context.Context, opts *SearchOpts) i
 		o := &SearchOpts{
 			Query:  opts.Query,
 			Filter: opts.Filter,
+			Within: opts.Within,
+			IDs:    opts.IDs,
 			Size:   searchAllSize,
 		}
 		for {
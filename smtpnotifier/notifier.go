@@ -0,0 +1,71 @@
+package smtpnotifier
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"time"
+
+	"github.com/ugent-library/bbl"
+)
+
+// Config holds the SMTP server and sender address.
+type Config struct {
+	Addr     string `yaml:"addr"` // host:port
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	From     string `yaml:"from"` // e.g. "Biblio <biblio@ugent.be>"
+}
+
+// Notifier mails notifications as plain text.
+type Notifier struct {
+	addr string
+	auth smtp.Auth
+	from *mail.Address
+}
+
+// New returns a Notifier for the given Config. Credentials are optional.
+func New(c Config) (*Notifier, error) {
+	host, _, err := net.SplitHostPort(c.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("smtpnotifier: addr: %w", err)
+	}
+	from, err := mail.ParseAddress(c.From)
+	if err != nil {
+		return nil, fmt.Errorf("smtpnotifier: from: %w", err)
+	}
+	n := &Notifier{addr: c.Addr, from: from}
+	if c.Username != "" {
+		n.auth = smtp.PlainAuth("", c.Username, c.Password, host)
+	}
+	return n, nil
+}
+
+// Notify mails n to the user's address. Users without an address are
+// skipped.
+func (n *Notifier) Notify(ctx context.Context, notif *bbl.Notification) error {
+	if notif.User == nil || notif.User.Email == "" {
+		return nil
+	}
+	to := &mail.Address{Name: notif.User.Name, Address: notif.User.Email}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", n.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", notif.Subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	msg.WriteString(notif.Body)
+	msg.WriteString("\r\n")
+
+	if err := smtp.SendMail(n.addr, n.auth, n.from.Address, []string{to.Address}, msg.Bytes()); err != nil {
+		return fmt.Errorf("smtpnotifier: %w", err)
+	}
+	return nil
+}
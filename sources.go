@@ -2,7 +2,11 @@ package bbl
 
 import (
 	"context"
+	"errors"
 	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 // builtinSources are system-level sources that every deployment needs.
@@ -46,3 +50,33 @@ func (r *Repo) UpsertSource(ctx context.Context, id string) error {
 	}
 	return nil
 }
+
+// GetSourceCheckpoint returns the checkpoint stored by the last successful
+// incremental run of a source, empty if there is none.
+func (r *Repo) GetSourceCheckpoint(ctx context.Context, id string) (string, error) {
+	var checkpoint pgtype.Text
+	err := r.db.QueryRow(ctx, `
+		SELECT checkpoint FROM bbl_sources WHERE id = $1`,
+		id).Scan(&checkpoint)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("GetSourceCheckpoint: %w", err)
+	}
+	return checkpoint.String, nil
+}
+
+// SetSourceCheckpoint stores the checkpoint of a source. An empty
+// checkpoint clears it, so the next run is a full run.
+func (r *Repo) SetSourceCheckpoint(ctx context.Context, id, checkpoint string) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE bbl_sources
+		SET checkpoint = NULLIF($2, ''), checkpoint_at = transaction_timestamp()
+		WHERE id = $1`,
+		id, checkpoint)
+	if err != nil {
+		return fmt.Errorf("SetSourceCheckpoint: %w", err)
+	}
+	return nil
+}
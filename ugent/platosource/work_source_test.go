@@ -0,0 +1,86 @@
+package platosource
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ugent-library/bbl"
+)
+
+func TestIterSince(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"list": [
+			{"plato_id": "P1", "titel": {"eng": "A thesis"}, "modified": "2024-03-02T10:00:00Z"},
+			{"plato_id": "P2", "titel": {"eng": "An old thesis"}, "modified": "2024-02-01 10:00:00"},
+			{"plato_id": "P3", "titel": {"eng": "An undated thesis"}}
+		]}`)
+	}))
+	defer srv.Close()
+
+	collect := func(seq iter.Seq2[*bbl.ImportWorkInput, error]) []string {
+		t.Helper()
+		var ids []string
+		for rec, err := range seq {
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids = append(ids, rec.SourceID)
+		}
+		return ids
+	}
+
+	src, err := New(Config{URL: srv.URL, ModifiedField: "modified"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws := src.(*WorkSource)
+	before := time.Now().Add(-checkpointOverlap).UTC().Truncate(time.Second)
+	seq, next, err := ws.IterSince(context.Background(), "2024-03-01T00:00:00Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := collect(seq); !slices.Equal(ids, []string{"P1", "P3"}) {
+		t.Errorf("records = %v, want [P1 P3]", ids)
+	}
+	if ts, err := time.Parse(time.RFC3339, next); err != nil || ts.Before(before) || !ts.Before(time.Now()) {
+		t.Errorf("next checkpoint = %q", next)
+	}
+	if _, _, err := ws.IterSince(context.Background(), "yesterday"); err == nil {
+		t.Error("expected an error for an invalid checkpoint")
+	}
+
+	// Without a modification date field every run is a full run.
+	src, err = New(Config{URL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	seq, next, err = src.(*WorkSource).IterSince(context.Background(), "2024-03-01T00:00:00Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := collect(seq); len(ids) != 3 || next != "" {
+		t.Errorf("records = %v, next checkpoint = %q", ids, next)
+	}
+}
+
+func TestMap(t *testing.T) {
+	ws := &WorkSource{}
+	record := []byte(`{"plato_id": "P1", "titel": {"eng": "A thesis"}}`)
+	rec, err := ws.Map(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.SourceID != "P1" || rec.Titles[0].Val != "A thesis" || !reflect.DeepEqual(rec.SourceRecord, record) {
+		t.Errorf("record = %+v", rec)
+	}
+	if _, err := ws.Map([]byte("{")); err == nil {
+		t.Error("expected an error for invalid json")
+	}
+}
@@ -15,13 +15,32 @@ import (
 //	{"unset": "work:volume", "id": "01J..."}
 //	{"create": "work", "id": "01J...", "kind": "journal_article"}
 //	{"delete": "work", "id": "01J..."}
+//	{"lock": "work", "id": "01J...", "fields": ["title"]}
+//	{"unlock": "work", "id": "01J..."}
+//	{"submit": "work", "id": "01J..."}
+//	{"pick_up": "work", "id": "01J..."}
+//	{"return": "work", "id": "01J...", "message": "..."}
+//	{"approve": "work", "id": "01J..."}
+//	{"change_kind": "work", "id": "01J...", "kind": "book_chapter"}
+//	{"attach_file": "work", "id": "01J...", "file": {"file_id": "01J...", "name": "paper.pdf", "access_kind": "open"}}
+//	{"detach_file": "work", "id": "01J...", "file_id": "01J..."}
 func DecodeUpdate(data []byte) (any, error) {
 	var envelope struct {
-		Set    string `json:"set"`
-		Hide   string `json:"hide"`
-		Unset  string `json:"unset"`
-		Create string `json:"create"`
-		Delete string `json:"delete"`
+		Set        string `json:"set"`
+		Hide       string `json:"hide"`
+		Unset      string `json:"unset"`
+		Create     string `json:"create"`
+		Delete     string `json:"delete"`
+		Lock       string `json:"lock"`
+		Unlock     string `json:"unlock"`
+		Submit     string `json:"submit"`
+		PickUp     string `json:"pick_up"`
+		Return     string `json:"return"`
+		Approve    string `json:"approve"`
+		ChangeKind string `json:"change_kind"`
+		Merge      string `json:"merge"`
+		AttachFile string `json:"attach_file"`
+		DetachFile string `json:"detach_file"`
 	}
 	if err := json.Unmarshal(data, &envelope); err != nil {
 		return nil, fmt.Errorf("decode update: %w", err)
@@ -39,20 +58,40 @@ func DecodeUpdate(data []byte) (any, error) {
 		op, target = "create", envelope.Create
 	case envelope.Delete != "":
 		op, target = "delete", envelope.Delete
+	case envelope.Lock != "":
+		op, target = "lock", envelope.Lock
+	case envelope.Unlock != "":
+		op, target = "unlock", envelope.Unlock
+	case envelope.Submit != "":
+		op, target = "submit", envelope.Submit
+	case envelope.PickUp != "":
+		op, target = "pick_up", envelope.PickUp
+	case envelope.Return != "":
+		op, target = "return", envelope.Return
+	case envelope.Approve != "":
+		op, target = "approve", envelope.Approve
+	case envelope.ChangeKind != "":
+		op, target = "change_kind", envelope.ChangeKind
+	case envelope.Merge != "":
+		op, target = "merge", envelope.Merge
+	case envelope.AttachFile != "":
+		op, target = "attach_file", envelope.AttachFile
+	case envelope.DetachFile != "":
+		op, target = "detach_file", envelope.DetachFile
 	default:
-		return nil, fmt.Errorf("decode update: missing operation (set/hide/unset/create/delete)")
+		return nil, fmt.Errorf("decode update: missing operation (set/hide/unset/create/delete/lock/unlock/submit/pick_up/return/approve/change_kind/merge/attach_file/detach_file)")
 	}
 
-	// Lifecycle operations — typed structs.
-	if op == "create" || op == "delete" {
-		return decodeLifecycle(op, target, data)
+	// Field operations — generic Set/Hide/Unset via catalog.
+	if op == "set" || op == "hide" || op == "unset" {
+		return decodeFieldOp(op, target, data)
 	}
 
-	// Field operations — generic Set/Hide/Unset via catalog.
-	return decodeFieldOp(op, target, data)
+	// Lifecycle operations — typed structs.
+	return decodeLifecycle(op, target, data)
 }
 
-// decodeLifecycle handles create/delete with typed structs.
+// decodeLifecycle handles lifecycle, lock and review operations with typed structs.
 func decodeLifecycle(op, target string, data []byte) (any, error) {
 	var m any
 	switch op {
@@ -82,6 +121,40 @@ func decodeLifecycle(op, target string, data []byte) (any, error) {
 		default:
 			return nil, fmt.Errorf("unknown delete target %q", target)
 		}
+	case "merge":
+		switch target {
+		case "work":
+			m = &MergeWorks{}
+		case "person":
+			m = &MergePeople{}
+		default:
+			return nil, fmt.Errorf("unknown merge target %q", target)
+		}
+	default:
+		// Work-only operations.
+		if target != "work" {
+			return nil, fmt.Errorf("unknown %s target %q", op, target)
+		}
+		switch op {
+		case "lock":
+			m = &LockWork{}
+		case "unlock":
+			m = &UnlockWork{}
+		case "submit":
+			m = &SubmitWork{}
+		case "pick_up":
+			m = &PickUpWork{}
+		case "return":
+			m = &ReturnWork{}
+		case "approve":
+			m = &ApproveWork{}
+		case "change_kind":
+			m = &ChangeWorkKind{}
+		case "attach_file":
+			m = &AttachWorkFile{}
+		case "detach_file":
+			m = &DetachWorkFile{}
+		}
 	}
 	if err := json.Unmarshal(data, m); err != nil {
 		return nil, fmt.Errorf("decode %s:%s: %w", op, target, err)
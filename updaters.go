@@ -21,9 +21,13 @@ type updater interface {
 
 // updateEffect is what apply returns for non-noop updates.
 type updateEffect struct {
-	recordType   string
-	recordID     ID
-	autoPinField string // non-empty for field ops that need auto-pin
+	recordType      string
+	recordID        ID
+	autoPinField    string // non-empty for field ops that need auto-pin
+	autoPinAll      bool   // re-run auto-pin for every field of the record
+	related         []ID   // other works whose assertions or cache change
+	relatedPeople   []ID   // other people whose assertions or cache change
+	relatedProjects []ID   // projects whose cache changes
 }
 
 // updateNeeds declares what existing state must be pre-fetched.
@@ -38,4 +42,5 @@ type updateNeeds struct {
 type updateState struct {
 	records    map[ID]*recordState
 	priorities map[string]int // source name → priority
+	profiles   *Profiles      // nil = no profiles loaded
 }
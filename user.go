@@ -3,10 +3,26 @@ package bbl
 import "time"
 
 const (
-	RoleAdmin = "admin"
-	RoleUser  = "user"
+	RoleAdmin   = "admin"
+	RoleCurator = "curator"
+	RoleUser    = "user"
+	// RoleSystem is the role of the system user that background jobs write
+	// as. It passes curator locks without setting them.
+	RoleSystem = "system"
 )
 
+// passesCuratorLocks reports whether user may change fields that a curator
+// pinned or locked.
+func passesCuratorLocks(user *User) bool {
+	return user.Role == RoleCurator || user.Role == RoleSystem
+}
+
+// CanManageRecords reports whether user may create, edit and delete people,
+// projects and organizations.
+func CanManageRecords(user *User) bool {
+	return user != nil && (user.Role == RoleCurator || user.Role == RoleAdmin)
+}
+
 // AuthProvider is an entry in User.AuthProviders.
 // Stored as a jsonb array to allow additional fields (e.g. added_at) in future.
 type AuthProvider struct {
@@ -20,9 +36,18 @@ type User struct {
 	Email         string
 	Name          string
 	Role          string
-	DeactivateAt  *time.Time
+	DeactivateAt  *time.Time // deactivated from then on
+	DeactivatedAt *time.Time // last deactivation that took effect
 	PersonID      *ID
 	AuthProviders []AuthProvider
+	// ImpersonatorID is set while an admin acts as this user. Revisions
+	// record it next to the user.
+	ImpersonatorID *ID
+}
+
+// Deactivated reports whether the account of user is deactivated at t.
+func (u *User) Deactivated(t time.Time) bool {
+	return u.DeactivateAt != nil && !u.DeactivateAt.After(t)
 }
 
 type UserAttrs struct {
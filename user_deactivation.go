@@ -0,0 +1,164 @@
+package bbl
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// User event kinds of the deactivation lifecycle.
+const (
+	UserEventDeactivationScheduled = "deactivation_scheduled"
+	UserEventDeactivated           = "deactivated"
+	UserEventReactivated           = "reactivated"
+)
+
+// UserSweepResult reports what a user source sweep did.
+type UserSweepResult struct {
+	Total     int // users the source keeps track of
+	Scheduled int // users whose deactivation was scheduled
+}
+
+// SweepUsers schedules the deactivation of the users of a source that were
+// not seen since startedAt, after a full harvest. Only users with an
+// expires_at on the source are swept; deactivate_at is set to it, so the
+// time between the last sighting and expires_at is a grace period. Users
+// that are already scheduled for deactivation are left alone. If more users
+// vanished than opts allow, nothing is scheduled and the error wraps
+// ErrSweepThreshold.
+func (r *Repo) SweepUsers(ctx context.Context, source string, startedAt time.Time, opts SweepOpts) (*UserSweepResult, error) {
+	maxVanished := opts.MaxVanished
+	if maxVanished <= 0 {
+		maxVanished = DefaultSweepMaxVanished
+	}
+
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("SweepUsers: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	res := &UserSweepResult{}
+	if err := tx.QueryRow(ctx, `
+		SELECT count(*) FROM bbl_user_sources
+		WHERE source = $1 AND expires_at IS NOT NULL`,
+		source).Scan(&res.Total); err != nil {
+		return nil, fmt.Errorf("SweepUsers: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT s.user_id, s.expires_at
+		FROM bbl_user_sources s
+		JOIN bbl_users u ON u.id = s.user_id
+		WHERE s.source = $1
+		  AND s.expires_at IS NOT NULL
+		  AND s.last_seen_at < $2
+		  AND u.deactivate_at IS NULL
+		ORDER BY s.user_id
+		FOR UPDATE OF u`,
+		source, startedAt)
+	if err != nil {
+		return nil, fmt.Errorf("SweepUsers: %w", err)
+	}
+	type vanished struct {
+		userID    ID
+		expiresAt time.Time
+	}
+	gone, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (vanished, error) {
+		var v vanished
+		err := row.Scan(&v.userID, &v.expiresAt)
+		return v, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("SweepUsers: %w", err)
+	}
+	if len(gone) == 0 {
+		return res, nil
+	}
+	if float64(len(gone)) > maxVanished*float64(res.Total) {
+		return nil, fmt.Errorf("SweepUsers: %w: %d of %d %s users vanished",
+			ErrSweepThreshold, len(gone), res.Total, source)
+	}
+
+	for _, v := range gone {
+		if _, err := tx.Exec(ctx, `
+			UPDATE bbl_users SET deactivate_at = $2, deactivate_source = $3
+			WHERE id = $1`,
+			v.userID, v.expiresAt, source); err != nil {
+			return nil, fmt.Errorf("SweepUsers: %w", err)
+		}
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO bbl_user_events (id, user_id, kind, payload)
+			VALUES ($1, $2, $3, jsonb_build_object('source', $4::text, 'deactivate_at', $5::timestamptz))`,
+			newID(), v.userID, UserEventDeactivationScheduled, source, v.expiresAt); err != nil {
+			return nil, fmt.Errorf("SweepUsers: %w", err)
+		}
+		res.Scheduled++
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("SweepUsers: %w", err)
+	}
+	return res, nil
+}
+
+// DeactivateUsers carries out the deactivations that are due at now: the
+// grants of the user are revoked, proxy relations in either direction end
+// and sessions issued before now are no longer accepted. Each deactivation
+// is logged once. Returns the number of users deactivated.
+func (r *Repo) DeactivateUsers(ctx context.Context, now time.Time) (int, error) {
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("DeactivateUsers: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+		UPDATE bbl_users SET deactivated_at = $1
+		WHERE deactivate_at <= $1
+		  AND (deactivated_at IS NULL OR deactivated_at < deactivate_at)
+		RETURNING id`,
+		now)
+	if err != nil {
+		return 0, fmt.Errorf("DeactivateUsers: %w", err)
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[ID])
+	if err != nil {
+		return 0, fmt.Errorf("DeactivateUsers: %w", err)
+	}
+
+	for _, id := range ids {
+		var grants, proxies int64
+		tag, err := tx.Exec(ctx, `
+			UPDATE bbl_grants SET revoked_at = $2
+			WHERE user_id = $1 AND revoked_at IS NULL
+			  AND (expires_at IS NULL OR expires_at > $2)`,
+			id, now)
+		if err != nil {
+			return 0, fmt.Errorf("DeactivateUsers: %w", err)
+		}
+		grants = tag.RowsAffected()
+		tag, err = tx.Exec(ctx, `
+			UPDATE bbl_user_proxies SET valid_to = $2
+			WHERE (user_id = $1 OR proxy_user_id = $1)
+			  AND (valid_to IS NULL OR valid_to > $2)`,
+			id, now)
+		if err != nil {
+			return 0, fmt.Errorf("DeactivateUsers: %w", err)
+		}
+		proxies = tag.RowsAffected()
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO bbl_user_events (id, user_id, kind, payload)
+			VALUES ($1, $2, $3, jsonb_build_object('revoked_grants', $4::bigint, 'ended_proxies', $5::bigint))`,
+			newID(), id, UserEventDeactivated, grants, proxies); err != nil {
+			return 0, fmt.Errorf("DeactivateUsers: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("DeactivateUsers: %w", err)
+	}
+	return len(ids), nil
+}
@@ -0,0 +1,122 @@
+package bbl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"testing"
+	"time"
+)
+
+func TestUserDeactivation(t *testing.T) {
+	repo := testRepo(t)
+	ctx := context.Background()
+
+	if err := repo.UpsertSource(ctx, "test-ldap"); err != nil {
+		t.Fatalf("upsert source: %v", err)
+	}
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Microsecond)
+	harvest := func(ids ...int) time.Time {
+		t.Helper()
+		startedAt, err := repo.StartSourceSweep(ctx)
+		if err != nil {
+			t.Fatalf("start sweep: %v", err)
+		}
+		seq := func(yield func(*ImportUserInput, error) bool) {
+			for _, i := range ids {
+				if !yield(&ImportUserInput{
+					SourceID:  fmt.Sprintf("ldap-%03d", i),
+					ExpiresAt: &expiresAt,
+					Username:  fmt.Sprintf("user%03d", i),
+					Email:     fmt.Sprintf("user%03d@example.org", i),
+					Name:      fmt.Sprintf("User %d", i),
+					Role:      RoleUser,
+				}, nil) {
+					return
+				}
+			}
+		}
+		if _, err := repo.ImportUsers(ctx, "test-ldap", "", iter.Seq2[*ImportUserInput, error](seq)); err != nil {
+			t.Fatalf("import users: %v", err)
+		}
+		return startedAt
+	}
+	events := func(userID ID) []string {
+		t.Helper()
+		ee, err := repo.GetUserEvents(ctx, userID)
+		if err != nil {
+			t.Fatalf("get user events: %v", err)
+		}
+		var kinds []string
+		for _, e := range ee {
+			kinds = append(kinds, e.Kind)
+		}
+		return kinds
+	}
+
+	all := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+	harvest(all...)
+	user, err := repo.GetUserByUsername(ctx, "user000")
+	if err != nil {
+		t.Fatalf("get user: %v", err)
+	}
+	if _, err := repo.db.Exec(ctx, `
+		INSERT INTO bbl_grants (id, user_id, kind) VALUES ($1, $2, 'curate')`,
+		newID(), user.ID); err != nil {
+		t.Fatalf("insert grant: %v", err)
+	}
+
+	// Half of the directory vanishing is over the threshold.
+	startedAt := harvest(all[5:]...)
+	if _, err := repo.SweepUsers(ctx, "test-ldap", startedAt, SweepOpts{}); !errors.Is(err, ErrSweepThreshold) {
+		t.Fatalf("sweep over threshold: err = %v", err)
+	}
+
+	// user000 vanished; it is deactivated when the grace period ends.
+	startedAt = harvest(all[1:]...)
+	res, err := repo.SweepUsers(ctx, "test-ldap", startedAt, SweepOpts{})
+	if err != nil {
+		t.Fatalf("sweep: %v", err)
+	}
+	if res.Total != 10 || res.Scheduled != 1 {
+		t.Fatalf("sweep result = %+v", res)
+	}
+	if n, err := repo.DeactivateUsers(ctx, time.Now()); err != nil || n != 0 {
+		t.Fatalf("deactivated %d users during the grace period, err = %v", n, err)
+	}
+	if n, err := repo.DeactivateUsers(ctx, expiresAt); err != nil || n != 1 {
+		t.Fatalf("deactivated %d users after the grace period, err = %v", n, err)
+	}
+	if n, err := repo.DeactivateUsers(ctx, expiresAt.Add(time.Hour)); err != nil || n != 0 {
+		t.Fatalf("deactivated %d users twice, err = %v", n, err)
+	}
+
+	user, err = repo.GetUser(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("get user: %v", err)
+	}
+	if !user.Deactivated(expiresAt) || user.DeactivatedAt == nil {
+		t.Errorf("user not deactivated: %+v", user)
+	}
+	var active int
+	if err := repo.db.QueryRow(ctx, `
+		SELECT count(*) FROM bbl_grants WHERE user_id = $1 AND revoked_at IS NULL`,
+		user.ID).Scan(&active); err != nil || active != 0 {
+		t.Errorf("active grants = %d, err = %v", active, err)
+	}
+
+	// The user comes back.
+	harvest(all...)
+	user, err = repo.GetUser(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("get user: %v", err)
+	}
+	if user.DeactivateAt != nil || user.DeactivatedAt == nil {
+		t.Errorf("user not reactivated: %+v", user)
+	}
+	want := []string{UserEventDeactivationScheduled, UserEventDeactivated, UserEventReactivated}
+	if got := events(user.ID); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+}
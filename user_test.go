@@ -0,0 +1,44 @@
+package bbl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCanManageRecords(t *testing.T) {
+	tests := []struct {
+		name string
+		user *User
+		want bool
+	}{
+		{"admin", &User{Role: RoleAdmin}, true},
+		{"curator", &User{Role: RoleCurator}, true},
+		{"user", &User{Role: RoleUser}, false},
+		{"nil", nil, false},
+	}
+	for _, tt := range tests {
+		if got := CanManageRecords(tt.user); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestUserDeactivated(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	tests := []struct {
+		name string
+		user *User
+		want bool
+	}{
+		{"active", &User{}, false},
+		{"scheduled", &User{DeactivateAt: &future}, false},
+		{"deactivated", &User{DeactivateAt: &past}, true},
+		{"at deactivation", &User{DeactivateAt: &now}, true},
+	}
+	for _, tt := range tests {
+		if got := tt.user.Deactivated(now); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -13,12 +13,26 @@ import (
 
 // importUserSQL is the find-or-create CTE used by importUserBatch.
 // $1=source, $2=source_id, $3=username, $4=email, $5=name, $6=role,
-// $7=expires_at, $8=schemes[], $9=vals[], $10=authProvider, $11=newID.
+// $7=expires_at, $8=schemes[], $9=vals[], $10=authProvider, $11=newID,
+// $12=eventID, $13=reactivated event kind.
+// A deactivation scheduled by the same source is lifted and logged.
 const importUserSQL = `
 	WITH
+	reactivated AS (
+		INSERT INTO bbl_user_events (id, user_id, kind, payload)
+		SELECT $12, id, $13, jsonb_build_object('source', $1::text)
+		FROM bbl_users
+		WHERE id = (
+		    SELECT user_id FROM bbl_user_sources
+		    WHERE source = $1 AND source_id = $2
+		)
+		  AND deactivate_source = $1
+	),
 	updated AS (
 		UPDATE bbl_users
 		SET username = $3, email = $4, name = $5,
+		    deactivate_at = CASE WHEN deactivate_source = $1 THEN NULL ELSE deactivate_at END,
+		    deactivate_source = CASE WHEN deactivate_source = $1 THEN NULL ELSE deactivate_source END,
 		    auth_providers = CASE
 		        WHEN $10::text IS NOT NULL
 		         AND NOT EXISTS (
@@ -32,7 +46,7 @@ const importUserSQL = `
 		    SELECT user_id FROM bbl_user_sources
 		    WHERE source = $1 AND source_id = $2
 		)
-		RETURNING id, created_at, username, email, name, role, deactivate_at, person_id, auth_providers
+		RETURNING id, created_at, username, email, name, role, deactivate_at, deactivated_at, person_id, auth_providers
 	),
 	created AS (
 		INSERT INTO bbl_users (id, username, email, name, role, auth_providers)
@@ -42,7 +56,7 @@ const importUserSQL = `
 		            ELSE '[]'::jsonb
 		       END
 		WHERE NOT EXISTS (SELECT 1 FROM updated)
-		RETURNING id, created_at, username, email, name, role, deactivate_at, person_id, auth_providers
+		RETURNING id, created_at, username, email, name, role, deactivate_at, deactivated_at, person_id, auth_providers
 	),
 	u AS (
 		SELECT * FROM updated UNION ALL SELECT * FROM created
@@ -89,13 +103,14 @@ func importUserArgs(in *ImportUserInput) []any {
 		schemes, vals,
 		authProvider,
 		newID(),
+		newID(), UserEventReactivated,
 	}
 }
 
 // GetUser fetches a user by primary key. Returns ErrNotFound if no row exists.
 func (r *Repo) GetUser(ctx context.Context, id ID) (*User, error) {
 	row := r.db.QueryRow(ctx, `
-		SELECT id, created_at, username, email, name, role, deactivate_at, person_id, auth_providers
+		SELECT id, created_at, username, email, name, role, deactivate_at, deactivated_at, person_id, auth_providers
 		FROM bbl_users
 		WHERE id = $1`, id)
 	u, err := scanUser(row)
@@ -111,7 +126,7 @@ func (r *Repo) GetUser(ctx context.Context, id ID) (*User, error) {
 // GetUserByUsername looks up a user by username. Returns ErrNotFound if no match.
 func (r *Repo) GetUserByUsername(ctx context.Context, username string) (*User, error) {
 	row := r.db.QueryRow(ctx, `
-		SELECT id, created_at, username, email, name, role, deactivate_at, person_id, auth_providers
+		SELECT id, created_at, username, email, name, role, deactivate_at, deactivated_at, person_id, auth_providers
 		FROM bbl_users
 		WHERE username = $1`, username)
 	u, err := scanUser(row)
@@ -128,7 +143,7 @@ func (r *Repo) GetUserByUsername(ctx context.Context, username string) (*User, e
 // This is the primary login lookup path. Returns ErrNotFound if no match.
 func (r *Repo) GetUserByIdentifier(ctx context.Context, scheme, val string) (*User, error) {
 	row := r.db.QueryRow(ctx, `
-		SELECT u.id, u.created_at, u.username, u.email, u.name, u.role, u.deactivate_at, u.person_id
+		SELECT u.id, u.created_at, u.username, u.email, u.name, u.role, u.deactivate_at, u.deactivated_at, u.person_id, u.auth_providers
 		FROM bbl_users u
 		JOIN bbl_user_identifiers i ON i.user_id = u.id
 		WHERE i.scheme = $1 AND i.val = $2`, scheme, val)
@@ -148,7 +163,7 @@ func (r *Repo) CreateUser(ctx context.Context, attrs UserAttrs) (*User, error) {
 	row := r.db.QueryRow(ctx, `
 		INSERT INTO bbl_users (id, username, email, name, role)
 		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id, created_at, username, email, name, role, deactivate_at, person_id, auth_providers`,
+		RETURNING id, created_at, username, email, name, role, deactivate_at, deactivated_at, person_id, auth_providers`,
 		newID(), attrs.Username, attrs.Email, attrs.Name, attrs.Role)
 	u, err := scanUser(row)
 	if isUniqueViolation(err) {
@@ -236,6 +251,7 @@ func scanUser(row pgx.Row) (*User, error) {
 		&u.Name,
 		&u.Role,
 		&u.DeactivateAt,
+		&u.DeactivatedAt,
 		&personID,
 		&u.AuthProviders,
 	)
@@ -253,3 +269,26 @@ func isUniqueViolation(err error) bool {
 	var pgErr *pgconn.PgError
 	return errors.As(err, &pgErr) && pgErr.Code == "23505"
 }
+
+// SystemUsername is the username of the user that background jobs write
+// revisions as.
+const SystemUsername = "system"
+
+// SystemUser returns the system user, creating it on first use. It has the
+// system role, so its edits pass curator locks without becoming curator
+// locks themselves.
+func (r *Repo) SystemUser(ctx context.Context) (*User, error) {
+	u, err := r.GetUserByUsername(ctx, SystemUsername)
+	if err == nil || !errors.Is(err, ErrNotFound) {
+		return u, err
+	}
+	u, err = r.CreateUser(ctx, UserAttrs{
+		Username: SystemUsername,
+		Name:     "System",
+		Role:     RoleSystem,
+	})
+	if errors.Is(err, ErrConflict) {
+		return r.GetUserByUsername(ctx, SystemUsername)
+	}
+	return u, err
+}
@@ -20,11 +20,21 @@ const (
 	WorkReviewReturned = "returned"
 )
 
+// Work review transition kinds, recorded in bbl_work_reviews.
+const (
+	WorkReviewKindSubmitted = "submitted"
+	WorkReviewKindPickedUp  = "picked_up"
+	WorkReviewKindReturned  = "returned"
+	WorkReviewKindApproved  = "approved"
+	WorkReviewKindFlagged   = "flagged" // by a source sweep or an import that matched existing works
+)
+
 // Work delete kind values (set when status = deleted).
 const (
 	WorkDeleteWithdrawn = "withdrawn"
 	WorkDeleteRetracted = "retracted"
 	WorkDeleteTakedown  = "takedown"
+	WorkDeleteMerged    = "merged"
 )
 
 type Work struct {
@@ -72,6 +82,7 @@ type Work struct {
 	Projects        []ID              `json:"projects,omitempty"`
 	Organizations   []ID              `json:"organizations,omitempty"`
 	Rels            []WorkRel         `json:"rels,omitempty"`
+	Files           []WorkFile        `json:"files,omitempty"`
 }
 
 // ImportWorkInput carries all data for one work record arriving from a source.
@@ -81,6 +92,10 @@ type ImportWorkInput struct {
 	Kind     string `json:"kind"`
 	Status   string `json:"status,omitempty"`
 
+	// Deleted marks a record that the source reports as deleted. Only
+	// SourceID is used; the assertions of an earlier import are withdrawn.
+	Deleted bool `json:"deleted,omitempty"`
+
 	// Scalar fields.
 	ArticleNumber       string     `json:"article_number,omitempty"`
 	BookTitle           string     `json:"book_title,omitempty"`
@@ -120,13 +135,19 @@ type ImportWorkInput struct {
 
 // ImportWorkContributor is a contributor arriving from a source.
 type ImportWorkContributor struct {
-	PersonRef  *Ref     `json:"person_ref,omitempty"`
-	Kind       string   `json:"kind,omitempty"` // "person" (default) or "organization"
-	Roles      []string `json:"roles,omitempty"`
-	Name       string   `json:"name,omitempty"`
-	GivenName  string   `json:"given_name,omitempty"`
-	MiddleName string   `json:"middle_name,omitempty"`
-	FamilyName string   `json:"family_name,omitempty"`
+	PersonRef    *Ref     `json:"person_ref,omitempty"`
+	Kind         string   `json:"kind,omitempty"` // "person" (default) or "organization"
+	Roles        []string `json:"roles,omitempty"`
+	Name         string   `json:"name,omitempty"`
+	GivenName    string   `json:"given_name,omitempty"`
+	MiddleName   string   `json:"middle_name,omitempty"`
+	FamilyName   string   `json:"family_name,omitempty"`
+	Affiliations []string `json:"affiliations,omitempty"`
+
+	// PersonRefOptional leaves the contributor unlinked if PersonRef matches
+	// no person, instead of failing the import. Sources that pass on
+	// identifiers from external metadata, like ORCIDs, set it.
+	PersonRefOptional bool `json:"person_ref_optional,omitempty"`
 }
 
 // ImportWorkProject links a work to a project during import.
@@ -147,12 +168,13 @@ type ImportWorkRel struct {
 
 // WorkContributor is a contributor read from the cache column.
 type WorkContributor struct {
-	Kind       string   `json:"kind,omitempty"` // "person" (default) or "organization"
-	PersonID   *ID      `json:"person_id,omitempty"`
-	Name       string   `json:"name,omitempty"`
-	GivenName  string   `json:"given_name,omitempty"`
-	FamilyName string   `json:"family_name,omitempty"`
-	Roles      []string `json:"roles,omitempty"`
+	Kind         string   `json:"kind,omitempty"` // "person" (default) or "organization"
+	PersonID     *ID      `json:"person_id,omitempty"`
+	Name         string   `json:"name,omitempty"`
+	GivenName    string   `json:"given_name,omitempty"`
+	FamilyName   string   `json:"family_name,omitempty"`
+	Roles        []string `json:"roles,omitempty"`
+	Affiliations []string `json:"affiliations,omitempty"` // as printed on the work
 }
 
 // WorkRel links two works with a typed relationship.
@@ -166,7 +188,31 @@ type WorkSourceIter interface {
 	Iter(ctx context.Context) (iter.Seq2[*ImportWorkInput, error], error)
 }
 
+// WorkSourceIterSince is implemented by sources that can iterate the records
+// changed since a checkpoint. since is the checkpoint of the previous
+// successful run, empty for a full run. next is the checkpoint to store once
+// seq has been imported without error; it is empty if the run shouldn't
+// move the checkpoint.
+type WorkSourceIterSince interface {
+	WorkSourceIter
+	IterSince(ctx context.Context, since string) (seq iter.Seq2[*ImportWorkInput, error], next string, err error)
+}
+
+// WorkSourcePartial is implemented by sources whose full runs can leave out
+// records, like an OAI-PMH harvest from a datestamp. Such a source is only
+// swept if Partial reports false.
+type WorkSourcePartial interface {
+	Partial() bool
+}
+
 // WorkSourceGetter is implemented by sources that can fetch a single record by ID.
 type WorkSourceGetter interface {
 	Get(ctx context.Context, id string) (*ImportWorkInput, error)
 }
+
+// WorkSourceMapper is implemented by sources that can map a stored source
+// record, the SourceRecord of an earlier import, without fetching it again.
+// Stored records are remapped after a fix to the mapping.
+type WorkSourceMapper interface {
+	Map(record []byte) (*ImportWorkInput, error)
+}
@@ -0,0 +1,276 @@
+package bbl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memFileStore is an in-memory FileStore. Presigned URLs are fake; tests
+// Put objects directly.
+type memFileStore struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (s *memFileStore) PresignPut(ctx context.Context, key, contentType string, ttl time.Duration) (string, error) {
+	return "mem://put/" + key, nil
+}
+
+func (s *memFileStore) PresignGet(ctx context.Context, key, filename string, ttl time.Duration) (string, error) {
+	return "mem://get/" + key, nil
+}
+
+func (s *memFileStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.objects[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(b)), nil
+}
+
+func (s *memFileStore) Put(ctx context.Context, key string, r io.Reader, contentType string) error {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.objects == nil {
+		s.objects = make(map[string][]byte)
+	}
+	s.objects[key] = b
+	return nil
+}
+
+func (s *memFileStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.objects, key)
+	return nil
+}
+
+func TestWorkFiles(t *testing.T) {
+	repo := testRepo(t)
+	repo.Profiles = testProfiles(t)
+	ctx := context.Background()
+	user := createTestUser(t, repo, RoleUser)
+	svc := &Services{Repo: repo, Files: &memFileStore{}}
+
+	f, u, err := svc.StartFileUpload(ctx, user, "paper.pdf", "application/octet-stream")
+	if err != nil {
+		t.Fatalf("start upload: %v", err)
+	}
+	if f.Status != FileStatusPending || u == "" {
+		t.Fatalf("file = %+v, url = %q", f, u)
+	}
+	body := []byte("%PDF-1.7\nhello")
+	if err := svc.Files.Put(ctx, f.ID.String(), bytes.NewReader(body), f.ContentType); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	other := createTestUser(t, repo, RoleUser)
+	if _, err := svc.FinishFileUpload(ctx, other, f.ID); !errors.Is(err, ErrForbidden) {
+		t.Errorf("expected ErrForbidden finishing another user's upload, got %v", err)
+	}
+	f, err = svc.FinishFileUpload(ctx, user, f.ID)
+	if err != nil {
+		t.Fatalf("finish upload: %v", err)
+	}
+	if f.Status != FileStatusUploaded || f.ContentType != "application/pdf" || f.Size != int64(len(body)) || f.SHA256 == "" {
+		t.Errorf("finished file = %+v", f)
+	}
+
+	workID := newID()
+	until := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, _, err := repo.Update(ctx, user,
+		&CreateWork{ID: workID, Kind: "journal_article"},
+		&Set{RecordType: RecordTypeWork, RecordID: workID, Field: "titles", Val: []Title{{Lang: "eng", Val: "Paper"}}},
+		&AttachWorkFile{WorkID: workID, File: WorkFile{
+			FileID:            f.ID,
+			Name:              "paper.pdf",
+			AccessKind:        WorkFileAccessClosed,
+			EmbargoUntil:      &until,
+			EmbargoAccessKind: WorkFileAccessOpen,
+			License:           "CC-BY-4.0",
+		}},
+	); err != nil {
+		t.Fatalf("attach: %v", err)
+	}
+
+	work, err := repo.GetWork(ctx, workID)
+	if err != nil {
+		t.Fatalf("get work: %v", err)
+	}
+	if len(work.Files) != 1 {
+		t.Fatalf("files = %v, want 1", work.Files)
+	}
+	wf := work.Files[0]
+	if wf.FileID != f.ID || wf.ContentType != "application/pdf" || wf.SHA256 != f.SHA256 || wf.License != "CC-BY-4.0" {
+		t.Errorf("work file = %+v", wf)
+	}
+	if wf.EmbargoUntil == nil || !wf.EmbargoUntil.Equal(until) {
+		t.Errorf("embargo until = %v, want %v", wf.EmbargoUntil, until)
+	}
+
+	// Reattaching with the same settings is a noop.
+	ok, _, err := repo.Update(ctx, user, &AttachWorkFile{WorkID: workID, File: wf})
+	if err != nil {
+		t.Fatalf("reattach: %v", err)
+	}
+	if ok {
+		t.Error("expected reattach with the same settings to be a noop")
+	}
+
+	// A file can't be finished twice or be attached to a second work.
+	if _, err := svc.FinishFileUpload(ctx, user, f.ID); err == nil {
+		t.Error("expected error finishing an uploaded file")
+	}
+	otherWorkID := newID()
+	if _, _, err := repo.Update(ctx, user,
+		&CreateWork{ID: otherWorkID, Kind: "journal_article"},
+		&Set{RecordType: RecordTypeWork, RecordID: otherWorkID, Field: "titles", Val: []Title{{Lang: "eng", Val: "Other"}}},
+		&AttachWorkFile{WorkID: otherWorkID, File: WorkFile{FileID: f.ID, Name: "paper.pdf", AccessKind: WorkFileAccessOpen}},
+	); err == nil {
+		t.Error("expected error attaching a file to a second work")
+	}
+
+	if _, _, err := repo.Update(ctx, user, &DetachWorkFile{WorkID: workID, FileID: f.ID}); err != nil {
+		t.Fatalf("detach: %v", err)
+	}
+	work, err = repo.GetWork(ctx, workID)
+	if err != nil {
+		t.Fatalf("get work: %v", err)
+	}
+	if len(work.Files) != 0 {
+		t.Errorf("files = %v, want none after detach", work.Files)
+	}
+}
+
+type recordingNotifier struct {
+	notifications []*Notification
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, notif *Notification) error {
+	n.notifications = append(n.notifications, notif)
+	return nil
+}
+
+func TestEmbargoes(t *testing.T) {
+	repo := testRepo(t)
+	repo.Profiles = testProfiles(t)
+	ctx := context.Background()
+	user := createTestUser(t, repo, RoleUser)
+	notifier := &recordingNotifier{}
+	svc := &Services{Repo: repo, Notifier: notifier}
+
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	soon := now.Add(3 * 24 * time.Hour)
+
+	expired := createUploadedFile(t, repo, user, "expired.pdf")
+	upcoming := createUploadedFile(t, repo, user, "upcoming.pdf")
+
+	workID := newID()
+	if _, _, err := repo.Update(ctx, user,
+		&CreateWork{ID: workID, Kind: "journal_article"},
+		&Set{RecordType: RecordTypeWork, RecordID: workID, Field: "titles", Val: []Title{{Lang: "eng", Val: "Embargoed"}}},
+		&AttachWorkFile{WorkID: workID, File: WorkFile{
+			FileID: expired.ID, Name: "expired.pdf", AccessKind: WorkFileAccessClosed,
+			EmbargoUntil: &past, EmbargoAccessKind: WorkFileAccessOpen,
+		}},
+		&AttachWorkFile{WorkID: workID, File: WorkFile{
+			FileID: upcoming.ID, Name: "upcoming.pdf", AccessKind: WorkFileAccessClosed,
+			EmbargoUntil: &soon, EmbargoAccessKind: WorkFileAccessOpen,
+		}},
+	); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	// Notices: only the upcoming embargo within the window, and only once.
+	n, err := svc.NotifyExpiringEmbargoes(ctx, now, 7*24*time.Hour)
+	if err != nil {
+		t.Fatalf("notify: %v", err)
+	}
+	if n != 1 || len(notifier.notifications) != 1 {
+		t.Fatalf("sent %d notices, want 1", n)
+	}
+	if got := notifier.notifications[0]; got.User.ID != user.ID || got.WorkID != workID {
+		t.Errorf("notification = %+v", got)
+	}
+	if n, err := svc.NotifyExpiringEmbargoes(ctx, now, 7*24*time.Hour); err != nil || n != 0 {
+		t.Errorf("second run sent %d notices (err %v), want 0", n, err)
+	}
+
+	// Lift: only the expired embargo.
+	n, err = svc.LiftEmbargoes(ctx, now)
+	if err != nil {
+		t.Fatalf("lift: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("lifted %d works, want 1", n)
+	}
+	work, err := repo.GetWork(ctx, workID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range work.Files {
+		switch f.FileID {
+		case expired.ID:
+			if f.AccessKind != WorkFileAccessOpen || f.EmbargoLiftedAt == nil {
+				t.Errorf("expired file not lifted: %+v", f)
+			}
+		case upcoming.ID:
+			if f.AccessKind != WorkFileAccessClosed || f.EmbargoLiftedAt != nil {
+				t.Errorf("upcoming file changed: %+v", f)
+			}
+		}
+	}
+	if n, err := svc.LiftEmbargoes(ctx, now); err != nil || n != 0 {
+		t.Errorf("second run lifted %d works (err %v), want 0", n, err)
+	}
+
+	// The lift is a revision by the system user.
+	system, err := repo.SystemUser(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	history, err := repo.GetWorkHistory(ctx, workID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var current, replaced bool
+	for _, e := range history {
+		if e.Field != "files" || e.RevUserID == nil || *e.RevUserID != system.ID {
+			continue
+		}
+		if e.IsHistory {
+			replaced = true
+		} else {
+			current = true
+		}
+	}
+	if !current || !replaced {
+		t.Errorf("history has no system lift of files: %+v", history)
+	}
+}
+
+// createUploadedFile registers a file as uploaded by user without storing
+// an object.
+func createUploadedFile(t *testing.T, repo *Repo, user *User, name string) *File {
+	t.Helper()
+	ctx := context.Background()
+	f, err := repo.CreateFile(ctx, user, name, "application/pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.markFileUploaded(ctx, f); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
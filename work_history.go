@@ -22,6 +22,9 @@ type WorkHistoryEntry struct {
 	Source    string
 	Pinned    bool
 	IsHistory bool // true = from bbl_history (old value), false = current assertion
+	RevUserID *ID  // user who made the revision; for history entries the one who replaced the value
+	// RevImpersonatorID is the admin who made the revision as RevUserID.
+	RevImpersonatorID *ID
 }
 
 // GetWorkHistory returns the full history for a work: current assertions
@@ -29,7 +32,8 @@ type WorkHistoryEntry struct {
 func (r *Repo) GetWorkHistory(ctx context.Context, workID ID) ([]WorkHistoryEntry, error) {
 	rows, err := r.db.Query(ctx, `
 		SELECT sub.rev_id, r.created_at, sub.field, sub.val, sub.hidden,
-		       sub.user_id, sub.role, sub.source, sub.pinned, sub.is_history
+		       sub.user_id, sub.role, sub.source, sub.pinned, sub.is_history,
+		       r.user_id, r.impersonator_id
 		FROM (
 			-- Current assertions
 			SELECT a.rev_id, a.field, a.val, a.hidden,
@@ -60,12 +64,13 @@ func (r *Repo) GetWorkHistory(ctx context.Context, workID ID) ([]WorkHistoryEntr
 	var result []WorkHistoryEntry
 	for rows.Next() {
 		var e WorkHistoryEntry
-		var userID pgtype.UUID
+		var userID, revUserID, revImpersonatorID pgtype.UUID
 		var role, source pgtype.Text
 		if err := rows.Scan(
 			&e.RevID, &e.RevAt, &e.Field, &e.Val, &e.Hidden,
 			&userID, &role, &source, &e.Pinned,
 			&e.IsHistory,
+			&revUserID, &revImpersonatorID,
 		); err != nil {
 			return nil, fmt.Errorf("GetWorkHistory: %w", err)
 		}
@@ -73,6 +78,14 @@ func (r *Repo) GetWorkHistory(ctx context.Context, workID ID) ([]WorkHistoryEntr
 			id := ID(userID.Bytes)
 			e.UserID = &id
 		}
+		if revUserID.Valid {
+			id := ID(revUserID.Bytes)
+			e.RevUserID = &id
+		}
+		if revImpersonatorID.Valid {
+			id := ID(revImpersonatorID.Bytes)
+			e.RevImpersonatorID = &id
+		}
 		if role.Valid {
 			e.Role = role.String
 		}
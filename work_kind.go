@@ -0,0 +1,71 @@
+package bbl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/ugent-library/vo"
+)
+
+// WorkKindChangePreview describes the outcome of a ChangeWorkKind without
+// writing it.
+type WorkKindChangePreview struct {
+	Kind     string
+	Dropped  []WorkKindChangeField // fields the new kind's profile doesn't keep
+	Restored []WorkKindChangeField // fields an earlier kind change hid that the new kind has again
+	Errs     vo.Errors             // validation errors against the new kind
+}
+
+// WorkKindChangeField is a field value that a kind change drops or restores.
+type WorkKindChangeField struct {
+	Field string
+	Val   json.RawMessage
+}
+
+// PreviewChangeWorkKind applies a ChangeWorkKind in a transaction that is
+// rolled back, and reports the dropped fields and validation errors.
+func (r *Repo) PreviewChangeWorkKind(ctx context.Context, user *User, m *ChangeWorkKind) (*WorkKindChangePreview, error) {
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("PreviewChangeWorkKind: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	state, err := fetchState(ctx, tx, m.needs(), []updater{m})
+	if err != nil {
+		return nil, fmt.Errorf("PreviewChangeWorkKind: %w", err)
+	}
+	state.profiles = r.Profiles
+	rs := state.records[m.WorkID]
+	if rs == nil {
+		return nil, ErrNotFound
+	}
+	vals := maps.Clone(rs.fields)
+
+	if _, err := m.apply(state, user); err != nil {
+		return nil, fmt.Errorf("PreviewChangeWorkKind: %w", err)
+	}
+
+	p := &WorkKindChangePreview{Kind: m.Kind}
+	for _, field := range m.dropped {
+		val, err := json.Marshal(vals[field])
+		if err != nil {
+			return nil, fmt.Errorf("PreviewChangeWorkKind: %w", err)
+		}
+		p.Dropped = append(p.Dropped, WorkKindChangeField{Field: field, Val: val})
+	}
+	for _, field := range m.restored {
+		val, err := json.Marshal(rs.fields[field])
+		if err != nil {
+			return nil, fmt.Errorf("PreviewChangeWorkKind: %w", err)
+		}
+		p.Restored = append(p.Restored, WorkKindChangeField{Field: field, Val: val})
+	}
+	if defs := r.Profiles.FieldDefs(RecordTypeWork, rs.kind); defs != nil {
+		p.Errs = validateRecord(rs.status, rs.fields, defs)
+	}
+	return p, nil
+}
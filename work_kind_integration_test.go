@@ -0,0 +1,128 @@
+package bbl
+
+import (
+	"context"
+	"iter"
+	"testing"
+)
+
+func TestUpdateChangeWorkKind(t *testing.T) {
+	for _, archive := range []bool{false, true} {
+		repo := testRepo(t)
+		repo.Profiles = testProfiles(t)
+		ctx := context.Background()
+		user := createTestUser(t, repo, RoleUser)
+
+		workID := newID()
+		if _, _, err := repo.Update(ctx, user,
+			&CreateWork{ID: workID, Kind: "journal_article"},
+			&Set{RecordType: RecordTypeWork, RecordID: workID, Field: "titles", Val: []Title{{Lang: "eng", Val: "Paper"}}},
+			&Set{RecordType: RecordTypeWork, RecordID: workID, Field: "volume", Val: "42"},
+		); err != nil {
+			t.Fatalf("create work: %v", err)
+		}
+
+		preview, err := repo.PreviewChangeWorkKind(ctx, user, &ChangeWorkKind{WorkID: workID, Kind: "book"})
+		if err != nil {
+			t.Fatalf("preview: %v", err)
+		}
+		if len(preview.Dropped) != 1 || preview.Dropped[0].Field != "volume" {
+			t.Fatalf("preview dropped = %v, want [volume]", preview.Dropped)
+		}
+
+		if _, _, err := repo.Update(ctx, user, &ChangeWorkKind{WorkID: workID, Kind: "book", Archive: archive}); err != nil {
+			t.Fatalf("change kind (archive=%t): %v", archive, err)
+		}
+		work, err := repo.GetWork(ctx, workID)
+		if err != nil {
+			t.Fatalf("get work: %v", err)
+		}
+		if work.Kind != "book" {
+			t.Errorf("kind = %q, want %q", work.Kind, "book")
+		}
+		if work.Volume != "" {
+			t.Errorf("volume = %q, want empty", work.Volume)
+		}
+
+		var n int
+		if err := repo.db.QueryRow(ctx, `
+			SELECT count(*) FROM bbl_work_assertions
+			WHERE work_id = $1 AND field = 'volume' AND val IS NOT NULL`, workID).Scan(&n); err != nil {
+			t.Fatalf("count assertions: %v", err)
+		}
+		if want := map[bool]int{false: 1, true: 0}[archive]; n != want {
+			t.Errorf("archive=%t: volume assertions with a value = %d, want %d", archive, n, want)
+		}
+
+		// Changing back restores a hidden value; an archived one is gone.
+		if _, _, err := repo.Update(ctx, user, &ChangeWorkKind{WorkID: workID, Kind: "journal_article"}); err != nil {
+			t.Fatalf("change kind back (archive=%t): %v", archive, err)
+		}
+		work, err = repo.GetWork(ctx, workID)
+		if err != nil {
+			t.Fatalf("get work: %v", err)
+		}
+		if want := map[bool]string{false: "42", true: ""}[archive]; work.Volume != want {
+			t.Errorf("archive=%t: volume after change back = %q, want %q", archive, work.Volume, want)
+		}
+	}
+}
+
+func TestUpdateChangeWorkKind_SourceAssertions(t *testing.T) {
+	repo := testRepo(t)
+	repo.Profiles = testProfiles(t)
+	ctx := context.Background()
+	user := createTestUser(t, repo, RoleUser)
+
+	if err := repo.UpsertSource(ctx, "test-source"); err != nil {
+		t.Fatalf("upsert source: %v", err)
+	}
+	seq := func(yield func(*ImportWorkInput, error) bool) {
+		yield(&ImportWorkInput{
+			SourceID:     "work-001",
+			Kind:         "journal_article",
+			SourceRecord: []byte(`{}`),
+			Titles:       []Title{{Lang: "eng", Val: "Paper"}},
+			Volume:       "7",
+		}, nil)
+	}
+	if _, err := repo.ImportWorks(ctx, "test-source", iter.Seq2[*ImportWorkInput, error](seq)); err != nil {
+		t.Fatalf("import works: %v", err)
+	}
+	var workID ID
+	if err := repo.db.QueryRow(ctx, `
+		SELECT work_id FROM bbl_work_sources
+		WHERE source = $1 AND source_id = $2`, "test-source", "work-001").Scan(&workID); err != nil {
+		t.Fatalf("get work id: %v", err)
+	}
+
+	if _, _, err := repo.Update(ctx, user, &ChangeWorkKind{WorkID: workID, Kind: "book", Archive: true}); err != nil {
+		t.Fatalf("change kind: %v", err)
+	}
+	work, err := repo.GetWork(ctx, workID)
+	if err != nil {
+		t.Fatalf("get work: %v", err)
+	}
+	if work.Volume != "" {
+		t.Errorf("volume = %q, want hidden", work.Volume)
+	}
+	var n int
+	if err := repo.db.QueryRow(ctx, `
+		SELECT count(*) FROM bbl_work_assertions
+		WHERE work_id = $1 AND field = 'volume' AND user_id IS NULL`, workID).Scan(&n); err != nil {
+		t.Fatalf("count assertions: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("source volume assertions = %d, want 1", n)
+	}
+
+	if _, _, err := repo.Update(ctx, user, &ChangeWorkKind{WorkID: workID, Kind: "journal_article"}); err != nil {
+		t.Fatalf("change kind back: %v", err)
+	}
+	if work, err = repo.GetWork(ctx, workID); err != nil {
+		t.Fatalf("get work: %v", err)
+	}
+	if work.Volume != "7" {
+		t.Errorf("volume after change back = %q, want the source value", work.Volume)
+	}
+}
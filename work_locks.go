@@ -0,0 +1,297 @@
+package bbl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Work lock conflict resolutions.
+const (
+	WorkLockConflictAccepted  = "accepted"
+	WorkLockConflictDismissed = "dismissed"
+)
+
+// WorkLock is a curator endorsement of a work or one of its fields.
+type WorkLock struct {
+	WorkID     ID
+	Field      string // empty = whole work
+	LockedByID *ID
+	LockedBy   string // user name, empty if the user was removed
+	LockedAt   time.Time
+	Note       string
+}
+
+// WorkLockConflict is a queued source value that disagrees with a locked field.
+// The source's previous assertion is kept until a curator resolves the conflict.
+type WorkLockConflict struct {
+	ID        int64
+	WorkID    ID
+	Field     string
+	Source    string
+	SourceID  string
+	LockedVal json.RawMessage // nil = field had no value
+	SourceVal json.RawMessage // nil = source dropped the field
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// AcceptUpdates returns the updates that apply the source value as a curator
+// assertion and close the conflict in a single revision.
+func (c *WorkLockConflict) AcceptUpdates() ([]any, error) {
+	resolve := &ResolveWorkLockConflict{WorkID: c.WorkID, ConflictID: c.ID, Resolution: WorkLockConflictAccepted}
+	if c.SourceVal == nil {
+		return []any{&Hide{RecordType: RecordTypeWork, RecordID: c.WorkID, Field: c.Field}, resolve}, nil
+	}
+	ft, err := resolveFieldType(RecordTypeWork, c.Field)
+	if err != nil {
+		return nil, fmt.Errorf("AcceptUpdates: %w", err)
+	}
+	val, err := ft.unmarshal(c.SourceVal)
+	if err != nil {
+		return nil, fmt.Errorf("AcceptUpdates: %w", err)
+	}
+	return []any{&Set{RecordType: RecordTypeWork, RecordID: c.WorkID, Field: c.Field, Val: val}, resolve}, nil
+}
+
+// DismissUpdates returns the update that closes the conflict, keeping the locked value.
+func (c *WorkLockConflict) DismissUpdates() []any {
+	return []any{&ResolveWorkLockConflict{WorkID: c.WorkID, ConflictID: c.ID, Resolution: WorkLockConflictDismissed}}
+}
+
+// GetWorkLocks returns the curator locks on a work, whole-work lock first.
+func (r *Repo) GetWorkLocks(ctx context.Context, workID ID) ([]WorkLock, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT l.work_id, l.field, l.locked_by_id, u.name, l.locked_at, l.note
+		FROM bbl_work_locks l
+		LEFT JOIN bbl_users u ON u.id = l.locked_by_id
+		WHERE l.work_id = $1
+		ORDER BY l.field`, workID)
+	if err != nil {
+		return nil, fmt.Errorf("GetWorkLocks: %w", err)
+	}
+	defer rows.Close()
+
+	var locks []WorkLock
+	for rows.Next() {
+		var l WorkLock
+		var lockedByID pgtype.UUID
+		var lockedBy, note pgtype.Text
+		if err := rows.Scan(&l.WorkID, &l.Field, &lockedByID, &lockedBy, &l.LockedAt, &note); err != nil {
+			return nil, fmt.Errorf("GetWorkLocks: %w", err)
+		}
+		if lockedByID.Valid {
+			id := ID(lockedByID.Bytes)
+			l.LockedByID = &id
+		}
+		l.LockedBy = lockedBy.String
+		l.Note = note.String
+		locks = append(locks, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetWorkLocks: %w", err)
+	}
+	return locks, nil
+}
+
+const workLockConflictCols = `
+	c.id, c.work_id, c.field, s.source, s.source_id,
+	c.locked_val, c.source_val, c.created_at, c.updated_at`
+
+// GetWorkLockConflict fetches an open lock conflict by ID.
+// Returns ErrNotFound if it doesn't exist or was already resolved.
+func (r *Repo) GetWorkLockConflict(ctx context.Context, id int64) (*WorkLockConflict, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+workLockConflictCols+`
+		FROM bbl_work_lock_conflicts c
+		JOIN bbl_work_sources s ON s.id = c.work_source_id
+		WHERE c.id = $1 AND c.resolved_at IS NULL`, id)
+	if err != nil {
+		return nil, fmt.Errorf("GetWorkLockConflict: %w", err)
+	}
+	conflicts, err := scanWorkLockConflicts(rows)
+	if err != nil {
+		return nil, fmt.Errorf("GetWorkLockConflict: %w", err)
+	}
+	if len(conflicts) == 0 {
+		return nil, ErrNotFound
+	}
+	return &conflicts[0], nil
+}
+
+// GetWorkLockConflicts returns the open lock conflicts for a work.
+func (r *Repo) GetWorkLockConflicts(ctx context.Context, workID ID) ([]WorkLockConflict, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+workLockConflictCols+`
+		FROM bbl_work_lock_conflicts c
+		JOIN bbl_work_sources s ON s.id = c.work_source_id
+		WHERE c.work_id = $1 AND c.resolved_at IS NULL
+		ORDER BY c.field, c.id`, workID)
+	if err != nil {
+		return nil, fmt.Errorf("GetWorkLockConflicts: %w", err)
+	}
+	conflicts, err := scanWorkLockConflicts(rows)
+	if err != nil {
+		return nil, fmt.Errorf("GetWorkLockConflicts: %w", err)
+	}
+	return conflicts, nil
+}
+
+// ListWorkLockConflicts returns the curator queue: open lock conflicts
+// across all works, oldest first.
+func (r *Repo) ListWorkLockConflicts(ctx context.Context, limit, offset int) ([]WorkLockConflict, int, error) {
+	var total int
+	if err := r.db.QueryRow(ctx, `
+		SELECT count(*) FROM bbl_work_lock_conflicts WHERE resolved_at IS NULL`).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("ListWorkLockConflicts: %w", err)
+	}
+	rows, err := r.db.Query(ctx, `
+		SELECT `+workLockConflictCols+`
+		FROM bbl_work_lock_conflicts c
+		JOIN bbl_work_sources s ON s.id = c.work_source_id
+		WHERE c.resolved_at IS NULL
+		ORDER BY c.created_at, c.id
+		LIMIT $1 OFFSET $2`, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("ListWorkLockConflicts: %w", err)
+	}
+	conflicts, err := scanWorkLockConflicts(rows)
+	if err != nil {
+		return nil, 0, fmt.Errorf("ListWorkLockConflicts: %w", err)
+	}
+	return conflicts, total, nil
+}
+
+func scanWorkLockConflicts(rows pgx.Rows) ([]WorkLockConflict, error) {
+	defer rows.Close()
+	var conflicts []WorkLockConflict
+	for rows.Next() {
+		var c WorkLockConflict
+		if err := rows.Scan(
+			&c.ID, &c.WorkID, &c.Field, &c.Source, &c.SourceID,
+			&c.LockedVal, &c.SourceVal, &c.CreatedAt, &c.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		conflicts = append(conflicts, c)
+	}
+	return conflicts, rows.Err()
+}
+
+// holdLockedWorkFields compares a source re-import against the pinned values
+// of locked fields. Rows that disagree with a locked value are dropped from
+// rows and queued as lock conflicts. The returned held fields must keep the
+// source's previous assertions, so the pinned value doesn't change.
+// A conflict that was dismissed before is not queued again for the same value.
+func holdLockedWorkFields(ctx context.Context, tx pgx.Tx, workID, sourceRecordID ID, revID int64, rows []assertionRow) ([]assertionRow, []string, error) {
+	lockRows, err := tx.Query(ctx, `SELECT field FROM bbl_work_locks WHERE work_id = $1`, workID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("holdLockedWorkFields: %w", err)
+	}
+	fields, err := pgx.CollectRows(lockRows, pgx.RowTo[string])
+	if err != nil {
+		return nil, nil, fmt.Errorf("holdLockedWorkFields: %w", err)
+	}
+	if len(fields) == 0 {
+		return rows, nil, nil
+	}
+	if slices.Contains(fields, "") {
+		fields = slices.Collect(maps.Keys(workFieldTypes))
+	}
+
+	rs := &recordState{
+		recordType: RecordTypeWork,
+		id:         workID,
+		fields:     make(map[string]any),
+		assertions: make(map[string][]assertion),
+	}
+	state := updateState{records: map[ID]*recordState{workID: rs}}
+	if err := fetchFieldState(ctx, tx, state, map[recordKey][]string{{RecordTypeWork, workID}: fields}); err != nil {
+		return nil, nil, fmt.Errorf("holdLockedWorkFields: %w", err)
+	}
+
+	newVals := assertionRowFields(rows)
+	held := make(map[string]bool)
+	batch := &pgx.Batch{}
+	for _, field := range fields {
+		ft, err := resolveFieldType(RecordTypeWork, field)
+		if err != nil {
+			return nil, nil, fmt.Errorf("holdLockedWorkFields: %w", err)
+		}
+		pinned, hasPinned := rs.fields[field]
+		val, hasVal := newVals[field]
+
+		if hasPinned == hasVal && (!hasVal || ft.equal(pinned, val)) {
+			continue
+		}
+
+		// Only a source that asserts (or asserted) the field can disagree with it.
+		asserted := hasVal
+		for _, a := range rs.assertions[field] {
+			if a.sourceRecordID != nil && *a.sourceRecordID == sourceRecordID {
+				asserted = true
+				break
+			}
+		}
+		if !asserted {
+			continue
+		}
+
+		var lockedVal, sourceVal []byte
+		if hasPinned {
+			if lockedVal, err = json.Marshal(pinned); err != nil {
+				return nil, nil, fmt.Errorf("holdLockedWorkFields: %w", err)
+			}
+		}
+		if hasVal {
+			if sourceVal, err = json.Marshal(val); err != nil {
+				return nil, nil, fmt.Errorf("holdLockedWorkFields: %w", err)
+			}
+		}
+		batch.Queue(`
+			INSERT INTO bbl_work_lock_conflicts (work_id, field, work_source_id, rev_id, locked_val, source_val)
+			SELECT $1::uuid, $2::text, $3::uuid, $4::bigint, $5::jsonb, $6::jsonb
+			WHERE NOT EXISTS (
+				SELECT 1 FROM bbl_work_lock_conflicts
+				WHERE work_id = $1 AND field = $2 AND work_source_id = $3
+				  AND resolution = 'dismissed' AND source_val IS NOT DISTINCT FROM $6::jsonb
+			)
+			ON CONFLICT (work_id, field, work_source_id) WHERE resolved_at IS NULL
+			DO UPDATE SET rev_id = EXCLUDED.rev_id, locked_val = EXCLUDED.locked_val,
+			              source_val = EXCLUDED.source_val, updated_at = transaction_timestamp()`,
+			workID, field, sourceRecordID, revID, lockedVal, sourceVal)
+		held[field] = true
+	}
+	if len(held) == 0 {
+		return rows, nil, nil
+	}
+
+	results := tx.SendBatch(ctx, batch)
+	for i := 0; i < batch.Len(); i++ {
+		if _, err := results.Exec(); err != nil {
+			results.Close()
+			return nil, nil, fmt.Errorf("holdLockedWorkFields: %w", err)
+		}
+	}
+	if err := results.Close(); err != nil {
+		return nil, nil, fmt.Errorf("holdLockedWorkFields: %w", err)
+	}
+
+	kept := rows[:0:0]
+	for _, row := range rows {
+		if !held[row.field] {
+			kept = append(kept, row)
+		}
+	}
+	heldFields := make([]string, 0, len(held))
+	for field := range held {
+		heldFields = append(heldFields, field)
+	}
+	return kept, heldFields, nil
+}
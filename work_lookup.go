@@ -0,0 +1,110 @@
+package bbl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// WorkIdentifierSource is implemented by work get sources whose source IDs
+// are persistent identifiers of a single scheme, e.g. arXiv IDs or DOIs.
+// They can be used to look up a work from a pasted identifier.
+type WorkIdentifierSource interface {
+	WorkSourceGetter
+	IdentifierScheme() string
+}
+
+// lookupSchemes are the schemes of a pasted identifier, in the order they
+// are tried for a value without prefix.
+var lookupSchemes = []string{"doi", "arxiv", "pubmed"}
+
+// lookupPrefixes are the prefixes and URL forms that name the scheme of a
+// pasted identifier. NormalizeIdentifier strips them.
+var lookupPrefixes = []struct {
+	prefix string
+	scheme string
+}{
+	{"https://doi.org/", "doi"},
+	{"http://doi.org/", "doi"},
+	{"https://dx.doi.org/", "doi"},
+	{"http://dx.doi.org/", "doi"},
+	{"doi:", "doi"},
+	{"https://arxiv.org/abs/", "arxiv"},
+	{"http://arxiv.org/abs/", "arxiv"},
+	{"arxiv:", "arxiv"},
+	{"https://pubmed.ncbi.nlm.nih.gov/", "pubmed"},
+	{"pmid:", "pubmed"},
+	{"pubmed:", "pubmed"},
+}
+
+// ParseWorkIdentifier recognizes a pasted arXiv ID, DOI or PMID, with or
+// without a scheme prefix or resolver URL, and returns it normalized.
+func ParseWorkIdentifier(s string) (Identifier, bool) {
+	s = strings.TrimSpace(s)
+	schemes := lookupSchemes
+	for _, p := range lookupPrefixes {
+		if len(s) >= len(p.prefix) && strings.EqualFold(s[:len(p.prefix)], p.prefix) {
+			schemes = []string{p.scheme}
+			break
+		}
+	}
+	for _, scheme := range schemes {
+		if v, err := NormalizeIdentifier(scheme, s); err == nil {
+			return Identifier{Scheme: scheme, Val: v}, true
+		}
+	}
+	return Identifier{}, false
+}
+
+// WorkLookup is a work fetched from a get source by identifier. Existing is
+// a work in the repository that already has one of its identifiers.
+type WorkLookup struct {
+	Source     string
+	Identifier Identifier
+	Work       *ImportWorkInput
+	Existing   *Work
+}
+
+// WorkIdentifierSource returns the get source that looks up identifiers of
+// the given scheme.
+func (s *Services) WorkIdentifierSource(scheme string) (string, WorkIdentifierSource, bool) {
+	for name, src := range s.WorkGetSources {
+		if is, ok := src.(WorkIdentifierSource); ok && is.IdentifierScheme() == scheme {
+			return name, is, true
+		}
+	}
+	return "", nil, false
+}
+
+// LookupWork fetches the work identified by a pasted identifier from the
+// matching get source and checks whether it is already in the repository.
+func (s *Services) LookupWork(ctx context.Context, identifier string) (*WorkLookup, error) {
+	id, ok := ParseWorkIdentifier(identifier)
+	if !ok {
+		return nil, fmt.Errorf("LookupWork: unrecognized identifier %q", identifier)
+	}
+	name, src, ok := s.WorkIdentifierSource(id.Scheme)
+	if !ok {
+		return nil, fmt.Errorf("LookupWork: no source for %s identifiers", id.Scheme)
+	}
+	rec, err := src.Get(ctx, id.Val)
+	if err != nil {
+		return nil, fmt.Errorf("LookupWork: %w", err)
+	}
+	l := &WorkLookup{Source: name, Identifier: id, Work: rec}
+	for _, i := range append([]Identifier{id}, rec.Identifiers...) {
+		w, err := s.Repo.GetWorkByIdentifier(ctx, i.Scheme, i.Val)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("LookupWork: %w", err)
+		}
+		if w.Status != WorkStatusDeleted {
+			l.Existing = w
+			break
+		}
+	}
+	return l, nil
+}
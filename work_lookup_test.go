@@ -0,0 +1,67 @@
+package bbl
+
+import (
+	"context"
+	"testing"
+)
+
+func TestParseWorkIdentifier(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Identifier
+		ok   bool
+	}{
+		{"10.1000/xyz123", Identifier{"doi", "10.1000/xyz123"}, true},
+		{" doi:10.1000/xyz123 ", Identifier{"doi", "10.1000/xyz123"}, true},
+		{"https://doi.org/10.1000/xyz123", Identifier{"doi", "10.1000/xyz123"}, true},
+		{"10.1000/XYZ123", Identifier{"doi", "10.1000/xyz123"}, true},
+		{"2101.00001", Identifier{"arxiv", "2101.00001"}, true},
+		{"2101.00001v2", Identifier{"arxiv", "2101.00001v2"}, true},
+		{"hep-th/9901001", Identifier{"arxiv", "hep-th/9901001"}, true},
+		{"arXiv:2101.00001", Identifier{"arxiv", "2101.00001"}, true},
+		{"https://arxiv.org/abs/2101.00001", Identifier{"arxiv", "2101.00001"}, true},
+		{"12345678", Identifier{"pubmed", "12345678"}, true},
+		{"PMID:12345678", Identifier{"pubmed", "12345678"}, true},
+		{"pmid:0012345678", Identifier{"pubmed", "12345678"}, true},
+		{"https://pubmed.ncbi.nlm.nih.gov/12345678/", Identifier{"pubmed", "12345678"}, true},
+		{"doi:", Identifier{}, false},
+		{"some title", Identifier{}, false},
+		{"", Identifier{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseWorkIdentifier(tt.in)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("ParseWorkIdentifier(%q) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+type testIdentifierSource struct{ scheme string }
+
+func (s testIdentifierSource) IdentifierScheme() string { return s.scheme }
+
+func (s testIdentifierSource) Get(ctx context.Context, id string) (*ImportWorkInput, error) {
+	return &ImportWorkInput{SourceID: id}, nil
+}
+
+type testGetSource struct{}
+
+func (testGetSource) Get(ctx context.Context, id string) (*ImportWorkInput, error) {
+	return &ImportWorkInput{SourceID: id}, nil
+}
+
+func TestWorkIdentifierSource(t *testing.T) {
+	s := &Services{WorkGetSources: map[string]WorkSourceGetter{
+		"arxiv": testIdentifierSource{"arxiv"},
+		"other": testGetSource{},
+	}}
+	if name, _, ok := s.WorkIdentifierSource("arxiv"); !ok || name != "arxiv" {
+		t.Errorf("arxiv: got %q, %v", name, ok)
+	}
+	if _, _, ok := s.WorkIdentifierSource("doi"); ok {
+		t.Error("doi: expected no source")
+	}
+	if _, err := s.LookupWork(context.Background(), "10.1000/xyz123"); err == nil {
+		t.Error("LookupWork without a doi source: expected error")
+	}
+}
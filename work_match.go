@@ -0,0 +1,275 @@
+package bbl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Work match modes. A record that is new to a source can be matched with
+// the works other sources already have by a strong identifier.
+const (
+	// WorkMatchNone always creates a new work.
+	WorkMatchNone = "none"
+	// WorkMatchAttach attaches the record to the work it matches. If it
+	// matches more than one work, or a work that already has a record of
+	// the source, a new work is created and reported as a possible
+	// duplicate of the works it matches.
+	WorkMatchAttach = "attach"
+	// WorkMatchReview creates a new work and reports it as a possible
+	// duplicate of the works it matches.
+	WorkMatchReview = "review"
+)
+
+// DefaultWorkMatchSchemes are the identifier schemes records are matched by
+// if a policy names none. ISBNs only match works of the same kind, since
+// chapters share the ISBN of their book.
+var DefaultWorkMatchSchemes = []string{"doi", "pubmed", "arxiv", "isbn"}
+
+// WorkMatchPolicy controls how a source's new records are matched with
+// existing works.
+type WorkMatchPolicy struct {
+	Mode    string   `json:"mode"`
+	Schemes []string `json:"schemes,omitempty"` // empty = DefaultWorkMatchSchemes
+}
+
+// Validate checks the mode and schemes of the policy.
+func (p WorkMatchPolicy) Validate() error {
+	switch p.Mode {
+	case WorkMatchNone, WorkMatchAttach, WorkMatchReview:
+	default:
+		return fmt.Errorf("invalid match mode %q", p.Mode)
+	}
+	for _, scheme := range p.Schemes {
+		if !slices.Contains(DefaultWorkMatchSchemes, scheme) {
+			return fmt.Errorf("invalid match scheme %q: use one of %s", scheme, strings.Join(DefaultWorkMatchSchemes, ", "))
+		}
+	}
+	return nil
+}
+
+// SetSourceMatchPolicy stores the match policy of a source. An empty mode
+// is WorkMatchNone.
+func (r *Repo) SetSourceMatchPolicy(ctx context.Context, source string, p WorkMatchPolicy) error {
+	if p.Mode == "" {
+		p.Mode = WorkMatchNone
+	}
+	if err := p.Validate(); err != nil {
+		return fmt.Errorf("SetSourceMatchPolicy: %w", err)
+	}
+	tag, err := r.db.Exec(ctx, `
+		UPDATE bbl_sources SET match_mode = $2, match_schemes = $3
+		WHERE id = $1`,
+		source, p.Mode, p.Schemes)
+	if err != nil {
+		return fmt.Errorf("SetSourceMatchPolicy: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("SetSourceMatchPolicy: source %s: %w", source, ErrNotFound)
+	}
+	return nil
+}
+
+// GetSourceMatchPolicy returns the match policy of a source.
+func (r *Repo) GetSourceMatchPolicy(ctx context.Context, source string) (WorkMatchPolicy, error) {
+	p, err := getSourceMatchPolicy(ctx, r.db, source)
+	if err != nil {
+		return p, fmt.Errorf("GetSourceMatchPolicy: %w", err)
+	}
+	return p, nil
+}
+
+func getSourceMatchPolicy(ctx context.Context, q interface {
+	QueryRow(context.Context, string, ...any) pgx.Row
+}, source string) (WorkMatchPolicy, error) {
+	var p WorkMatchPolicy
+	err := q.QueryRow(ctx, `
+		SELECT match_mode, coalesce(match_schemes, '{}') FROM bbl_sources WHERE id = $1`,
+		source).Scan(&p.Mode, &p.Schemes)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return WorkMatchPolicy{Mode: WorkMatchNone}, nil
+	}
+	if err != nil {
+		return p, err
+	}
+	if len(p.Schemes) == 0 {
+		p.Schemes = nil
+	}
+	return p, nil
+}
+
+// workMatch is the outcome of matching a new source record.
+type workMatch struct {
+	workIDs []ID            // matching works
+	reasons map[ID][]string // duplicate reasons per matching work
+	attach  bool            // attach the record to workIDs[0]
+}
+
+// matchWork looks up the works that share a normalized identifier of one
+// of the policy's schemes with in. Deleted works don't match.
+func matchWork(ctx context.Context, tx pgx.Tx, source string, in *ImportWorkInput) (workMatch, error) {
+	var m workMatch
+	p, err := getSourceMatchPolicy(ctx, tx, source)
+	if err != nil || p.Mode == WorkMatchNone {
+		return m, err
+	}
+	schemes := p.Schemes
+	if len(schemes) == 0 {
+		schemes = DefaultWorkMatchSchemes
+	}
+
+	for _, id := range in.Identifiers {
+		scheme := strings.ToLower(id.Scheme)
+		if scheme == "pmid" {
+			scheme = "pubmed"
+		}
+		if !slices.Contains(schemes, scheme) {
+			continue
+		}
+		var ids []ID
+		switch scheme {
+		case "isbn":
+			ids, err = matchWorkISBN(ctx, tx, in.Kind, id.Val)
+		default:
+			ids, err = matchWorkIdentifier(ctx, tx, scheme, id.Val)
+		}
+		if err != nil {
+			return m, err
+		}
+		reason := matchDuplicateReasons[scheme]
+		for _, workID := range ids {
+			if m.reasons == nil {
+				m.reasons = make(map[ID][]string)
+			}
+			if !slices.Contains(m.workIDs, workID) {
+				m.workIDs = append(m.workIDs, workID)
+			}
+			if !slices.Contains(m.reasons[workID], reason) {
+				m.reasons[workID] = append(m.reasons[workID], reason)
+			}
+		}
+	}
+	if p.Mode != WorkMatchAttach || len(m.workIDs) != 1 {
+		return m, nil
+	}
+
+	// A work gets at most one record of a source.
+	var taken bool
+	if err := tx.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM bbl_work_sources
+			WHERE work_id = $1 AND source = $2 AND withdrawn_at IS NULL
+		)`, m.workIDs[0], source).Scan(&taken); err != nil {
+		return m, err
+	}
+	m.attach = !taken
+	return m, nil
+}
+
+// matchDuplicateReasons are the duplicate reasons of a match by scheme.
+var matchDuplicateReasons = map[string]string{
+	"doi":    DuplicateReasonDOI,
+	"pubmed": DuplicateReasonPMID,
+	"arxiv":  DuplicateReasonIdentifier,
+	"isbn":   DuplicateReasonISBN,
+}
+
+// matchIdentifierVariants are the prefixes an identifier is commonly
+// stored with.
+var matchIdentifierVariants = map[string][]string{
+	"doi":    {"doi:", "https://doi.org/", "http://doi.org/", "https://dx.doi.org/", "http://dx.doi.org/"},
+	"pubmed": {"pmid:"},
+	"arxiv":  {"arxiv:", "https://arxiv.org/abs/", "http://arxiv.org/abs/"},
+}
+
+func matchWorkIdentifier(ctx context.Context, tx pgx.Tx, scheme, val string) ([]ID, error) {
+	var v string
+	schemes := []string{scheme}
+	switch scheme {
+	case "doi":
+		v = normalizeDOI(val)
+	case "pubmed":
+		v = normalizePMID(val)
+		schemes = append(schemes, "pmid")
+	case "arxiv":
+		v = normalizeArxiv(val)
+	}
+	if v == "" {
+		return nil, nil
+	}
+	v = strings.ToLower(v)
+	vals := []string{v}
+	for _, prefix := range matchIdentifierVariants[scheme] {
+		vals = append(vals, prefix+v)
+	}
+	rows, err := tx.Query(ctx, `
+		SELECT DISTINCT a.work_id
+		FROM bbl_work_assertions a
+		JOIN bbl_works w ON w.id = a.work_id
+		WHERE a.field = 'identifiers' AND NOT a.hidden AND w.status <> $3
+		  AND lower(a.val->>'scheme') = ANY($1) AND lower(a.val->>'val') = ANY($2)
+		ORDER BY a.work_id`,
+		schemes, vals, WorkStatusDeleted)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[ID])
+}
+
+// matchWorkISBN matches the ISBN-13 form of val. Stored ISBNs are
+// normalized to it on write.
+func matchWorkISBN(ctx context.Context, tx pgx.Tx, kind, val string) ([]ID, error) {
+	v := normalizeISBN(val)
+	if v == "" {
+		return nil, nil
+	}
+	rows, err := tx.Query(ctx, `
+		SELECT DISTINCT a.work_id
+		FROM bbl_work_assertions a
+		JOIN bbl_works w ON w.id = a.work_id
+		WHERE a.field = 'identifiers' AND NOT a.hidden AND w.status <> $3 AND w.kind = $2
+		  AND lower(a.val->>'scheme') = 'isbn' AND lower(a.val->>'val') = $1
+		ORDER BY a.work_id`,
+		v, kind, WorkStatusDeleted)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[ID])
+}
+
+// addMatchDuplicates adds the new works that match existing works to the
+// duplicates report, as pairs with the confidence of their strongest
+// reason. Duplicate detection rescores the pairs later on.
+func addMatchDuplicates(ctx context.Context, tx pgx.Tx, matches map[ID]map[ID][]string) error {
+	var ids, otherIDs []ID
+	var confidences []float64
+	var reasons []string
+	for workID, matched := range matches {
+		for otherID, rr := range matched {
+			id, other := workID, otherID
+			if bytes.Compare(id[:], other[:]) > 0 {
+				id, other = other, id
+			}
+			p := scoreDuplicate(rr)
+			ids = append(ids, id)
+			otherIDs = append(otherIDs, other)
+			confidences = append(confidences, p.confidence)
+			reasons = append(reasons, strings.Join(p.reasons, ","))
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := tx.Exec(ctx, `
+		INSERT INTO bbl_duplicates (record_type, id, other_id, confidence, reasons)
+		SELECT $1, p.id, p.other_id, p.confidence, string_to_array(p.reasons, ',')
+		FROM unnest($2::uuid[], $3::uuid[], $4::real[], $5::text[]) AS p(id, other_id, confidence, reasons)
+		ON CONFLICT (record_type, id, other_id) DO NOTHING`,
+		RecordTypeWork, ids, otherIDs, confidences, reasons)
+	return err
+}
@@ -0,0 +1,15 @@
+package bbl
+
+import "testing"
+
+func TestWorkMatchPolicyValidate(t *testing.T) {
+	if err := (WorkMatchPolicy{Mode: WorkMatchAttach, Schemes: []string{"doi", "isbn"}}).Validate(); err != nil {
+		t.Errorf("valid policy: %v", err)
+	}
+	if err := (WorkMatchPolicy{Mode: "merge"}).Validate(); err == nil {
+		t.Error("expected an invalid mode to fail")
+	}
+	if err := (WorkMatchPolicy{Mode: WorkMatchReview, Schemes: []string{"issn"}}).Validate(); err == nil {
+		t.Error("expected an invalid scheme to fail")
+	}
+}
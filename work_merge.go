@@ -0,0 +1,64 @@
+package bbl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// GetWorkRedirect returns the ID of the work a merged work redirects to.
+// Returns ErrNotFound if the work was not merged.
+func (r *Repo) GetWorkRedirect(ctx context.Context, id ID) (ID, error) {
+	var workID ID
+	err := r.db.QueryRow(ctx, `SELECT work_id FROM bbl_work_redirects WHERE id = $1`, id).Scan(&workID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ID{}, ErrNotFound
+	}
+	if err != nil {
+		return ID{}, fmt.Errorf("GetWorkRedirect: %w", err)
+	}
+	return workID, nil
+}
+
+// fetchMergeRelated finds the works with rels to a work that is being merged,
+// records them on the MergeWorks updater and adds them to needs so their rows
+// are locked and their version and cache are updated with the merge.
+func fetchMergeRelated(ctx context.Context, tx pgx.Tx, needs *updateNeeds, muts []updater) error {
+	merges := make(map[ID][]*MergeWorks)
+	var mergedIDs []ID
+	for _, m := range muts {
+		if u, ok := m.(*MergeWorks); ok {
+			merges[u.MergedID] = append(merges[u.MergedID], u)
+			mergedIDs = append(mergedIDs, u.MergedID)
+		}
+	}
+	if len(mergedIDs) == 0 {
+		return nil
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT DISTINCT r.related_work_id, a.work_id
+		FROM bbl_work_assertion_rels r
+		JOIN bbl_work_assertions a ON a.id = r.assertion_id
+		WHERE r.related_work_id = ANY($1)`, mergedIDs)
+	if err != nil {
+		return fmt.Errorf("fetchMergeRelated: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var mergedID, workID ID
+		if err := rows.Scan(&mergedID, &workID); err != nil {
+			return fmt.Errorf("fetchMergeRelated: %w", err)
+		}
+		for _, u := range merges[mergedID] {
+			u.related = append(u.related, workID)
+		}
+		needs.workIDs = append(needs.workIDs, workID)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("fetchMergeRelated: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package bbl
+
+import (
+	"context"
+	"iter"
+	"testing"
+)
+
+func TestUpdateMergeWorks(t *testing.T) {
+	repo := testRepo(t)
+	repo.Profiles = testProfiles(t)
+	ctx := context.Background()
+	user := createTestUser(t, repo, RoleUser)
+	curator := createTestUser(t, repo, RoleCurator)
+
+	workID, mergedID := newID(), newID()
+	if _, _, err := repo.Update(ctx, user,
+		&CreateWork{ID: workID, Kind: "journal_article"},
+		&Set{RecordType: RecordTypeWork, RecordID: workID, Field: "titles", Val: []Title{{Lang: "eng", Val: "Paper"}}},
+		&CreateWork{ID: mergedID, Kind: "journal_article"},
+		&Set{RecordType: RecordTypeWork, RecordID: mergedID, Field: "titles", Val: []Title{{Lang: "eng", Val: "Duplicate"}}},
+		&Set{RecordType: RecordTypeWork, RecordID: mergedID, Field: "volume", Val: "42"},
+	); err != nil {
+		t.Fatalf("create works: %v", err)
+	}
+
+	if _, _, err := repo.Update(ctx, user, &MergeWorks{WorkID: workID, MergedID: mergedID}); err == nil {
+		t.Fatal("expected merge by a regular user to fail")
+	}
+	if _, _, err := repo.Update(ctx, curator, &MergeWorks{WorkID: workID, MergedID: mergedID}); err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+
+	work, err := repo.GetWork(ctx, workID)
+	if err != nil {
+		t.Fatalf("get work: %v", err)
+	}
+	if len(work.Titles) != 1 || work.Titles[0].Val != "Paper" {
+		t.Errorf("titles = %v, want the survivor's title", work.Titles)
+	}
+	if work.Volume != "42" {
+		t.Errorf("volume = %q, want %q", work.Volume, "42")
+	}
+
+	merged, err := repo.GetWork(ctx, mergedID)
+	if err != nil {
+		t.Fatalf("get merged work: %v", err)
+	}
+	if merged.Status != WorkStatusDeleted || merged.DeleteKind != WorkDeleteMerged {
+		t.Errorf("merged work = %s/%s, want %s/%s", merged.Status, merged.DeleteKind, WorkStatusDeleted, WorkDeleteMerged)
+	}
+	redirect, err := repo.GetWorkRedirect(ctx, mergedID)
+	if err != nil {
+		t.Fatalf("get redirect: %v", err)
+	}
+	if redirect != workID {
+		t.Errorf("redirect = %s, want %s", redirect, workID)
+	}
+
+	var n int
+	if err := repo.db.QueryRow(ctx, `SELECT count(*) FROM bbl_revs r JOIN bbl_work_redirects d ON d.rev_id = r.id WHERE d.id = $1`, mergedID).Scan(&n); err != nil {
+		t.Fatalf("count revs: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("merge revisions = %d, want 1", n)
+	}
+}
+
+func TestUpdateMergeLockedWorks(t *testing.T) {
+	repo := testRepo(t)
+	repo.Profiles = testProfiles(t)
+	ctx := context.Background()
+
+	if err := repo.UpsertSource(ctx, "test-source"); err != nil {
+		t.Fatalf("upsert source: %v", err)
+	}
+	curator := createTestUser(t, repo, RoleCurator)
+
+	importVolume := func(volume string) {
+		t.Helper()
+		rec := &ImportWorkInput{
+			SourceID:     "work-merged-locked",
+			Kind:         "journal_article",
+			Volume:       volume,
+			SourceRecord: []byte(`{}`),
+			Titles:       []Title{{Lang: "eng", Val: "Locked Duplicate"}},
+		}
+		seq := func(yield func(*ImportWorkInput, error) bool) { yield(rec, nil) }
+		if _, err := repo.ImportWorks(ctx, "test-source", iter.Seq2[*ImportWorkInput, error](seq)); err != nil {
+			t.Fatalf("import works: %v", err)
+		}
+	}
+
+	importVolume("1")
+	var mergedID ID
+	if err := repo.db.QueryRow(ctx, `
+		SELECT work_id FROM bbl_work_sources
+		WHERE source = $1 AND source_id = $2`, "test-source", "work-merged-locked").Scan(&mergedID); err != nil {
+		t.Fatalf("lookup work by source: %v", err)
+	}
+
+	workID := newID()
+	if _, _, err := repo.Update(ctx, curator,
+		&CreateWork{ID: workID, Kind: "journal_article"},
+		&Set{RecordType: RecordTypeWork, RecordID: workID, Field: "titles", Val: []Title{{Lang: "eng", Val: "Paper"}}},
+		&LockWork{WorkID: workID, Fields: []string{"titles"}, Note: "survivor"},
+	); err != nil {
+		t.Fatalf("create work: %v", err)
+	}
+	if _, _, err := repo.Update(ctx, curator,
+		&LockWork{WorkID: mergedID, Fields: []string{"titles", "volume"}, Note: "merged"},
+	); err != nil {
+		t.Fatalf("lock merged work: %v", err)
+	}
+
+	// Queue a conflict on the merged work.
+	importVolume("2")
+	if conflicts, err := repo.GetWorkLockConflicts(ctx, mergedID); err != nil || len(conflicts) != 1 {
+		t.Fatalf("merged work conflicts = %d (%v), want 1", len(conflicts), err)
+	}
+
+	if _, _, err := repo.Update(ctx, curator, &MergeWorks{WorkID: workID, MergedID: mergedID}); err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+
+	locks, err := repo.GetWorkLocks(ctx, workID)
+	if err != nil {
+		t.Fatalf("get locks: %v", err)
+	}
+	notes := make(map[string]string)
+	for _, l := range locks {
+		notes[l.Field] = l.Note
+	}
+	if len(notes) != 2 || notes["titles"] != "survivor" || notes["volume"] != "merged" {
+		t.Errorf("survivor locks = %v, want titles locked by the survivor and volume moved", notes)
+	}
+
+	conflicts, err := repo.GetWorkLockConflicts(ctx, workID)
+	if err != nil {
+		t.Fatalf("get lock conflicts: %v", err)
+	}
+	if len(conflicts) != 1 || conflicts[0].Field != "volume" || string(conflicts[0].SourceVal) != `"2"` {
+		t.Errorf("survivor conflicts = %+v, want the moved volume conflict", conflicts)
+	}
+	if conflicts, _ := repo.GetWorkLockConflicts(ctx, mergedID); len(conflicts) != 0 {
+		t.Errorf("merged work conflicts = %d, want 0", len(conflicts))
+	}
+}
+
+func TestListPublicWorksIncludeDeleted(t *testing.T) {
+	repo := testRepo(t)
+	repo.Profiles = testProfiles(t)
+	ctx := context.Background()
+	user := createTestUser(t, repo, RoleUser)
+
+	publicID, privateID := newID(), newID()
+	if _, _, err := repo.Update(ctx, user,
+		&CreateWork{ID: publicID, Kind: "journal_article", Status: WorkStatusPublic},
+		&Set{RecordType: RecordTypeWork, RecordID: publicID, Field: "titles", Val: []Title{{Lang: "eng", Val: "Public"}}},
+		&Set{RecordType: RecordTypeWork, RecordID: publicID, Field: "journal_title", Val: "Journal"},
+		&Set{RecordType: RecordTypeWork, RecordID: publicID, Field: "publication_year", Val: "2024"},
+		&CreateWork{ID: privateID, Kind: "journal_article"},
+		&Set{RecordType: RecordTypeWork, RecordID: privateID, Field: "titles", Val: []Title{{Lang: "eng", Val: "Private"}}},
+	); err != nil {
+		t.Fatalf("create works: %v", err)
+	}
+	if _, _, err := repo.Update(ctx, user,
+		&DeleteWork{WorkID: publicID, DeleteKind: "withdrawn"},
+		&DeleteWork{WorkID: privateID, DeleteKind: "withdrawn"},
+	); err != nil {
+		t.Fatalf("delete works: %v", err)
+	}
+
+	ids := []ID{publicID, privateID}
+	res, err := repo.ListPublicWorks(ctx, ListPublicWorksOpts{IDs: ids, Limit: 10})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(res.Works) != 0 {
+		t.Errorf("listed %d deleted works, want 0", len(res.Works))
+	}
+	res, err = repo.ListPublicWorks(ctx, ListPublicWorksOpts{IDs: ids, Limit: 10, IncludeDeleted: true})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(res.Works) != 1 || res.Works[0].ID != publicID || res.Works[0].Status != WorkStatusDeleted {
+		t.Errorf("works = %v, want only the deleted public work", res.Works)
+	}
+}
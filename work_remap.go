@@ -0,0 +1,268 @@
+package bbl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// RemapOpts selects the stored source records to remap.
+type RemapOpts struct {
+	Since  time.Time // only records ingested since; zero = all
+	DryRun bool      // report the diffs without writing them
+}
+
+// RemapDiff lists the values a source record asserts that change when the
+// stored record is mapped again. Error is set if the record could not be
+// mapped or the new values were rejected; nothing is written for it then.
+type RemapDiff struct {
+	WorkID   ID          `json:"work_id"`
+	SourceID string      `json:"source_id"`
+	Fields   []FieldDiff `json:"fields,omitempty"`
+	Error    string      `json:"error,omitempty"`
+}
+
+// FieldDiff is the old and new value of a field, null if there is none.
+// Collections are compared as a whole. Linked people, projects and
+// organizations are compared by their inline values.
+type FieldDiff struct {
+	Field string          `json:"field"`
+	Old   json.RawMessage `json:"old"`
+	New   json.RawMessage `json:"new"`
+}
+
+type remapRecord struct {
+	id       ID
+	workID   ID
+	sourceID string
+	record   []byte
+	kind     string
+}
+
+// RemapWorks maps the stored records of a source again with mapper and
+// replaces the source's assertions where they differ, as a re-import would.
+// It yields a diff for every record that changes. Records are processed in
+// batches, each in its own transaction.
+func (r *Repo) RemapWorks(ctx context.Context, source string, mapper WorkSourceMapper, opts RemapOpts) iter.Seq2[*RemapDiff, error] {
+	return func(yield func(*RemapDiff, error) bool) {
+		var after ID
+		for {
+			diffs, last, done, err := r.remapWorkBatch(ctx, source, mapper, opts, after)
+			if err != nil {
+				yield(nil, fmt.Errorf("RemapWorks: %w", err))
+				return
+			}
+			for _, d := range diffs {
+				if !yield(d, nil) {
+					return
+				}
+			}
+			if done {
+				return
+			}
+			after = last
+		}
+	}
+}
+
+func (r *Repo) remapWorkBatch(ctx context.Context, source string, mapper WorkSourceMapper, opts RemapOpts, after ID) ([]*RemapDiff, ID, bool, error) {
+	const batchSize = 250
+
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, after, false, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+		SELECT s.id, s.work_id, s.source_id, s.record, w.kind
+		FROM bbl_work_sources s
+		JOIN bbl_works w ON w.id = s.work_id
+		WHERE s.source = $1 AND s.withdrawn_at IS NULL AND w.status <> $2
+		  AND s.ingested_at >= $3 AND s.id > $4
+		ORDER BY s.id
+		LIMIT $5
+		FOR UPDATE OF s`,
+		source, WorkStatusDeleted, opts.Since, after, batchSize)
+	if err != nil {
+		return nil, after, false, err
+	}
+	recs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (remapRecord, error) {
+		var rec remapRecord
+		err := row.Scan(&rec.id, &rec.workID, &rec.sourceID, &rec.record, &rec.kind)
+		return rec, err
+	})
+	if err != nil {
+		return nil, after, false, err
+	}
+	if len(recs) == 0 {
+		return nil, after, true, nil
+	}
+
+	priorities, err := fetchSourcePriorities(ctx, tx)
+	if err != nil {
+		return nil, after, false, err
+	}
+	var revID int64
+	if err := tx.QueryRow(ctx, `
+		INSERT INTO bbl_revs (source) VALUES ($1) RETURNING id`,
+		source).Scan(&revID); err != nil {
+		return nil, after, false, err
+	}
+
+	var diffs []*RemapDiff
+	var changedWorkIDs []ID
+	for _, rec := range recs {
+		d := &RemapDiff{WorkID: rec.workID, SourceID: rec.sourceID}
+		in, err := mapper.Map(rec.record)
+		if err != nil {
+			d.Error = err.Error()
+			diffs = append(diffs, d)
+			continue
+		}
+		// The kind of a work is not asserted by sources.
+		in.SourceID = rec.sourceID
+		in.SourceRecord = rec.record
+		in.Kind = rec.kind
+
+		if d.Fields, err = remapFieldDiffs(ctx, tx, source, rec, in); err != nil {
+			return nil, after, false, fmt.Errorf("source_id=%s: %w", rec.sourceID, err)
+		}
+		if len(d.Fields) == 0 {
+			continue
+		}
+		diffs = append(diffs, d)
+
+		sp, err := tx.Begin(ctx)
+		if err != nil {
+			return nil, after, false, err
+		}
+		if _, err := r.importWorkRecord(ctx, sp, source, in, revID, priorities); err != nil {
+			d.Error = err.Error()
+			if err := sp.Rollback(ctx); err != nil {
+				return nil, after, false, err
+			}
+			continue
+		}
+		if err := sp.Commit(ctx); err != nil {
+			return nil, after, false, err
+		}
+		changedWorkIDs = append(changedWorkIDs, rec.workID)
+	}
+
+	last := recs[len(recs)-1].id
+	done := len(recs) < batchSize
+	if opts.DryRun {
+		return diffs, last, done, nil
+	}
+	if err := rebuildWorkCache(ctx, tx, changedWorkIDs); err != nil {
+		return nil, after, false, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, after, false, err
+	}
+	return diffs, last, done, nil
+}
+
+// remapFieldDiffs compares the values the source record asserts with the
+// values mapped from in.
+func remapFieldDiffs(ctx context.Context, tx pgx.Tx, source string, rec remapRecord, in *ImportWorkInput) ([]FieldDiff, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT field, val FROM bbl_work_assertions
+		WHERE work_source_id = $1
+		ORDER BY id`, rec.id)
+	if err != nil {
+		return nil, err
+	}
+	oldItems := make(map[string][]json.RawMessage)
+	var field string
+	var val json.RawMessage
+	if _, err := pgx.ForEachRow(rows, []any{&field, &val}, func() error {
+		oldItems[field] = append(oldItems[field], canonicalJSON(val))
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	newRows, err := workImportAssertions(ctx, tx, source, rec.workID, rec.id, in)
+	if err != nil {
+		return nil, err
+	}
+	newItems := make(map[string][]json.RawMessage)
+	for _, row := range newRows {
+		ft, err := resolveFieldType(RecordTypeWork, row.field)
+		if err != nil {
+			return nil, err
+		}
+		items, err := ft.marshal(row.val)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			newItems[row.field] = append(newItems[row.field], canonicalJSON(item))
+		}
+	}
+
+	fields := slices.Sorted(maps.Keys(oldItems))
+	for f := range newItems {
+		if _, ok := oldItems[f]; !ok {
+			fields = append(fields, f)
+		}
+	}
+	slices.Sort(fields)
+
+	var diffs []FieldDiff
+	for _, f := range fields {
+		ft, err := resolveFieldType(RecordTypeWork, f)
+		if err != nil {
+			return nil, err
+		}
+		oldVal, newVal := joinItems(ft, oldItems[f]), joinItems(ft, newItems[f])
+		if !bytes.Equal(oldVal, newVal) {
+			diffs = append(diffs, FieldDiff{Field: f, Old: oldVal, New: newVal})
+		}
+	}
+	return diffs, nil
+}
+
+// canonicalJSON re-encodes a JSON value with sorted keys, so that values
+// read back from jsonb compare equal to freshly marshaled ones.
+func canonicalJSON(b json.RawMessage) json.RawMessage {
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		return b
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return b
+	}
+	return out
+}
+
+// joinItems returns the items of a field as a JSON array for collections
+// and as a single value otherwise, null if there are none.
+func joinItems(ft *fieldType, items []json.RawMessage) json.RawMessage {
+	if len(items) == 0 {
+		return json.RawMessage("null")
+	}
+	if !ft.collection {
+		return items[0]
+	}
+	var b bytes.Buffer
+	b.WriteByte('[')
+	for i, item := range items {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.Write(item)
+	}
+	b.WriteByte(']')
+	return b.Bytes()
+}
@@ -0,0 +1,118 @@
+package bbl
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// WorkReview is a single review transition of a work.
+type WorkReview struct {
+	ID        ID
+	WorkID    ID
+	Seq       int
+	RevID     *int64
+	UserID    *ID
+	UserName  string // empty if the user was removed
+	Kind      string // submitted, picked_up, returned, approved
+	Body      string // message; required for returned
+	CreatedAt time.Time
+}
+
+// ReviewQueueOpts filters the review queue.
+type ReviewQueueOpts struct {
+	ReviewStatuses []string // default: pending and in_review
+	OrganizationID *ID      // only works with this organization pinned
+	Limit          int
+	Offset         int
+}
+
+// GetWorkReviews returns the review transitions of a work, oldest first.
+func (r *Repo) GetWorkReviews(ctx context.Context, workID ID) ([]WorkReview, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT r.id, r.work_id, r.seq, r.rev_id, r.user_id, u.name, r.kind, r.body, r.created_at
+		FROM bbl_work_reviews r
+		LEFT JOIN bbl_users u ON u.id = r.user_id
+		WHERE r.work_id = $1
+		ORDER BY r.seq`, workID)
+	if err != nil {
+		return nil, fmt.Errorf("GetWorkReviews: %w", err)
+	}
+	defer rows.Close()
+
+	var reviews []WorkReview
+	for rows.Next() {
+		var rv WorkReview
+		var revID pgtype.Int8
+		var userID pgtype.UUID
+		var userName pgtype.Text
+		if err := rows.Scan(&rv.ID, &rv.WorkID, &rv.Seq, &revID, &userID, &userName, &rv.Kind, &rv.Body, &rv.CreatedAt); err != nil {
+			return nil, fmt.Errorf("GetWorkReviews: %w", err)
+		}
+		if revID.Valid {
+			rv.RevID = &revID.Int64
+		}
+		if userID.Valid {
+			id := ID(userID.Bytes)
+			rv.UserID = &id
+		}
+		rv.UserName = userName.String
+		reviews = append(reviews, rv)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetWorkReviews: %w", err)
+	}
+	return reviews, nil
+}
+
+// GetReviewQueue returns works in review, longest waiting first, with the
+// total count. Works are filtered by their pinned organizations.
+func (r *Repo) GetReviewQueue(ctx context.Context, opts ReviewQueueOpts) ([]*Work, int, error) {
+	statuses := opts.ReviewStatuses
+	if len(statuses) == 0 {
+		statuses = []string{WorkReviewPending, WorkReviewInReview}
+	}
+	const where = `
+		WHERE w.review_status = ANY($1)
+		  AND ($2::uuid IS NULL OR EXISTS (
+		      SELECT 1 FROM bbl_work_assertions a
+		      JOIN bbl_work_assertion_organizations o ON o.assertion_id = a.id
+		      WHERE a.work_id = w.id AND a.field = 'organizations' AND a.pinned
+		        AND o.organization_id = $2))`
+
+	var total int
+	if err := r.db.QueryRow(ctx, `SELECT count(*) FROM bbl_works w`+where,
+		statuses, opts.OrganizationID).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("GetReviewQueue: %w", err)
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT w.id, w.version, w.created_at, w.updated_at,
+		       w.created_by_id, w.updated_by_id,
+		       w.kind, w.status, w.review_status, w.delete_kind,
+		       w.deleted_at, w.deleted_by_id,
+		       w.cache
+		FROM bbl_works w`+where+`
+		ORDER BY w.updated_at, w.id
+		LIMIT $3 OFFSET $4`,
+		statuses, opts.OrganizationID, opts.Limit, opts.Offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("GetReviewQueue: %w", err)
+	}
+	defer rows.Close()
+
+	var works []*Work
+	for rows.Next() {
+		w, err := scanWork(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("GetReviewQueue: %w", err)
+		}
+		works = append(works, w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("GetReviewQueue: %w", err)
+	}
+	return works, total, nil
+}
@@ -0,0 +1,213 @@
+package bbl
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// DefaultSweepMaxVanished is the fraction of a source's records that may
+// vanish in a single sweep.
+const DefaultSweepMaxVanished = 0.1
+
+// SweepOpts controls a work source sweep.
+type SweepOpts struct {
+	// MaxVanished aborts the sweep if more than this fraction of the
+	// source's records was not seen. Zero means DefaultSweepMaxVanished;
+	// 1 disables the check.
+	MaxVanished float64
+}
+
+// SweepResult reports what a work source sweep withdrew.
+type SweepResult struct {
+	Total     int  // source records that were not withdrawn before the sweep
+	Withdrawn int  // source records withdrawn
+	Flagged   int  // works flagged for review because no assertions are left
+	WorkIDs   []ID // works whose assertions changed
+}
+
+// StartSourceSweep returns the database time at the start of a full
+// harvest, to pass to SweepWorkSource once the harvest has completed.
+func (r *Repo) StartSourceSweep(ctx context.Context) (time.Time, error) {
+	var t time.Time
+	if err := r.db.QueryRow(ctx, `SELECT transaction_timestamp()`).Scan(&t); err != nil {
+		return t, fmt.Errorf("StartSourceSweep: %w", err)
+	}
+	return t, nil
+}
+
+// StartWorkSourceSweep is StartSourceSweep for a run of a work source that
+// starts from since. Only a full run sees all records of a source, so the
+// error wraps ErrSweepPartialRun if since is set or the source reports its
+// runs as partial.
+func (r *Repo) StartWorkSourceSweep(ctx context.Context, src WorkSourceIter, since string) (time.Time, error) {
+	if err := checkFullRun(src, since); err != nil {
+		return time.Time{}, fmt.Errorf("StartWorkSourceSweep: %w", err)
+	}
+	return r.StartSourceSweep(ctx)
+}
+
+func checkFullRun(src WorkSourceIter, since string) error {
+	if since != "" {
+		return fmt.Errorf("%w: the run starts from checkpoint %q", ErrSweepPartialRun, since)
+	}
+	if p, ok := src.(WorkSourcePartial); ok && p.Partial() {
+		return fmt.Errorf("%w: the source is limited to part of its records", ErrSweepPartialRun)
+	}
+	return nil
+}
+
+// SweepWorkSource withdraws the records of a source that were not seen
+// since startedAt, after a full harvest. Their assertions are deleted, as
+// for records the source reports as deleted, and the works are re-pinned.
+// Works left without any assertion are flagged for curator review. If
+// more records vanished than opts allow, nothing is withdrawn and the
+// error wraps ErrSweepThreshold.
+func (r *Repo) SweepWorkSource(ctx context.Context, source string, startedAt time.Time, opts SweepOpts) (*SweepResult, error) {
+	maxVanished := opts.MaxVanished
+	if maxVanished <= 0 {
+		maxVanished = DefaultSweepMaxVanished
+	}
+
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("SweepWorkSource: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	res := &SweepResult{}
+	if err := tx.QueryRow(ctx, `
+		SELECT count(*) FROM bbl_work_sources
+		WHERE source = $1 AND withdrawn_at IS NULL`,
+		source).Scan(&res.Total); err != nil {
+		return nil, fmt.Errorf("SweepWorkSource: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT work_id, id FROM bbl_work_sources
+		WHERE source = $1 AND withdrawn_at IS NULL AND last_seen_at < $2
+		ORDER BY work_id
+		FOR UPDATE`,
+		source, startedAt)
+	if err != nil {
+		return nil, fmt.Errorf("SweepWorkSource: %w", err)
+	}
+	type vanished struct{ workID, sourceRecordID ID }
+	gone, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (vanished, error) {
+		var v vanished
+		err := row.Scan(&v.workID, &v.sourceRecordID)
+		return v, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("SweepWorkSource: %w", err)
+	}
+	if len(gone) == 0 {
+		return res, nil
+	}
+	if float64(len(gone)) > maxVanished*float64(res.Total) {
+		return nil, fmt.Errorf("SweepWorkSource: %w: %d of %d %s records vanished",
+			ErrSweepThreshold, len(gone), res.Total, source)
+	}
+
+	priorities, err := fetchSourcePriorities(ctx, tx)
+	if err != nil {
+		return nil, fmt.Errorf("SweepWorkSource: %w", err)
+	}
+	for _, v := range gone {
+		ok, err := withdrawWorkSource(ctx, tx, v.workID, v.sourceRecordID, priorities)
+		if err != nil {
+			return nil, fmt.Errorf("SweepWorkSource: work_id=%s: %w", v.workID, err)
+		}
+		res.Withdrawn++
+		if ok {
+			res.WorkIDs = append(res.WorkIDs, v.workID)
+		}
+	}
+
+	if err := rebuildWorkCache(ctx, tx, res.WorkIDs); err != nil {
+		return nil, fmt.Errorf("SweepWorkSource: %w", err)
+	}
+	if res.Flagged, err = r.flagEmptyWorks(ctx, tx, source, res.WorkIDs); err != nil {
+		return nil, fmt.Errorf("SweepWorkSource: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("SweepWorkSource: %w", err)
+	}
+	return res, nil
+}
+
+// flagEmptyWorks puts the works without assertions in the review queue,
+// with a review entry by the system user. Works already in review keep
+// their review status.
+func (r *Repo) flagEmptyWorks(ctx context.Context, tx pgx.Tx, source string, workIDs []ID) (int, error) {
+	if len(workIDs) == 0 {
+		return 0, nil
+	}
+	rows, err := tx.Query(ctx, `
+		SELECT w.id, coalesce(w.review_status, '') FROM bbl_works w
+		WHERE w.id = ANY($1) AND w.status <> $2
+		  AND NOT EXISTS (SELECT 1 FROM bbl_work_assertions a WHERE a.work_id = w.id)`,
+		workIDs, WorkStatusDeleted)
+	if err != nil {
+		return 0, err
+	}
+	type emptyWork struct {
+		id           ID
+		reviewStatus string
+	}
+	empty, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (emptyWork, error) {
+		var w emptyWork
+		err := row.Scan(&w.id, &w.reviewStatus)
+		return w, err
+	})
+	if err != nil || len(empty) == 0 {
+		return 0, err
+	}
+
+	user, err := r.SystemUser(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var revID int64
+	if err := tx.QueryRow(ctx, `
+		INSERT INTO bbl_revs (user_id, source) VALUES ($1, $2) RETURNING id`,
+		user.ID, source).Scan(&revID); err != nil {
+		return 0, err
+	}
+	body := fmt.Sprintf("Withdrawn by source %s, no assertions left.", source)
+	for _, w := range empty {
+		reviewStatus := w.reviewStatus
+		if reviewStatus != WorkReviewPending && reviewStatus != WorkReviewInReview {
+			reviewStatus = WorkReviewPending
+		}
+		q, args := writeWorkReview(w.id, "", reviewStatus, WorkReviewKindFlagged, body, revID, user)
+		if _, err := tx.Exec(ctx, q, args...); err != nil {
+			return 0, err
+		}
+	}
+	return len(empty), nil
+}
+
+// SweepWorkSourceAndIndex sweeps a work source and best-effort indexes the
+// works that changed.
+func (s *Services) SweepWorkSourceAndIndex(ctx context.Context, source string, startedAt time.Time, opts SweepOpts) (*SweepResult, error) {
+	res, err := s.Repo.SweepWorkSource(ctx, source, startedAt, opts)
+	if err != nil || s.Index == nil || len(res.WorkIDs) == 0 {
+		return res, err
+	}
+	works, err := s.Repo.GetWorks(ctx, res.WorkIDs)
+	if err != nil {
+		slog.Error("SweepWorkSourceAndIndex", "err", err)
+		return res, nil
+	}
+	for _, w := range works {
+		if err := s.Index.Works().Add(ctx, w); err != nil {
+			slog.Error("SweepWorkSourceAndIndex", "work_id", w.ID, "err", err)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,32 @@
+package bbl
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"testing"
+)
+
+type testWorkSource struct {
+	partial bool
+}
+
+func (s *testWorkSource) Iter(ctx context.Context) (iter.Seq2[*ImportWorkInput, error], error) {
+	return func(yield func(*ImportWorkInput, error) bool) {}, nil
+}
+
+func (s *testWorkSource) Partial() bool {
+	return s.partial
+}
+
+func TestCheckFullRun(t *testing.T) {
+	if err := checkFullRun(&testWorkSource{}, ""); err != nil {
+		t.Errorf("full run: %v", err)
+	}
+	if err := checkFullRun(&testWorkSource{}, "2024-03-01T00:00:00Z"); !errors.Is(err, ErrSweepPartialRun) {
+		t.Errorf("run from a checkpoint: err = %v, want ErrSweepPartialRun", err)
+	}
+	if err := checkFullRun(&testWorkSource{partial: true}, ""); !errors.Is(err, ErrSweepPartialRun) {
+		t.Errorf("partial source: err = %v, want ErrSweepPartialRun", err)
+	}
+}
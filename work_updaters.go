@@ -1,6 +1,11 @@
 package bbl
 
-import "fmt"
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
 
 // CreateWork creates a new work entity.
 type CreateWork struct {
@@ -66,8 +71,788 @@ func (m *DeleteWork) apply(state updateState, user *User) (*updateEffect, error)
 
 func (m *DeleteWork) write(revID int64, user *User) (string, []any) {
 	return `UPDATE bbl_works
-		SET status = $2, delete_kind = $3,
+		SET status = $2, delete_kind = $3, deleted_status = status,
 		    deleted_at = transaction_timestamp(), deleted_by_id = $4
 		WHERE id = $1`,
 		[]any{m.WorkID, WorkStatusDeleted, nilIfEmpty(m.DeleteKind), &user.ID}
 }
+
+// MergeWorks merges a duplicate work into the surviving work. Source
+// records, their assertions and the merged work's human assertions move to
+// the survivor (a human assertion the survivor already has for the same
+// field wins), incoming rels are repointed and the survivor is re-pinned.
+// Curator locks move to the survivor unless it already locks the field, and
+// lock conflicts follow their source records.
+// The merged work is soft-deleted and redirects to the survivor.
+type MergeWorks struct {
+	WorkID   ID `json:"id"`        // surviving work
+	MergedID ID `json:"merged_id"` // duplicate, deleted after the merge
+
+	mergedStatus string // set by apply
+	related      []ID   // works with rels to MergedID; set by fetchState
+}
+
+func (m *MergeWorks) name() string { return "merge:work" }
+
+func (m *MergeWorks) needs() updateNeeds {
+	return updateNeeds{workIDs: []ID{m.WorkID, m.MergedID}}
+}
+
+func (m *MergeWorks) apply(state updateState, user *User) (*updateEffect, error) {
+	if user.Role != RoleCurator {
+		return nil, ErrForbidden
+	}
+	if m.WorkID == m.MergedID {
+		return nil, fmt.Errorf("MergeWorks: cannot merge work %s into itself", m.WorkID)
+	}
+	rs := state.records[m.WorkID]
+	if rs == nil {
+		return nil, fmt.Errorf("MergeWorks: work %s not found", m.WorkID)
+	}
+	merged := state.records[m.MergedID]
+	if merged == nil {
+		return nil, fmt.Errorf("MergeWorks: work %s not found", m.MergedID)
+	}
+	if rs.status == WorkStatusDeleted {
+		return nil, fmt.Errorf("MergeWorks: work %s is deleted", m.WorkID)
+	}
+	if merged.status == WorkStatusDeleted {
+		return nil, fmt.Errorf("MergeWorks: work %s is deleted", m.MergedID)
+	}
+	m.mergedStatus = merged.status
+	merged.status = WorkStatusDeleted
+	for f := range merged.locks {
+		if rs.locks == nil {
+			rs.locks = make(map[string]bool)
+		}
+		rs.locks[f] = true
+	}
+	merged.reviewStatus = ""
+
+	related := []ID{m.MergedID}
+	for _, id := range m.related {
+		if id != m.WorkID && id != m.MergedID {
+			related = append(related, id)
+		}
+	}
+	return &updateEffect{
+		recordType: RecordTypeWork,
+		recordID:   m.WorkID,
+		autoPinAll: true,
+		related:    related,
+	}, nil
+}
+
+func (m *MergeWorks) write(revID int64, user *User) (string, []any) {
+	// Data-modifying CTEs see the same snapshot, so every step is written
+	// against the pre-merge state. Assertions that would relate the survivor
+	// to itself stay with the merged work.
+	return `WITH src AS (
+			UPDATE bbl_work_sources SET work_id = $1 WHERE work_id = $2
+		), moved AS (
+			UPDATE bbl_work_assertions a SET work_id = $1, pinned = false
+			WHERE a.work_id = $2
+			  AND (a.work_source_id IS NOT NULL OR NOT EXISTS (
+			      SELECT 1 FROM bbl_work_assertions s
+			      WHERE s.work_id = $1 AND s.field = a.field AND s.user_id IS NOT NULL))
+			  AND NOT EXISTS (
+			      SELECT 1 FROM bbl_work_assertion_rels r
+			      WHERE r.assertion_id = a.id AND r.related_work_id = $1)
+		), self AS (
+			DELETE FROM bbl_work_assertions a
+			USING bbl_work_assertion_rels r
+			WHERE r.assertion_id = a.id AND a.work_id = $1 AND r.related_work_id = $2
+		), rels AS (
+			UPDATE bbl_work_assertion_rels r SET related_work_id = $1
+			FROM bbl_work_assertions a
+			WHERE a.id = r.assertion_id AND r.related_work_id = $2 AND a.work_id NOT IN ($1, $2)
+		), locks AS (
+			UPDATE bbl_work_locks l SET work_id = $1
+			WHERE l.work_id = $2
+			  AND NOT EXISTS (
+			      SELECT 1 FROM bbl_work_locks s
+			      WHERE s.work_id = $1 AND s.field = l.field)
+		), conflicts AS (
+			UPDATE bbl_work_lock_conflicts SET work_id = $1 WHERE work_id = $2
+		), chains AS (
+			UPDATE bbl_work_redirects SET work_id = $1 WHERE work_id = $2
+		), redirect AS (
+			INSERT INTO bbl_work_redirects (id, work_id, rev_id, status)
+			VALUES ($2, $1, $3, $5)
+		)
+		UPDATE bbl_works
+		SET status = 'deleted', delete_kind = $6, deleted_status = status, review_status = NULL,
+		    deleted_at = transaction_timestamp(), deleted_by_id = $4
+		WHERE id = $2`,
+		[]any{m.WorkID, m.MergedID, revID, &user.ID, m.mergedStatus, WorkDeleteMerged}
+}
+
+// ChangeWorkKind changes the kind of a work. Fields with a value that the
+// new kind's profile doesn't have are dropped: by default their human
+// assertions are kept as hidden assertions, and fields with only source
+// assertions are hidden behind a placeholder. With Archive the human values
+// are moved to history and removed; source assertions always stay. Fields
+// that an earlier kind change hid are restored when the new kind has them
+// again. The work is revalidated against the new kind.
+type ChangeWorkKind struct {
+	WorkID  ID     `json:"id"`
+	Kind    string `json:"kind"`
+	Archive bool   `json:"archive,omitempty"`
+
+	dropped  []string // set by apply
+	restored []string // set by apply
+}
+
+func (m *ChangeWorkKind) name() string { return "change_kind:work" }
+
+func (m *ChangeWorkKind) needs() updateNeeds {
+	return updateNeeds{workIDs: []ID{m.WorkID}}
+}
+
+func (m *ChangeWorkKind) apply(state updateState, user *User) (*updateEffect, error) {
+	rs := state.records[m.WorkID]
+	if rs == nil {
+		return nil, fmt.Errorf("ChangeWorkKind: work %s not found", m.WorkID)
+	}
+	if m.Kind == "" {
+		return nil, fmt.Errorf("ChangeWorkKind: kind is required")
+	}
+	if rs.kind == m.Kind {
+		return nil, nil // noop
+	}
+	if rs.status == WorkStatusDeleted {
+		return nil, fmt.Errorf("ChangeWorkKind: work %s is deleted", m.WorkID)
+	}
+
+	m.dropped, m.restored = nil, nil
+	if state.profiles != nil {
+		defs := state.profiles.FieldDefs(RecordTypeWork, m.Kind)
+		if defs == nil {
+			return nil, fmt.Errorf("ChangeWorkKind: unknown kind %q", m.Kind)
+		}
+		keep := make(map[string]bool, len(defs))
+		for _, def := range defs {
+			keep[def.Name] = true
+		}
+		for field := range rs.fields {
+			if !keep[field] {
+				m.dropped = append(m.dropped, field)
+			}
+		}
+		for field := range rs.kindHidden {
+			if keep[field] {
+				m.restored = append(m.restored, field)
+			}
+		}
+		slices.Sort(m.dropped)
+		slices.Sort(m.restored)
+	}
+
+	if err := checkFieldLock(rs, "kind", user); err != nil {
+		return nil, err
+	}
+	for _, field := range m.dropped {
+		if err := checkFieldLock(rs, field, user); err != nil {
+			return nil, err
+		}
+	}
+
+	rs.kind = m.Kind
+	for _, field := range m.dropped {
+		delete(rs.fields, field)
+	}
+	for _, field := range m.restored {
+		rs.fields[field] = rs.kindHidden[field]
+		delete(rs.kindHidden, field)
+	}
+	return &updateEffect{
+		recordType: RecordTypeWork,
+		recordID:   m.WorkID,
+		autoPinAll: true,
+	}, nil
+}
+
+func (m *ChangeWorkKind) write(revID int64, user *User) (string, []any) {
+	// Restored human assertions are unhidden; restored placeholders are
+	// removed, so auto-pin picks the source assertions again. Dropped
+	// fields get a placeholder unless a human assertion is kept hidden.
+	// Source assertions are unpinned by auto-pin.
+	restore := `WITH w AS (
+			UPDATE bbl_works SET kind = $2 WHERE id = $1
+		), r AS (
+			UPDATE bbl_work_assertions SET hidden = false, kind_hidden = false
+			WHERE work_id = $1 AND field = ANY($7) AND kind_hidden AND val IS NOT NULL
+		), rd AS (
+			DELETE FROM bbl_work_assertions
+			WHERE work_id = $1 AND field = ANY($7) AND kind_hidden AND val IS NULL
+		)`
+	if m.Archive {
+		return restore + `, h AS (
+				INSERT INTO bbl_history (rev_id, record_type, record_id, field, val, hidden)
+				SELECT $4, 'work', work_id, field, val, hidden
+				FROM bbl_work_assertions
+				WHERE work_id = $1 AND field = ANY($3) AND user_id IS NOT NULL
+			), d AS (
+				DELETE FROM bbl_work_assertions
+				WHERE work_id = $1 AND field = ANY($3) AND user_id IS NOT NULL
+			)
+			INSERT INTO bbl_work_assertions (rev_id, work_id, field, val, hidden, user_id, role, pinned, kind_hidden)
+			SELECT $4, $1, f, NULL, true, $5, $6, true, true
+			FROM unnest($3::text[]) f`,
+			[]any{m.WorkID, m.Kind, m.dropped, revID, &user.ID, user.Role, m.restored}
+	}
+	return restore + `, h AS (
+			UPDATE bbl_work_assertions SET hidden = true, kind_hidden = true
+			WHERE work_id = $1 AND field = ANY($3) AND user_id IS NOT NULL
+		)
+		INSERT INTO bbl_work_assertions (rev_id, work_id, field, val, hidden, user_id, role, pinned, kind_hidden)
+		SELECT $4, $1, f, NULL, true, $5, $6, true, true
+		FROM unnest($3::text[]) f
+		WHERE NOT EXISTS (
+			SELECT 1 FROM bbl_work_assertions a
+			WHERE a.work_id = $1 AND a.field = f AND a.user_id IS NOT NULL
+		)`,
+		[]any{m.WorkID, m.Kind, m.dropped, revID, &user.ID, user.Role, m.restored}
+}
+
+// SubmitWork submits a private work for review. Returned works can be
+// resubmitted.
+type SubmitWork struct {
+	WorkID  ID     `json:"id"`
+	Message string `json:"message,omitempty"`
+}
+
+func (m *SubmitWork) name() string { return "submit:work" }
+
+func (m *SubmitWork) needs() updateNeeds {
+	return updateNeeds{workIDs: []ID{m.WorkID}}
+}
+
+func (m *SubmitWork) apply(state updateState, user *User) (*updateEffect, error) {
+	rs := state.records[m.WorkID]
+	if rs == nil {
+		return nil, fmt.Errorf("SubmitWork: work %s not found", m.WorkID)
+	}
+	if rs.reviewStatus == WorkReviewPending {
+		return nil, nil // noop
+	}
+	if rs.status != WorkStatusPrivate || (rs.reviewStatus != "" && rs.reviewStatus != WorkReviewReturned) {
+		return nil, fmt.Errorf("SubmitWork: %w", reviewTransitionError(rs, WorkReviewKindSubmitted))
+	}
+	rs.reviewStatus = WorkReviewPending
+	return &updateEffect{
+		recordType: RecordTypeWork,
+		recordID:   m.WorkID,
+	}, nil
+}
+
+func (m *SubmitWork) write(revID int64, user *User) (string, []any) {
+	return writeWorkReview(m.WorkID, "", WorkReviewPending, WorkReviewKindSubmitted, m.Message, revID, user)
+}
+
+// PickUpWork marks a submitted work as being reviewed by a curator.
+type PickUpWork struct {
+	WorkID ID `json:"id"`
+}
+
+func (m *PickUpWork) name() string { return "pick_up:work" }
+
+func (m *PickUpWork) needs() updateNeeds {
+	return updateNeeds{workIDs: []ID{m.WorkID}}
+}
+
+func (m *PickUpWork) apply(state updateState, user *User) (*updateEffect, error) {
+	if user.Role != RoleCurator {
+		return nil, ErrForbidden
+	}
+	rs := state.records[m.WorkID]
+	if rs == nil {
+		return nil, fmt.Errorf("PickUpWork: work %s not found", m.WorkID)
+	}
+	if rs.reviewStatus == WorkReviewInReview {
+		return nil, nil // noop
+	}
+	if rs.reviewStatus != WorkReviewPending {
+		return nil, fmt.Errorf("PickUpWork: %w", reviewTransitionError(rs, WorkReviewKindPickedUp))
+	}
+	rs.reviewStatus = WorkReviewInReview
+	return &updateEffect{
+		recordType: RecordTypeWork,
+		recordID:   m.WorkID,
+	}, nil
+}
+
+func (m *PickUpWork) write(revID int64, user *User) (string, []any) {
+	return writeWorkReview(m.WorkID, "", WorkReviewInReview, WorkReviewKindPickedUp, "", revID, user)
+}
+
+// ReturnWork sends a work under review back to the submitter with a message.
+type ReturnWork struct {
+	WorkID  ID     `json:"id"`
+	Message string `json:"message"`
+}
+
+func (m *ReturnWork) name() string { return "return:work" }
+
+func (m *ReturnWork) needs() updateNeeds {
+	return updateNeeds{workIDs: []ID{m.WorkID}}
+}
+
+func (m *ReturnWork) apply(state updateState, user *User) (*updateEffect, error) {
+	if user.Role != RoleCurator {
+		return nil, ErrForbidden
+	}
+	rs := state.records[m.WorkID]
+	if rs == nil {
+		return nil, fmt.Errorf("ReturnWork: work %s not found", m.WorkID)
+	}
+	if strings.TrimSpace(m.Message) == "" {
+		return nil, fmt.Errorf("ReturnWork: message is required")
+	}
+	if rs.reviewStatus != WorkReviewPending && rs.reviewStatus != WorkReviewInReview {
+		return nil, fmt.Errorf("ReturnWork: %w", reviewTransitionError(rs, WorkReviewKindReturned))
+	}
+	rs.reviewStatus = WorkReviewReturned
+	return &updateEffect{
+		recordType: RecordTypeWork,
+		recordID:   m.WorkID,
+	}, nil
+}
+
+func (m *ReturnWork) write(revID int64, user *User) (string, []any) {
+	return writeWorkReview(m.WorkID, "", WorkReviewReturned, WorkReviewKindReturned, m.Message, revID, user)
+}
+
+// ApproveWork ends the review of a work and publishes it. The whole record
+// is validated as public.
+type ApproveWork struct {
+	WorkID  ID     `json:"id"`
+	Message string `json:"message,omitempty"`
+}
+
+func (m *ApproveWork) name() string { return "approve:work" }
+
+func (m *ApproveWork) needs() updateNeeds {
+	return updateNeeds{workIDs: []ID{m.WorkID}}
+}
+
+func (m *ApproveWork) apply(state updateState, user *User) (*updateEffect, error) {
+	if user.Role != RoleCurator {
+		return nil, ErrForbidden
+	}
+	rs := state.records[m.WorkID]
+	if rs == nil {
+		return nil, fmt.Errorf("ApproveWork: work %s not found", m.WorkID)
+	}
+	if rs.reviewStatus != WorkReviewPending && rs.reviewStatus != WorkReviewInReview {
+		return nil, fmt.Errorf("ApproveWork: %w", reviewTransitionError(rs, WorkReviewKindApproved))
+	}
+	rs.reviewStatus = ""
+	rs.status = WorkStatusPublic
+	return &updateEffect{
+		recordType: RecordTypeWork,
+		recordID:   m.WorkID,
+	}, nil
+}
+
+func (m *ApproveWork) write(revID int64, user *User) (string, []any) {
+	return writeWorkReview(m.WorkID, WorkStatusPublic, "", WorkReviewKindApproved, m.Message, revID, user)
+}
+
+func reviewTransitionError(rs *recordState, kind string) error {
+	reviewStatus := rs.reviewStatus
+	if reviewStatus == "" {
+		reviewStatus = "none"
+	}
+	return fmt.Errorf("%w: cannot apply %s to %s work with review status %s",
+		ErrInvalidTransition, kind, rs.status, reviewStatus)
+}
+
+// writeWorkReview sets the review status (and, if non-empty, the status) of
+// a work and appends the transition to bbl_work_reviews.
+func writeWorkReview(workID ID, status, reviewStatus, kind, body string, revID int64, user *User) (string, []any) {
+	return `WITH w AS (
+			UPDATE bbl_works
+			SET review_status = $2, status = coalesce($3, status)
+			WHERE id = $1
+			RETURNING id
+		)
+		INSERT INTO bbl_work_reviews (id, work_id, seq, rev_id, user_id, kind, body)
+		SELECT $4, w.id,
+		       coalesce((SELECT max(seq) FROM bbl_work_reviews WHERE work_id = $1), 0) + 1,
+		       $5, $6, $7, $8
+		FROM w`,
+		[]any{workID, nilIfEmpty(reviewStatus), nilIfEmpty(status), newID(), revID, &user.ID, kind, body}
+}
+
+// LockWork marks a work, or specific fields of a work, as endorsed by a
+// curator. Locked fields reject edits by non-curators; source re-imports that
+// disagree with a locked value are queued as lock conflicts instead of being
+// written.
+type LockWork struct {
+	WorkID ID       `json:"id"`
+	Fields []string `json:"fields,omitempty"` // empty = whole work
+	Note   string   `json:"note,omitempty"`
+}
+
+func (m *LockWork) name() string { return "lock:work" }
+
+func (m *LockWork) needs() updateNeeds {
+	return updateNeeds{workIDs: []ID{m.WorkID}}
+}
+
+func (m *LockWork) apply(state updateState, user *User) (*updateEffect, error) {
+	if user.Role != RoleCurator {
+		return nil, ErrForbidden
+	}
+	rs := state.records[m.WorkID]
+	if rs == nil {
+		return nil, fmt.Errorf("LockWork: work %s not found", m.WorkID)
+	}
+	fields, err := lockFields(m.Fields)
+	if err != nil {
+		return nil, fmt.Errorf("LockWork: %w", err)
+	}
+	m.Fields = fields
+
+	// Noop: every field is already locked.
+	noop := true
+	for _, f := range fields {
+		if !rs.locks[f] {
+			noop = false
+			break
+		}
+	}
+	if noop {
+		return nil, nil
+	}
+
+	if rs.locks == nil {
+		rs.locks = make(map[string]bool)
+	}
+	for _, f := range fields {
+		rs.locks[f] = true
+	}
+
+	return &updateEffect{
+		recordType: RecordTypeWork,
+		recordID:   m.WorkID,
+	}, nil
+}
+
+func (m *LockWork) write(revID int64, user *User) (string, []any) {
+	return `INSERT INTO bbl_work_locks (work_id, field, rev_id, locked_by_id, note)
+		SELECT $1, f, $2, $3, $4 FROM unnest($5::text[]) f
+		ON CONFLICT (work_id, field) DO UPDATE
+		SET rev_id = EXCLUDED.rev_id, locked_by_id = EXCLUDED.locked_by_id,
+		    locked_at = transaction_timestamp(), note = EXCLUDED.note`,
+		[]any{m.WorkID, revID, &user.ID, nilIfEmpty(m.Note), m.Fields}
+}
+
+// UnlockWork removes curator locks from a work.
+type UnlockWork struct {
+	WorkID ID       `json:"id"`
+	Fields []string `json:"fields,omitempty"` // empty = whole-work lock
+}
+
+func (m *UnlockWork) name() string { return "unlock:work" }
+
+func (m *UnlockWork) needs() updateNeeds {
+	return updateNeeds{workIDs: []ID{m.WorkID}}
+}
+
+func (m *UnlockWork) apply(state updateState, user *User) (*updateEffect, error) {
+	if user.Role != RoleCurator {
+		return nil, ErrForbidden
+	}
+	rs := state.records[m.WorkID]
+	if rs == nil {
+		return nil, fmt.Errorf("UnlockWork: work %s not found", m.WorkID)
+	}
+	fields, err := lockFields(m.Fields)
+	if err != nil {
+		return nil, fmt.Errorf("UnlockWork: %w", err)
+	}
+	m.Fields = fields
+
+	// Noop: none of the fields are locked.
+	noop := true
+	for _, f := range fields {
+		if rs.locks[f] {
+			noop = false
+			delete(rs.locks, f)
+		}
+	}
+	if noop {
+		return nil, nil
+	}
+
+	return &updateEffect{
+		recordType: RecordTypeWork,
+		recordID:   m.WorkID,
+	}, nil
+}
+
+func (m *UnlockWork) write(revID int64, user *User) (string, []any) {
+	return `DELETE FROM bbl_work_locks WHERE work_id = $1 AND field = ANY($2)`,
+		[]any{m.WorkID, m.Fields}
+}
+
+// ResolveWorkLockConflict closes a queued lock conflict. Accepting a conflict
+// is paired with a Set or Hide in the same revision; see
+// WorkLockConflict.AcceptUpdates.
+type ResolveWorkLockConflict struct {
+	WorkID     ID     `json:"id"`
+	ConflictID int64  `json:"conflict_id"`
+	Resolution string `json:"resolution"` // accepted, dismissed
+}
+
+func (m *ResolveWorkLockConflict) name() string { return "resolve:work_lock_conflict" }
+
+func (m *ResolveWorkLockConflict) needs() updateNeeds {
+	return updateNeeds{workIDs: []ID{m.WorkID}}
+}
+
+func (m *ResolveWorkLockConflict) apply(state updateState, user *User) (*updateEffect, error) {
+	if user.Role != RoleCurator {
+		return nil, ErrForbidden
+	}
+	if state.records[m.WorkID] == nil {
+		return nil, fmt.Errorf("ResolveWorkLockConflict: work %s not found", m.WorkID)
+	}
+	switch m.Resolution {
+	case WorkLockConflictAccepted, WorkLockConflictDismissed:
+	default:
+		return nil, fmt.Errorf("ResolveWorkLockConflict: invalid resolution %q", m.Resolution)
+	}
+	return &updateEffect{
+		recordType: RecordTypeWork,
+		recordID:   m.WorkID,
+	}, nil
+}
+
+func (m *ResolveWorkLockConflict) write(revID int64, user *User) (string, []any) {
+	return `UPDATE bbl_work_lock_conflicts
+		SET resolution = $3, resolved_at = transaction_timestamp(),
+		    resolved_by_id = $4, resolved_rev_id = $5
+		WHERE id = $1 AND work_id = $2 AND resolved_at IS NULL`,
+		[]any{m.ConflictID, m.WorkID, m.Resolution, &user.ID, revID}
+}
+
+// lockFields validates lock field names. No fields means the whole work.
+func lockFields(fields []string) ([]string, error) {
+	if len(fields) == 0 {
+		return []string{""}, nil
+	}
+	for _, f := range fields {
+		if _, err := resolveFieldType(RecordTypeWork, f); err != nil {
+			return nil, err
+		}
+	}
+	return dedupStrings(fields), nil
+}
+
+// AttachWorkFile adds an uploaded file to the files of a work, or replaces
+// the name, access level, embargo and license of a file that is already
+// attached. A new file must have been finished with
+// Services.FinishFileUpload by the same user and can't be attached to another
+// work. Written like a Set of the whole files field.
+type AttachWorkFile struct {
+	WorkID ID       `json:"id"`
+	File   WorkFile `json:"file"`
+	files  []WorkFile
+
+	fileStatus      string // set by fetchState; empty if the file doesn't exist
+	fileCreatedByID *ID    // set by fetchState
+	fileWorkIDs     []ID   // works with an assertion on the file; set by fetchState
+}
+
+func (m *AttachWorkFile) name() string { return "attach_file:work" }
+
+func (m *AttachWorkFile) needs() updateNeeds {
+	return updateNeeds{workIDs: []ID{m.WorkID}}
+}
+
+func (m *AttachWorkFile) apply(state updateState, user *User) (*updateEffect, error) {
+	rs := state.records[m.WorkID]
+	if rs == nil {
+		return nil, fmt.Errorf("AttachWorkFile: work %s not found", m.WorkID)
+	}
+	if m.File.FileID == (ID{}) {
+		return nil, fmt.Errorf("AttachWorkFile: file id is required")
+	}
+
+	files, _ := rs.fields["files"].([]WorkFile)
+	files = slices.Clone(files)
+	if i := slices.IndexFunc(files, func(f WorkFile) bool { return f.FileID == m.File.FileID }); i >= 0 {
+		// Keep the lift marker while the embargo date is unchanged, so that
+		// editing a lifted file doesn't reopen it.
+		if m.File.EmbargoLiftedAt == nil && timePtrEqual(files[i].EmbargoUntil, m.File.EmbargoUntil) {
+			m.File.EmbargoLiftedAt = files[i].EmbargoLiftedAt
+		}
+		// Noop: attached with the same settings.
+		if workFileEqual(files[i], m.File) {
+			return nil, nil
+		}
+		files[i] = m.File
+	} else {
+		if err := m.checkNewFile(user); err != nil {
+			return nil, err
+		}
+		files = append(files, m.File)
+	}
+
+	if err := checkFieldLock(rs, "files", user); err != nil {
+		return nil, err
+	}
+
+	rs.fields["files"] = files
+	m.files = files
+
+	return &updateEffect{
+		recordType:   RecordTypeWork,
+		recordID:     m.WorkID,
+		autoPinField: "files",
+	}, nil
+}
+
+// checkNewFile checks that a file that isn't attached to the work yet was
+// uploaded by user and isn't attached to another work.
+func (m *AttachWorkFile) checkNewFile(user *User) error {
+	switch {
+	case m.fileStatus == "":
+		return fmt.Errorf("AttachWorkFile: file %s: %w", m.File.FileID, ErrNotFound)
+	case m.fileStatus != FileStatusUploaded:
+		return fmt.Errorf("AttachWorkFile: file %s is not uploaded", m.File.FileID)
+	case m.fileCreatedByID == nil || *m.fileCreatedByID != user.ID:
+		return fmt.Errorf("AttachWorkFile: file %s: %w", m.File.FileID, ErrForbidden)
+	}
+	for _, id := range m.fileWorkIDs {
+		if id != m.WorkID {
+			return fmt.Errorf("AttachWorkFile: file %s is attached to work %s", m.File.FileID, id)
+		}
+	}
+	return nil
+}
+
+func (m *AttachWorkFile) write(revID int64, user *User) (string, []any) {
+	return "", nil // field ops use executeFieldWrites
+}
+
+// DetachWorkFile removes a file from the files of a work. The stored object
+// is kept; earlier revisions still reference it.
+type DetachWorkFile struct {
+	WorkID ID `json:"id"`
+	FileID ID `json:"file_id"`
+	files  []WorkFile
+}
+
+func (m *DetachWorkFile) name() string { return "detach_file:work" }
+
+func (m *DetachWorkFile) needs() updateNeeds {
+	return updateNeeds{workIDs: []ID{m.WorkID}}
+}
+
+func (m *DetachWorkFile) apply(state updateState, user *User) (*updateEffect, error) {
+	rs := state.records[m.WorkID]
+	if rs == nil {
+		return nil, fmt.Errorf("DetachWorkFile: work %s not found", m.WorkID)
+	}
+
+	files, _ := rs.fields["files"].([]WorkFile)
+	i := slices.IndexFunc(files, func(f WorkFile) bool { return f.FileID == m.FileID })
+	// Noop: not attached.
+	if i < 0 {
+		return nil, nil
+	}
+
+	if err := checkFieldLock(rs, "files", user); err != nil {
+		return nil, err
+	}
+
+	files = slices.Delete(slices.Clone(files), i, i+1)
+	rs.fields["files"] = files
+	m.files = files
+
+	return &updateEffect{
+		recordType:   RecordTypeWork,
+		recordID:     m.WorkID,
+		autoPinField: "files",
+	}, nil
+}
+
+func (m *DetachWorkFile) write(revID int64, user *User) (string, []any) {
+	return "", nil // field ops use executeFieldWrites
+}
+
+// LiftWorkFileEmbargoes lifts the expired file embargoes of a work: each
+// file whose embargo ended before At gets its embargo access level and is
+// marked lifted. Run by the embargo job as the system user; written like a
+// Set of the whole files field.
+type LiftWorkFileEmbargoes struct {
+	WorkID ID        `json:"id"`
+	At     time.Time `json:"at"`
+	files  []WorkFile
+}
+
+func (m *LiftWorkFileEmbargoes) name() string { return "lift_embargoes:work" }
+
+func (m *LiftWorkFileEmbargoes) needs() updateNeeds {
+	return updateNeeds{workIDs: []ID{m.WorkID}}
+}
+
+func (m *LiftWorkFileEmbargoes) apply(state updateState, user *User) (*updateEffect, error) {
+	rs := state.records[m.WorkID]
+	if rs == nil {
+		return nil, fmt.Errorf("LiftWorkFileEmbargoes: work %s not found", m.WorkID)
+	}
+
+	files, _ := rs.fields["files"].([]WorkFile)
+	var lifted []WorkFile
+	for _, f := range files {
+		if f.EmbargoExpired(m.At) {
+			if f.EmbargoAccessKind != "" {
+				f.AccessKind = f.EmbargoAccessKind
+			}
+			at := m.At
+			f.EmbargoLiftedAt = &at
+		}
+		lifted = append(lifted, f)
+	}
+	// Noop: nothing expired.
+	if slices.EqualFunc(files, lifted, workFileEqual) {
+		return nil, nil
+	}
+
+	if err := checkFieldLock(rs, "files", user); err != nil {
+		return nil, err
+	}
+
+	rs.fields["files"] = lifted
+	m.files = lifted
+
+	return &updateEffect{
+		recordType:   RecordTypeWork,
+		recordID:     m.WorkID,
+		autoPinField: "files",
+	}, nil
+}
+
+func (m *LiftWorkFileEmbargoes) write(revID int64, user *User) (string, []any) {
+	return "", nil // field ops use executeFieldWrites
+}
+
+// checkFieldLock returns ErrCuratorLock if a non-curator edits a field that
+// a curator asserted or locked.
+func checkFieldLock(rs *recordState, field string, user *User) error {
+	if passesCuratorLocks(user) {
+		return nil
+	}
+	if p := firstPinned(rs.assertions[field]); p != nil && p.userID != nil && p.role == RoleCurator {
+		return ErrCuratorLock
+	}
+	if rs.locked(field) {
+		return ErrCuratorLock
+	}
+	return nil
+}
@@ -1,7 +1,10 @@
 package bbl
 
 import (
+	"errors"
+	"slices"
 	"testing"
+	"time"
 )
 
 func TestCreateWork_Apply(t *testing.T) {
@@ -105,3 +108,573 @@ func TestDeleteWork_AlreadyDeleted(t *testing.T) {
 		t.Fatal("expected nil (noop) for already-deleted work")
 	}
 }
+
+func newTestWorkState(id ID) updateState {
+	return updateState{records: map[ID]*recordState{
+		id: {
+			recordType: RecordTypeWork,
+			id:         id,
+			version:    1,
+			status:     WorkStatusPrivate,
+			kind:       "journal_article",
+			fields:     make(map[string]any),
+			assertions: make(map[string][]assertion),
+		},
+	}}
+}
+
+func TestLockWork_Apply(t *testing.T) {
+	id := newID()
+	state := newTestWorkState(id)
+
+	m := &LockWork{WorkID: id, Fields: []string{"volume", "volume"}}
+	eff, err := m.apply(state, &User{Role: RoleCurator})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if eff == nil {
+		t.Fatal("expected non-nil effect")
+	}
+	if len(m.Fields) != 1 || m.Fields[0] != "volume" {
+		t.Errorf("expected fields [volume], got %v", m.Fields)
+	}
+	if !state.records[id].locked("volume") {
+		t.Error("expected volume to be locked")
+	}
+	if state.records[id].locked("issue") {
+		t.Error("expected issue to be unlocked")
+	}
+
+	// Locking again is a noop.
+	eff, err = (&LockWork{WorkID: id, Fields: []string{"volume"}}).apply(state, &User{Role: RoleCurator})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if eff != nil {
+		t.Error("expected nil (noop) for already-locked field")
+	}
+}
+
+func TestLockWork_WholeWork(t *testing.T) {
+	id := newID()
+	state := newTestWorkState(id)
+
+	m := &LockWork{WorkID: id}
+	if _, err := m.apply(state, &User{Role: RoleCurator}); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Fields) != 1 || m.Fields[0] != "" {
+		t.Errorf("expected whole-work lock, got %v", m.Fields)
+	}
+	if !state.records[id].locked("issue") {
+		t.Error("expected every field to be locked")
+	}
+}
+
+func TestLockWork_RequiresCurator(t *testing.T) {
+	id := newID()
+	state := newTestWorkState(id)
+
+	_, err := (&LockWork{WorkID: id}).apply(state, &User{Role: RoleUser})
+	if !errors.Is(err, ErrForbidden) {
+		t.Errorf("expected ErrForbidden, got %v", err)
+	}
+}
+
+func TestLockWork_UnknownField(t *testing.T) {
+	id := newID()
+	state := newTestWorkState(id)
+
+	_, err := (&LockWork{WorkID: id, Fields: []string{"nope"}}).apply(state, &User{Role: RoleCurator})
+	if err == nil {
+		t.Error("expected error for unknown field")
+	}
+}
+
+func TestUnlockWork_Apply(t *testing.T) {
+	id := newID()
+	state := newTestWorkState(id)
+	state.records[id].locks = map[string]bool{"volume": true}
+
+	eff, err := (&UnlockWork{WorkID: id, Fields: []string{"volume"}}).apply(state, &User{Role: RoleCurator})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if eff == nil {
+		t.Fatal("expected non-nil effect")
+	}
+	if state.records[id].locked("volume") {
+		t.Error("expected volume to be unlocked")
+	}
+
+	// Unlocking an unlocked field is a noop.
+	eff, err = (&UnlockWork{WorkID: id, Fields: []string{"volume"}}).apply(state, &User{Role: RoleCurator})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if eff != nil {
+		t.Error("expected nil (noop) for unlocked field")
+	}
+}
+
+func TestSet_LockedField(t *testing.T) {
+	id := newID()
+	state := newTestWorkState(id)
+	state.records[id].locks = map[string]bool{"volume": true}
+
+	m := &Set{RecordType: RecordTypeWork, RecordID: id, Field: "volume", Val: "42"}
+	if _, err := m.apply(state, &User{Role: RoleUser}); !errors.Is(err, ErrCuratorLock) {
+		t.Errorf("expected ErrCuratorLock for user, got %v", err)
+	}
+	if _, err := m.apply(state, &User{Role: RoleCurator}); err != nil {
+		t.Errorf("expected curator to bypass lock, got %v", err)
+	}
+}
+
+func TestCheckFieldLock_System(t *testing.T) {
+	id := newID()
+	state := newTestWorkState(id)
+	rs := state.records[id]
+	rs.locks = map[string]bool{"volume": true}
+	systemID := newID()
+	rs.assertions["files"] = []assertion{{userID: &systemID, role: RoleSystem, pinned: true}}
+
+	system := &User{ID: systemID, Role: RoleSystem}
+	if err := checkFieldLock(rs, "volume", system); err != nil {
+		t.Errorf("expected system user to pass lock, got %v", err)
+	}
+	// Assertions by the system user are not curator locks.
+	if err := checkFieldLock(rs, "files", &User{Role: RoleUser}); err != nil {
+		t.Errorf("expected user to edit field asserted by system user, got %v", err)
+	}
+}
+
+func TestResolveWorkLockConflict_InvalidResolution(t *testing.T) {
+	id := newID()
+	state := newTestWorkState(id)
+
+	m := &ResolveWorkLockConflict{WorkID: id, ConflictID: 1, Resolution: "maybe"}
+	if _, err := m.apply(state, &User{Role: RoleCurator}); err == nil {
+		t.Error("expected error for invalid resolution")
+	}
+}
+
+func TestReviewWorkflow_Apply(t *testing.T) {
+	id := newID()
+	state := newTestWorkState(id)
+	rs := state.records[id]
+	user := &User{Role: RoleUser}
+	curator := &User{Role: RoleCurator}
+
+	steps := []struct {
+		update       updater
+		user         *User
+		reviewStatus string
+	}{
+		{&SubmitWork{WorkID: id}, user, WorkReviewPending},
+		{&PickUpWork{WorkID: id}, curator, WorkReviewInReview},
+		{&ReturnWork{WorkID: id, Message: "missing abstract"}, curator, WorkReviewReturned},
+		{&SubmitWork{WorkID: id}, user, WorkReviewPending},
+		{&ApproveWork{WorkID: id}, curator, ""},
+	}
+	for _, step := range steps {
+		eff, err := step.update.apply(state, step.user)
+		if err != nil {
+			t.Fatalf("%s: %v", step.update.name(), err)
+		}
+		if eff == nil {
+			t.Fatalf("%s: expected non-nil effect", step.update.name())
+		}
+		if rs.reviewStatus != step.reviewStatus {
+			t.Errorf("%s: review status = %q, want %q", step.update.name(), rs.reviewStatus, step.reviewStatus)
+		}
+	}
+	if rs.status != WorkStatusPublic {
+		t.Errorf("expected status public after approval, got %q", rs.status)
+	}
+}
+
+func TestReviewWorkflow_InvalidTransitions(t *testing.T) {
+	curator := &User{Role: RoleCurator}
+	tests := []struct {
+		name         string
+		status       string
+		reviewStatus string
+		update       func(ID) updater
+	}{
+		{"pick up not submitted", WorkStatusPrivate, "", func(id ID) updater { return &PickUpWork{WorkID: id} }},
+		{"approve returned", WorkStatusPrivate, WorkReviewReturned, func(id ID) updater { return &ApproveWork{WorkID: id} }},
+		{"return not submitted", WorkStatusPrivate, "", func(id ID) updater { return &ReturnWork{WorkID: id, Message: "no"} }},
+		{"submit in review", WorkStatusPrivate, WorkReviewInReview, func(id ID) updater { return &SubmitWork{WorkID: id} }},
+		{"submit public", WorkStatusPublic, "", func(id ID) updater { return &SubmitWork{WorkID: id} }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := newID()
+			state := newTestWorkState(id)
+			state.records[id].status = tt.status
+			state.records[id].reviewStatus = tt.reviewStatus
+			_, err := tt.update(id).apply(state, curator)
+			if !errors.Is(err, ErrInvalidTransition) {
+				t.Errorf("expected ErrInvalidTransition, got %v", err)
+			}
+		})
+	}
+}
+
+func TestReviewWorkflow_RequiresCurator(t *testing.T) {
+	id := newID()
+	state := newTestWorkState(id)
+	state.records[id].reviewStatus = WorkReviewPending
+
+	for _, m := range []updater{
+		&PickUpWork{WorkID: id},
+		&ReturnWork{WorkID: id, Message: "no"},
+		&ApproveWork{WorkID: id},
+	} {
+		if _, err := m.apply(state, &User{Role: RoleUser}); !errors.Is(err, ErrForbidden) {
+			t.Errorf("%s: expected ErrForbidden, got %v", m.name(), err)
+		}
+	}
+}
+
+func TestReturnWork_RequiresMessage(t *testing.T) {
+	id := newID()
+	state := newTestWorkState(id)
+	state.records[id].reviewStatus = WorkReviewInReview
+
+	if _, err := (&ReturnWork{WorkID: id, Message: " "}).apply(state, &User{Role: RoleCurator}); err == nil {
+		t.Error("expected error for empty message")
+	}
+}
+
+func TestChangeWorkKind_Apply(t *testing.T) {
+	id := newID()
+	state := newTestWorkState(id)
+	state.profiles = testProfiles(t)
+	rs := state.records[id]
+	rs.fields["titles"] = []Title{{Lang: "eng", Val: "A"}}
+	rs.fields["volume"] = "42"
+	rs.fields["journal_title"] = "J"
+
+	m := &ChangeWorkKind{WorkID: id, Kind: "book"}
+	eff, err := m.apply(state, &User{Role: RoleUser})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if eff == nil {
+		t.Fatal("expected non-nil effect")
+	}
+	if rs.kind != "book" {
+		t.Errorf("expected kind book, got %q", rs.kind)
+	}
+	if want := []string{"journal_title", "volume"}; !slices.Equal(m.dropped, want) {
+		t.Errorf("dropped = %v, want %v", m.dropped, want)
+	}
+	if _, ok := rs.fields["volume"]; ok {
+		t.Error("expected volume to be dropped from fields")
+	}
+	if _, ok := rs.fields["titles"]; !ok {
+		t.Error("expected titles to be kept")
+	}
+}
+
+func TestChangeWorkKind_Restore(t *testing.T) {
+	id := newID()
+	state := newTestWorkState(id)
+	state.profiles = testProfiles(t)
+	rs := state.records[id]
+	rs.kind = "book"
+	rs.fields["titles"] = []Title{{Lang: "eng", Val: "A"}}
+	rs.kindHidden = map[string]any{"volume": "42"}
+
+	m := &ChangeWorkKind{WorkID: id, Kind: "journal_article"}
+	eff, err := m.apply(state, &User{Role: RoleUser})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if eff == nil || !eff.autoPinAll {
+		t.Fatalf("effect = %+v, want auto-pin of the whole work", eff)
+	}
+	if want := []string{"volume"}; !slices.Equal(m.restored, want) {
+		t.Errorf("restored = %v, want %v", m.restored, want)
+	}
+	if rs.fields["volume"] != "42" {
+		t.Errorf("volume = %v, want restored value", rs.fields["volume"])
+	}
+}
+
+func TestChangeWorkKind_CuratorLock(t *testing.T) {
+	id := newID()
+	state := newTestWorkState(id)
+	state.profiles = testProfiles(t)
+	rs := state.records[id]
+	rs.fields["volume"] = "42"
+	rs.locks = map[string]bool{"volume": true}
+
+	m := &ChangeWorkKind{WorkID: id, Kind: "book"}
+	if _, err := m.apply(state, &User{Role: RoleUser}); !errors.Is(err, ErrCuratorLock) {
+		t.Errorf("expected ErrCuratorLock for user, got %v", err)
+	}
+	if rs.kind != "journal_article" {
+		t.Errorf("kind = %q, want unchanged", rs.kind)
+	}
+	if _, err := m.apply(state, &User{Role: RoleCurator}); err != nil {
+		t.Errorf("curator: %v", err)
+	}
+}
+
+func TestChangeWorkKind_SameKind(t *testing.T) {
+	id := newID()
+	state := newTestWorkState(id)
+	state.profiles = testProfiles(t)
+
+	eff, err := (&ChangeWorkKind{WorkID: id, Kind: "journal_article"}).apply(state, &User{Role: RoleUser})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if eff != nil {
+		t.Error("expected nil (noop) for unchanged kind")
+	}
+}
+
+func TestChangeWorkKind_UnknownKind(t *testing.T) {
+	id := newID()
+	state := newTestWorkState(id)
+	state.profiles = testProfiles(t)
+
+	if _, err := (&ChangeWorkKind{WorkID: id, Kind: "nope"}).apply(state, &User{Role: RoleUser}); err == nil {
+		t.Error("expected error for unknown kind")
+	}
+}
+
+func TestMergeWorks_Apply(t *testing.T) {
+	id, mergedID, relatedID := newID(), newID(), newID()
+	state := newTestWorkState(id)
+	merged := newTestWorkState(mergedID).records[mergedID]
+	merged.status = WorkStatusPublic
+	merged.reviewStatus = WorkReviewPending
+	state.records[mergedID] = merged
+
+	m := &MergeWorks{WorkID: id, MergedID: mergedID, related: []ID{relatedID, id}}
+	eff, err := m.apply(state, &User{Role: RoleCurator})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if eff == nil || eff.recordID != id || !eff.autoPinAll {
+		t.Fatalf("expected auto-pin effect on survivor, got %+v", eff)
+	}
+	if want := []ID{mergedID, relatedID}; !slices.Equal(eff.related, want) {
+		t.Errorf("related = %v, want %v", eff.related, want)
+	}
+	if merged.status != WorkStatusDeleted || merged.reviewStatus != "" {
+		t.Errorf("expected merged work deleted without review, got %q/%q", merged.status, merged.reviewStatus)
+	}
+	if m.mergedStatus != WorkStatusPublic {
+		t.Errorf("mergedStatus = %q, want %q", m.mergedStatus, WorkStatusPublic)
+	}
+}
+
+func TestMergeWorks_Invalid(t *testing.T) {
+	id, mergedID := newID(), newID()
+	state := newTestWorkState(id)
+	state.records[mergedID] = newTestWorkState(mergedID).records[mergedID]
+	curator := &User{Role: RoleCurator}
+
+	if _, err := (&MergeWorks{WorkID: id, MergedID: mergedID}).apply(state, &User{Role: RoleUser}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("expected ErrForbidden, got %v", err)
+	}
+	if _, err := (&MergeWorks{WorkID: id, MergedID: id}).apply(state, curator); err == nil {
+		t.Error("expected error merging a work into itself")
+	}
+	if _, err := (&MergeWorks{WorkID: id, MergedID: newID()}).apply(state, curator); err == nil {
+		t.Error("expected error for missing work")
+	}
+	state.records[mergedID].status = WorkStatusDeleted
+	if _, err := (&MergeWorks{WorkID: id, MergedID: mergedID}).apply(state, curator); err == nil {
+		t.Error("expected error for deleted work")
+	}
+}
+
+func TestAttachWorkFile_Apply(t *testing.T) {
+	id := newID()
+	state := newTestWorkState(id)
+	fileID := newID()
+	user := &User{ID: newID(), Role: RoleUser}
+
+	m := &AttachWorkFile{WorkID: id, File: WorkFile{FileID: fileID, Name: "paper.pdf", AccessKind: WorkFileAccessOpen},
+		fileStatus: FileStatusUploaded, fileCreatedByID: &user.ID}
+	eff, err := m.apply(state, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if eff == nil || eff.autoPinField != "files" {
+		t.Fatalf("expected files effect, got %+v", eff)
+	}
+	files := state.records[id].fields["files"].([]WorkFile)
+	if len(files) != 1 || files[0].FileID != fileID {
+		t.Fatalf("expected attached file, got %v", files)
+	}
+
+	// Attaching with the same settings is a noop.
+	eff, err = (&AttachWorkFile{WorkID: id, File: files[0]}).apply(state, &User{Role: RoleUser})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if eff != nil {
+		t.Error("expected nil (noop) for unchanged file")
+	}
+
+	// Attaching again replaces the settings in place.
+	m = &AttachWorkFile{WorkID: id, File: WorkFile{FileID: fileID, Name: "paper.pdf", AccessKind: WorkFileAccessClosed}}
+	if _, err := m.apply(state, &User{Role: RoleUser}); err != nil {
+		t.Fatal(err)
+	}
+	files = state.records[id].fields["files"].([]WorkFile)
+	if len(files) != 1 || files[0].AccessKind != WorkFileAccessClosed {
+		t.Errorf("expected one closed file, got %v", files)
+	}
+	if !slices.EqualFunc(m.files, files, workFileEqual) {
+		t.Errorf("expected updater to carry the new list, got %v", m.files)
+	}
+}
+
+func TestAttachWorkFile_Locked(t *testing.T) {
+	id := newID()
+	state := newTestWorkState(id)
+	state.records[id].locks = map[string]bool{"files": true}
+	user := &User{ID: newID(), Role: RoleUser}
+	curator := &User{ID: user.ID, Role: RoleCurator}
+
+	m := &AttachWorkFile{WorkID: id, File: WorkFile{FileID: newID(), Name: "paper.pdf", AccessKind: WorkFileAccessOpen},
+		fileStatus: FileStatusUploaded, fileCreatedByID: &user.ID}
+	if _, err := m.apply(state, user); !errors.Is(err, ErrCuratorLock) {
+		t.Errorf("expected ErrCuratorLock for user, got %v", err)
+	}
+	if _, err := m.apply(state, curator); err != nil {
+		t.Errorf("expected curator to bypass lock, got %v", err)
+	}
+}
+
+func TestAttachWorkFile_NewFileChecks(t *testing.T) {
+	id, otherID := newID(), newID()
+	state := newTestWorkState(id)
+	user := &User{ID: newID(), Role: RoleUser}
+	file := WorkFile{FileID: newID(), Name: "paper.pdf", AccessKind: WorkFileAccessOpen}
+	otherUser := newID()
+
+	tests := []struct {
+		name string
+		m    *AttachWorkFile
+	}{
+		{"missing", &AttachWorkFile{WorkID: id, File: file}},
+		{"pending", &AttachWorkFile{WorkID: id, File: file, fileStatus: FileStatusPending, fileCreatedByID: &user.ID}},
+		{"other uploader", &AttachWorkFile{WorkID: id, File: file, fileStatus: FileStatusUploaded, fileCreatedByID: &otherUser}},
+		{"attached elsewhere", &AttachWorkFile{WorkID: id, File: file, fileStatus: FileStatusUploaded, fileCreatedByID: &user.ID, fileWorkIDs: []ID{otherID}}},
+	}
+	for _, tt := range tests {
+		if _, err := tt.m.apply(state, user); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+	if files, _ := state.records[id].fields["files"].([]WorkFile); len(files) != 0 {
+		t.Errorf("expected no files attached, got %v", files)
+	}
+}
+
+func TestDetachWorkFile_Apply(t *testing.T) {
+	id := newID()
+	state := newTestWorkState(id)
+	keep, drop := newID(), newID()
+	state.records[id].fields["files"] = []WorkFile{
+		{FileID: keep, Name: "a.pdf", AccessKind: WorkFileAccessOpen},
+		{FileID: drop, Name: "b.pdf", AccessKind: WorkFileAccessOpen},
+	}
+
+	eff, err := (&DetachWorkFile{WorkID: id, FileID: drop}).apply(state, &User{Role: RoleUser})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if eff == nil {
+		t.Fatal("expected non-nil effect")
+	}
+	files := state.records[id].fields["files"].([]WorkFile)
+	if len(files) != 1 || files[0].FileID != keep {
+		t.Errorf("expected only %s to remain, got %v", keep, files)
+	}
+
+	// Detaching a file that is not attached is a noop.
+	eff, err = (&DetachWorkFile{WorkID: id, FileID: drop}).apply(state, &User{Role: RoleUser})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if eff != nil {
+		t.Error("expected nil (noop) for unattached file")
+	}
+}
+
+func TestLiftWorkFileEmbargoes_Apply(t *testing.T) {
+	id := newID()
+	state := newTestWorkState(id)
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	expired, embargoed, open := newID(), newID(), newID()
+	state.records[id].fields["files"] = []WorkFile{
+		{FileID: expired, Name: "a.pdf", AccessKind: WorkFileAccessClosed, EmbargoUntil: &past, EmbargoAccessKind: WorkFileAccessOpen},
+		{FileID: embargoed, Name: "b.pdf", AccessKind: WorkFileAccessClosed, EmbargoUntil: &future, EmbargoAccessKind: WorkFileAccessOpen},
+		{FileID: open, Name: "c.pdf", AccessKind: WorkFileAccessOpen},
+	}
+
+	eff, err := (&LiftWorkFileEmbargoes{WorkID: id, At: now}).apply(state, &User{Role: RoleCurator})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if eff == nil || eff.autoPinField != "files" {
+		t.Fatalf("expected files effect, got %+v", eff)
+	}
+	files := state.records[id].fields["files"].([]WorkFile)
+	if files[0].AccessKind != WorkFileAccessOpen || files[0].EmbargoLiftedAt == nil || !files[0].EmbargoLiftedAt.Equal(now) {
+		t.Errorf("expired file not lifted: %+v", files[0])
+	}
+	if files[1].AccessKind != WorkFileAccessClosed || files[1].EmbargoLiftedAt != nil {
+		t.Errorf("embargoed file changed: %+v", files[1])
+	}
+	if files[2].AccessKind != WorkFileAccessOpen || files[2].EmbargoLiftedAt != nil {
+		t.Errorf("open file changed: %+v", files[2])
+	}
+
+	// Lifting again is a noop.
+	eff, err = (&LiftWorkFileEmbargoes{WorkID: id, At: now}).apply(state, &User{Role: RoleCurator})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if eff != nil {
+		t.Error("expected nil (noop) when no embargo expired")
+	}
+}
+
+func TestAttachWorkFile_KeepsEmbargoLift(t *testing.T) {
+	id := newID()
+	state := newTestWorkState(id)
+	fileID := newID()
+	until := time.Now().Add(-time.Hour)
+	lifted := time.Now()
+	state.records[id].fields["files"] = []WorkFile{
+		{FileID: fileID, Name: "a.pdf", AccessKind: WorkFileAccessOpen, EmbargoUntil: &until, EmbargoAccessKind: WorkFileAccessOpen, EmbargoLiftedAt: &lifted},
+	}
+
+	// A curator closes the file again; the form doesn't carry the lift marker.
+	_, err := (&AttachWorkFile{WorkID: id, File: WorkFile{
+		FileID: fileID, Name: "a.pdf", AccessKind: WorkFileAccessClosed, EmbargoUntil: &until, EmbargoAccessKind: WorkFileAccessOpen,
+	}}).apply(state, &User{Role: RoleCurator})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := state.records[id].fields["files"].([]WorkFile)[0]
+	if f.EmbargoLiftedAt == nil {
+		t.Fatal("expected lift marker to be kept")
+	}
+	if got := f.AccessKindAt(time.Now()); got != WorkFileAccessClosed {
+		t.Errorf("access = %q, want closed", got)
+	}
+}
@@ -69,6 +69,25 @@ func TestWorkValidation_PublicWithRequired(t *testing.T) {
 	}
 }
 
+func TestWorkValidation_InvalidIdentifier(t *testing.T) {
+	p := testProfiles(t)
+	defs := p.FieldDefs("work", "journal_article")
+	fields := map[string]any{
+		"titles": []Title{{Lang: "eng", Val: "A title"}},
+		"identifiers": []Identifier{
+			{Scheme: "doi", Val: "https://doi.org/10.1000/ABC"},
+			{Scheme: "issn", Val: "1234-5678"},
+		},
+	}
+	errs := validateRecord("private", fields, defs)
+	if hasError(errs, "identifiers[0].val", "identifier") {
+		t.Errorf("expected a DOI with resolver URL to be valid, got: %v", errs)
+	}
+	if !hasError(errs, "identifiers[1].val", "identifier") {
+		t.Errorf("expected identifier error for an ISSN with a bad check digit, got: %v", errs)
+	}
+}
+
 // hasError checks if any error matches the given path and rule.
 func hasError(errs []*vo.Error, path, rule string) bool {
 	for _, e := range errs {
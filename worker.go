@@ -0,0 +1,114 @@
+package bbl
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/ugent-library/catbird"
+)
+
+// Scheduled tasks run by the background worker.
+const (
+	taskEmbargoes     = "embargoes"
+	taskBatchEdit     = "batch_edit"
+	taskDeactivations = "deactivations"
+)
+
+// WorkerConfig configures the background worker.
+type WorkerConfig struct {
+	Logger *slog.Logger
+	// EmbargoSchedule is the cron spec of the embargo task. Defaults to
+	// "@hourly".
+	EmbargoSchedule string
+	// EmbargoNoticeDays is how many days before expiry depositors are told
+	// about an embargo ending. 0 = no notices.
+	EmbargoNoticeDays int
+	// DeactivationSchedule is the cron spec of the user deactivation task.
+	// Defaults to "@hourly".
+	DeactivationSchedule string
+}
+
+type embargoTaskOutput struct {
+	Lifted   int `json:"lifted"`
+	Notified int `json:"notified"`
+}
+
+// RunWorker runs the scheduled background tasks until ctx is done. Tasks are
+// coordinated through the database, so several processes can run a worker.
+func (s *Services) RunWorker(ctx context.Context, c WorkerConfig) error {
+	logger := c.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	schedule := c.EmbargoSchedule
+	if schedule == "" {
+		schedule = "@hourly"
+	}
+	deactivationSchedule := c.DeactivationSchedule
+	if deactivationSchedule == "" {
+		deactivationSchedule = "@hourly"
+	}
+	noticeWindow := time.Duration(c.EmbargoNoticeDays) * 24 * time.Hour
+
+	client := catbird.New(s.Repo.db)
+
+	embargoTask := catbird.NewTask(taskEmbargoes).
+		WithDescription("Lift expired file embargoes and notify depositors of upcoming ones").
+		Do(func(ctx context.Context, _ struct{}) (embargoTaskOutput, error) {
+			now := time.Now()
+			var out embargoTaskOutput
+			var err error
+			if out.Lifted, err = s.LiftEmbargoes(ctx, now); err != nil {
+				return out, err
+			}
+			if out.Notified, err = s.NotifyExpiringEmbargoes(ctx, now, noticeWindow); err != nil {
+				return out, err
+			}
+			logger.Info("embargoes", "lifted", out.Lifted, "notified", out.Notified)
+			return out, nil
+		}, catbird.WithConcurrency(1), catbird.WithTimeout(30*time.Minute))
+
+	deactivationTask := catbird.NewTask(taskDeactivations).
+		WithDescription("Deactivate users whose deactivation date has passed").
+		Do(func(ctx context.Context, _ struct{}) (int, error) {
+			n, err := s.Repo.DeactivateUsers(ctx, time.Now())
+			if err != nil {
+				return n, err
+			}
+			logger.Info("deactivations", "deactivated", n)
+			return n, nil
+		}, catbird.WithConcurrency(1), catbird.WithTimeout(10*time.Minute))
+
+	batchEditTask := catbird.NewTask(taskBatchEdit).
+		WithDescription("Check and apply batch edits uploaded in the backoffice").
+		Do(func(ctx context.Context, in batchEditTaskInput) (struct{}, error) {
+			return struct{}{}, s.runBatchEdit(ctx, in.ID, in.Apply)
+		}, catbird.WithConcurrency(2), catbird.WithTimeout(2*time.Hour))
+
+	// Schedules reference the task definition, so create it first.
+	if err := client.CreateTask(ctx, embargoTask); err != nil {
+		return fmt.Errorf("RunWorker: %w", err)
+	}
+	if err := client.CreateTask(ctx, deactivationTask); err != nil {
+		return fmt.Errorf("RunWorker: %w", err)
+	}
+	if err := client.CreateTask(ctx, batchEditTask); err != nil {
+		return fmt.Errorf("RunWorker: %w", err)
+	}
+	if err := client.CreateTaskSchedule(ctx, taskEmbargoes, schedule); err != nil {
+		return fmt.Errorf("RunWorker: %w", err)
+	}
+	if err := client.CreateTaskSchedule(ctx, taskDeactivations, deactivationSchedule); err != nil {
+		return fmt.Errorf("RunWorker: %w", err)
+	}
+
+	return client.NewWorker().
+		WithLogger(logger).
+		WithShutdownTimeout(10 * time.Second).
+		AddTask(embargoTask).
+		AddTask(deactivationTask).
+		AddTask(batchEditTask).
+		Start(ctx)
+}
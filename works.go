@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"iter"
+	"slices"
 	"strings"
 	"time"
 
@@ -18,96 +19,186 @@ import (
 // Re-import = delete all of this source's assertions for the entity + insert new ones.
 // Returns the number of records that resulted in a create or update.
 func (r *Repo) ImportWorks(ctx context.Context, source string, seq iter.Seq2[*ImportWorkInput, error]) (int, error) {
-	const batchSize = 250
-	var pending []*ImportWorkInput
-	var total int
-
-	flush := func() error {
-		n, err := r.importWorkBatch(ctx, source, pending)
-		total += n
-		pending = pending[:0]
-		return err
-	}
+	res, err := r.ImportWorksWithOpts(ctx, source, seq, ImportOpts{})
+	return res.Imported, err
+}
 
-	for in, err := range seq {
-		if err != nil {
-			return total, fmt.Errorf("ImportWorks: %w", err)
-		}
-		pending = append(pending, in)
-		if len(pending) == batchSize {
-			if err := flush(); err != nil {
-				return total, err
-			}
-		}
-	}
-	if len(pending) > 0 {
-		if err := flush(); err != nil {
-			return total, err
-		}
+// ImportWorksWithOpts imports seq like ImportWorks, but can leave out the
+// records that fail and resume an interrupted run. The result is never nil.
+func (r *Repo) ImportWorksWithOpts(ctx context.Context, source string, seq iter.Seq2[*ImportWorkInput, error], opts ImportOpts) (*ImportResult, error) {
+	res, err := importBatches(seq, opts, func(records []*ImportWorkInput) (int, []*ImportFailure, error) {
+		return r.importWorkBatch(ctx, source, records, opts.OnFailure != nil)
+	})
+	if err != nil {
+		return res, fmt.Errorf("ImportWorks: %w", err)
 	}
-	return total, nil
+	return res, nil
 }
 
-func (r *Repo) importWorkBatch(ctx context.Context, source string, records []*ImportWorkInput) (int, error) {
+// importWorkBatch imports records in a single transaction. If
+// skipFailures is set, records that fail validation or ref resolution are
+// rolled back to a savepoint and returned instead of aborting the batch.
+func (r *Repo) importWorkBatch(ctx context.Context, source string, records []*ImportWorkInput, skipFailures bool) (int, []*ImportFailure, error) {
 	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
-		return 0, fmt.Errorf("importWorkBatch: %w", err)
+		return 0, nil, fmt.Errorf("importWorkBatch: %w", err)
 	}
 	defer tx.Rollback(ctx)
 
 	priorities, err := fetchSourcePriorities(ctx, tx)
 	if err != nil {
-		return 0, fmt.Errorf("importWorkBatch: %w", err)
+		return 0, nil, fmt.Errorf("importWorkBatch: %w", err)
 	}
 
 	var revID int64
 	if err := tx.QueryRow(ctx, `
 		INSERT INTO bbl_revs (source) VALUES ($1) RETURNING id`,
 		source).Scan(&revID); err != nil {
-		return 0, fmt.Errorf("importWorkBatch: %w", err)
+		return 0, nil, fmt.Errorf("importWorkBatch: %w", err)
 	}
 
 	var changedWorkIDs []ID
+	var seen, failed []string
+	var failures []*ImportFailure
+	matches := make(map[ID]map[ID][]string)
 	var n int
 	for _, in := range records {
-		workID, err := r.importWorkRecord(ctx, tx, source, in, revID, priorities)
+		if in.Deleted {
+			workID, ok, err := withdrawWorkSourceRecord(ctx, tx, source, in.SourceID, priorities)
+			if err != nil {
+				return n, nil, fmt.Errorf("importWorkBatch: source_id=%s: %w", in.SourceID, err)
+			}
+			if ok {
+				changedWorkIDs = append(changedWorkIDs, workID)
+				n++
+			}
+			continue
+		}
+		var res workImport
+		if skipFailures {
+			res, err = r.tryImportWorkRecord(ctx, tx, source, in, revID, priorities)
+			if isRecordFailure(err) {
+				failures = append(failures, &ImportFailure{SourceID: in.SourceID, Reason: err.Error()})
+				failed = append(failed, in.SourceID)
+				continue
+			}
+		} else {
+			res, err = r.importWorkRecord(ctx, tx, source, in, revID, priorities)
+		}
 		if err != nil {
-			return n, fmt.Errorf("importWorkBatch: source_id=%s: %w", in.SourceID, err)
+			return n, nil, fmt.Errorf("importWorkBatch: source_id=%s: %w", in.SourceID, err)
+		}
+		if len(res.matches) > 0 {
+			matches[res.workID] = res.matchReasons
 		}
-		changedWorkIDs = append(changedWorkIDs, workID)
+		changedWorkIDs = append(changedWorkIDs, res.workID)
+		seen = append(seen, in.SourceID)
 		n++
 	}
 
+	// Stamp the records the source still has, for sweeps.
+	if _, err := tx.Exec(ctx, `
+		UPDATE bbl_work_sources SET last_seen_at = transaction_timestamp(), withdrawn_at = NULL
+		WHERE source = $1 AND source_id = ANY($2)`,
+		source, seen); err != nil {
+		return n, nil, fmt.Errorf("importWorkBatch: %w", err)
+	}
+	// Records that failed are still in the source and mustn't be swept,
+	// but they keep their previous assertions and withdrawal.
+	if _, err := tx.Exec(ctx, `
+		UPDATE bbl_work_sources SET last_seen_at = transaction_timestamp()
+		WHERE source = $1 AND source_id = ANY($2)`,
+		source, failed); err != nil {
+		return n, nil, fmt.Errorf("importWorkBatch: %w", err)
+	}
 	if err := rebuildWorkCache(ctx, tx, changedWorkIDs); err != nil {
-		return n, fmt.Errorf("importWorkBatch: %w", err)
+		return n, nil, fmt.Errorf("importWorkBatch: %w", err)
+	}
+	if err := addMatchDuplicates(ctx, tx, matches); err != nil {
+		return n, nil, fmt.Errorf("importWorkBatch: %w", err)
 	}
 
 	if err := tx.Commit(ctx); err != nil {
-		return 0, fmt.Errorf("importWorkBatch: %w", err)
+		return 0, nil, fmt.Errorf("importWorkBatch: %w", err)
+	}
+	return n, failures, nil
+}
+
+// tryImportWorkRecord imports a record within a savepoint, so that a
+// failing record leaves the transaction usable.
+func (r *Repo) tryImportWorkRecord(ctx context.Context, tx pgx.Tx, source string, in *ImportWorkInput, revID int64, priorities map[string]int) (workImport, error) {
+	sp, err := tx.Begin(ctx)
+	if err != nil {
+		return workImport{}, err
+	}
+	res, err := r.importWorkRecord(ctx, sp, source, in, revID, priorities)
+	if err != nil {
+		if rbErr := sp.Rollback(ctx); rbErr != nil {
+			return workImport{}, rbErr
+		}
+		return workImport{}, err
 	}
-	return n, nil
+	return res, sp.Commit(ctx)
 }
 
-func (r *Repo) importWorkRecord(ctx context.Context, tx pgx.Tx, source string, in *ImportWorkInput, revID int64, priorities map[string]int) (ID, error) {
-	var workID ID
+// checkImportWork checks what field validation doesn't: the source id,
+// kind and status of a record.
+func (r *Repo) checkImportWork(in *ImportWorkInput) error {
+	if in.SourceID == "" {
+		return fmt.Errorf("%w: source_id is missing", errInvalidRecord)
+	}
+	if in.Kind == "" || (r.Profiles != nil && !slices.Contains(r.Profiles.WorkKinds(), in.Kind)) {
+		return fmt.Errorf("%w: unknown work kind %q", errInvalidRecord, in.Kind)
+	}
+	switch in.Status {
+	case "", WorkStatusPrivate, WorkStatusPublic:
+	default:
+		return fmt.Errorf("%w: invalid status %q", errInvalidRecord, in.Status)
+	}
+	return nil
+}
+
+// workImport tells what importWorkRecord did with a record.
+type workImport struct {
+	workID  ID
+	matched bool // the record was new to the source and attached to an existing work
+	matches []ID // existing works a new work shares an identifier with
+	// duplicate reasons per work in matches
+	matchReasons map[ID][]string
+}
+
+func (r *Repo) importWorkRecord(ctx context.Context, tx pgx.Tx, source string, in *ImportWorkInput, revID int64, priorities map[string]int) (workImport, error) {
+	var res workImport
+	if err := r.checkImportWork(in); err != nil {
+		return res, err
+	}
 	var sourceRecordID ID
-	var isNew bool
+	var newWork, newRecord bool
 	err := tx.QueryRow(ctx, `
 		SELECT work_id, id FROM bbl_work_sources
 		WHERE source = $1 AND source_id = $2
-		FOR UPDATE`, source, in.SourceID).Scan(&workID, &sourceRecordID)
+		FOR UPDATE`, source, in.SourceID).Scan(&res.workID, &sourceRecordID)
 	if errors.Is(err, pgx.ErrNoRows) {
-		isNew = true
+		newWork, newRecord = true, true
 		if in.ID != nil {
-			workID = *in.ID
+			res.workID = *in.ID
 		} else {
-			workID = newID()
+			m, err := matchWork(ctx, tx, source, in)
+			if err != nil {
+				return res, fmt.Errorf("match work: %w", err)
+			}
+			if m.attach {
+				res.workID, res.matched, newWork = m.workIDs[0], true, false
+			} else {
+				res.workID, res.matches, res.matchReasons = newID(), m.workIDs, m.reasons
+			}
 		}
 	} else if err != nil {
-		return ID{}, err
+		return res, err
 	}
+	workID := res.workID
 
-	if isNew {
+	if newWork {
 		status := in.Status
 		if status == "" {
 			status = WorkStatusPrivate
@@ -116,36 +207,23 @@ func (r *Repo) importWorkRecord(ctx context.Context, tx pgx.Tx, source string, i
 			INSERT INTO bbl_works (id, version, kind, status)
 			VALUES ($1, 1, $2, $3)`,
 			workID, in.Kind, status); err != nil {
-			return ID{}, fmt.Errorf("insert bbl_works: %w", err)
+			return res, fmt.Errorf("insert bbl_works: %w", err)
 		}
+	}
+	if newRecord {
 		sourceRecordID = newID()
 		if _, err := tx.Exec(ctx, `
 			INSERT INTO bbl_work_sources (id, work_id, source, source_id, record, ingested_at)
 			VALUES ($1, $2, $3, $4, $5, transaction_timestamp())`,
 			sourceRecordID, workID, source, in.SourceID, in.SourceRecord); err != nil {
-			return ID{}, fmt.Errorf("insert bbl_work_sources: %w", err)
-		}
-	} else {
-		if err := deleteSourceAssertions(ctx, tx, "bbl_work_assertions", "work_source_id", sourceRecordID); err != nil {
-			return ID{}, err
-		}
-		if _, err := tx.Exec(ctx, `
-			UPDATE bbl_work_sources SET record = $1, ingested_at = transaction_timestamp()
-			WHERE id = $2`,
-			in.SourceRecord, sourceRecordID); err != nil {
-			return ID{}, fmt.Errorf("update bbl_work_sources: %w", err)
-		}
-		if _, err := tx.Exec(ctx, `
-			UPDATE bbl_works SET version = version + 1, updated_at = transaction_timestamp()
-			WHERE id = $1`, workID); err != nil {
-			return ID{}, fmt.Errorf("bump version: %w", err)
+			return res, fmt.Errorf("insert bbl_work_sources: %w", err)
 		}
 	}
 
 	// Build assertion rows, validate, write via shared pipeline.
 	rows, err := workImportAssertions(ctx, tx, source, workID, sourceRecordID, in)
 	if err != nil {
-		return ID{}, err
+		return res, err
 	}
 	if defs := r.Profiles.FieldDefs(RecordTypeWork, in.Kind); defs != nil {
 		status := in.Status
@@ -153,19 +231,105 @@ func (r *Repo) importWorkRecord(ctx context.Context, tx pgx.Tx, source string, i
 			status = WorkStatusPrivate
 		}
 		if errs := validateRecord(status, assertionRowFields(rows), defs); errs != nil {
-			return ID{}, errs.ToError()
+			return res, errs.ToError()
 		}
 	}
+
+	if !newWork {
+		// Locked fields that the source disagrees with keep their previous
+		// assertions; the new value goes to the curator queue.
+		var held []string
+		rows, held, err = holdLockedWorkFields(ctx, tx, workID, sourceRecordID, revID, rows)
+		if err != nil {
+			return res, err
+		}
+		if !newRecord {
+			if err := deleteSourceAssertions(ctx, tx, "bbl_work_assertions", "work_source_id", sourceRecordID, held...); err != nil {
+				return res, err
+			}
+			if _, err := tx.Exec(ctx, `
+				UPDATE bbl_work_sources SET record = $1, ingested_at = transaction_timestamp()
+				WHERE id = $2`,
+				in.SourceRecord, sourceRecordID); err != nil {
+				return res, fmt.Errorf("update bbl_work_sources: %w", err)
+			}
+		}
+		if _, err := tx.Exec(ctx, `
+			UPDATE bbl_works SET version = version + 1, updated_at = transaction_timestamp()
+			WHERE id = $1`, workID); err != nil {
+			return res, fmt.Errorf("bump version: %w", err)
+		}
+	}
+
 	if err := writeAssertionRows(ctx, tx, &pgx.Batch{}, 0, revID, rows); err != nil {
-		return ID{}, err
+		return res, err
 	}
 
 	// Auto-pin all grouping keys.
 	if err := autoPinRecord(ctx, tx, RecordTypeWork, workID, priorities); err != nil {
-		return ID{}, err
+		return res, err
 	}
 
-	return workID, nil
+	return res, nil
+}
+
+// withdrawWorkSourceRecord withdraws a source record that the source
+// reports as deleted. ok is false if the source never imported the record
+// or nothing changed.
+func withdrawWorkSourceRecord(ctx context.Context, tx pgx.Tx, source, sourceID string, priorities map[string]int) (workID ID, ok bool, err error) {
+	var sourceRecordID ID
+	err = tx.QueryRow(ctx, `
+		SELECT work_id, id FROM bbl_work_sources
+		WHERE source = $1 AND source_id = $2
+		FOR UPDATE`, source, sourceID).Scan(&workID, &sourceRecordID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return workID, false, nil
+	}
+	if err != nil {
+		return workID, false, err
+	}
+	ok, err = withdrawWorkSource(ctx, tx, workID, sourceRecordID, priorities)
+	return workID, ok, err
+}
+
+// withdrawWorkSource deletes the assertions of a source record and re-pins
+// the work. Assertions of locked fields are kept, as on re-import; if the
+// whole work is locked nothing is deleted and ok is false. The source
+// record itself is kept and marked withdrawn, so a record that comes back
+// is imported into the same work.
+func withdrawWorkSource(ctx context.Context, tx pgx.Tx, workID, sourceRecordID ID, priorities map[string]int) (ok bool, err error) {
+	if _, err := tx.Exec(ctx, `
+		UPDATE bbl_work_sources
+		SET ingested_at = transaction_timestamp(), withdrawn_at = transaction_timestamp()
+		WHERE id = $1`, sourceRecordID); err != nil {
+		return false, fmt.Errorf("update bbl_work_sources: %w", err)
+	}
+
+	lockRows, err := tx.Query(ctx, `SELECT field FROM bbl_work_locks WHERE work_id = $1`, workID)
+	if err != nil {
+		return false, err
+	}
+	locked, err := pgx.CollectRows(lockRows, pgx.RowTo[string])
+	if err != nil {
+		return false, err
+	}
+	if slices.Contains(locked, "") {
+		// The whole work is locked.
+		return false, nil
+	}
+
+	if err := deleteSourceAssertions(ctx, tx, "bbl_work_assertions", "work_source_id", sourceRecordID, locked...); err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE bbl_works SET version = version + 1, updated_at = transaction_timestamp()
+		WHERE id = $1`, workID); err != nil {
+		return false, fmt.Errorf("bump version: %w", err)
+	}
+	if err := autoPinRecord(ctx, tx, RecordTypeWork, workID, priorities); err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
 // workImportAssertions resolves refs and converts an ImportWorkInput into assertion rows.
@@ -218,7 +382,7 @@ func workImportAssertions(ctx context.Context, tx pgx.Tx, source string, workID
 	if len(in.Identifiers) > 0 {
 		rows = append(rows, assertionRow{
 			recordType: RecordTypeWork, recordID: workID,
-			field: "identifiers", val: in.Identifiers, sourceRecordID: src,
+			field: "identifiers", val: normalizeIdentifiers(in.Identifiers), sourceRecordID: src,
 		})
 	}
 	if len(in.Classifications) > 0 {
@@ -266,12 +430,15 @@ func workImportAssertions(ctx context.Context, tx pgx.Tx, source string, workID
 			name, givenName, familyName := c.Name, c.GivenName, c.FamilyName
 			if c.PersonRef != nil {
 				person, err := resolvePersonRef(ctx, tx, *c.PersonRef, source)
-				if err != nil {
+				switch {
+				case errors.Is(err, pgx.ErrNoRows) && c.PersonRefOptional:
+				case err != nil:
 					return nil, fmt.Errorf("workImportAssertions: resolve person ref: %w", err)
-				}
-				personID = &person.ID
-				if name == "" && givenName == "" && familyName == "" {
-					name, givenName, familyName = person.Name, person.GivenName, person.FamilyName
+				default:
+					personID = &person.ID
+					if name == "" && givenName == "" && familyName == "" {
+						name, givenName, familyName = person.Name, person.GivenName, person.FamilyName
+					}
 				}
 			}
 			kind := c.Kind
@@ -283,7 +450,7 @@ func workImportAssertions(ctx context.Context, tx pgx.Tx, source string, workID
 			}
 			contributors = append(contributors, WorkContributor{
 				Kind: kind, Name: name, GivenName: givenName, FamilyName: familyName,
-				PersonID: personID, Roles: c.Roles,
+				PersonID: personID, Roles: c.Roles, Affiliations: c.Affiliations,
 			})
 		}
 		rows = append(rows, assertionRow{
@@ -409,6 +576,9 @@ func (r *Repo) GetWorks(ctx context.Context, ids []ID) ([]*Work, error) {
 // GetWorkByIdentifier fetches the work that owns the given scheme:val identifier.
 // Returns ErrNotFound if no match.
 func (r *Repo) GetWorkByIdentifier(ctx context.Context, scheme, val string) (*Work, error) {
+	if v, err := NormalizeIdentifier(scheme, val); err == nil {
+		val = v
+	}
 	row := r.db.QueryRow(ctx, `
 		SELECT w.id, w.version, w.created_at, w.updated_at,
 		       w.created_by_id, w.updated_by_id,
@@ -416,8 +586,11 @@ func (r *Repo) GetWorkByIdentifier(ctx context.Context, scheme, val string) (*Wo
 		       w.deleted_at, w.deleted_by_id,
 		       w.cache
 		FROM bbl_works w
-		JOIN bbl_work_identifiers i ON i.work_id = w.id
-		WHERE i.scheme = $1 AND i.val = $2`, scheme, val)
+		WHERE w.id = (
+			SELECT work_id FROM bbl_work_assertions
+			WHERE field = 'identifiers' AND NOT hidden
+			  AND val->>'scheme' = $1 AND val->>'val' = $2
+			LIMIT 1)`, scheme, val)
 	w, err := scanWork(row)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, ErrNotFound
@@ -479,6 +652,16 @@ type ListPublicWorksOpts struct {
 	Until  time.Time
 	Cursor string // opaque, from previous result
 	Limit  int
+	// IncludeDeleted also returns works that were public when they were
+	// deleted or merged into another work. They have status deleted.
+	IncludeDeleted bool
+	// IDs restricts the result to these works. Nil means no restriction.
+	IDs []ID
+	// CollectionID restricts the result to the works of a manual collection.
+	CollectionID *ID
+	// SnapshotID restricts the result to the works of a collection
+	// snapshot, see CreateCollectionSnapshot.
+	SnapshotID *ID
 }
 
 // ListPublicWorksResult holds the result of ListPublicWorks.
@@ -492,10 +675,14 @@ type workCursor struct {
 	ID        ID        `json:"i"`
 }
 
-// GetEarliestWorkTimestamp returns the earliest updated_at of any public work.
+// GetEarliestWorkTimestamp returns the earliest updated_at of any public work,
+// including works that were public when they were deleted or merged.
 func (r *Repo) GetEarliestWorkTimestamp(ctx context.Context) (time.Time, error) {
 	var t time.Time
-	err := r.db.QueryRow(ctx, `SELECT COALESCE(MIN(updated_at), NOW()) FROM bbl_works WHERE status = 'public'`).Scan(&t)
+	err := r.db.QueryRow(ctx, `
+		SELECT COALESCE(MIN(updated_at), NOW()) FROM bbl_works
+		WHERE status = 'public'
+		   OR deleted_status = 'public'`).Scan(&t)
 	if err != nil {
 		return time.Time{}, fmt.Errorf("GetEarliestWorkTimestamp: %w", err)
 	}
@@ -510,8 +697,14 @@ func (r *Repo) ListPublicWorks(ctx context.Context, opts ListPublicWorksOpts) (*
 		       kind, status, review_status, delete_kind,
 		       deleted_at, deleted_by_id,
 		       cache
-		FROM bbl_works
+		FROM bbl_works`
+	if opts.IncludeDeleted {
+		query += `
+		WHERE (status = 'public' OR deleted_status = 'public')`
+	} else {
+		query += `
 		WHERE status = 'public'`
+	}
 	var args []any
 	n := 0
 
@@ -525,6 +718,21 @@ func (r *Repo) ListPublicWorks(ctx context.Context, opts ListPublicWorksOpts) (*
 		query += fmt.Sprintf(` AND updated_at <= $%d`, n)
 		args = append(args, opts.Until)
 	}
+	if opts.IDs != nil {
+		n++
+		query += fmt.Sprintf(` AND id = ANY($%d)`, n)
+		args = append(args, opts.IDs)
+	}
+	if opts.CollectionID != nil {
+		n++
+		query += fmt.Sprintf(` AND id IN (SELECT work_id FROM bbl_work_collection_works WHERE collection_id = $%d)`, n)
+		args = append(args, *opts.CollectionID)
+	}
+	if opts.SnapshotID != nil {
+		n++
+		query += fmt.Sprintf(` AND id IN (SELECT work_id FROM bbl_work_collection_snapshot_works WHERE snapshot_id = $%d)`, n)
+		args = append(args, *opts.SnapshotID)
+	}
 	if opts.Cursor != "" {
 		cur, err := decodeWorkCursor(opts.Cursor)
 		if err != nil {
@@ -659,6 +867,13 @@ func parseWorkCache(w *Work, cache []byte) error {
 		Projects      []ID      `json:"projects,omitempty"`
 		Organizations []ID      `json:"organizations,omitempty"`
 		Rels          []WorkRel `json:"rels,omitempty"`
+		Files         []struct {
+			Val         json.RawMessage `json:"val"`
+			FileID      ID              `json:"file_id"`
+			ContentType string          `json:"content_type"`
+			Size        int64           `json:"size"`
+			SHA256      string          `json:"sha256"`
+		} `json:"files,omitempty"`
 	}
 	if err := json.Unmarshal(cache, &d); err != nil {
 		return fmt.Errorf("parseWorkCache: %w", err)
@@ -688,6 +903,19 @@ func parseWorkCache(w *Work, cache []byte) error {
 	w.Projects = d.Projects
 	w.Organizations = d.Organizations
 	w.Rels = d.Rels
+	for _, f := range d.Files {
+		var wf WorkFile
+		if f.Val != nil {
+			if err := json.Unmarshal(f.Val, &wf); err != nil {
+				return fmt.Errorf("parseWorkCache: unmarshal file: %w", err)
+			}
+		}
+		wf.FileID = f.FileID
+		wf.ContentType = f.ContentType
+		wf.Size = f.Size
+		wf.SHA256 = f.SHA256
+		w.Files = append(w.Files, wf)
+	}
 	return nil
 }
 